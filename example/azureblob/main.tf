@@ -0,0 +1,67 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+variable "storage_account_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "release_sas_token" {
+  type      = string
+  sensitive = true
+}
+
+# A blob in an Azure storage account. The account is the first label of host,
+# password is its base64 encoded account key. Blobs larger than block_size are
+# uploaded in blocks.
+resource "remotefile_azureblob" "config" {
+  host     = "deployartifacts.blob.core.windows.net"
+  password = var.storage_account_key
+  path     = "/config/app.json"
+  contents = jsonencode({ environment = "production" })
+
+  azureblob = {
+    container    = "deploy"
+    content_type = "application/json"
+    block_size   = 8388608
+    metadata = {
+      managed-by = "terraform"
+    }
+  }
+}
+
+# A blob read with a shared access signature scoped to one container
+data "remotefile_azureblob" "release" {
+  host = "deployartifacts.blob.core.windows.net"
+  path = "/latest/VERSION"
+
+  azureblob = {
+    container = "releases"
+    sas_token = var.release_sas_token
+  }
+}
+
+# A blob in a local Azurite, which serves the development account over plain
+# HTTP with the account in the path
+resource "remotefile_azureblob" "azurite" {
+  host     = "127.0.0.1"
+  port     = 10000
+  user     = "devstoreaccount1"
+  password = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+  path     = "/fixtures/hosts.txt"
+  contents = "10.0.0.5 app\n"
+
+  azureblob = {
+    container = "terraform"
+    scheme    = "http"
+  }
+}
+
+output "release_version" {
+  value = data.remotefile_azureblob.release.contents
+}
@@ -0,0 +1,34 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# Owns the lines between
+#   # BEGIN terraform managed: cluster
+#   # END terraform managed: cluster
+# and leaves the rest of /etc/hosts alone
+resource "remotefile_sftp_block" "cluster_hosts" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/hosts"
+  name        = "cluster"
+  content     = <<EOT
+10.0.0.1 node-1.internal
+10.0.0.2 node-2.internal
+EOT
+}
+
+resource "remotefile_sftp_block" "php_limits" {
+  host          = "your.hostname.tld"
+  user          = "root"
+  private_key   = file("~/.ssh/id_ed25519")
+  path          = "/etc/php/8.2/fpm/conf.d/99-limits.ini"
+  name          = "limits"
+  marker_prefix = ";"
+  permissions   = "0644"
+  content       = "memory_limit = 512M"
+}
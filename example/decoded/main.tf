@@ -0,0 +1,34 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+data "remotefile_sftp_decoded" "app_env" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/srv/app/.env"
+  format      = "dotenv"
+}
+
+# Only the [client] section of my.cnf
+data "remotefile_sftp_decoded" "mysql_client" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/mysql/my.cnf"
+  format      = "ini"
+  query       = "client"
+}
+
+output "database_url" {
+  value     = data.remotefile_sftp_decoded.app_env.value.DATABASE_URL
+  sensitive = true
+}
+
+output "mysql_port" {
+  value = nonsensitive(data.remotefile_sftp_decoded.mysql_client.value.port)
+}
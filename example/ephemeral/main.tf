@@ -0,0 +1,22 @@
+terraform {
+  required_version = ">= 1.10"
+
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# The join token is read fresh on every run and never written to the plan or state
+ephemeral "remotefile_sftp" "join_token" {
+  host        = "control-plane.hostname.tld"
+  user        = "default"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/kubernetes/join-token"
+}
+
+provider "kubernetes" {
+  host  = "https://control-plane.hostname.tld:6443"
+  token = trimspace(ephemeral.remotefile_sftp.join_token.contents)
+}
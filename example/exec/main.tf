@@ -0,0 +1,35 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# A file inside a running container. host names the container and {script}
+# is where the provider puts the shell script reading or writing the file.
+resource "remotefile_exec" "nginx_conf" {
+  host     = "web-1"
+  path     = "/etc/nginx/conf.d/app.conf"
+  contents = file("${path.module}/app.conf")
+
+  validate_command  = "nginx -t -c %s"
+  on_update_command = "nginx -s reload"
+
+  exec = {
+    command = "docker exec -i {host} sh -c {script}"
+  }
+}
+
+# A file inside a pod, with the namespace as an extra placeholder
+data "remotefile_exec" "version" {
+  host = "api-7c9d8f6b5-x2k4q"
+  path = "/app/VERSION"
+
+  exec = {
+    command = "kubectl exec -i -n {namespace} {host} -- sh -c {script}"
+    args = {
+      namespace = "production"
+    }
+  }
+}
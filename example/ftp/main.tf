@@ -0,0 +1,43 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+variable "ftp_password" {
+  type      = string
+  sensitive = true
+}
+
+# A NAS that only offers FTPS, upgraded with AUTH TLS and verified against
+# the NAS's own CA. Permissions are set with SITE CHMOD.
+resource "remotefile_ftp" "index" {
+  host        = "nas.example.com"
+  user        = "web"
+  password    = var.ftp_password
+  path        = "/www/index.html"
+  permissions = "0644"
+  contents    = "<h1>Managed by Terraform</h1>\n"
+
+  ftp = {
+    tls     = "explicit"
+    ca_cert = file("${path.module}/nas-ca.pem")
+  }
+}
+
+# An anonymous mirror behind a firewall that only allows active mode
+data "remotefile_ftp" "release" {
+  host = "mirror.example.com"
+  path = "/pub/RELEASE"
+
+  ftp = {
+    mode = "active"
+  }
+}
+
+output "release" {
+  value     = trimspace(data.remotefile_ftp.release.contents)
+  sensitive = true
+}
@@ -0,0 +1,43 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+variable "artifacts_token" {
+  type      = string
+  sensitive = true
+}
+
+# The release manifest of an internal artifact server, revalidated with
+# If-None-Match on later runs rather than downloaded again
+data "remotefile_http" "manifest" {
+  url       = "https://artifacts.internal.example.com/releases/api/manifest.json"
+  cache_dir = "${path.root}/.terraform/http-cache"
+
+  request_headers = {
+    Authorization = "Bearer ${var.artifacts_token}"
+  }
+
+  retry_count    = 5
+  retry_interval = "5s"
+}
+
+# A config served by a service behind mutual TLS, which may not exist yet
+data "remotefile_http" "feature_flags" {
+  url           = "https://config.internal.example.com:8443/flags/api.yaml"
+  ca_cert       = file("${path.module}/internal-ca.pem")
+  client_cert   = file("${path.module}/terraform.pem")
+  client_key    = file("${path.module}/terraform-key.pem")
+  allow_missing = true
+}
+
+output "release" {
+  value = jsondecode(data.remotefile_http.manifest.contents).version
+}
+
+output "flags" {
+  value = data.remotefile_http.feature_flags.status_code == 404 ? {} : yamldecode(data.remotefile_http.feature_flags.contents)
+}
@@ -0,0 +1,36 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# Sets two keys in an existing JSON file, values that parse as JSON keep
+# their type so the port is written as a number
+resource "remotefile_sftp_keys" "app_config" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/app/config.json"
+  format      = "json"
+  set = {
+    "server.port"  = "8080"
+    "server.debug" = "false"
+  }
+  remove = ["server.legacy_mode"]
+}
+
+# Sets a key in the [mysqld] section of an INI file
+resource "remotefile_sftp_keys" "mysql" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/mysql/conf.d/tuning.cnf"
+  format      = "ini"
+  create      = true
+  permissions = "0644"
+  set = {
+    "mysqld.max_connections" = "500"
+  }
+}
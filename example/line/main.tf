@@ -0,0 +1,36 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# Own a single directive in sshd_config, replacing whatever value it had
+resource "remotefile_sftp_line" "permit_root_login" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/ssh/sshd_config"
+  regexp      = "^#?PermitRootLogin "
+  line        = "PermitRootLogin no"
+}
+
+resource "remotefile_sftp_line" "db_host" {
+  host         = "your.hostname.tld"
+  user         = "root"
+  private_key  = file("~/.ssh/id_ed25519")
+  path         = "/etc/hosts"
+  line         = "10.0.0.5 db.internal"
+  insert_after = "^127\\.0\\.0\\.1"
+}
+
+resource "remotefile_sftp_line" "no_legacy_mirror" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/hosts"
+  line        = "10.0.0.9 mirror.legacy"
+  regexp      = "mirror\\.legacy$"
+  state       = "absent"
+}
@@ -0,0 +1,19 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# A file on the machine running Terraform, written as the user running it.
+# host and the other connection attributes are ignored, so switching this to
+# remotefile_sftp is enough to manage the same file on a remote host.
+resource "remotefile_local" "motd" {
+  host        = "localhost"
+  path        = "/tmp/remotefile-example-motd"
+  contents    = "Managed by Terraform\n"
+  permissions = "0644"
+
+  validate_command = "test -s %s"
+}
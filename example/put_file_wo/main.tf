@@ -0,0 +1,28 @@
+terraform {
+  required_version = ">= 1.11"
+
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+ephemeral "remotefile_sftp" "tls_key" {
+  host        = "ca.hostname.tld"
+  user        = "default"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/pki/issued/web.key"
+}
+
+# The key is written to the web server while state only keeps its hash,
+# bump contents_wo_version to push a new key
+resource "remotefile_sftp" "tls_key" {
+  host                = "web.hostname.tld"
+  user                = "default"
+  private_key         = file("~/.ssh/id_ed25519")
+  path                = "/etc/nginx/tls/web.key"
+  permissions         = "0600"
+  contents_wo         = ephemeral.remotefile_sftp.tls_key.contents
+  contents_wo_version = 1
+}
@@ -0,0 +1,36 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# nginx is only reloaded when the site configuration actually changes
+resource "remotefile_sftp" "site" {
+  host             = "your.hostname.tld"
+  user             = "root"
+  private_key      = file("~/.ssh/id_ed25519")
+  path             = "/etc/nginx/conf.d/site.conf"
+  contents         = file("${path.module}/site.conf")
+  validate_command = "nginx -t -q -c %s"
+
+  on_create_command = "systemctl reload nginx"
+  on_update_command = "systemctl reload nginx"
+  on_delete_command = "systemctl reload nginx"
+}
+
+resource "remotefile_sftp" "exporter_env" {
+  host        = "your.hostname.tld"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/default/node_exporter"
+  contents    = "ARGS=\"--web.listen-address=:9100\"\n"
+
+  on_update_command  = "systemctl restart node_exporter && systemctl is-active node_exporter"
+  on_command_failure = "warn"
+}
+
+output "exporter_restart" {
+  value = remotefile_sftp.exporter_env.command_stdout
+}
@@ -0,0 +1,39 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# Uploaded as the unprivileged user, then moved into place as root with
+# `sudo install -m 0440 -o root -g root`
+resource "remotefile_sftp" "sudoers" {
+  host             = "your.hostname.tld"
+  user             = "deploy"
+  private_key      = file("~/.ssh/id_ed25519")
+  sudo             = "install"
+  sudo_password    = var.sudo_password
+  path             = "/etc/sudoers.d/monitoring"
+  permissions      = "0440"
+  owner            = "root"
+  group            = "root"
+  contents         = "monitoring ALL=(root) NOPASSWD: /usr/sbin/smartctl\n"
+  validate_command = "visudo -cf %s"
+}
+
+# Every SFTP operation runs as root, for hosts where deploy has NOPASSWD sudo
+resource "remotefile_sftp" "resolv" {
+  host             = "your.hostname.tld"
+  user             = "deploy"
+  private_key      = file("~/.ssh/id_ed25519")
+  sudo             = "sftp_server"
+  sudo_sftp_server = "/usr/libexec/openssh/sftp-server"
+  path             = "/etc/resolv.conf"
+  contents         = "nameserver 10.0.0.53\n"
+}
+
+variable "sudo_password" {
+  type      = string
+  sensitive = true
+}
@@ -0,0 +1,37 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# Rendered on each host, with the hostname reported by the host itself
+resource "remotefile_sftp" "node_exporter_env" {
+  for_each = toset(["web-1.hostname.tld", "web-2.hostname.tld"])
+
+  host        = each.key
+  user        = "default"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/default/node_exporter"
+
+  template = <<EOT
+# managed by terraform on {{ .Hostname }}
+ARGS="--web.listen-address=:{{ .Vars.port }} --collector.textfile.directory={{ .Vars.textfile_dir }}"
+EOT
+  vars = {
+    port         = "9100"
+    textfile_dir = "/var/lib/node_exporter"
+  }
+}
+
+# The same with Terraform-style interpolation, which needs ${} escaped as $${}
+# inside a Terraform string
+resource "remotefile_sftp" "motd" {
+  host            = "web-1.hostname.tld"
+  user            = "default"
+  private_key     = file("~/.ssh/id_ed25519")
+  path            = "/etc/motd"
+  template_format = "terraform"
+  template        = "Welcome to $${facts.hostname}, machine $${remote_file(\"/etc/machine-id\")}"
+}
@@ -0,0 +1,29 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# nginx checks the new configuration before it replaces the current one, a
+# broken file fails the apply and leaves the running configuration alone
+resource "remotefile_sftp" "nginx_conf" {
+  host             = "your.hostname.tld"
+  user             = "root"
+  private_key      = file("~/.ssh/id_ed25519")
+  path             = "/etc/nginx/nginx.conf"
+  permissions      = "0644"
+  contents         = file("${path.module}/nginx.conf")
+  validate_command = "nginx -t -q -c %s"
+}
+
+resource "remotefile_sftp" "deploy_sudoers" {
+  host             = "your.hostname.tld"
+  user             = "root"
+  private_key      = file("~/.ssh/id_ed25519")
+  path             = "/etc/sudoers.d/deploy"
+  permissions      = "0440"
+  contents         = "deploy ALL=(root) NOPASSWD: /usr/bin/systemctl reload nginx\n"
+  validate_command = "visudo -cf %s"
+}
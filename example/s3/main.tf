@@ -0,0 +1,50 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+variable "minio_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+# An object in a MinIO bucket. user and password are the access key ID and
+# the secret access key, MinIO expects the bucket in the path.
+resource "remotefile_s3" "config" {
+  host     = "minio.internal.example.com"
+  port     = 9000
+  user     = "terraform"
+  password = var.minio_secret_key
+  path     = "/config/app.json"
+  contents = jsonencode({ environment = "production" })
+
+  s3 = {
+    bucket       = "deploy"
+    path_style   = true
+    ca_cert      = file("${path.module}/internal-ca.pem")
+    content_type = "application/json"
+    metadata = {
+      managed-by = "terraform"
+    }
+  }
+}
+
+# An object in a Ceph RGW bucket in another zone group
+data "remotefile_s3" "inventory" {
+  host     = "rgw.example.com"
+  user     = "terraform"
+  password = var.minio_secret_key
+  path     = "/inventory/hosts.txt"
+
+  s3 = {
+    bucket = "inventory"
+    region = "eu-central"
+  }
+}
+
+output "inventory_last_modified" {
+  value = data.remotefile_s3.inventory.last_modified
+}
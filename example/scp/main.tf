@@ -0,0 +1,30 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# A router running dropbear without an SFTP subsystem, files are transferred
+# with scp and permissions set with chmod
+resource "remotefile_scp" "banner" {
+  host        = "192.168.1.1"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/banner"
+  permissions = "0644"
+  contents    = "Managed by Terraform\n"
+}
+
+data "remotefile_scp" "board" {
+  host        = "192.168.1.1"
+  user        = "root"
+  private_key = file("~/.ssh/id_ed25519")
+  path        = "/etc/board.json"
+}
+
+output "board" {
+  value     = jsondecode(data.remotefile_scp.board.contents)
+  sensitive = true
+}
@@ -0,0 +1,28 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+# Connects with the ssh binary, so the user, port, ProxyJump, identity and
+# ControlMaster settings of the "bastioned-db" entry in ~/.ssh/config apply and
+# keys come from the agent.
+resource "remotefile" "pg_hba" {
+  host           = "bastioned-db"
+  path           = "/etc/postgresql/16/main/pg_hba.conf"
+  contents       = file("${path.module}/pg_hba.conf")
+  use_system_ssh = true
+}
+
+# A specific ssh build, here one linked against a PKCS#11 provider, with the
+# host key pinned instead of taken from known_hosts
+data "remotefile" "motd" {
+  host            = "10.0.4.12"
+  user            = "admin"
+  path            = "/etc/motd"
+  host_key        = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHq5pCy0aXnBY4D8hGZPfI0xSXa5cHg6m5Z4tqIvYp8D"
+  use_system_ssh  = true
+  system_ssh_path = "/opt/openssh/bin/ssh"
+}
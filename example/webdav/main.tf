@@ -0,0 +1,48 @@
+terraform {
+  required_providers {
+    remotefile = {
+      source = "zerobull-consulting/remotefile"
+    }
+  }
+}
+
+variable "nextcloud_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "dav_token" {
+  type      = string
+  sensitive = true
+}
+
+# A file in a Nextcloud user's storage. Paths are resolved against base_path,
+# so this ends up at /remote.php/dav/files/deploy/config/app.json.
+resource "remotefile_webdav" "config" {
+  host     = "cloud.example.com"
+  user     = "deploy"
+  password = var.nextcloud_password
+  path     = "/config/app.json"
+  contents = jsonencode({ environment = "production" })
+
+  webdav = {
+    base_path = "/remote.php/dav/files/deploy"
+  }
+}
+
+# A WebDAV share on the internal network, authenticated with a bearer token
+# and verified against the internal CA
+data "remotefile_webdav" "inventory" {
+  host = "dav.internal.example.com"
+  port = 8443
+  path = "/inventory/hosts.txt"
+
+  webdav = {
+    bearer_token = var.dav_token
+    ca_cert      = file("${path.module}/internal-ca.pem")
+  }
+}
+
+output "inventory_last_modified" {
+  value = data.remotefile_webdav.inventory.last_modified
+}
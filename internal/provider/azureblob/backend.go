@@ -0,0 +1,351 @@
+package azureblob
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// maxBlocks is the most blocks a block blob can be committed from
+const maxBlocks = 50000
+
+// copyPollInterval is how often the status of a pending copy is checked
+var copyPollInterval = time.Second
+
+// azureblobBackend is a container of an Azure Blob Storage account, paths are
+// blob names. Blobs have no permissions or owners, and there are no commands
+// to run, so none of the attributes relying on them are available.
+//
+// last_modified and size come from HEAD, the contents from a GET sent with
+// If-Match set to the ETag HEAD returned, so a blob replaced between both
+// requests fails with remote.ErrConflict and is read again rather than
+// stored with the properties of another version. Downloads are checked
+// against the Content-MD5 stored with the blob, which every upload sets, so
+// a corrupted transfer is retried. The hash isn't kept in state, drift is
+// found by comparing the contents downloaded like for the other protocols.
+type azureblobBackend struct {
+	// ctx is the one the backend was dialed with, the Backend methods don't
+	// take one
+	ctx     context.Context
+	client  *client
+	address string
+
+	// etags are the entity tags HEAD last returned, by blob name
+	etags map[string]string
+}
+
+var _ backend.Backend = &azureblobBackend{}
+
+// Dialer returns a Dialer for the container described by params. HTTP has no
+// session, so nothing is sent until the first operation, which reports a
+// missing container or rejected credentials.
+func Dialer(params *ConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		tflog.SubsystemDebug(ctx, Subsystem, "using Azure Blob Storage container", map[string]interface{}{
+			"url":           params.GetContainerURL().String(),
+			"account":       params.GetAccount(),
+			"authorization": params.GetAuthorization(),
+		})
+
+		return &azureblobBackend{
+			ctx:     ctx,
+			client:  newClient(params),
+			address: params.GetAddress(),
+			etags:   map[string]string{},
+		}, nil
+	}
+}
+
+func (b *azureblobBackend) Address() string {
+	return b.address
+}
+
+// Open starts downloading the blob at path. It fails with
+// remote.ErrConflict if the blob was replaced since the last Stat of path.
+func (b *azureblobBackend) Open(path string) (backend.File, error) {
+	name := blobName(path)
+	header := http.Header{}
+	if etag := b.etags[name]; etag != "" {
+		header.Set("If-Match", etag)
+	}
+
+	response, err := b.client.do(b.ctx, http.MethodGet, name, nil, header, nil, http.StatusOK)
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) && responseErr.Status == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("%w: %s changed between reading its properties and its contents: %w",
+			remote.ErrConflict, path, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := parseProperties(name, response.Header, response.ContentLength)
+	if err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	file := &azureblobFile{name: name, body: response.Body}
+	if expected := info.ContentMD5(); expected != nil {
+		file.expectedMD5 = expected
+		file.hash = md5.New()
+	}
+	return file, nil
+}
+
+// OpenFile opens path for writing unless flag is os.O_RDONLY. The contents
+// are uploaded on close as a block blob, with the configured content type
+// and metadata, in blocks of block_size if they are larger. Blobs are always
+// replaced as a whole, so appending isn't supported.
+func (b *azureblobBackend) OpenFile(path string, flag int) (backend.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return b.Open(path)
+	}
+	if flag&os.O_APPEND != 0 {
+		return nil, errors.New("Azure Blob Storage can't append to block blobs")
+	}
+	return &azureblobFile{backend: b, name: blobName(path), upload: &bytes.Buffer{}}, nil
+}
+
+func (b *azureblobBackend) Stat(path string) (os.FileInfo, error) {
+	name := blobName(path)
+	info, err := b.client.head(b.ctx, name)
+	if err != nil {
+		delete(b.etags, name)
+		return nil, err
+	}
+	b.etags[name] = info.ETag()
+	return info, nil
+}
+
+// Lstat is Stat, Azure Blob Storage has no symbolic links
+func (b *azureblobBackend) Lstat(path string) (os.FileInfo, error) {
+	return b.Stat(path)
+}
+
+func (b *azureblobBackend) Chmod(path string, mode os.FileMode) error {
+	return errors.New("Azure blobs have no permissions")
+}
+
+func (b *azureblobBackend) Chown(path string, uid int, gid int) error {
+	return errors.New("Azure blobs have no owner")
+}
+
+// Rename copies oldpath over newpath, keeping its properties and metadata,
+// and deletes oldpath. Azure Blob Storage has no atomic rename, but readers
+// of newpath see either blob as a whole.
+func (b *azureblobBackend) Rename(oldpath string, newpath string) error {
+	oldName, newName := blobName(oldpath), blobName(newpath)
+	header := http.Header{"X-Ms-Copy-Source": {b.client.url(oldName, nil).String()}}
+	response, err := b.client.do(b.ctx, http.MethodPut, newName, nil, header, nil, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	delete(b.etags, newName)
+
+	// copies within an account usually complete before the response, larger
+	// ones continue in the background
+	status := response.Header.Get("X-Ms-Copy-Status")
+	for status == "pending" {
+		select {
+		case <-b.ctx.Done():
+			return b.ctx.Err()
+		case <-time.After(copyPollInterval):
+		}
+
+		response, err := b.client.do(b.ctx, http.MethodHead, newName, nil, nil, nil, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+		status = response.Header.Get("X-Ms-Copy-Status")
+		if status != "pending" && status != "success" {
+			return fmt.Errorf("%w: copying %s to %s: %s %s", errCopyFailed, oldpath, newpath,
+				status, response.Header.Get("X-Ms-Copy-Status-Description"))
+		}
+	}
+	if status != "" && status != "success" {
+		return fmt.Errorf("%w: copying %s to %s: %s", errCopyFailed, oldpath, newpath, status)
+	}
+
+	return b.Remove(oldpath)
+}
+
+// Remove deletes the blob at path
+func (b *azureblobBackend) Remove(path string) error {
+	name := blobName(path)
+	response, err := b.client.do(b.ctx, http.MethodDelete, name, nil, nil, nil, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+	delete(b.etags, name)
+	return response.Body.Close()
+}
+
+// Mkdir does nothing, directories only exist as the prefix of blob names
+func (b *azureblobBackend) Mkdir(path string) error {
+	return nil
+}
+
+func (b *azureblobBackend) ReadDir(path string) ([]os.FileInfo, error) {
+	infos, err := b.client.list(b.ctx, blobName(path))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]os.FileInfo, len(infos))
+	for i, info := range infos {
+		entries[i] = info
+	}
+	return entries, nil
+}
+
+func (b *azureblobBackend) Close() error {
+	b.client.http.CloseIdleConnections()
+	return nil
+}
+
+// upload stores contents as the block blob with name, with a single Put Blob
+// if they fit into a block and as a list of blocks otherwise
+func (b *azureblobBackend) upload(name string, contents []byte) error {
+	params := b.client.params
+	sum := md5.Sum(contents)
+	header := http.Header{
+		"X-Ms-Blob-Content-Type": {params.GetContentType()},
+		"X-Ms-Blob-Content-Md5":  {base64.StdEncoding.EncodeToString(sum[:])},
+	}
+	for name, value := range params.GetMetadata() {
+		header.Set("X-Ms-Meta-"+name, value)
+	}
+
+	blockSize := params.GetBlockSize()
+	if int64(len(contents)) <= blockSize {
+		header.Set("X-Ms-Blob-Type", "BlockBlob")
+		header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		response, err := b.client.do(b.ctx, http.MethodPut, name, nil, header, contents, http.StatusCreated)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+
+	count := (int64(len(contents)) + blockSize - 1) / blockSize
+	if count > maxBlocks {
+		return fmt.Errorf("%s would take %d blocks of %d bytes, more than the %d a blob can have, "+
+			"increase azureblob.block_size", name, count, blockSize, maxBlocks)
+	}
+
+	blockList := blockList{}
+	for i := int64(0); i < count; i++ {
+		block := contents[i*blockSize : min((i+1)*blockSize, int64(len(contents)))]
+		// every ID of a blob must have the same length
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%06d", i)))
+		blockSum := md5.Sum(block)
+
+		query := url.Values{"comp": {"block"}, "blockid": {id}}
+		blockHeader := http.Header{"Content-MD5": {base64.StdEncoding.EncodeToString(blockSum[:])}}
+		response, err := b.client.do(b.ctx, http.MethodPut, name, query, blockHeader, block, http.StatusCreated)
+		if err != nil {
+			return fmt.Errorf("error uploading block %d of %d: %w", i+1, count, err)
+		}
+		response.Body.Close()
+		blockList.Latest = append(blockList.Latest, id)
+	}
+
+	body, err := xml.Marshal(blockList)
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+	response, err := b.client.do(b.ctx, http.MethodPut, name, url.Values{"comp": {"blocklist"}}, header, body, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// blockList is the body of Put Block List, the blocks the blob is committed
+// from in order
+type blockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+// azureblobFile is a download streaming the body of a GET, or an upload
+// buffered until it is sent on close
+type azureblobFile struct {
+	name string
+	body io.ReadCloser
+	// hash is nil unless the download is checked against expectedMD5
+	hash        hash.Hash
+	expectedMD5 []byte
+
+	backend *azureblobBackend
+	upload  *bytes.Buffer
+
+	closed bool
+}
+
+// Read reads from the download. Reaching its end fails with
+// remote.ErrConnect if the contents don't match the stored MD5 hash, so a
+// transfer corrupted on the way is read again.
+func (f *azureblobFile) Read(p []byte) (int, error) {
+	if f.upload != nil {
+		return 0, errors.New("file was opened for writing")
+	}
+	n, err := f.body.Read(p)
+	if f.hash == nil {
+		return n, err
+	}
+	f.hash.Write(p[:n])
+	if err == io.EOF {
+		if actual := f.hash.Sum(nil); !bytes.Equal(actual, f.expectedMD5) {
+			return n, fmt.Errorf("%w: contents of %s don't match its Content-MD5, expected %s, got %s",
+				remote.ErrConnect, f.name, base64.StdEncoding.EncodeToString(f.expectedMD5),
+				base64.StdEncoding.EncodeToString(actual))
+		}
+	}
+	return n, err
+}
+
+func (f *azureblobFile) Write(p []byte) (int, error) {
+	if f.upload == nil {
+		return 0, errors.New("file was opened for reading")
+	}
+	return f.upload.Write(p)
+}
+
+// Close ends a download or sends the contents of an upload
+func (f *azureblobFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if f.upload == nil {
+		return f.body.Close()
+	}
+
+	b := f.backend
+	if err := b.upload(f.name, f.upload.Bytes()); err != nil {
+		return err
+	}
+	delete(b.etags, f.name)
+	return nil
+}
@@ -0,0 +1,484 @@
+package azureblob
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend/backendtest"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// nullModel returns a model for host and the terraform container with every
+// other attribute null
+func nullModel(host string) *backendtest.Model {
+	data := backendtest.NullModel(host)
+	data.Azureblob.Container = types.StringValue("terraform")
+	return data
+}
+
+// newTestModel returns a model signing requests to the container of server
+// with the account key, with nothing else set
+func newTestModel(server *testServer) *backendtest.Model {
+	host, port := server.host()
+	data := nullModel(host)
+	data.Port = types.Int64Value(port)
+	data.Azureblob.Scheme = types.StringValue(SchemeHTTP)
+	data.Timeout = types.StringValue("5s")
+	data.Azureblob.Container = types.StringValue(server.container)
+	data.User = types.StringValue(server.account)
+	data.Password = types.StringValue(server.key)
+	return data
+}
+
+// testDialer returns the dialer for data
+func testDialer(t *testing.T, data *backendtest.Model) func(context.Context) (*azureblobBackend, error) {
+	t.Helper()
+	return backendtest.Dialer[*azureblobBackend, ModelSubset](t, data, CreateConnectionParameters, Dialer)
+}
+
+func TestDialerRoundTrip(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/config/app v1.json")
+	data.Contents = types.StringValue(`{"environment":"production"}`)
+	data.Azureblob.ContentType = types.StringValue("application/json")
+	data.Azureblob.Metadata = types.MapValueMust(types.StringType, map[string]attr.Value{
+		"Team": types.StringValue("platform"),
+	})
+
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+	ctx := context.Background()
+
+	if err := remote.ConnectAndWrite(dial, data, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	blob := server.blob("config/app v1.json")
+	if blob == nil {
+		t.Fatal("blob was not written")
+	}
+	if string(blob.data) != `{"environment":"production"}` {
+		t.Errorf("blob = %q, expected the contents", blob.data)
+	}
+	if blob.contentType != "application/json" {
+		t.Errorf("content type = %q, expected application/json", blob.contentType)
+	}
+	if blob.metadata["team"] != "platform" {
+		t.Errorf("metadata = %v, expected team = platform", blob.metadata)
+	}
+	if blob.contentMD5 == nil {
+		t.Error("expected the upload to store the Content-MD5 of the blob")
+	}
+	if data.Size.ValueInt64() != int64(len(blob.data)) {
+		t.Errorf("size after write = %d, expected %d", data.Size.ValueInt64(), len(blob.data))
+	}
+
+	read := newTestModel(server)
+	read.Path = data.Path
+	if err := remote.ConnectAndCopy(dial, read, read)(ctx); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if read.Contents.ValueString() != data.Contents.ValueString() {
+		t.Errorf("contents = %q, expected %q", read.Contents.ValueString(), data.Contents.ValueString())
+	}
+	if read.ID.ValueString() != "app v1.json" {
+		t.Errorf("id = %q, expected %q", read.ID.ValueString(), "app v1.json")
+	}
+	if read.LastModified.ValueString() != data.LastModified.ValueString() {
+		t.Errorf("last_modified = %q, expected %q", read.LastModified.ValueString(), data.LastModified.ValueString())
+	}
+
+	if err := remote.ConnectAndDelete(dial, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndDelete() error = %v", err)
+	}
+	if server.blob("config/app v1.json") != nil {
+		t.Error("blob still exists after delete")
+	}
+}
+
+func TestDialerChunkedUpload(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/large.txt")
+	data.Contents = types.StringValue("0123456789")
+	data.Azureblob.BlockSize = types.Int64Value(4)
+
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+
+	if err := remote.ConnectAndWrite(dial, data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if count := server.count("PUT /devstoreaccount1/terraform/large.txt block"); count != 3 {
+		t.Errorf("uploaded %d blocks, expected 3 blocks of at most 4 bytes", count)
+	}
+	if server.count("PUT /devstoreaccount1/terraform/large.txt blocklist") != 1 {
+		t.Error("expected the blocks to be committed with a block list")
+	}
+	blob := server.blob("large.txt")
+	if blob == nil || string(blob.data) != "0123456789" {
+		t.Fatalf("blob = %+v, expected the blocks in order", blob)
+	}
+
+	// the MD5 of the whole blob is stored with the block list, so reading it
+	// back is verified as well
+	read := newTestModel(server)
+	read.Path = data.Path
+	if err := remote.ConnectAndCopy(dial, read, read)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if read.Contents.ValueString() != "0123456789" {
+		t.Errorf("contents = %q, expected the whole blob", read.Contents.ValueString())
+	}
+}
+
+func TestDialerTooManyBlocks(t *testing.T) {
+	server := newTestServer(t)
+	b, err := testDialer(t, newTestModel(server))(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	b.client.params.blockSize = 1
+
+	err = b.upload("huge", make([]byte, maxBlocks+1))
+	if err == nil || !strings.Contains(err.Error(), "increase azureblob.block_size") {
+		t.Errorf("upload() error = %v, expected too many blocks", err)
+	}
+	if server.count("PUT /devstoreaccount1/terraform/huge block") != 0 {
+		t.Error("expected no block to be uploaded")
+	}
+}
+
+func TestDialerLastModified(t *testing.T) {
+	server := newTestServer(t)
+	server.put("version", "1.2.3", time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/version")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	if err := remote.ConnectAndCopy(Dialer(params), data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if data.Contents.ValueString() != "1.2.3" || data.Size.ValueInt64() != 5 {
+		t.Errorf("contents = %q (%d bytes), expected %q", data.Contents.ValueString(), data.Size.ValueInt64(), "1.2.3")
+	}
+	if data.LastModified.ValueString() != "2024-05-01T12:30:00Z" {
+		t.Errorf("last_modified = %q, expected the upload time of the blob", data.LastModified.ValueString())
+	}
+}
+
+func TestDialerChangedWhileReading(t *testing.T) {
+	var server *testServer
+	changes := 0
+	server = newTestServer(t, withBefore(func(r *http.Request) {
+		// the blob is replaced between HEAD and GET, once
+		if r.Method == http.MethodGet && changes == 0 {
+			changes++
+			server.blobs["counter"] = &testBlob{data: []byte("2"), modTime: time.Now().Add(time.Second)}
+		}
+	}))
+	server.put("counter", "1", time.Now())
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/counter")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	operation := remote.ConnectAndCopy(Dialer(params), data, data)
+
+	err = operation(context.Background())
+	if !errors.Is(err, remote.ErrConflict) {
+		t.Fatalf("ConnectAndCopy() error = %v, expected ErrConflict", err)
+	}
+	if !remote.IsRetryable(err) {
+		t.Errorf("expected %v to be retried", err)
+	}
+
+	if err := operation(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() retry error = %v", err)
+	}
+	if data.Contents.ValueString() != "2" {
+		t.Errorf("contents = %q, expected the replaced blob", data.Contents.ValueString())
+	}
+}
+
+func TestDialerCorruptDownload(t *testing.T) {
+	var server *testServer
+	server = newTestServer(t, withBefore(func(r *http.Request) {
+		// the contents no longer match the MD5 stored with them
+		if r.Method == http.MethodGet {
+			server.blobs["config"].data = []byte("corrupt")
+		}
+	}))
+	server.put("config", "correct", time.Now())
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/config")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndCopy(Dialer(params), data, data)(context.Background())
+	if !errors.Is(err, remote.ErrConnect) || !strings.Contains(err.Error(), "Content-MD5") {
+		t.Fatalf("ConnectAndCopy() error = %v, expected a Content-MD5 mismatch", err)
+	}
+	if !remote.IsRetryable(err) {
+		t.Errorf("expected %v to be retried", err)
+	}
+}
+
+func TestDialerMissingBlob(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/missing")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+
+	err = remote.ConnectAndCopy(dial, data, data)(context.Background())
+	if !remote.IsFileNotFound(err) {
+		t.Errorf("ConnectAndCopy() error = %v, expected a missing file", err)
+	}
+
+	data.AllowMissing = types.BoolValue(true)
+	if err := remote.ConnectAndCopy(dial, data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() with allow_missing error = %v", err)
+	}
+	if data.Size.ValueInt64() != -1 {
+		t.Errorf("size = %d, expected -1 for a missing blob", data.Size.ValueInt64())
+	}
+
+	if err := remote.ConnectAndDelete(dial, data)(context.Background()); err != nil {
+		t.Errorf("ConnectAndDelete() error = %v", err)
+	}
+}
+
+func TestDialerMissingContainer(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Azureblob.Container = types.StringValue("elsewhere")
+	data.Path = types.StringValue("/file")
+	data.Contents = types.StringValue("contents")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(Dialer(params), data, data)(context.Background())
+	if !remote.IsFileNotFound(err) || !strings.Contains(err.Error(), "ContainerNotFound") {
+		t.Errorf("ConnectAndWrite() error = %v, expected a missing container", err)
+	}
+}
+
+func TestDialerSasToken(t *testing.T) {
+	server := newTestServer(t)
+	server.put("shared", "via sas", time.Now())
+
+	data := newTestModel(server)
+	data.Password = types.StringNull()
+	data.Azureblob.SasToken = types.StringValue("?" + server.sasToken.Encode())
+	data.Path = types.StringValue("/shared")
+	data.Contents = types.StringValue("written via sas")
+
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+
+	if err := remote.ConnectAndWrite(dial, data, data, remote.WithResumable(true))(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if blob := server.blob("shared"); blob == nil || string(blob.data) != "written via sas" {
+		t.Errorf("blob = %+v, expected the new contents", blob)
+	}
+
+	b, err := testDialer(t, data)(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	if _, err := b.ReadDir("/"); err != nil {
+		t.Errorf("ReadDir() error = %v", err)
+	}
+}
+
+func TestDialerAuthFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*backendtest.Model, *testServer)
+		expected error
+	}{
+		{name: "wrong key", modify: func(m *backendtest.Model, _ *testServer) {
+			m.Password = types.StringValue("d3Jvbmcga2V5")
+		}, expected: remote.ErrAuthFailed},
+		{name: "unknown account", modify: func(m *backendtest.Model, _ *testServer) {
+			m.User = types.StringValue("otheraccount")
+		}, expected: remote.ErrAuthFailed},
+		{name: "wrong sas signature", modify: func(m *backendtest.Model, _ *testServer) {
+			m.Password = types.StringNull()
+			m.Azureblob.SasToken = types.StringValue("sv=2020-10-02&sp=racwdl&sig=d3Jvbmc%3D")
+		}, expected: remote.ErrAuthFailed},
+		{name: "read-only sas", modify: func(m *backendtest.Model, s *testServer) {
+			m.Password = types.StringNull()
+			m.Azureblob.SasToken = types.StringValue("sv=2020-10-02&sp=r&sig=" + s.sasToken.Get("sig"))
+		}, expected: remote.ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			data := newTestModel(server)
+			tt.modify(data, server)
+			data.Path = types.StringValue("/file")
+			data.Contents = types.StringValue("contents")
+
+			params, err := CreateConnectionParameters(data)
+			if err != nil {
+				t.Fatalf("CreateConnectionParameters() error = %v", err)
+			}
+			err = remote.ConnectAndWrite(Dialer(params), data, data)(context.Background())
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("ConnectAndWrite() error = %v, expected %v", err, tt.expected)
+			}
+			if remote.IsRetryable(err) {
+				t.Errorf("expected %v not to be retried", err)
+			}
+		})
+	}
+}
+
+func TestDialerResumable(t *testing.T) {
+	server := newTestServer(t, withPendingCopies(2))
+	copyPollInterval = time.Millisecond
+	t.Cleanup(func() { copyPollInterval = time.Second })
+	server.put("etc/.app.conf.partial", "stale", time.Now())
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/etc/app.conf")
+	data.Contents = types.StringValue("listen 8080\n")
+	data.Azureblob.ContentType = types.StringValue("text/plain")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(Dialer(params), data, data, remote.WithResumable(true))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	blob := server.blob("etc/app.conf")
+	if blob == nil || string(blob.data) != "listen 8080\n" {
+		t.Fatalf("blob = %+v, expected the new contents", blob)
+	}
+	if blob.contentType != "text/plain" {
+		t.Errorf("content type = %q, expected the copy to keep text/plain", blob.contentType)
+	}
+	if blob.copyStatus != "success" {
+		t.Errorf("copy status = %q, expected the pending copy to be waited for", blob.copyStatus)
+	}
+	if server.blob("etc/.app.conf.partial") != nil {
+		t.Error("partial blob still exists")
+	}
+}
+
+func TestBackendReadDir(t *testing.T) {
+	server := newTestServer(t, withPageSize(2))
+	for _, name := range []string{"conf/a.conf", "conf/b.conf", "conf/c.conf", "conf/d/e.conf", "other"} {
+		server.put(name, name, time.Now())
+	}
+
+	b, err := testDialer(t, newTestModel(server))(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Mkdir("/conf/d"); err != nil {
+		t.Errorf("Mkdir() error = %v, expected directories to be implicit", err)
+	}
+
+	entries, err := b.ReadDir("/conf")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		if entry.Name() == "d" && !entry.IsDir() {
+			t.Error("expected d to be a directory")
+		}
+		if entry.Name() == "a.conf" && entry.Size() != int64(len("conf/a.conf")) {
+			t.Errorf("a.conf size = %d, expected %d", entry.Size(), len("conf/a.conf"))
+		}
+	}
+	sort.Strings(names)
+	expected := []string{"a.conf", "b.conf", "c.conf", "d"}
+	if len(names) != len(expected) {
+		t.Fatalf("entries = %v, expected %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("entries = %v, expected %v", names, expected)
+			break
+		}
+	}
+
+	if err := b.Chmod("/conf/a.conf", 0600); err == nil {
+		t.Error("Chmod() succeeded, expected Azure Blob Storage to refuse changing permissions")
+	}
+	if _, err := b.OpenFile("/conf/a.conf", os.O_WRONLY|os.O_APPEND); err == nil {
+		t.Error("OpenFile() succeeded, expected appending to be refused")
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		code     string
+		expected error
+	}{
+		{name: "authentication", status: http.StatusForbidden, code: "AuthenticationFailed", expected: remote.ErrAuthFailed},
+		{name: "permission", status: http.StatusForbidden, code: "AuthorizationPermissionMismatch", expected: os.ErrPermission},
+		{name: "forbidden", status: http.StatusForbidden, expected: os.ErrPermission},
+		{name: "blob not found", status: http.StatusNotFound, code: "BlobNotFound", expected: os.ErrNotExist},
+		{name: "container not found", status: http.StatusNotFound, code: "ContainerNotFound", expected: os.ErrNotExist},
+		{name: "condition", status: http.StatusPreconditionFailed, code: "ConditionNotMet", expected: remote.ErrConflict},
+		{name: "insufficient storage", status: http.StatusInsufficientStorage, expected: syscall.EDQUOT},
+		{name: "server busy", status: http.StatusServiceUnavailable, code: "ServerBusy", expected: remote.ErrConnect},
+		{name: "bad request", status: http.StatusBadRequest, code: "InvalidBlockList", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &ResponseError{Method: http.MethodGet, Blob: "file", Status: tt.status, Code: tt.code}
+			if unwrapped := err.Unwrap(); unwrapped != tt.expected {
+				t.Errorf("Unwrap() = %v, expected %v", unwrapped, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package azureblob
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// Subsystem is the tflog subsystem the Azure Blob Storage client logs to
+const Subsystem = "azureblob"
+
+// apiVersion is the version of the Blob service REST API requests are sent
+// with, one Azurite supports as well
+const apiVersion = "2020-10-02"
+
+// ResponseError is a response refusing a request. Unwrap maps the
+// x-ms-error-code, or the status when the service sent none, to the os,
+// syscall or typed error the remote operations look for.
+type ResponseError struct {
+	Method string
+	Blob   string
+	Status int
+	// Code comes from the x-ms-error-code header or the error document,
+	// Message only from the document, responses to HEAD have none
+	Code    string
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	message := fmt.Sprintf("azureblob: %s %s: %d %s", e.Method, e.Blob, e.Status, http.StatusText(e.Status))
+	if e.Code != "" {
+		message += ": " + e.Code
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+func (e *ResponseError) Unwrap() error {
+	switch e.Code {
+	case "AuthenticationFailed", "InvalidAuthenticationInfo":
+		return remote.ErrAuthFailed
+	case "AuthorizationFailure", "AuthorizationPermissionMismatch", "AuthorizationResourceTypeMismatch",
+		"InsufficientAccountPermissions":
+		return os.ErrPermission
+	case "BlobNotFound", "ContainerNotFound", "ResourceNotFound":
+		return os.ErrNotExist
+	case "ConditionNotMet":
+		return remote.ErrConflict
+	case "AccountIsDisabled":
+		return os.ErrPermission
+	case "ServerBusy", "InternalError", "OperationTimedOut":
+		return remote.ErrConnect
+	}
+
+	switch e.Status {
+	case http.StatusUnauthorized:
+		return remote.ErrAuthFailed
+	case http.StatusForbidden:
+		return os.ErrPermission
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusPreconditionFailed:
+		return remote.ErrConflict
+	case http.StatusInsufficientStorage:
+		return syscall.EDQUOT
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return remote.ErrConnect
+	}
+	return nil
+}
+
+// errorDocument is the body of a failed response
+type errorDocument struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// maxErrorBody is how much of the body of a failed response is read
+const maxErrorBody = 64 << 10
+
+// client sends authorized requests for the blobs of a container
+type client struct {
+	params    *ConnectionParameters
+	http      *http.Client
+	container *url.URL
+}
+
+func newClient(params *ConnectionParameters) *client {
+	dialer := &net.Dialer{Timeout: params.GetTimeout()}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     params.GetTLSConfig(),
+		TLSHandshakeTimeout: params.GetTimeout(),
+		ForceAttemptHTTP2:   true,
+	}
+
+	return &client{
+		params: params,
+		http: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		container: params.GetContainerURL(),
+	}
+}
+
+// blobName returns the name of the blob at remotePath, relative to the
+// container
+func blobName(remotePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+remotePath), "/")
+}
+
+// url returns the URL of the blob with name, the container itself if name is
+// empty. The SAS token, if any, is added to query.
+func (c *client) url(name string, query url.Values) *url.URL {
+	u := *c.container
+	if name != "" {
+		u.Path += "/" + name
+	}
+
+	values := url.Values{}
+	for key, value := range c.params.sasToken {
+		values[key] = value
+	}
+	for key, value := range query {
+		values[key] = value
+	}
+	u.RawQuery = values.Encode()
+	return &u
+}
+
+// do sends an authorized request for the blob with name and returns the
+// response if its status is one of expected, the caller closes its body
+func (c *client) do(ctx context.Context, method string, name string, query url.Values, header http.Header, body []byte, expected ...int) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.url(name, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		request.Body = http.NoBody
+		request.GetBody = nil
+	}
+	for key, values := range header {
+		request.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	request.Header.Set("X-Ms-Version", apiVersion)
+
+	if c.params.key != nil {
+		c.params.key.sign(request, time.Now())
+	} else {
+		request.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	start := time.Now()
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, Subsystem, "Azure Blob Storage request", map[string]interface{}{
+		"method":      method,
+		"blob":        name,
+		"status":      response.StatusCode,
+		"request_id":  response.Header.Get("X-Ms-Request-Id"),
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	for _, status := range expected {
+		if response.StatusCode == status {
+			return response, nil
+		}
+	}
+
+	defer response.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	return nil, parseError(method, name, response, data)
+}
+
+// parseError returns the error a failed response with body reports
+func parseError(method string, name string, response *http.Response, body []byte) *ResponseError {
+	responseErr := &ResponseError{
+		Method: method,
+		Blob:   name,
+		Status: response.StatusCode,
+		Code:   response.Header.Get("X-Ms-Error-Code"),
+	}
+
+	var document errorDocument
+	if len(body) > 0 && xml.Unmarshal(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")), &document) == nil {
+		if responseErr.Code == "" {
+			responseErr.Code = document.Code
+		}
+		responseErr.Message = strings.TrimSpace(document.Message)
+	}
+	return responseErr
+}
+
+// head returns the properties of the blob with name
+func (c *client) head(ctx context.Context, name string) (*blobInfo, error) {
+	response, err := c.do(ctx, http.MethodHead, name, nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+
+	return parseProperties(name, response.Header, response.ContentLength)
+}
+
+// parseProperties returns the blob with name described by the headers of a
+// response to HEAD or GET
+func parseProperties(name string, header http.Header, size int64) (*blobInfo, error) {
+	info := &blobInfo{
+		name: path.Base(name),
+		size: size,
+		etag: header.Get("ETag"),
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		var err error
+		info.modTime, err = http.ParseTime(lastModified)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Last-Modified %q of %s: %w", lastModified, name, err)
+		}
+	}
+	if contentMD5 := header.Get("Content-MD5"); contentMD5 != "" {
+		var err error
+		info.contentMD5, err = base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Content-MD5 %q of %s: %w", contentMD5, name, err)
+		}
+	}
+	return info, nil
+}
+
+// enumerationResults is a page of a List Blobs response
+type enumerationResults struct {
+	Blobs struct {
+		Blob []struct {
+			Name       string `xml:"Name"`
+			Properties struct {
+				LastModified  string `xml:"Last-Modified"`
+				Etag          string `xml:"Etag"`
+				ContentLength int64  `xml:"Content-Length"`
+			} `xml:"Properties"`
+		} `xml:"Blob"`
+		BlobPrefix []struct {
+			Name string `xml:"Name"`
+		} `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// list returns the blobs and the blob prefixes, as directories, one level
+// below prefix
+func (c *client) list(ctx context.Context, prefix string) ([]*blobInfo, error) {
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+
+	var infos []*blobInfo
+	marker := ""
+	for {
+		query := url.Values{
+			"restype":   {"container"},
+			"comp":      {"list"},
+			"delimiter": {"/"},
+			"prefix":    {prefix},
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		response, err := c.do(ctx, http.MethodGet, "", query, nil, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		var result enumerationResults
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing blob listing of %q: %w", prefix, err)
+		}
+
+		for _, blob := range result.Blobs.Blob {
+			if blob.Name == prefix {
+				// the marker some tools create for directories
+				continue
+			}
+			info := &blobInfo{
+				name: strings.TrimPrefix(blob.Name, prefix),
+				size: blob.Properties.ContentLength,
+				etag: blob.Properties.Etag,
+			}
+			if blob.Properties.LastModified != "" {
+				info.modTime, err = http.ParseTime(blob.Properties.LastModified)
+				if err != nil {
+					return nil, fmt.Errorf("invalid Last-Modified %q of %s: %w", blob.Properties.LastModified, blob.Name, err)
+				}
+			}
+			infos = append(infos, info)
+		}
+		for _, blobPrefix := range result.Blobs.BlobPrefix {
+			infos = append(infos, &blobInfo{
+				name: strings.TrimSuffix(strings.TrimPrefix(blobPrefix.Name, prefix), "/"),
+				dir:  true,
+			})
+		}
+
+		if result.NextMarker == "" {
+			return infos, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// errCopyFailed is returned when a copy the service accepted doesn't succeed
+var errCopyFailed = errors.New("blob copy failed")
+
+// blobInfo is a blob as described by HEAD or a listing. Blobs have no
+// permissions, they report 0644 and blob prefixes 0755.
+type blobInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	etag    string
+	// contentMD5 is the hash stored with the blob, nil if it has none
+	contentMD5 []byte
+}
+
+func (i *blobInfo) Name() string       { return i.name }
+func (i *blobInfo) Size() int64        { return i.size }
+func (i *blobInfo) ModTime() time.Time { return i.modTime }
+func (i *blobInfo) IsDir() bool        { return i.dir }
+func (i *blobInfo) Sys() any           { return nil }
+
+func (i *blobInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ETag returns the entity tag of the blob, empty for directories
+func (i *blobInfo) ETag() string {
+	return i.etag
+}
+
+// ContentMD5 returns the MD5 hash stored with the blob, nil if it has none
+func (i *blobInfo) ContentMD5() []byte {
+	return i.contentMD5
+}
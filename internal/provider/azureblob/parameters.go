@@ -0,0 +1,280 @@
+package azureblob
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// URL schemes
+const (
+	SchemeHTTPS = "https"
+	// SchemeHTTP is for endpoints on a trusted network, e.g. a local Azurite
+	SchemeHTTP = "http"
+)
+
+// Schemes are the supported values of scheme
+var Schemes = []string{SchemeHTTPS, SchemeHTTP}
+
+// Block sizes, blobs larger than the block size are uploaded in blocks of
+// that size and committed together
+const (
+	DefaultBlockSize = 4 << 20
+	MaxBlockSize     = 4000 << 20
+)
+
+// defaultContentType is what blobs are stored as unless configured
+const defaultContentType = "application/octet-stream"
+
+// the subset of terraform schema fields that are needed to connect to a
+// storage account
+type ModelSubset interface {
+	GetAzureblobBlockSize() types.Int64
+	GetAzureblobCaCert() types.String
+	GetAzureblobContainer() types.String
+	GetAzureblobContentType() types.String
+	GetAzureblobMetadata() types.Map
+	GetAzureblobPathStyle() types.Bool
+	GetAzureblobSasToken() types.String
+	GetAzureblobScheme() types.String
+	GetHost() types.String
+	GetHostKey() types.String
+	GetPassword() types.String
+	GetPort() types.Int64
+	GetPrivateKey() types.String
+	GetTimeout() types.String
+	GetUser() types.String
+}
+
+// ConnectionParameters describes the container blobs are stored in and how
+// requests to it are authorized
+type ConnectionParameters struct {
+	endpoint  *url.URL
+	account   string
+	container string
+	pathStyle bool
+	timeout   time.Duration
+
+	// key is nil unless requests are signed with the account key
+	key *sharedKey
+	// sasToken is nil unless requests carry a shared access signature
+	sasToken url.Values
+
+	blockSize   int64
+	contentType string
+	metadata    map[string]string
+
+	// tlsConfig is nil over plain HTTP
+	tlsConfig *tls.Config
+}
+
+// GetAddress returns host:port of the endpoint
+func (p *ConnectionParameters) GetAddress() string {
+	if p.endpoint.Port() != "" {
+		return p.endpoint.Host
+	}
+	port := "443"
+	if p.endpoint.Scheme == SchemeHTTP {
+		port = "80"
+	}
+	return net.JoinHostPort(p.endpoint.Hostname(), port)
+}
+
+// GetContainerURL returns the URL of the container, the account is part of
+// the path when path-style addressing is used, as Azurite expects
+func (p *ConnectionParameters) GetContainerURL() *url.URL {
+	u := *p.endpoint
+	if p.pathStyle {
+		u.Path = "/" + p.account + "/" + p.container
+	} else {
+		u.Path = "/" + p.container
+	}
+	return &u
+}
+
+func (p *ConnectionParameters) GetAccount() string {
+	return p.account
+}
+
+func (p *ConnectionParameters) GetContainer() string {
+	return p.container
+}
+
+func (p *ConnectionParameters) GetPathStyle() bool {
+	return p.pathStyle
+}
+
+func (p *ConnectionParameters) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// GetAuthorization returns how requests are authorized, "shared key", "sas"
+// or "anonymous"
+func (p *ConnectionParameters) GetAuthorization() string {
+	switch {
+	case p.key != nil:
+		return "shared key"
+	case p.sasToken != nil:
+		return "sas"
+	}
+	return "anonymous"
+}
+
+// GetBlockSize returns the size of the blocks large blobs are uploaded in
+func (p *ConnectionParameters) GetBlockSize() int64 {
+	return p.blockSize
+}
+
+// GetContentType returns the Content-Type blobs are written with
+func (p *ConnectionParameters) GetContentType() string {
+	return p.contentType
+}
+
+// GetMetadata returns the metadata blobs are written with, without the
+// x-ms-meta- prefix
+func (p *ConnectionParameters) GetMetadata() map[string]string {
+	return p.metadata
+}
+
+// GetTLSConfig returns the configuration HTTPS connections are made with, nil
+// over plain HTTP
+func (p *ConnectionParameters) GetTLSConfig() *tls.Config {
+	return p.tlsConfig
+}
+
+func CreateConnectionParameters(data ModelSubset) (*ConnectionParameters, error) {
+	if !data.GetHostKey().IsNull() {
+		return nil, errors.New("host_key only applies to SSH, set azureblob.ca_cert to verify an HTTPS endpoint")
+	}
+	if !data.GetPrivateKey().IsNull() {
+		return nil, errors.New("private_key only applies to SSH, Azure Blob Storage authorizes requests with " +
+			"password as account key or azureblob.sas_token")
+	}
+
+	container := data.GetAzureblobContainer().ValueString()
+	if container == "" {
+		return nil, errors.New("azureblob.container is required")
+	}
+
+	scheme := SchemeHTTPS
+	if value := data.GetAzureblobScheme(); !value.IsNull() {
+		scheme = value.ValueString()
+	}
+	if !slices.Contains(Schemes, scheme) {
+		return nil, fmt.Errorf("azureblob.scheme must be one of %s, got %q", strings.Join(Schemes, ", "), scheme)
+	}
+
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+
+	host := data.GetHost().ValueString()
+	endpointHost := host
+	if strings.Contains(host, ":") {
+		// IPv6 literals are bracketed in URLs
+		endpointHost = "[" + host + "]"
+	}
+	if !data.GetPort().IsNull() {
+		endpointHost = net.JoinHostPort(host, strconv.FormatInt(data.GetPort().ValueInt64(), 10))
+	}
+
+	// Endpoints addressed by IP or as localhost can't hold the account in
+	// the host name, Azurite takes it as the first path segment
+	pathStyle := data.GetAzureblobPathStyle().ValueBool() || net.ParseIP(host) != nil || host == "localhost"
+
+	// The account is the first label of its endpoint, e.g.
+	// <account>.blob.core.windows.net, unless user names it
+	account := data.GetUser().ValueString()
+	if account == "" {
+		if pathStyle {
+			return nil, errors.New("user, the storage account name, is required for path-style endpoints such as Azurite")
+		}
+		account, _, _ = strings.Cut(host, ".")
+	}
+
+	params := &ConnectionParameters{
+		endpoint:    &url.URL{Scheme: scheme, Host: endpointHost},
+		account:     account,
+		container:   container,
+		pathStyle:   pathStyle,
+		timeout:     timeoutDuration,
+		blockSize:   DefaultBlockSize,
+		contentType: defaultContentType,
+		metadata:    map[string]string{},
+	}
+
+	accountKey := data.GetPassword().ValueString()
+	sasToken := strings.TrimPrefix(data.GetAzureblobSasToken().ValueString(), "?")
+	switch {
+	case accountKey != "" && sasToken != "":
+		return nil, errors.New("password, the account key, conflicts with azureblob.sas_token, set either")
+	case accountKey != "":
+		key, err := base64.StdEncoding.DecodeString(accountKey)
+		if err != nil {
+			return nil, fmt.Errorf("password must be the base64 encoded account key: %w", err)
+		}
+		params.key = &sharedKey{account: account, key: key}
+	case sasToken != "":
+		params.sasToken, err = url.ParseQuery(sasToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse azureblob.sas_token: %w", err)
+		}
+		if params.sasToken.Get("sig") == "" {
+			return nil, errors.New("azureblob.sas_token has no signature, expected e.g. 'sv=...&sp=racwd&sig=...'")
+		}
+	}
+
+	if value := data.GetAzureblobBlockSize(); !value.IsNull() {
+		params.blockSize = value.ValueInt64()
+		if params.blockSize < 1 || params.blockSize > MaxBlockSize {
+			return nil, fmt.Errorf("azureblob.block_size must be between 1 and %d bytes, got %d", int64(MaxBlockSize), params.blockSize)
+		}
+	}
+	if value := data.GetAzureblobContentType(); !value.IsNull() && value.ValueString() != "" {
+		params.contentType = value.ValueString()
+	}
+	for name, value := range data.GetAzureblobMetadata().Elements() {
+		s, ok := value.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			return nil, fmt.Errorf("azureblob.metadata[%q] must be a known string", name)
+		}
+		params.metadata[strings.ToLower(name)] = s.ValueString()
+	}
+
+	caCert := data.GetAzureblobCaCert()
+	if scheme == SchemeHTTP {
+		if !caCert.IsNull() {
+			return nil, errors.New("azureblob.ca_cert only applies when azureblob.scheme is https")
+		}
+		return params, nil
+	}
+
+	params.tlsConfig = &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if !caCert.IsNull() {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert.ValueString())) {
+			return nil, errors.New("failed to parse azureblob.ca_cert: no PEM certificates found")
+		}
+		params.tlsConfig.RootCAs = pool
+	}
+
+	return params, nil
+}
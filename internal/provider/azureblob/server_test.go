@@ -0,0 +1,446 @@
+package azureblob
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The development account every Azurite instance has, see
+// https://learn.microsoft.com/en-us/azure/storage/common/storage-use-azurite
+const (
+	azuriteAccount = "devstoreaccount1"
+	azuriteKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// testServer is an in-memory Blob service behaving like Azurite: a single
+// container of the development account, addressed path-style. It checks the
+// Shared Key signature or the SAS token of every request.
+type testServer struct {
+	*httptest.Server
+
+	account   string
+	key       string
+	container string
+	// sasToken is the token the server accepts besides the account key, its
+	// sp field the permissions it grants
+	sasToken url.Values
+
+	mu    sync.Mutex
+	blobs map[string]*testBlob
+	// blocks are the uncommitted blocks by blob name and block ID
+	blocks map[string]map[string][]byte
+	// requests are "METHOD path comp" of every request received
+	requests []string
+	// before runs ahead of every request
+	before func(r *http.Request)
+	// pageSize is the maximum number of blobs per listing page
+	pageSize int
+	// pendingCopies is how many HEAD requests report a copy as pending
+	// before it succeeds
+	pendingCopies int
+}
+
+type testBlob struct {
+	data        []byte
+	contentType string
+	contentMD5  []byte
+	metadata    map[string]string
+	modTime     time.Time
+	copyStatus  string
+}
+
+func (b *testBlob) etag() string {
+	sum := md5.Sum([]byte(b.modTime.String()))
+	return `"0x` + strings.ToUpper(hex.EncodeToString(sum[:8])) + `"`
+}
+
+type testServerOption func(*testServer)
+
+// withBefore runs before ahead of every request, with the server locked
+func withBefore(before func(r *http.Request)) testServerOption {
+	return func(s *testServer) {
+		s.before = before
+	}
+}
+
+// withPageSize limits listings to size blobs per page
+func withPageSize(size int) testServerOption {
+	return func(s *testServer) {
+		s.pageSize = size
+	}
+}
+
+// withPendingCopies reports copies as pending to the first count HEAD
+// requests after them
+func withPendingCopies(count int) testServerOption {
+	return func(s *testServer) {
+		s.pendingCopies = count
+	}
+}
+
+func newTestServer(t *testing.T, opts ...testServerOption) *testServer {
+	t.Helper()
+
+	s := &testServer{
+		account:   azuriteAccount,
+		key:       azuriteKey,
+		container: "terraform",
+		sasToken: url.Values{
+			"sv":  {apiVersion},
+			"sr":  {"c"},
+			"sp":  {"racwdl"},
+			"sig": {"dGVzdC1zaWduYXR1cmU="},
+		},
+		blobs:    map[string]*testBlob{},
+		blocks:   map[string]map[string][]byte{},
+		pageSize: 5000,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// put stores a blob as if it had been uploaded at modTime
+func (s *testServer) put(name string, data string, modTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := md5.Sum([]byte(data))
+	s.blobs[name] = &testBlob{data: []byte(data), contentType: defaultContentType, contentMD5: sum[:], modTime: modTime}
+}
+
+// blob returns the blob stored under name, nil if there is none
+func (s *testServer) blob(name string) *testBlob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blobs[name]
+}
+
+// host returns the host and port the server listens on
+func (s *testServer) host() (string, int64) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.ParseInt(u.Port(), 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return u.Hostname(), port
+}
+
+// count returns how many requests were received as request, "METHOD path comp"
+func (s *testServer) count(request string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, received := range s.requests {
+		if received == request {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+query.Get("comp"))
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeTestError(w, r, http.StatusBadRequest, "InvalidInput", err.Error())
+		return
+	}
+	if status, code := s.authorize(r); status != 0 {
+		writeTestError(w, r, status, code, "request rejected")
+		return
+	}
+	if contentMD5 := r.Header.Get("Content-MD5"); contentMD5 != "" {
+		sum := md5.Sum(body)
+		if contentMD5 != base64.StdEncoding.EncodeToString(sum[:]) {
+			writeTestError(w, r, http.StatusBadRequest, "Md5Mismatch", "The MD5 value specified in the request did not match")
+			return
+		}
+	}
+
+	if s.before != nil {
+		s.before(r)
+	}
+
+	account, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	container, name, _ := strings.Cut(rest, "/")
+	if account != s.account || container != s.container {
+		writeTestError(w, r, http.StatusNotFound, "ContainerNotFound", "The specified container does not exist.")
+		return
+	}
+
+	w.Header().Set("X-Ms-Version", apiVersion)
+	switch {
+	case name == "" && r.Method == http.MethodGet && query.Get("comp") == "list":
+		s.list(w, r)
+	case r.Method == http.MethodHead, r.Method == http.MethodGet:
+		blob, ok := s.blobs[name]
+		if !ok {
+			writeTestError(w, r, http.StatusNotFound, "BlobNotFound", "The specified blob does not exist.")
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && match != blob.etag() {
+			writeTestError(w, r, http.StatusPreconditionFailed, "ConditionNotMet", "The condition specified using HTTP conditional header(s) is not met.")
+			return
+		}
+		w.Header().Set("Content-Type", blob.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(blob.data)))
+		w.Header().Set("ETag", blob.etag())
+		w.Header().Set("Last-Modified", blob.modTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Ms-Blob-Type", "BlockBlob")
+		if blob.contentMD5 != nil {
+			w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(blob.contentMD5))
+		}
+		for key, value := range blob.metadata {
+			w.Header().Set("X-Ms-Meta-"+key, value)
+		}
+		if blob.copyStatus != "" {
+			if blob.copyStatus == "pending" && s.pendingCopies > 0 {
+				s.pendingCopies--
+			} else {
+				blob.copyStatus = "success"
+			}
+			w.Header().Set("X-Ms-Copy-Status", blob.copyStatus)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(blob.data)
+		}
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		if s.blocks[name] == nil {
+			s.blocks[name] = map[string][]byte{}
+		}
+		s.blocks[name][query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var list blockList
+		if err := xml.Unmarshal(body, &list); err != nil {
+			writeTestError(w, r, http.StatusBadRequest, "InvalidXmlDocument", err.Error())
+			return
+		}
+		var data []byte
+		for _, id := range list.Latest {
+			block, ok := s.blocks[name][id]
+			if !ok {
+				writeTestError(w, r, http.StatusBadRequest, "InvalidBlockList", "The specified block list is invalid.")
+				return
+			}
+			data = append(data, block...)
+		}
+		delete(s.blocks, name)
+		s.store(w, r, name, data)
+	case r.Method == http.MethodPut && r.Header.Get("X-Ms-Copy-Source") != "":
+		source, err := url.Parse(r.Header.Get("X-Ms-Copy-Source"))
+		if err != nil {
+			writeTestError(w, r, http.StatusBadRequest, "InvalidHeaderValue", err.Error())
+			return
+		}
+		blob, ok := s.blobs[strings.TrimPrefix(source.Path, "/"+s.account+"/"+s.container+"/")]
+		if !ok {
+			writeTestError(w, r, http.StatusNotFound, "CannotVerifyCopySource", "The specified blob does not exist.")
+			return
+		}
+		copied := *blob
+		copied.modTime = time.Now()
+		copied.copyStatus = "success"
+		if s.pendingCopies > 0 {
+			copied.copyStatus = "pending"
+		}
+		s.blobs[name] = &copied
+		w.Header().Set("ETag", copied.etag())
+		w.Header().Set("X-Ms-Copy-Status", copied.copyStatus)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut:
+		if r.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+			writeTestError(w, r, http.StatusBadRequest, "MissingRequiredHeader", "x-ms-blob-type is required")
+			return
+		}
+		s.store(w, r, name, body)
+	case r.Method == http.MethodDelete:
+		if _, ok := s.blobs[name]; !ok {
+			writeTestError(w, r, http.StatusNotFound, "BlobNotFound", "The specified blob does not exist.")
+			return
+		}
+		delete(s.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeTestError(w, r, http.StatusMethodNotAllowed, "UnsupportedHttpVerb", "The resource doesn't support the specified HTTP verb.")
+	}
+}
+
+// store commits data as the blob with name, with the properties and
+// metadata set by the headers of r
+func (s *testServer) store(w http.ResponseWriter, r *http.Request, name string, data []byte) {
+	blob := &testBlob{
+		data:        data,
+		contentType: r.Header.Get("X-Ms-Blob-Content-Type"),
+		metadata:    map[string]string{},
+		modTime:     time.Now(),
+	}
+	if contentMD5 := r.Header.Get("X-Ms-Blob-Content-Md5"); contentMD5 != "" {
+		blob.contentMD5, _ = base64.StdEncoding.DecodeString(contentMD5)
+	} else if r.URL.Query().Get("comp") == "" {
+		// Put Blob stores the hash of the body
+		sum := md5.Sum(data)
+		blob.contentMD5 = sum[:]
+	}
+	for key, values := range r.Header {
+		if meta, ok := strings.CutPrefix(strings.ToLower(key), "x-ms-meta-"); ok {
+			blob.metadata[meta] = values[0]
+		}
+	}
+	s.blobs[name] = blob
+	w.Header().Set("ETag", blob.etag())
+	w.WriteHeader(http.StatusCreated)
+}
+
+// authorize returns the status and error code of a request whose signature
+// or SAS token is wrong or grants too little, 0 if it is authorized
+func (s *testServer) authorize(r *http.Request) (int, string) {
+	query := r.URL.Query()
+	if signature := query.Get("sig"); signature != "" {
+		if signature != s.sasToken.Get("sig") {
+			return http.StatusForbidden, "AuthenticationFailed"
+		}
+		permission := "r"
+		switch {
+		case r.Method == http.MethodDelete:
+			permission = "d"
+		case r.Method == http.MethodPut:
+			permission = "w"
+		case query.Get("comp") == "list":
+			permission = "l"
+		}
+		if !strings.Contains(query.Get("sp"), permission) {
+			return http.StatusForbidden, "AuthorizationPermissionMismatch"
+		}
+		return 0, ""
+	}
+
+	authorization, ok := strings.CutPrefix(r.Header.Get("Authorization"), "SharedKey ")
+	if !ok {
+		// anonymous access is disabled
+		return http.StatusNotFound, "ResourceNotFound"
+	}
+	account, signature, _ := strings.Cut(authorization, ":")
+	if account != s.account {
+		return http.StatusForbidden, "AuthenticationFailed"
+	}
+
+	key, _ := base64.StdEncoding.DecodeString(s.key)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign(r, s.account)))
+	if !hmac.Equal([]byte(signature), []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))) {
+		return http.StatusForbidden, "AuthenticationFailed"
+	}
+	return 0, ""
+}
+
+// list answers a List Blobs request
+func (s *testServer) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+
+	var names []string
+	for name := range s.blobs {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if marker := query.Get("marker"); marker != "" {
+		start, _ = strconv.Atoi(marker)
+	}
+
+	type properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		Etag          string
+		ContentLength int `xml:"Content-Length"`
+	}
+	type blob struct {
+		Name       string
+		Properties properties
+	}
+	var result struct {
+		XMLName xml.Name `xml:"EnumerationResults"`
+		Blobs   struct {
+			Blob       []blob
+			BlobPrefix []struct {
+				Name string
+			}
+		}
+		NextMarker string
+	}
+	seen := map[string]bool{}
+	for i := start; i < len(names); i++ {
+		if i-start == s.pageSize {
+			result.NextMarker = strconv.Itoa(i)
+			break
+		}
+
+		name := names[i]
+		if rest := strings.TrimPrefix(name, prefix); delimiter != "" && strings.Contains(rest, delimiter) {
+			blobPrefix := prefix + rest[:strings.Index(rest, delimiter)+1]
+			if !seen[blobPrefix] {
+				seen[blobPrefix] = true
+				result.Blobs.BlobPrefix = append(result.Blobs.BlobPrefix, struct{ Name string }{blobPrefix})
+			}
+			continue
+		}
+
+		stored := s.blobs[name]
+		result.Blobs.Blob = append(result.Blobs.Blob, blob{
+			Name: name,
+			Properties: properties{
+				LastModified:  stored.modTime.UTC().Format(http.TimeFormat),
+				Etag:          stored.etag(),
+				ContentLength: len(stored.data),
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func writeTestError(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("X-Ms-Error-Code", code)
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprint(&buffer, xml.Header)
+	xml.NewEncoder(&buffer).Encode(errorDocument{Code: code, Message: message})
+	w.Write(buffer.Bytes())
+}
@@ -0,0 +1,65 @@
+package azureblob
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStringToSignContainerMetadata builds the string to sign of the Get
+// Container Metadata example of the Shared Key documentation,
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func TestStringToSignContainerMetadata(t *testing.T) {
+	request, err := http.NewRequest(http.MethodGet,
+		"https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=metadata&timeout=20", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	request.Header.Set("X-Ms-Date", "Sun, 11 Oct 2009 21:49:13 GMT")
+	request.Header.Set("X-Ms-Version", "2009-09-19")
+
+	expected := "GET\n\n\n\n\n\n\n\n\n\n\n\n" +
+		"x-ms-date:Sun, 11 Oct 2009 21:49:13 GMT\nx-ms-version:2009-09-19\n" +
+		"/myaccount/mycontainer\ncomp:metadata\nrestype:container\ntimeout:20"
+	if actual := stringToSign(request, "myaccount"); actual != expected {
+		t.Errorf("stringToSign() = %q, expected %q", actual, expected)
+	}
+}
+
+func TestStringToSignPutBlob(t *testing.T) {
+	request, err := http.NewRequest(http.MethodPut,
+		"http://127.0.0.1:10000/devstoreaccount1/terraform/app%20v1.json", strings.NewReader("contents"))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	request.Header.Set("Content-MD5", "mJhKkHsFMVQK0HBU5gyb3Q==")
+	request.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	request.Header.Set("X-Ms-Meta-Team", "platform")
+	request.Header.Set("X-Ms-Version", apiVersion)
+
+	key := &sharedKey{account: azuriteAccount, key: []byte("key")}
+	key.sign(request, time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+
+	expected := "PUT\n\n\n8\nmJhKkHsFMVQK0HBU5gyb3Q==\n\n\n\n\n\n\n\n" +
+		"x-ms-blob-type:BlockBlob\nx-ms-date:Wed, 01 May 2024 12:30:00 GMT\nx-ms-meta-team:platform\n" +
+		"x-ms-version:2020-10-02\n/devstoreaccount1/devstoreaccount1/terraform/app%20v1.json"
+	if actual := stringToSign(request, azuriteAccount); actual != expected {
+		t.Errorf("stringToSign() = %q, expected %q", actual, expected)
+	}
+	if authorization := request.Header.Get("Authorization"); !strings.HasPrefix(authorization, "SharedKey devstoreaccount1:") {
+		t.Errorf("Authorization = %q, expected a Shared Key signature of devstoreaccount1", authorization)
+	}
+}
+
+func TestStringToSignEmptyBody(t *testing.T) {
+	request, err := http.NewRequest(http.MethodDelete, "https://myaccount.blob.core.windows.net/c/b", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	// a Content-Length of 0 is signed as empty since version 2015-02-21
+	if actual := stringToSign(request, "myaccount"); !strings.HasPrefix(actual, "DELETE\n\n\n\n") {
+		t.Errorf("stringToSign() = %q, expected an empty Content-Length", actual)
+	}
+}
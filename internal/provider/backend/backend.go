@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// File is a remote file opened by a Backend
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// Backend is an open connection to wherever a protocol keeps remote files.
+// Paths are passed as given in the configuration, errors for missing files
+// wrap os.ErrNotExist and errors for files the user may not access wrap
+// os.ErrPermission.
+type Backend interface {
+	// Address identifies where the files live in logs, e.g. host:port
+	Address() string
+
+	// Open opens path for reading
+	Open(path string) (File, error)
+	// OpenFile opens path with the os.O_* flags in flag, files it creates
+	// get the backend's default mode
+	OpenFile(path string, flag int) (File, error)
+	Stat(path string) (os.FileInfo, error)
+	// Lstat is Stat without following a symbolic link at path
+	Lstat(path string) (os.FileInfo, error)
+	Chmod(path string, mode os.FileMode) error
+	Chown(path string, uid int, gid int) error
+	// Rename moves oldpath to newpath, replacing newpath if it exists.
+	// Backends that could only replace newpath by removing it first fail
+	// with errors.ErrUnsupported instead, callers decide whether that will do.
+	Rename(oldpath string, newpath string) error
+	Remove(path string) error
+	Mkdir(path string) error
+	ReadDir(path string) ([]os.FileInfo, error)
+
+	Close() error
+}
+
+// Dialer opens a Backend. The connection is torn down once ctx is done, so a
+// cancelled operation doesn't wait for a transfer in flight.
+type Dialer func(ctx context.Context) (Backend, error)
+
+// CommandResult is the outcome of a command run on the remote host
+type CommandResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Commander is implemented by backends that can run commands on the host the
+// files live on. A command that ran and exited non-zero is not an error, its
+// exit code is in the result.
+type Commander interface {
+	Run(ctx context.Context, command string) (CommandResult, error)
+}
+
+// Owned is implemented by the Sys() of file infos that know who owns the file
+type Owned interface {
+	Ownership() (uid int, gid int)
+}
+
+// Installer is implemented by backends that write files the user can't
+// write on their own, by staging the contents where the user may and moving
+// them into place with elevated privileges, e.g. SFTP with `sudo install`
+type Installer interface {
+	// Stage returns the path the contents for target are staged in, and a
+	// function that removes whatever staging left behind
+	Stage(target string) (staged string, cleanup func(), err error)
+	// Install moves staged over target with mode, and with owner and group
+	// unless they are empty, given as names or numeric ids
+	Install(ctx context.Context, staged string, target string, mode os.FileMode, owner string, group string) error
+	// Uninstall removes target with elevated privileges, failing like Remove
+	// if it doesn't exist
+	Uninstall(ctx context.Context, target string) error
+}
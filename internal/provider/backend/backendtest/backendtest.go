@@ -0,0 +1,59 @@
+// Package backendtest holds what the tests of the protocol packages share: a
+// model with the settings of every protocol and the dialer built from it.
+package backendtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+)
+
+// Model is the resource model with the settings of every protocol, as far as
+// the protocols and the remote operations are concerned
+type Model struct {
+	model.RemoteFileResourceModel
+	model.AzureblobSettings
+	model.ExecSettings
+	model.FtpSettings
+	model.S3Settings
+	model.WebdavSettings
+}
+
+// NullModel returns a model for host with every other attribute null. The
+// settings attributes are set, with every setting null.
+func NullModel(host string) *Model {
+	return &Model{
+		RemoteFileResourceModel: model.RemoteFileResourceModel{Host: types.StringValue(host)},
+		AzureblobSettings: model.AzureblobSettings{
+			Azureblob: &model.AzureblobModel{Metadata: types.MapNull(types.StringType)},
+		},
+		ExecSettings:   model.ExecSettings{Exec: &model.ExecModel{Args: types.MapNull(types.StringType)}},
+		FtpSettings:    model.FtpSettings{Ftp: &model.FtpModel{}},
+		S3Settings:     model.S3Settings{S3: &model.S3Model{Metadata: types.MapNull(types.StringType)}},
+		WebdavSettings: model.WebdavSettings{Webdav: &model.WebdavModel{}},
+	}
+}
+
+// Dialer returns a function dialing the backend of the protocol, a B, with
+// the connection parameters create builds from data. It fails t if data is
+// invalid.
+func Dialer[B backend.Backend, M, P any](t *testing.T, data M, create func(M) (P, error), dialer func(P) backend.Dialer) func(context.Context) (B, error) {
+	t.Helper()
+
+	params, err := create(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	return func(ctx context.Context) (B, error) {
+		b, err := dialer(params)(ctx)
+		if err != nil {
+			var zero B
+			return zero, err
+		}
+		return b.(B), nil
+	}
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Protocol is a way of reaching remote files. Every registered protocol gets
+// a remotefile_<Name> resource, all of them sharing one model, extended with
+// the protocol's own Settings.
+type Protocol struct {
+	// Name is the suffix of the resource type name, e.g. "sftp"
+	Name string
+	// Description names the protocol in schema descriptions, e.g. "SFTP"
+	Description string
+	// Dialer returns the Dialer for the connection settings in data, a model
+	// implementing the getters the protocol needs
+	Dialer func(data any) (Dialer, error)
+	// Unsupported names the attributes of the shared model the protocol
+	// can't honour, configuring them is an error
+	Unsupported []string
+	// Settings are the attributes only this protocol takes, nil if it has none
+	Settings *Settings
+}
+
+// Settings are grouped in an attribute named after the protocol, which only
+// the schemas of its resource, data source and ephemeral resource have
+type Settings struct {
+	// Description describes the attribute in the schemas
+	Description string
+	// Attributes are the settings, by name
+	Attributes map[string]Setting
+	// Validate checks the settings of data, a model one of the constructors
+	// below returned. readOnly is set for data sources and ephemeral
+	// resources.
+	Validate func(data any, readOnly bool, diags *diag.Diagnostics)
+	// Credential returns the name of the setting of data that authenticates
+	// instead of password and private_key, or "" if it isn't set. It is nil
+	// when none of the settings is a credential.
+	Credential func(data any) string
+	// NewResourceModel, NewDataSourceModel and NewEphemeralResourceModel
+	// return empty models of the resource, the data source and the ephemeral
+	// resource: the shared model embedded with the settings and their getters
+	NewResourceModel          func() any
+	NewDataSourceModel        func() any
+	NewEphemeralResourceModel func() any
+}
+
+// Setting is an optional attribute of Settings
+type Setting struct {
+	Description string
+	// Type is a string, a bool, an int64 or a map of strings
+	Type      attr.Type
+	Sensitive bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Protocol{}
+)
+
+// Register makes protocol available under its name. It panics if the name is
+// empty or already registered, as both are programming errors.
+func Register(protocol Protocol) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if protocol.Name == "" || protocol.Dialer == nil {
+		panic("backend: Register requires a name and a dialer")
+	}
+	if settings := protocol.Settings; settings != nil && (settings.NewResourceModel == nil ||
+		settings.NewDataSourceModel == nil || settings.NewEphemeralResourceModel == nil) {
+		panic(fmt.Sprintf("backend: the settings of protocol %q require models", protocol.Name))
+	}
+	if _, ok := registry[protocol.Name]; ok {
+		panic(fmt.Sprintf("backend: protocol %q registered twice", protocol.Name))
+	}
+	registry[protocol.Name] = protocol
+}
+
+// Lookup returns the protocol registered under name
+func Lookup(name string) (Protocol, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	protocol, ok := registry[name]
+	return protocol, ok
+}
+
+// Protocols returns every registered protocol, ordered by name
+func Protocols() []Protocol {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	protocols := make([]Protocol, 0, len(registry))
+	for _, protocol := range registry {
+		protocols = append(protocols, protocol)
+	}
+	sort.Slice(protocols, func(i, j int) bool {
+		return protocols[i].Name < protocols[j].Name
+	})
+	return protocols
+}
@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func testDialer(any) (Dialer, error) {
+	return func(context.Context) (Backend, error) {
+		return nil, errors.New("not implemented")
+	}, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(Protocol{Name: "test-b", Description: "Test B", Dialer: testDialer})
+	Register(Protocol{Name: "test-a", Description: "Test A", Dialer: testDialer})
+
+	protocol, ok := Lookup("test-a")
+	if !ok || protocol.Description != "Test A" {
+		t.Errorf("Lookup(%q) = %v, %v, expected the registered protocol", "test-a", protocol, ok)
+	}
+
+	if _, ok := Lookup("test-missing"); ok {
+		t.Errorf("Lookup(%q) found a protocol that was never registered", "test-missing")
+	}
+
+	var names []string
+	for _, protocol := range Protocols() {
+		names = append(names, protocol.Name)
+	}
+	if len(names) < 2 || names[0] != "test-a" || names[1] != "test-b" {
+		t.Errorf("Protocols() = %v, expected test-a before test-b", names)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	Register(Protocol{Name: "test-twice", Dialer: testDialer})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of a duplicate name didn't panic")
+		}
+	}()
+	Register(Protocol{Name: "test-twice", Dialer: testDialer})
+}
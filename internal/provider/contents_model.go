@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+)
+
+// contentsModel routes file contents between the resource model and the
+// remote operations. Contents taken from contents_wo or rendered from
+// template are written to the remote file but never copied back into the
+// model, so they stay out of the plan and state.
+type contentsModel struct {
+	*model.RemoteFileResourceModel
+	hashOnly bool
+	contents types.String
+}
+
+// newContentsModel wraps data. When hashOnly is set, contents are sourced
+// from contents_wo rather than contents, and only their hash is kept.
+func newContentsModel(data *model.RemoteFileResourceModel, hashOnly bool) *contentsModel {
+	contents := data.Contents
+	if hashOnly {
+		contents = data.ContentsWo
+	}
+
+	return &contentsModel{
+		RemoteFileResourceModel: data,
+		hashOnly:                hashOnly,
+		contents:                contents,
+	}
+}
+
+func (c *contentsModel) GetContents() types.String { return c.contents }
+
+func (c *contentsModel) SetContents(contents types.String) {
+	c.contents = contents
+	if !c.hashOnly {
+		c.RemoteFileResourceModel.SetContents(contents)
+	}
+}
+
+// hashContents is the digest kept in state to detect drift without storing
+// the contents themselves
+func hashContents(contents types.String) types.String {
+	return types.StringValue(fmt.Sprintf("%x", sha256.Sum256([]byte(contents.ValueString()))))
+}
+
+// writtenHashKey is the private state key holding the hash of the contents
+// last written, which the contents_hash a refresh found is compared with
+const writtenHashKey = "written_hash"
+
+// privateState is the private state of a resource as the requests and
+// responses of Terraform carry it
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// writtenHash returns the hash of the contents last written, null if there
+// is none in private state
+func writtenHash(ctx context.Context, private privateState) (types.String, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, writtenHashKey)
+	if diags.HasError() || value == nil {
+		return types.StringNull(), diags
+	}
+
+	var hash string
+	if err := json.Unmarshal(value, &hash); err != nil {
+		diags.AddError("invalid private state", fmt.Sprintf("error parsing %s: %s", writtenHashKey, err))
+		return types.StringNull(), diags
+	}
+	return types.StringValue(hash), diags
+}
+
+// setWrittenHash records hash as the hash of the contents last written
+func setWrittenHash(ctx context.Context, private privateState, hash types.String) diag.Diagnostics {
+	value, err := json.Marshal(hash.ValueString())
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("invalid private state", fmt.Sprintf("error encoding %s: %s", writtenHashKey, err))
+		return diags
+	}
+	return private.SetKey(ctx, writtenHashKey, value)
+}
@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/structured"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource                   = &decodedFileDataSource{}
+	_ datasource.DataSourceWithConfigure      = &decodedFileDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &decodedFileDataSource{}
+)
+
+// NewDecodedFileDataSource is a helper function to simplify the provider implementation.
+func NewDecodedFileDataSource() datasource.DataSource {
+	return &decodedFileDataSource{
+		retryPolicy: defaultRetryPolicy,
+	}
+}
+
+// decodedFileDataSource reads a structured remote file and decodes it
+type decodedFileDataSource struct {
+	retryPolicy retry.Policy
+}
+
+// Configure adds the provider-level defaults to the data source.
+func (d *decodedFileDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected data source configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.retryPolicy = data.retryPolicy
+}
+
+// Metadata returns the data source type name.
+func (d *decodedFileDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sftp_decoded"
+}
+
+// Schema defines the schema for the data source.
+func (d *decodedFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"allow_missing": schema.BoolAttribute{
+			Description: "Whether to ignore that the file is missing, value is null if it is",
+			Optional:    true,
+		},
+		"format": schema.StringAttribute{
+			Description: "The file format, one of " + strings.Join(structured.DecodeFormats, ", "),
+			Required:    true,
+		},
+		"path": schema.StringAttribute{
+			Description: "The file path",
+			Required:    true,
+		},
+		"query": schema.StringAttribute{
+			Description: "If set, the dotted path of the value to return rather than the whole file (e.g. 'server.ports.0', " +
+				"escape dots within keys as '\\.')",
+			Optional: true,
+		},
+		"value": schema.DynamicAttribute{
+			Description: "The decoded file, or the value at query. INI, dotenv and properties files only hold strings.",
+			Computed:    true,
+			Sensitive:   true,
+		},
+		"id": schema.StringAttribute{
+			Description: "The ID of the remote file",
+			Computed:    true,
+		},
+	}
+	maps.Copy(attributes, connectionDataSourceAttributes())
+
+	resp.Schema = schema.Schema{
+		Description: "Retrieves a JSON, YAML, TOML, INI, dotenv or Java properties file from a remote system using SFTP " +
+			"and decodes it.",
+		Attributes: attributes,
+	}
+}
+
+// ValidateConfig checks the format
+func (d *decodedFileDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data model.RemoteFileDecodedDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	format := data.Format
+	if !format.IsUnknown() && !slices.Contains(structured.DecodeFormats, format.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("format"),
+			"unsupported file format",
+			fmt.Sprintf("format must be one of %s, got %q.", strings.Join(structured.DecodeFormats, ", "), format.ValueString()),
+		)
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *decodedFileDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data model.RemoteFileDecodedDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	dial, retryPolicy, diags := prepareConnection(d.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	contents, exists, err := readSnapshot(ctx, dial, retryPolicy, data.Path)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error reading remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = types.StringValue(fmt.Sprintf("%s:%s", data.Host.ValueString(), data.Path.ValueString()))
+
+	if !exists {
+		if !data.AllowMissing.ValueBool() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("path"),
+				"remote file not found",
+				fmt.Sprintf("The remote file %s does not exist, set allow_missing to read it as null.", data.Path.ValueString()),
+			)
+			return
+		}
+
+		data.Value = types.DynamicNull()
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	document, err := structured.Decode(data.Format.ValueString(), contents)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("format"),
+			"error decoding remote file",
+			fmt.Sprintf("The remote file %s could not be decoded as %s.\n\n%s",
+				data.Path.ValueString(), data.Format.ValueString(), err),
+		)
+		return
+	}
+
+	if query := data.Query.ValueString(); query != "" {
+		found, ok := structured.Lookup(document, structured.SplitPath(query))
+		if !ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("query"),
+				"query not found",
+				fmt.Sprintf("The remote file %s has no value at %s.", data.Path.ValueString(), query),
+			)
+			return
+		}
+		document = found
+	}
+
+	value, diags := decodedValue(document)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Value = types.DynamicValue(value)
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// decodedValue converts a decoded document into a Terraform value. Objects
+// become objects rather than maps and arrays tuples, so their elements may
+// differ in type. Nulls within a document are null strings, as dynamic
+// values cannot nest.
+func decodedValue(document any) (attr.Value, diag.Diagnostics) {
+	switch value := document.(type) {
+	case nil:
+		return types.StringNull(), nil
+	case string:
+		return types.StringValue(value), nil
+	case bool:
+		return types.BoolValue(value), nil
+	case int64:
+		return types.NumberValue(new(big.Float).SetInt64(value)), nil
+	case float64:
+		return types.NumberValue(big.NewFloat(value)), nil
+	case map[string]any:
+		var diags diag.Diagnostics
+		attrTypes := make(map[string]attr.Type, len(value))
+		attrValues := make(map[string]attr.Value, len(value))
+		for key, element := range value {
+			converted, elementDiags := decodedValue(element)
+			diags.Append(elementDiags...)
+			if diags.HasError() {
+				return nil, diags
+			}
+			attrTypes[key] = converted.Type(context.Background())
+			attrValues[key] = converted
+		}
+		object, objectDiags := types.ObjectValue(attrTypes, attrValues)
+		diags.Append(objectDiags...)
+		return object, diags
+	case []any:
+		var diags diag.Diagnostics
+		elementTypes := make([]attr.Type, 0, len(value))
+		elements := make([]attr.Value, 0, len(value))
+		for _, element := range value {
+			converted, elementDiags := decodedValue(element)
+			diags.Append(elementDiags...)
+			if diags.HasError() {
+				return nil, diags
+			}
+			elementTypes = append(elementTypes, converted.Type(context.Background()))
+			elements = append(elements, converted)
+		}
+		tuple, tupleDiags := types.TupleValue(elementTypes, elements)
+		diags.Append(tupleDiags...)
+		return tuple, diags
+	default:
+		var diags diag.Diagnostics
+		diags.AddError(
+			"error decoding remote file",
+			fmt.Sprintf("unexpected decoded value of type %T", document),
+		)
+		return nil, diags
+	}
+}
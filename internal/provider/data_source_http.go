@@ -0,0 +1,288 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/httpfile"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &httpFileDataSource{}
+	_ datasource.DataSourceWithConfigure = &httpFileDataSource{}
+)
+
+// NewHttpFileDataSource is a helper function to simplify the provider implementation.
+func NewHttpFileDataSource() datasource.DataSource {
+	return &httpFileDataSource{
+		retryPolicy: defaultRetryPolicy,
+	}
+}
+
+// httpFileDataSource fetches a file served over HTTP(S)
+type httpFileDataSource struct {
+	retryPolicy retry.Policy
+}
+
+// Configure adds the provider-level defaults to the data source.
+func (d *httpFileDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected data source configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.retryPolicy = data.retryPolicy
+}
+
+// Metadata returns the data source type name.
+func (d *httpFileDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_http"
+}
+
+// Schema defines the schema for the data source.
+func (d *httpFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Retrieves the content of a file served over HTTP or HTTPS.",
+		Attributes: map[string]schema.Attribute{
+			"allow_missing": schema.BoolAttribute{
+				Description: "Whether to ignore a 404 Not Found, contents is then empty and status_code 404",
+				Optional:    true,
+			},
+			"ca_cert": schema.StringAttribute{
+				Description: "PEM encoded CA certificates to verify the server with instead of the system roots",
+				Optional:    true,
+			},
+			"cache_dir": schema.StringAttribute{
+				Description: "If set, the directory responses with an ETag or Last-Modified header are cached in. " +
+					"Later reads send If-None-Match and If-Modified-Since and reuse the cached file if the server " +
+					"answers 304 Not Modified.",
+				Optional: true,
+			},
+			"client_cert": schema.StringAttribute{
+				Description: "PEM encoded certificate to authenticate with over mutual TLS, requires client_key",
+				Optional:    true,
+			},
+			"client_key": schema.StringAttribute{
+				Description: "PEM encoded private key of client_cert",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"contents": schema.StringAttribute{
+				Description: "The file contents",
+				Computed:    true,
+			},
+			"last_modified": schema.StringAttribute{
+				Description: "The Last-Modified header of the response, null if the server didn't send one",
+				Computed:    true,
+			},
+			"password": schema.StringAttribute{
+				Description: "The password sent with user over basic auth",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"request_headers": schema.MapAttribute{
+				Description: "Headers sent with the request, e.g. an Authorization or API key header",
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"response_headers": schema.MapAttribute{
+				Description: "The headers of the response, values of repeated headers joined by ', '",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"size": schema.Int64Attribute{
+				Description: "The file size",
+				Computed:    true,
+			},
+			"status_code": schema.Int64Attribute{
+				Description: "The HTTP status code of the response, that of the cached response if the server " +
+					"answered 304 Not Modified",
+				Computed: true,
+			},
+			"timeout": schema.StringAttribute{
+				Description: "The request timeout",
+				Optional:    true,
+			},
+			"url": schema.StringAttribute{
+				Description: "The URL of the file, https:// or http://",
+				Required:    true,
+			},
+			"user": schema.StringAttribute{
+				Description: "If set, the username sent over basic auth",
+				Optional:    true,
+			},
+			"id": schema.StringAttribute{
+				Description: "The URL of the file",
+				Computed:    true,
+			},
+			"retry_count": schema.Int64Attribute{
+				Description: "Number of times to retry on failure",
+				Optional:    true,
+			},
+			"retry_interval": schema.StringAttribute{
+				Description: "Time to wait between retries (e.g. '10s')",
+				Optional:    true,
+			},
+			"retry_max_interval": schema.StringAttribute{
+				Description: "Upper bound for the time to wait between retries when a multiplier is set (e.g. '1m')",
+				Optional:    true,
+			},
+			"retry_multiplier": schema.Float64Attribute{
+				Description: "Factor the wait between retries grows by after every retry, 1 keeps it fixed",
+				Optional:    true,
+			},
+			"retry_jitter": schema.Float64Attribute{
+				Description: "Fraction (0 to 1) by which every wait between retries is randomized",
+				Optional:    true,
+			},
+			"retry_max_elapsed": schema.StringAttribute{
+				Description: "Total time after which no further retries are attempted (e.g. '5m')",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *httpFileDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data model.RemoteFileHttpDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	retryPolicy, retryDiags := buildRetryPolicy(d.retryPolicy, &data)
+	resp.Diagnostics.Append(retryDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	params, err := httpfile.CreateRequestParameters(&data)
+	if err != nil {
+		resp.Diagnostics.AddError("error creating HTTP request parameters", err.Error())
+		return
+	}
+
+	var response *httpfile.Response
+	err = retry.Do(ctx, retryPolicy, func(ctx context.Context) error {
+		var err error
+		response, err = httpfile.Get(ctx, params)
+		return err
+	})
+
+	var statusErr *httpfile.StatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound && data.AllowMissing.ValueBool() {
+		tflog.SubsystemDebug(ctx, httpfile.Subsystem, "remote file missing, allowed by allow_missing", map[string]interface{}{
+			"url": params.GetURL().String(),
+		})
+		response, err = &httpfile.Response{StatusCode: statusErr.Code, Header: statusErr.Header}, nil
+	}
+	if err != nil {
+		addHttpError(&resp.Diagnostics, "error reading remote file", err, &data)
+		return
+	}
+
+	headers, diags := responseHeaders(response.Header)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ID = types.StringValue(params.GetURL().String())
+	data.Contents = types.StringValue(string(response.Body))
+	data.Size = types.Int64Value(int64(len(response.Body)))
+	data.StatusCode = types.Int64Value(int64(response.StatusCode))
+	data.ResponseHeaders = headers
+	data.LastModified = types.StringNull()
+	if lastModified := response.LastModified(); !lastModified.IsZero() {
+		data.LastModified = types.StringValue(lastModified.Format(time.RFC3339))
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// responseHeaders converts header into a map attribute
+func responseHeaders(header http.Header) (types.Map, diag.Diagnostics) {
+	elements := make(map[string]attr.Value, len(header))
+	for name, values := range header {
+		elements[name] = types.StringValue(strings.Join(values, ", "))
+	}
+	return types.MapValue(types.StringType, elements)
+}
+
+// addHttpError reports err, why fetching the file of data failed, on the
+// attribute to fix
+func addHttpError(diags *diag.Diagnostics, summary string, err error, data *model.RemoteFileHttpDataSourceModel) {
+	switch {
+	case errors.Is(err, remote.ErrAuthFailed):
+		credential := path.Root("request_headers")
+		if !data.User.IsNull() {
+			credential = path.Root("password")
+		}
+		diags.AddAttributeError(
+			credential,
+			summary,
+			fmt.Sprintf("The server rejected the credentials for %s. Check user and password or the "+
+				"Authorization header in request_headers.\n\n%s", data.URL.ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrPermissionDenied):
+		diags.AddAttributeError(
+			path.Root("url"),
+			summary,
+			fmt.Sprintf("The server refused to serve %s with the credentials given.\n\n%s", data.URL.ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrNotFound):
+		diags.AddAttributeError(
+			path.Root("url"),
+			summary,
+			fmt.Sprintf("The server has no file at %s, set allow_missing to tolerate a missing file.\n\n%s",
+				data.URL.ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrConnect):
+		diags.AddAttributeError(
+			path.Root("url"),
+			summary,
+			fmt.Sprintf("Could not fetch %s. Check the URL and timeout, and that the server is reachable "+
+				"from where Terraform runs.\n\n%s", data.URL.ValueString(), err),
+		)
+	case errors.Is(err, context.DeadlineExceeded):
+		diags.AddError(
+			summary,
+			fmt.Sprintf("The request did not complete in time. Increase the timeout or lower the retry "+
+				"settings.\n\n%s", err),
+		)
+	default:
+		diags.AddError(summary, err.Error())
+	}
+}
@@ -3,40 +3,69 @@ package provider
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
 	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
 	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
-	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/connect"
-	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/parameters"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ datasource.DataSource = &remoteFileDataSource{}
+	_ datasource.DataSource                   = &remoteFileDataSource{}
+	_ datasource.DataSourceWithConfigure      = &remoteFileDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &remoteFileDataSource{}
 )
 
-// NewRemoteFileDataSource is a helper function to simplify the provider implementation.
-func NewRemoteFileDataSource() datasource.DataSource {
-	return &remoteFileDataSource{}
+// NewRemoteFileDataSource returns the constructor of the data source reading
+// whole files over protocol, named remotefile_<protocol>
+func NewRemoteFileDataSource(protocol backend.Protocol) func() datasource.DataSource {
+	return func() datasource.DataSource {
+		return &remoteFileDataSource{
+			protocol:    protocol,
+			retryPolicy: defaultRetryPolicy,
+		}
+	}
 }
 
 // remoteFileDataSource is the data source implementation.
-type remoteFileDataSource struct{}
+type remoteFileDataSource struct {
+	protocol    backend.Protocol
+	retryPolicy retry.Policy
+}
+
+// Configure adds the provider-level defaults to the data source.
+func (d *remoteFileDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected data source configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.retryPolicy = data.retryPolicy
+}
 
 // Metadata returns the data source type name.
 func (d *remoteFileDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
-	resp.TypeName = "remotefile_sftp"
+	resp.TypeName = req.ProviderTypeName + "_" + d.protocol.Name
 }
 
 // Schema defines the schema for the data source.
 func (d *remoteFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "Retrieves a file from a remote system using SFTP.",
+		Description: "Retrieves a file from a remote system using " + d.protocol.Description + ".",
 		Attributes: map[string]schema.Attribute{
 			"allow_missing": schema.BoolAttribute{
 				Description: "Whether to ignore that the file is missing",
@@ -81,6 +110,10 @@ func (d *remoteFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequ
 				Description: "The file size (in bytes)",
 				Computed:    true,
 			},
+			"system_ssh_path": schema.StringAttribute{
+				Description: "The ssh binary run with use_system_ssh, defaults to ssh from PATH",
+				Optional:    true,
+			},
 			"timeout": schema.StringAttribute{
 				Description: "The connect timeout",
 				Optional:    true,
@@ -90,6 +123,11 @@ func (d *remoteFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequ
 				Optional:    true,
 				ElementType: types.StringType,
 			},
+			"use_system_ssh": schema.BoolAttribute{
+				Description: "Connect by running the system ssh binary instead of the built in client, which honours " +
+					"~/.ssh/config, ControlMaster, ProxyCommand and the agent. It can't authenticate with a password",
+				Optional: true,
+			},
 			"user": schema.StringAttribute{
 				Description: "The username",
 				Optional:    true,
@@ -106,61 +144,90 @@ func (d *remoteFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequ
 				Description: "Time to wait between retries (e.g. '10s')",
 				Optional:    true,
 			},
+			"retry_max_interval": schema.StringAttribute{
+				Description: "Upper bound for the time to wait between retries when a multiplier is set (e.g. '1m')",
+				Optional:    true,
+			},
+			"retry_multiplier": schema.Float64Attribute{
+				Description: "Factor the wait between retries grows by after every retry, 1 keeps it fixed",
+				Optional:    true,
+			},
+			"retry_jitter": schema.Float64Attribute{
+				Description: "Fraction (0 to 1) by which every wait between retries is randomized",
+				Optional:    true,
+			},
+			"retry_max_elapsed": schema.StringAttribute{
+				Description: "Total time after which no further retries are attempted (e.g. '5m')",
+				Optional:    true,
+			},
 		},
 	}
+	if settings := d.protocol.Settings; settings != nil {
+		resp.Schema.Attributes[d.protocol.Name] = settingsDataSourceAttribute(settings)
+	}
+}
+
+// newModel returns an empty model of the data source, with the settings of
+// its protocol if it has any
+func (d *remoteFileDataSource) newModel() model.DataSourceModel {
+	if d.protocol.Settings == nil {
+		return &model.RemoteFileDataSourceModel{}
+	}
+	return d.protocol.Settings.NewDataSourceModel().(model.DataSourceModel)
+}
+
+// ValidateConfig ensures the protocol supports every attribute set
+func (d *remoteFileDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	protocolModel := d.newModel()
+	resp.Diagnostics.Append(req.Config.Get(ctx, protocolModel)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateSupported(ctx, d.protocol, req.Config, &resp.Diagnostics)
+	if settings := d.protocol.Settings; settings != nil {
+		settings.Validate(protocolModel, true, &resp.Diagnostics)
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (d *remoteFileDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
-	var data model.RemoteFileDataSourceModel
+	protocolModel := d.newModel()
 
 	// Read Terraform configuration data into the model
-	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, protocolModel)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	data := protocolModel.Shared()
 
-	// Set default retry values if not specified
-	retryCount := int64(10)
-	if !data.RetryCount.IsNull() {
-		retryCount = data.RetryCount.ValueInt64()
-	}
+	ctx = withLogging(ctx, protocolModel)
 
-	retryInterval := 10 * time.Second
-	if !data.RetryInterval.IsNull() {
-		interval, err := time.ParseDuration(data.RetryInterval.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"invalid retry interval",
-				fmt.Sprintf("enable to parse retry interval: %s", err),
-			)
-			return
-		}
-		retryInterval = interval
+	retryPolicy, retryDiags := buildRetryPolicy(d.retryPolicy, data)
+	resp.Diagnostics.Append(retryDiags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	sshConnParams, err := parameters.CreateSSHConnectionParameters(&data)
+	dial, err := d.protocol.Dialer(protocolModel)
 	if err != nil {
 		resp.Diagnostics.AddError(
-			"error creating SSH connection parameters",
+			fmt.Sprintf("error creating %s connection parameters", d.protocol.Description),
 			err.Error(),
 		)
 		return
 	}
 
-	operation := connect.ConnectAndCopy(sshConnParams, &data, &data)
+	operation := remote.ConnectAndCopy(dial, data, data)
 
 	// Wrap the entire operation in the retry logic
-	err = retry.WithRetry(retryCount, retryInterval, operation)
+	err = retry.Do(ctx, retryPolicy, operation)
 
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"error reading remote file",
-			err.Error(),
-		)
+		addOperationError(&resp.Diagnostics, "error reading remote file", err, data, protocolCredential(d.protocol, protocolModel))
 		return
 	}
 
 	// Save data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, protocolModel)...)
 }
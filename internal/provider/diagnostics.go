@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/parameters"
+)
+
+// protocolCredential returns the setting of protocol that protocolModel
+// authenticates with, or an empty path if it uses password or private_key
+func protocolCredential(protocol backend.Protocol, protocolModel any) path.Path {
+	if protocol.Settings == nil || protocol.Settings.Credential == nil {
+		return path.Empty()
+	}
+	name := protocol.Settings.Credential(protocolModel)
+	if name == "" {
+		return path.Empty()
+	}
+	return path.Root(protocol.Name).AtName(name)
+}
+
+// addOperationError reports err from a remote operation as a diagnostic
+// pointing at the attribute most likely to need fixing. credential is the
+// protocol setting data authenticates with, as protocolCredential returns it.
+func addOperationError(diags *diag.Diagnostics, summary string, err error, data parameters.SshModelSubset, credential path.Path) {
+	switch {
+	case errors.Is(err, remote.ErrAuthFailed):
+		if credential.Equal(path.Empty()) {
+			credential = path.Root("password")
+			if data.GetPassword().IsNull() && !data.GetPrivateKey().IsNull() {
+				credential = path.Root("private_key")
+			}
+		}
+		diags.AddAttributeError(
+			credential,
+			summary,
+			fmt.Sprintf("The server at %s rejected the credentials for user %q. Check that the user exists "+
+				"and that the password or private key is authorized on the host.\n\n%s",
+				data.GetHost().ValueString(), data.GetUser().ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrHostKeyMismatch):
+		diags.AddAttributeError(
+			path.Root("host_key"),
+			summary,
+			fmt.Sprintf("The host key presented by %s does not match host_key. If the host was rebuilt, "+
+				"update host_key to its new public key, otherwise the connection may be intercepted.\n\n%s",
+				data.GetHost().ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrConnect):
+		diags.AddAttributeError(
+			path.Root("host"),
+			summary,
+			fmt.Sprintf("Could not reach the server at %s. Check host, port and timeout, and that the "+
+				"host is reachable from where Terraform runs.\n\n%s",
+				data.GetHost().ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrNotFound):
+		diags.AddAttributeError(
+			path.Root("path"),
+			summary,
+			fmt.Sprintf("The remote file or its parent directory does not exist. Create the directory "+
+				"first, or set allow_missing to tolerate a missing file.\n\n%s", err),
+		)
+	case errors.Is(err, remote.ErrPermissionDenied):
+		diags.AddAttributeError(
+			path.Root("path"),
+			summary,
+			fmt.Sprintf("User %q is not allowed to access the remote file. Check the ownership and "+
+				"permissions of the file and its parent directory.\n\n%s",
+				data.GetUser().ValueString(), err),
+		)
+	case errors.Is(err, remote.ErrQuotaExceeded):
+		diags.AddAttributeError(
+			path.Root("contents"),
+			summary,
+			fmt.Sprintf("The remote filesystem is out of space or the user's quota is exhausted. Free up "+
+				"space on the host and apply again.\n\n%s", err),
+		)
+	case errors.Is(err, remote.ErrRender):
+		diags.AddAttributeError(
+			path.Root("template"),
+			summary,
+			fmt.Sprintf("The file contents could not be rendered from template. Check that every variable "+
+				"it references is set in vars and that any remote files it reads exist.\n\n%s", err),
+		)
+	case errors.Is(err, remote.ErrEdit):
+		diags.AddAttributeError(
+			path.Root("path"),
+			summary,
+			fmt.Sprintf("The current contents of the remote file could not be edited as configured. "+
+				"Fix the file on the host, or adjust the configuration to match it.\n\n%s", err),
+		)
+	case errors.Is(err, remote.ErrConflict):
+		diags.AddAttributeError(
+			path.Root("path"),
+			summary,
+			fmt.Sprintf("The remote file kept changing while it was being edited. Check whether another "+
+				"process or resource manages the same file, then apply again.\n\n%s", err),
+		)
+	case errors.Is(err, remote.ErrValidation):
+		diags.AddAttributeError(
+			path.Root("validate_command"),
+			summary,
+			fmt.Sprintf("validate_command rejected the new contents, the remote file was left untouched. "+
+				"Fix the contents, or check that the command can run on the host.\n\n%s", err),
+		)
+	case errors.Is(err, remote.ErrSudo):
+		diags.AddAttributeError(
+			path.Root("sudo"),
+			summary,
+			fmt.Sprintf("sudo could not run as root for user %q on %s. Check the user's sudoers entry, and that "+
+				"sudo_password is right if it asks for one.\n\n%s",
+				data.GetUser().ValueString(), data.GetHost().ValueString(), err),
+		)
+	case errors.Is(err, context.DeadlineExceeded):
+		diags.AddError(
+			summary,
+			fmt.Sprintf("The operation did not complete in time. Increase the timeouts for this "+
+				"operation or lower the retry settings.\n\n%s", err),
+		)
+	default:
+		diags.AddError(summary, err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package edit
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DefaultMarkerPrefix starts the marker lines of a block, a comment in most
+// configuration files
+const DefaultMarkerPrefix = "#"
+
+// Block is a delimited region of a file, owned as a whole and identified by
+// its name
+type Block struct {
+	begin   string
+	end     string
+	content []string
+}
+
+// NewBlock describes the block called name holding content, delimited by
+// "<prefix> BEGIN terraform managed: <name>" and
+// "<prefix> END terraform managed: <name>" marker lines
+func NewBlock(name string, content string, prefix string) Block {
+	if prefix == "" {
+		prefix = DefaultMarkerPrefix
+	}
+
+	return Block{
+		begin:   fmt.Sprintf("%s BEGIN terraform managed: %s", prefix, name),
+		end:     fmt.Sprintf("%s END terraform managed: %s", prefix, name),
+		content: splitLines(content).lines,
+	}
+}
+
+// find returns the indexes of the block's marker lines, or -1 if the block
+// isn't in lines
+func (b Block) find(lines []string) (int, int, error) {
+	begin, end := -1, -1
+	for i, text := range lines {
+		switch text {
+		case b.begin:
+			if begin >= 0 {
+				return -1, -1, fmt.Errorf("found %q more than once", b.begin)
+			}
+			begin = i
+		case b.end:
+			if end >= 0 {
+				return -1, -1, fmt.Errorf("found %q more than once", b.end)
+			}
+			end = i
+		}
+	}
+
+	switch {
+	case begin < 0 && end < 0:
+		return -1, -1, nil
+	case begin < 0:
+		return -1, -1, fmt.Errorf("found %q without %q", b.end, b.begin)
+	case end < 0:
+		return -1, -1, fmt.Errorf("found %q without %q", b.begin, b.end)
+	case end < begin:
+		return -1, -1, fmt.Errorf("found %q before %q", b.end, b.begin)
+	}
+	return begin, end, nil
+}
+
+// Content returns what is currently between the block's markers in contents,
+// and whether the block was found
+func (b Block) Content(contents string) (string, bool, error) {
+	file := splitLines(contents)
+	begin, end, err := b.find(file.lines)
+	if err != nil || begin < 0 {
+		return "", false, err
+	}
+
+	inner := lines{lines: file.lines[begin+1 : end], terminated: true}
+	return inner.String(), true, nil
+}
+
+// Ensure returns contents with the block holding its content, appending the
+// block when it is missing, and whether that changed anything
+func (b Block) Ensure(contents string) (string, bool, error) {
+	file := splitLines(contents)
+	begin, end, err := b.find(file.lines)
+	if err != nil {
+		return "", false, err
+	}
+
+	block := append(append([]string{b.begin}, b.content...), b.end)
+	if begin < 0 {
+		file.lines = append(file.lines, block...)
+		// The markers must stay on lines of their own
+		file.terminated = true
+	} else {
+		if slices.Equal(file.lines[begin:end+1], block) {
+			return contents, false, nil
+		}
+		file.lines = append(file.lines[:begin], append(block, file.lines[end+1:]...)...)
+	}
+
+	return file.String(), true, nil
+}
+
+// Remove returns contents without the block, markers included, and whether
+// that changed anything
+func (b Block) Remove(contents string) (string, bool, error) {
+	file := splitLines(contents)
+	begin, end, err := b.find(file.lines)
+	if err != nil {
+		return "", false, err
+	}
+	if begin < 0 {
+		return contents, false, nil
+	}
+
+	file.lines = append(file.lines[:begin], file.lines[end+1:]...)
+	return file.String(), true, nil
+}
@@ -0,0 +1,155 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+)
+
+const sshdConfig = "Port 22\n# BEGIN terraform managed: hardening\nPermitRootLogin no\n# END terraform managed: hardening\nUsePAM yes\n"
+
+func TestBlockEnsure(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		contents string
+		expected string
+		changed  bool
+	}{
+		{
+			name:     "appended when missing",
+			content:  "PermitRootLogin no\n",
+			contents: "Port 22\n",
+			expected: "Port 22\n# BEGIN terraform managed: hardening\nPermitRootLogin no\n# END terraform managed: hardening\n",
+			changed:  true,
+		},
+		{
+			name:     "new file",
+			content:  "PermitRootLogin no",
+			contents: "",
+			expected: "# BEGIN terraform managed: hardening\nPermitRootLogin no\n# END terraform managed: hardening\n",
+			changed:  true,
+		},
+		{
+			name:     "unterminated last line",
+			content:  "PermitRootLogin no",
+			contents: "Port 22",
+			expected: "Port 22\n# BEGIN terraform managed: hardening\nPermitRootLogin no\n# END terraform managed: hardening\n",
+			changed:  true,
+		},
+		{
+			name:     "already up to date",
+			content:  "PermitRootLogin no\n",
+			contents: sshdConfig,
+			expected: sshdConfig,
+		},
+		{
+			name:     "replaced in place",
+			content:  "PermitRootLogin no\nPasswordAuthentication no\n",
+			contents: sshdConfig,
+			expected: "Port 22\n# BEGIN terraform managed: hardening\nPermitRootLogin no\nPasswordAuthentication no\n# END terraform managed: hardening\nUsePAM yes\n",
+			changed:  true,
+		},
+		{
+			name:     "emptied",
+			content:  "",
+			contents: sshdConfig,
+			expected: "Port 22\n# BEGIN terraform managed: hardening\n# END terraform managed: hardening\nUsePAM yes\n",
+			changed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := NewBlock("hardening", tt.content, "")
+
+			result, changed, err := block.Ensure(tt.contents)
+			if err != nil {
+				t.Fatalf("Ensure() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Ensure() = %q, expected %q", result, tt.expected)
+			}
+			if changed != tt.changed {
+				t.Errorf("Ensure() changed = %v, expected %v", changed, tt.changed)
+			}
+
+			content, found, err := block.Content(result)
+			if err != nil || !found {
+				t.Fatalf("Content() found = %v, error = %v", found, err)
+			}
+			if strings.TrimSuffix(content, "\n") != strings.TrimSuffix(tt.content, "\n") {
+				t.Errorf("Content() = %q, expected %q", content, tt.content)
+			}
+		})
+	}
+}
+
+func TestBlockRemove(t *testing.T) {
+	block := NewBlock("hardening", "", "")
+
+	result, changed, err := block.Remove(sshdConfig)
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if !changed || result != "Port 22\nUsePAM yes\n" {
+		t.Errorf("Remove() = %q, %v, expected the block gone", result, changed)
+	}
+
+	result, changed, err = block.Remove(result)
+	if err != nil || changed || result != "Port 22\nUsePAM yes\n" {
+		t.Errorf("Remove() of a missing block = %q, %v, %v, expected no change", result, changed, err)
+	}
+}
+
+func TestBlockMarkers(t *testing.T) {
+	block := NewBlock("limits", "nofile 4096", ";")
+
+	result, _, err := block.Ensure("[global]\n")
+	if err != nil {
+		t.Fatalf("Ensure() error = %v", err)
+	}
+	expected := "[global]\n; BEGIN terraform managed: limits\nnofile 4096\n; END terraform managed: limits\n"
+	if result != expected {
+		t.Errorf("Ensure() = %q, expected %q", result, expected)
+	}
+
+	// Blocks with other names are left alone
+	other := NewBlock("hardening", "", "")
+	if _, found, _ := other.Content(sshdConfig + result); !found {
+		t.Errorf("expected to find the hardening block")
+	}
+	if _, found, _ := block.Content(sshdConfig); found {
+		t.Errorf("expected not to find the limits block")
+	}
+}
+
+func TestBlockMalformed(t *testing.T) {
+	begin := "# BEGIN terraform managed: hardening\n"
+	end := "# END terraform managed: hardening\n"
+
+	tests := []struct {
+		name     string
+		contents string
+		errMsg   string
+	}{
+		{"missing end", "Port 22\n" + begin, "without"},
+		{"missing begin", end + "Port 22\n", "without"},
+		{"reversed", end + begin, "before"},
+		{"duplicated", begin + end + begin + end, "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := NewBlock("hardening", "PermitRootLogin no", "")
+			if _, _, err := block.Ensure(tt.contents); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Ensure() error = %v, expected one containing %q", err, tt.errMsg)
+			}
+			if _, _, err := block.Remove(tt.contents); err == nil {
+				t.Errorf("Remove() expected an error")
+			}
+			if _, _, err := block.Content(tt.contents); err == nil {
+				t.Errorf("Content() expected an error")
+			}
+		})
+	}
+}
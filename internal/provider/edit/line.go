@@ -0,0 +1,151 @@
+package edit
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Special insert_after and insert_before values
+const (
+	// EOF inserts the line at the end of the file
+	EOF = "EOF"
+	// BOF inserts the line at the beginning of the file
+	BOF = "BOF"
+)
+
+// Line is a single line that should, or should not, be in a file
+type Line struct {
+	line         string
+	regexp       *regexp.Regexp
+	insertAfter  *regexp.Regexp
+	insertBefore *regexp.Regexp
+	atStart      bool
+}
+
+// NewLine describes line. If expr is set, the last line it matches is
+// replaced by line, or every line it matches is removed. Otherwise line is
+// only compared as a whole. When nothing matches, line is added after the
+// last line matching insertAfter or before the first line matching
+// insertBefore, falling back to the end of the file. insertAfter may be EOF
+// and insertBefore may be BOF. Empty strings leave an option unset.
+func NewLine(line string, expr string, insertAfter string, insertBefore string) (Line, error) {
+	l := Line{line: line}
+
+	var err error
+	if expr != "" {
+		l.regexp, err = regexp.Compile(expr)
+		if err != nil {
+			return Line{}, fmt.Errorf("invalid regexp: %w", err)
+		}
+	}
+
+	switch insertAfter {
+	case "", EOF:
+	default:
+		l.insertAfter, err = regexp.Compile(insertAfter)
+		if err != nil {
+			return Line{}, fmt.Errorf("invalid insert_after: %w", err)
+		}
+	}
+
+	switch insertBefore {
+	case "":
+	case BOF:
+		l.atStart = true
+	default:
+		l.insertBefore, err = regexp.Compile(insertBefore)
+		if err != nil {
+			return Line{}, fmt.Errorf("invalid insert_before: %w", err)
+		}
+	}
+
+	return l, nil
+}
+
+// matches reports whether text is a line l manages
+func (l Line) matches(text string) bool {
+	if l.regexp != nil {
+		return l.regexp.MatchString(text)
+	}
+	return text == l.line
+}
+
+// Ensure returns contents with the line present, and whether that changed
+// anything
+func (l Line) Ensure(contents string) (string, bool) {
+	file := splitLines(contents)
+
+	// Replace the last line the regexp matches
+	if l.regexp != nil {
+		for i := len(file.lines) - 1; i >= 0; i-- {
+			if l.regexp.MatchString(file.lines[i]) {
+				if file.lines[i] == l.line {
+					return contents, false
+				}
+				file.lines[i] = l.line
+				return file.String(), true
+			}
+		}
+	}
+
+	for _, text := range file.lines {
+		if text == l.line {
+			return contents, false
+		}
+	}
+
+	file.insert(l.insertIndex(file.lines), l.line)
+	return file.String(), true
+}
+
+// insertIndex returns where a missing line is added
+func (l Line) insertIndex(lines []string) int {
+	switch {
+	case l.atStart:
+		return 0
+	case l.insertBefore != nil:
+		for i, text := range lines {
+			if l.insertBefore.MatchString(text) {
+				return i
+			}
+		}
+	case l.insertAfter != nil:
+		for i := len(lines) - 1; i >= 0; i-- {
+			if l.insertAfter.MatchString(lines[i]) {
+				return i + 1
+			}
+		}
+	}
+	return len(lines)
+}
+
+// Remove returns contents without any line matching the regexp, or equal to
+// the line when no regexp is set, and whether that changed anything
+func (l Line) Remove(contents string) (string, bool) {
+	file := splitLines(contents)
+
+	kept := file.lines[:0]
+	for _, text := range file.lines {
+		if !l.matches(text) {
+			kept = append(kept, text)
+		}
+	}
+	if len(kept) == len(file.lines) {
+		return contents, false
+	}
+
+	file.lines = kept
+	return file.String(), true
+}
+
+// Present reports whether contents already hold the line
+func (l Line) Present(contents string) bool {
+	_, changed := l.Ensure(contents)
+	return !changed
+}
+
+// Absent reports whether contents hold no line Remove would remove
+func (l Line) Absent(contents string) bool {
+	_, changed := l.Remove(contents)
+	return !changed
+}
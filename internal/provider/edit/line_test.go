@@ -0,0 +1,212 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+)
+
+const hosts = "127.0.0.1 localhost\n10.0.0.1 db\n# cluster\n10.0.0.2 web\n"
+
+func TestLineEnsure(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		regexp       string
+		insertAfter  string
+		insertBefore string
+		contents     string
+		expected     string
+		changed      bool
+	}{
+		{
+			name:     "already present",
+			line:     "10.0.0.1 db",
+			contents: hosts,
+			expected: hosts,
+		},
+		{
+			name:     "appended by default",
+			line:     "10.0.0.3 cache",
+			contents: hosts,
+			expected: hosts + "10.0.0.3 cache\n",
+			changed:  true,
+		},
+		{
+			name:     "replaces last regexp match",
+			line:     "10.0.0.9 web",
+			regexp:   `^10\.0\.0\.\d+ `,
+			contents: hosts,
+			expected: "127.0.0.1 localhost\n10.0.0.1 db\n# cluster\n10.0.0.9 web\n",
+			changed:  true,
+		},
+		{
+			name:     "regexp match already the line",
+			line:     "10.0.0.2 web",
+			regexp:   `web$`,
+			contents: hosts,
+			expected: hosts,
+		},
+		{
+			name:     "regexp without match keeps existing line",
+			line:     "10.0.0.1 db",
+			regexp:   `^nothing`,
+			contents: hosts,
+			expected: hosts,
+		},
+		{
+			name:        "insert after last match",
+			line:        "10.0.0.3 cache",
+			insertAfter: `^10\.`,
+			contents:    hosts,
+			expected:    hosts + "10.0.0.3 cache\n",
+			changed:     true,
+		},
+		{
+			name:        "insert after comment",
+			line:        "10.0.0.3 cache",
+			insertAfter: `^# cluster`,
+			contents:    hosts,
+			expected:    "127.0.0.1 localhost\n10.0.0.1 db\n# cluster\n10.0.0.3 cache\n10.0.0.2 web\n",
+			changed:     true,
+		},
+		{
+			name:         "insert before first match",
+			line:         "# hosts",
+			insertBefore: `^10\.`,
+			contents:     hosts,
+			expected:     "127.0.0.1 localhost\n# hosts\n10.0.0.1 db\n# cluster\n10.0.0.2 web\n",
+			changed:      true,
+		},
+		{
+			name:         "insert at beginning of file",
+			line:         "# managed",
+			insertBefore: BOF,
+			contents:     hosts,
+			expected:     "# managed\n" + hosts,
+			changed:      true,
+		},
+		{
+			name:        "insert anchor without match appends",
+			line:        "10.0.0.3 cache",
+			insertAfter: `^nothing`,
+			contents:    hosts,
+			expected:    hosts + "10.0.0.3 cache\n",
+			changed:     true,
+		},
+		{
+			name:     "empty file",
+			line:     "first",
+			contents: "",
+			expected: "first\n",
+			changed:  true,
+		},
+		{
+			name:     "unterminated last line stays unterminated",
+			line:     "b",
+			contents: "a",
+			expected: "a\nb",
+			changed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := NewLine(tt.line, tt.regexp, tt.insertAfter, tt.insertBefore)
+			if err != nil {
+				t.Fatalf("NewLine() error = %v", err)
+			}
+
+			result, changed := line.Ensure(tt.contents)
+			if result != tt.expected {
+				t.Errorf("Ensure() = %q, expected %q", result, tt.expected)
+			}
+			if changed != tt.changed {
+				t.Errorf("Ensure() changed = %v, expected %v", changed, tt.changed)
+			}
+			if !line.Present(result) {
+				t.Errorf("Present() = false after Ensure()")
+			}
+		})
+	}
+}
+
+func TestLineRemove(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		regexp   string
+		contents string
+		expected string
+		changed  bool
+	}{
+		{
+			name:     "exact line",
+			line:     "10.0.0.1 db",
+			contents: hosts,
+			expected: "127.0.0.1 localhost\n# cluster\n10.0.0.2 web\n",
+			changed:  true,
+		},
+		{
+			name:     "every regexp match",
+			line:     "ignored",
+			regexp:   `^10\.`,
+			contents: hosts,
+			expected: "127.0.0.1 localhost\n# cluster\n",
+			changed:  true,
+		},
+		{
+			name:     "nothing to remove",
+			line:     "10.0.0.3 cache",
+			contents: hosts,
+			expected: hosts,
+		},
+		{
+			name:     "only line",
+			line:     "only",
+			contents: "only\n",
+			expected: "",
+			changed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := NewLine(tt.line, tt.regexp, "", "")
+			if err != nil {
+				t.Fatalf("NewLine() error = %v", err)
+			}
+
+			result, changed := line.Remove(tt.contents)
+			if result != tt.expected {
+				t.Errorf("Remove() = %q, expected %q", result, tt.expected)
+			}
+			if changed != tt.changed {
+				t.Errorf("Remove() changed = %v, expected %v", changed, tt.changed)
+			}
+			if !line.Absent(result) {
+				t.Errorf("Absent() = false after Remove()")
+			}
+		})
+	}
+}
+
+func TestNewLineInvalidRegexp(t *testing.T) {
+	tests := []struct {
+		name                              string
+		regexp, insertAfter, insertBefore string
+		errMsg                            string
+	}{
+		{"regexp", "(", "", "", "invalid regexp"},
+		{"insert after", "", "[", "", "invalid insert_after"},
+		{"insert before", "", "", "*", "invalid insert_before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLine("line", tt.regexp, tt.insertAfter, tt.insertBefore)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
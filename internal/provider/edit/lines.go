@@ -0,0 +1,42 @@
+package edit
+
+import "strings"
+
+// lines is a file split into lines, remembering whether the last line was
+// terminated so that joining it again doesn't touch the end of the file
+type lines struct {
+	lines      []string
+	terminated bool
+}
+
+// splitLines splits contents on line feeds
+func splitLines(contents string) lines {
+	if contents == "" {
+		// An empty file gets a terminated line once something is added
+		return lines{terminated: true}
+	}
+
+	terminated := strings.HasSuffix(contents, "\n")
+	return lines{
+		lines:      strings.Split(strings.TrimSuffix(contents, "\n"), "\n"),
+		terminated: terminated,
+	}
+}
+
+// String joins the lines back into file contents
+func (l lines) String() string {
+	if len(l.lines) == 0 {
+		return ""
+	}
+
+	contents := strings.Join(l.lines, "\n")
+	if l.terminated {
+		contents += "\n"
+	}
+	return contents
+}
+
+// insert places line at index i
+func (l *lines) insert(i int, line string) {
+	l.lines = append(l.lines[:i], append([]string{line}, l.lines[i:]...)...)
+}
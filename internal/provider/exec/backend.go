@@ -0,0 +1,299 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// statFormat is what stat prints for a file, understood by GNU coreutils and
+// BusyBox alike: size, modification time, raw mode in hex, uid, gid and name
+const statFormat = "%s %Y %f %u %g %n"
+
+// execBackend is a target reached by running a command template on the
+// machine running Terraform, e.g. `docker exec -i {host} sh -c {script}`.
+// Every operation is a shell script run on the target, so it needs cat,
+// stat, chmod, chown, mv, rm, mkdir and find.
+type execBackend struct {
+	// ctx is the one the backend was dialed with, the Backend methods don't
+	// take one
+	ctx    context.Context
+	params *ConnectionParameters
+}
+
+var (
+	_ backend.Backend   = &execBackend{}
+	_ backend.Commander = &execBackend{}
+)
+
+// Dialer returns a Dialer for the target params runs commands on. There is
+// no connection, a target that can't be reached fails the first operation.
+func Dialer(params *ConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		return &execBackend{ctx: ctx, params: params}, nil
+	}
+}
+
+func (b *execBackend) Address() string {
+	return b.params.GetAddress()
+}
+
+// Open reads path in full with cat
+func (b *execBackend) Open(path string) (backend.File, error) {
+	result, err := b.script("cat -- "+remote.ShellQuote(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &execFile{reader: strings.NewReader(result.Stdout)}, nil
+}
+
+// OpenFile opens path for writing unless flag is os.O_RDONLY. Writes are
+// buffered and piped into cat when the file is closed, replacing whatever
+// path held unless flag has os.O_APPEND.
+func (b *execBackend) OpenFile(path string, flag int) (backend.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return b.Open(path)
+	}
+	return &execFile{backend: b, path: path, writing: true, append: flag&os.O_APPEND != 0}, nil
+}
+
+func (b *execBackend) Stat(path string) (os.FileInfo, error) {
+	return b.stat("stat -L -c " + remote.ShellQuote(statFormat) + " -- " + remote.ShellQuote(path))
+}
+
+func (b *execBackend) Lstat(path string) (os.FileInfo, error) {
+	return b.stat("stat -c " + remote.ShellQuote(statFormat) + " -- " + remote.ShellQuote(path))
+}
+
+func (b *execBackend) stat(script string) (os.FileInfo, error) {
+	result, err := b.script(script, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseStat(strings.TrimSuffix(result.Stdout, "\n"))
+}
+
+func (b *execBackend) Chmod(path string, mode os.FileMode) error {
+	_, err := b.script(fmt.Sprintf("chmod %04o -- %s", mode.Perm(), remote.ShellQuote(path)), nil)
+	return err
+}
+
+func (b *execBackend) Chown(path string, uid int, gid int) error {
+	_, err := b.script(fmt.Sprintf("chown %d:%d -- %s", uid, gid, remote.ShellQuote(path)), nil)
+	return err
+}
+
+func (b *execBackend) Rename(oldpath string, newpath string) error {
+	_, err := b.script("mv -f -- "+remote.ShellQuote(oldpath)+" "+remote.ShellQuote(newpath), nil)
+	return err
+}
+
+func (b *execBackend) Remove(path string) error {
+	_, err := b.script("rm -- "+remote.ShellQuote(path), nil)
+	return err
+}
+
+func (b *execBackend) Mkdir(path string) error {
+	_, err := b.script("mkdir -- "+remote.ShellQuote(path), nil)
+	return err
+}
+
+// ReadDir stats every entry of dir in a single script
+func (b *execBackend) ReadDir(dir string) ([]os.FileInfo, error) {
+	result, err := b.script("find "+remote.ShellQuote(dir)+" -mindepth 1 -maxdepth 1 -exec stat -c "+
+		remote.ShellQuote(statFormat)+" {} +", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []os.FileInfo
+	for _, line := range strings.Split(strings.TrimSuffix(result.Stdout, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fileInfo, err := parseStat(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileInfo)
+	}
+	return entries, nil
+}
+
+// Run runs command on the target. A command that ran and exited non-zero is
+// not an error, its exit code is in the result.
+func (b *execBackend) Run(ctx context.Context, command string) (backend.CommandResult, error) {
+	return run(ctx, b.params, command, nil)
+}
+
+// script runs script with stdin, failing with what it printed if it exits
+// non-zero
+func (b *execBackend) script(script string, stdin []byte) (backend.CommandResult, error) {
+	result, err := run(b.ctx, b.params, script, stdin)
+	if err != nil {
+		return result, err
+	}
+	if result.ExitCode != 0 {
+		return result, scriptError(script, result)
+	}
+	return result, nil
+}
+
+func (b *execBackend) Close() error {
+	return nil
+}
+
+// execFile is a file read in full, or one being written that is piped to the
+// target when closed
+type execFile struct {
+	reader *strings.Reader
+
+	backend *execBackend
+	path    string
+	writing bool
+	append  bool
+	buffer  bytes.Buffer
+}
+
+func (f *execFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, errors.New("file was opened for writing")
+	}
+	return f.reader.Read(p)
+}
+
+func (f *execFile) Write(p []byte) (int, error) {
+	if !f.writing {
+		return 0, errors.New("file was opened for reading")
+	}
+	return f.buffer.Write(p)
+}
+
+func (f *execFile) Close() error {
+	if !f.writing {
+		return nil
+	}
+	f.writing = false
+
+	redirect := " > "
+	if f.append {
+		redirect = " >> "
+	}
+	_, err := f.backend.script("cat"+redirect+remote.ShellQuote(f.path), f.buffer.Bytes())
+	return err
+}
+
+// parseStat parses a line stat printed with statFormat
+func parseStat(line string) (os.FileInfo, error) {
+	fields := strings.SplitN(line, " ", 6)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("unexpected stat output %q", line)
+	}
+
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size in stat output %q: %w", line, err)
+	}
+	modTime, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modification time in stat output %q: %w", line, err)
+	}
+	rawMode, err := strconv.ParseUint(fields[2], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mode in stat output %q: %w", line, err)
+	}
+	uid, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid in stat output %q: %w", line, err)
+	}
+	gid, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid in stat output %q: %w", line, err)
+	}
+
+	return &fileInfo{
+		name:    path.Base(fields[5]),
+		size:    size,
+		mode:    fileMode(uint32(rawMode)),
+		modTime: time.Unix(modTime, 0),
+		owner:   owner{uid: uid, gid: gid},
+	}, nil
+}
+
+// The file type bits of a raw st_mode
+const (
+	modeTypeMask = 0170000
+	modeDir      = 0040000
+	modeSymlink  = 0120000
+	modeFifo     = 0010000
+	modeSocket   = 0140000
+	modeCharDev  = 0020000
+	modeBlockDev = 0060000
+	modeSetuid   = 0004000
+	modeSetgid   = 0002000
+	modeSticky   = 0001000
+)
+
+// fileMode converts a raw st_mode to an os.FileMode
+func fileMode(raw uint32) os.FileMode {
+	mode := os.FileMode(raw & 0777)
+	switch raw & modeTypeMask {
+	case modeDir:
+		mode |= os.ModeDir
+	case modeSymlink:
+		mode |= os.ModeSymlink
+	case modeFifo:
+		mode |= os.ModeNamedPipe
+	case modeSocket:
+		mode |= os.ModeSocket
+	case modeCharDev:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case modeBlockDev:
+		mode |= os.ModeDevice
+	}
+	if raw&modeSetuid != 0 {
+		mode |= os.ModeSetuid
+	}
+	if raw&modeSetgid != 0 {
+		mode |= os.ModeSetgid
+	}
+	if raw&modeSticky != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// fileInfo is a file as described by stat on the target
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	owner   owner
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() os.FileMode  { return i.mode }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *fileInfo) Sys() any           { return i.owner }
+
+// owner is the uid and gid of a file
+type owner struct {
+	uid int
+	gid int
+}
+
+func (o owner) Ownership() (uid int, gid int) {
+	return o.uid, o.gid
+}
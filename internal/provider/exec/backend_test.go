@@ -0,0 +1,254 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend/backendtest"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// nullModel returns a model running command for host with every other
+// attribute null
+func nullModel(host string, command string) *backendtest.Model {
+	data := backendtest.NullModel(host)
+	data.Exec.Command = types.StringValue(command)
+	return data
+}
+
+// newTestModel returns a model running scripts with the local shell, the
+// way `docker exec -i {host} sh -c {script}` runs them in a container
+func newTestModel(path string) *backendtest.Model {
+	data := nullModel("localhost", "sh -c {script}")
+	data.Timeout = types.StringValue("10s")
+	data.Path = types.StringValue(path)
+	return data
+}
+
+// testDialer returns the dialer for data
+func testDialer(t *testing.T, data *backendtest.Model) func(context.Context) (*execBackend, error) {
+	t.Helper()
+	return backendtest.Dialer[*execBackend, ModelSubset](t, data, CreateConnectionParameters, Dialer)
+}
+
+func TestDialerRoundTrip(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "app config.json")
+	data := newTestModel(target)
+	data.Contents = types.StringValue("{\"quote\":\"it's\"}\n\x00binary")
+	data.Permissions = types.StringValue("0640")
+
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+	ctx := context.Background()
+
+	if err := remote.ConnectAndWrite(dial, data, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	written, err := os.ReadFile(target)
+	if err != nil || string(written) != data.Contents.ValueString() {
+		t.Fatalf("file = %q, %v, expected the contents", written, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %o, expected 0640", info.Mode().Perm())
+	}
+
+	read := newTestModel(target)
+	if err := remote.ConnectAndCopy(dial, read, read)(ctx); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if read.Contents.ValueString() != data.Contents.ValueString() {
+		t.Errorf("contents = %q, expected %q", read.Contents.ValueString(), data.Contents.ValueString())
+	}
+	if read.ID.ValueString() != "app config.json" {
+		t.Errorf("id = %q, expected %q", read.ID.ValueString(), "app config.json")
+	}
+	if read.Size.ValueInt64() != int64(len(written)) {
+		t.Errorf("size = %d, expected %d", read.Size.ValueInt64(), len(written))
+	}
+	if read.LastModified.ValueString() != info.ModTime().Format("2006-01-02T15:04:05Z07:00") {
+		t.Errorf("last_modified = %q, expected %q", read.LastModified.ValueString(), info.ModTime().Format("2006-01-02T15:04:05Z07:00"))
+	}
+
+	if err := remote.ConnectAndDelete(dial, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndDelete() error = %v", err)
+	}
+	if _, err := os.Stat(target); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after delete: %v", err)
+	}
+}
+
+func TestDialerMissingFile(t *testing.T) {
+	data := newTestModel(filepath.Join(t.TempDir(), "missing"))
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndCopy(Dialer(params), data, data)(context.Background())
+	if !remote.IsFileNotFound(err) {
+		t.Errorf("ConnectAndCopy() error = %v, expected a missing file", err)
+	}
+}
+
+func TestDialerValidateAndHook(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app.conf")
+
+	// the hook sees the container name the way it would inside docker exec
+	data := newTestModel(target)
+	data.Host = types.StringValue("web-1")
+	data.Exec.Command = types.StringValue("env CONTAINER={host} ROLE={role} sh -c {script}")
+	data.Exec.Args = types.MapValueMust(types.StringType, map[string]attr.Value{
+		"role": types.StringValue("frontend"),
+	})
+	data.Contents = types.StringValue("valid\n")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	var result remote.HookResult
+	write := remote.ConnectAndWrite(Dialer(params), data, data,
+		remote.WithValidate("grep -q valid %s"),
+		remote.WithHook(`echo "$CONTAINER $ROLE"`, &result),
+	)
+	if err := write(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if !result.Ran || result.Stdout != "web-1 frontend\n" {
+		t.Errorf("hook result = %+v, expected it to print the placeholders", result)
+	}
+
+	data.Contents = types.StringValue("broken\n")
+	err = remote.ConnectAndWrite(Dialer(params), data, data, remote.WithValidate("grep -q valid %s"))(context.Background())
+	if !errors.Is(err, remote.ErrValidation) {
+		t.Fatalf("ConnectAndWrite() error = %v, expected %v", err, remote.ErrValidation)
+	}
+	contents, err := os.ReadFile(target)
+	if err != nil || string(contents) != "valid\n" {
+		t.Errorf("contents = %q, %v, expected the validated contents to be kept", contents, err)
+	}
+}
+
+func TestDialerResumable(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "large.bin")
+	contents := strings.Repeat("0123456789", 1000)
+	// the partial file can't be seeked over exec, so it is rewritten
+	if err := os.WriteFile(filepath.Join(dir, ".large.bin.partial"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := newTestModel(target)
+	data.Contents = types.StringValue(contents)
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	if err := remote.ConnectAndWrite(Dialer(params), data, data, remote.WithResumable(true))(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	written, err := os.ReadFile(target)
+	if err != nil || string(written) != contents {
+		t.Errorf("contents = %d bytes, %v, expected %d bytes", len(written), err, len(contents))
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".large.bin.partial")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial file was left behind: %v", err)
+	}
+}
+
+func TestDialerTargetUnavailable(t *testing.T) {
+	data := newTestModel(filepath.Join(t.TempDir(), "file"))
+	data.Exec.Command = types.StringValue("echo 'Error response from daemon: No such container: web-1' >&2; exit 1; {script}")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndCopy(Dialer(params), data, data)(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "No such container") {
+		t.Errorf("ConnectAndCopy() error = %v, expected the error of the wrapper", err)
+	}
+}
+
+func TestBackendTimeout(t *testing.T) {
+	data := newTestModel("/")
+	data.Timeout = types.StringValue("100ms")
+	b, err := testDialer(t, data)(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+
+	_, err = b.Run(context.Background(), "sleep 5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBackendReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "with space", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := testDialer(t, newTestModel(dir))(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	infos, err := b.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	var names []string
+	for _, info := range infos {
+		switch {
+		case info.IsDir():
+			names = append(names, info.Name()+"/")
+		case info.Mode().Perm() != 0600:
+			t.Errorf("mode of %s = %s, expected 0600", info.Name(), info.Mode())
+			fallthrough
+		default:
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != ".hidden,a,sub/,with space" {
+		t.Errorf("ReadDir() = %q, expected .hidden, a, sub/ and with space", names)
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	for raw, expected := range map[uint32]os.FileMode{
+		0100644: 0644,
+		0040755: os.ModeDir | 0755,
+		0120777: os.ModeSymlink | 0777,
+		0104755: os.ModeSetuid | 0755,
+		0041777: os.ModeDir | os.ModeSticky | 0777,
+	} {
+		if mode := fileMode(raw); mode != expected {
+			t.Errorf("fileMode(%o) = %s, expected %s", raw, mode, expected)
+		}
+	}
+}
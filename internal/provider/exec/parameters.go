@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// ScriptPlaceholder is replaced with the quoted shell script to run on the
+// target, every command template has to contain it
+const ScriptPlaceholder = "script"
+
+// placeholderPattern matches the {name} placeholders of a command template
+var placeholderPattern = regexp.MustCompile(`\{([a-z][a-z0-9_]*)\}`)
+
+// the subset of terraform schema fields that are needed to run commands on
+// the target
+type ModelSubset interface {
+	GetExecArgs() types.Map
+	GetExecCommand() types.String
+	GetHost() types.String
+	GetTimeout() types.String
+	GetUser() types.String
+}
+
+// ConnectionParameters describes the command that runs scripts on the target
+type ConnectionParameters struct {
+	host     string
+	template string
+	values   map[string]string
+	timeout  time.Duration
+}
+
+// GetAddress identifies the target in logs
+func (p *ConnectionParameters) GetAddress() string {
+	return p.host
+}
+
+// GetTimeout returns how long a single command may run
+func (p *ConnectionParameters) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// Command returns the command template with every placeholder replaced by
+// its quoted value and {script} by script
+func (p *ConnectionParameters) Command(script string) string {
+	return placeholderPattern.ReplaceAllStringFunc(p.template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if name == ScriptPlaceholder {
+			return remote.ShellQuote(script)
+		}
+		return remote.ShellQuote(p.values[name])
+	})
+}
+
+func CreateConnectionParameters(data ModelSubset) (*ConnectionParameters, error) {
+	if data.GetExecCommand().IsNull() || data.GetExecCommand().ValueString() == "" {
+		return nil, errors.New("exec.command is required, e.g. 'docker exec -i {host} sh -c {script}'")
+	}
+
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+
+	params := &ConnectionParameters{
+		host:     data.GetHost().ValueString(),
+		template: data.GetExecCommand().ValueString(),
+		values: map[string]string{
+			"host": data.GetHost().ValueString(),
+			"user": data.GetUser().ValueString(),
+		},
+		timeout: timeoutDuration,
+	}
+
+	for name, value := range data.GetExecArgs().Elements() {
+		if name == ScriptPlaceholder || name == "host" || name == "user" {
+			return nil, fmt.Errorf("exec.args can't set {%s}, it is provided by the provider", name)
+		}
+		if !placeholderPattern.MatchString("{" + name + "}") {
+			return nil, fmt.Errorf("exec.args key %q is not a valid placeholder name, use lower case letters, digits and underscores", name)
+		}
+		str, ok := value.(types.String)
+		if !ok || str.IsNull() || str.IsUnknown() {
+			return nil, fmt.Errorf("exec.args[%q] must be a known string", name)
+		}
+		params.values[name] = str.ValueString()
+	}
+
+	var hasScript bool
+	var unknown []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(params.template, -1) {
+		name := match[1]
+		if name == ScriptPlaceholder {
+			hasScript = true
+			continue
+		}
+		if _, ok := params.values[name]; !ok {
+			unknown = append(unknown, "{"+name+"}")
+		}
+	}
+	if !hasScript {
+		return nil, fmt.Errorf("exec.command must contain {%s}, where the script to run on the target goes", ScriptPlaceholder)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("exec.command uses %s, which exec.args doesn't set", strings.Join(unknown, ", "))
+	}
+
+	return params, nil
+}
@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCreateConnectionParameters(t *testing.T) {
+	data := nullModel("web-1", "kubectl exec -i -n {namespace} {host} -- sh -c {script}")
+	data.Exec.Args = types.MapValueMust(types.StringType, map[string]attr.Value{
+		"namespace": types.StringValue("prod"),
+	})
+
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	if params.GetAddress() != "web-1" {
+		t.Errorf("address = %q, expected web-1", params.GetAddress())
+	}
+	if params.GetTimeout() != 5*time.Minute {
+		t.Errorf("timeout = %s, expected 5m", params.GetTimeout())
+	}
+
+	expected := `kubectl exec -i -n 'prod' 'web-1' -- sh -c 'cat -- '\''/etc/it'\''\'\'''\''s'\'''`
+	if command := params.Command(`cat -- '/etc/it'\''s'`); command != expected {
+		t.Errorf("Command() = %s, expected %s", command, expected)
+	}
+}
+
+func TestCreateConnectionParametersErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		command  string
+		args     map[string]string
+		expected string
+	}{
+		"no command":          {"", nil, "exec.command is required"},
+		"no script":           {"docker exec -i {host} sh", nil, "must contain {script}"},
+		"unknown placeholder": {"docker exec -i {container} sh -c {script}", nil, "uses {container}, which exec.args doesn't set"},
+		"reserved arg":        {"sh -c {script}", map[string]string{"host": "other"}, "can't set {host}"},
+		"invalid arg name":    {"sh -c {script}", map[string]string{"Container": "web"}, "not a valid placeholder name"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := nullModel("web-1", test.command)
+			if test.args != nil {
+				elements := map[string]attr.Value{}
+				for key, value := range test.args {
+					elements[key] = types.StringValue(value)
+				}
+				data.Exec.Args = types.MapValueMust(types.StringType, elements)
+			}
+
+			_, err := CreateConnectionParameters(data)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("CreateConnectionParameters() error = %v, expected %q", err, test.expected)
+			}
+		})
+	}
+}
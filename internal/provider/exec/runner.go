@@ -0,0 +1,97 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// Subsystem is the tflog subsystem the exec transport logs to
+const Subsystem = "exec"
+
+// Shell runs the command template on the machine running Terraform
+const Shell = "/bin/sh"
+
+// waitDelay is how long a command killed on timeout gets to close its output
+const waitDelay = time.Second
+
+// run runs script on the target through the command template of params,
+// with stdin as its standard input. A script that ran and exited non-zero
+// is not an error, its exit code is in the result.
+func run(ctx context.Context, params *ConnectionParameters, script string, stdin []byte) (backend.CommandResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, params.GetTimeout())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := osexec.CommandContext(ctx, Shell, "-c", params.Command(script))
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// processes the shell started may hold on to stdout and stderr once it
+	// was killed, they are closed rather than waited for
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	err := cmd.Run()
+
+	result := backend.CommandResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+
+	var exitErr *osexec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return result, fmt.Errorf("error running %q on %s: %w", script, params.GetAddress(), ctx.Err())
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return result, fmt.Errorf("error running %q on %s: %w", script, params.GetAddress(), err)
+	}
+
+	tflog.SubsystemTrace(ctx, Subsystem, "ran script on target", map[string]interface{}{
+		"script":      script,
+		"exit_code":   result.ExitCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	return result, nil
+}
+
+// scriptError explains why a script failed from what it printed to stderr,
+// wrapping the os or syscall error the message of coreutils or BusyBox
+// corresponds to
+func scriptError(script string, result backend.CommandResult) error {
+	message := strings.TrimSpace(result.Stderr)
+	if message == "" {
+		message = fmt.Sprintf("%q exited with status %d", script, result.ExitCode)
+	}
+
+	var kind error
+	switch {
+	case strings.Contains(message, "No such file or directory"):
+		kind = os.ErrNotExist
+	case strings.Contains(message, "Permission denied"), strings.Contains(message, "Operation not permitted"),
+		strings.Contains(message, "Read-only file system"):
+		kind = os.ErrPermission
+	case strings.Contains(message, "File exists"):
+		kind = os.ErrExist
+	case strings.Contains(message, "No space left on device"):
+		kind = syscall.ENOSPC
+	case strings.Contains(message, "Disk quota exceeded"):
+		kind = syscall.EDQUOT
+	default:
+		return errors.New(message)
+	}
+	return fmt.Errorf("%w: %s", kind, message)
+}
@@ -0,0 +1,238 @@
+package ftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// ftpBackend is a server reached over FTP or FTPS. FTP can't run commands, so
+// validate_command and the hooks aren't available, and permissions are set
+// with SITE CHMOD, which most Unix servers support.
+type ftpBackend struct {
+	// ctx is the one the backend was dialed with, the Backend methods don't
+	// take one
+	ctx     context.Context
+	client  *client
+	address string
+}
+
+var _ backend.Backend = &ftpBackend{}
+
+// Dialer returns a Dialer that logs in to the FTP server described by params
+func Dialer(params *ConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		client, err := dial(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ftpBackend{
+			ctx:     ctx,
+			client:  client,
+			address: params.GetAddress(),
+		}, nil
+	}
+}
+
+func (b *ftpBackend) Address() string {
+	return b.address
+}
+
+// Open starts downloading path, the file has to be closed before anything
+// else is done over the connection
+func (b *ftpBackend) Open(path string) (backend.File, error) {
+	conn, err := b.client.retrieve(b.ctx, path)
+	if err != nil {
+		return nil, b.client.classifyMissing(b.ctx, err, path)
+	}
+	return &ftpFile{client: b.client, conn: conn}, nil
+}
+
+// OpenFile opens path for writing unless flag is os.O_RDONLY. The upload
+// starts with the first write or on close, so the file can be seeked to
+// resume an earlier upload with REST first. Unless appending, whatever path
+// held beyond the offset is replaced.
+func (b *ftpBackend) OpenFile(path string, flag int) (backend.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return b.Open(path)
+	}
+	return &ftpFile{
+		ctx:       b.ctx,
+		client:    b.client,
+		path:      path,
+		writing:   true,
+		appending: flag&os.O_APPEND != 0,
+	}, nil
+}
+
+func (b *ftpBackend) Stat(path string) (os.FileInfo, error) {
+	info, err := b.client.stat(path)
+	if err != nil {
+		return nil, b.client.classifyMissing(b.ctx, err, path)
+	}
+	return info, nil
+}
+
+// Lstat is Stat, FTP follows symbolic links
+func (b *ftpBackend) Lstat(path string) (os.FileInfo, error) {
+	return b.Stat(path)
+}
+
+func (b *ftpBackend) Chmod(path string, mode os.FileMode) error {
+	_, _, err := b.client.cmd(2, "SITE CHMOD %04o %s", mode.Perm(), path)
+	var replyErr *ReplyError
+	if errors.As(err, &replyErr) && (replyErr.Code == 500 || replyErr.Code == 502 || replyErr.Code == 504) {
+		return fmt.Errorf("server does not support changing permissions: %w", err)
+	}
+	return b.client.classifyMissing(b.ctx, err, path)
+}
+
+func (b *ftpBackend) Chown(path string, uid int, gid int) error {
+	return errors.New("FTP can't change the owner of files")
+}
+
+// Rename moves oldpath over newpath. Servers that refuse to overwrite get the
+// target removed first.
+func (b *ftpBackend) Rename(oldpath string, newpath string) error {
+	err := b.rename(oldpath, newpath)
+	if err == nil {
+		return nil
+	}
+
+	if _, statErr := b.client.stat(oldpath); statErr != nil {
+		return err
+	}
+	if _, statErr := b.client.stat(newpath); statErr != nil {
+		return err
+	}
+	if err := b.Remove(newpath); err != nil {
+		return err
+	}
+	return b.rename(oldpath, newpath)
+}
+
+// rename sends RNFR and RNTO, RNTO fails for a missing file if the directory
+// of newpath is
+func (b *ftpBackend) rename(oldpath string, newpath string) error {
+	if _, _, err := b.client.cmd(3, "RNFR %s", oldpath); err != nil {
+		return b.client.classifyMissing(b.ctx, err, oldpath)
+	}
+	_, _, err := b.client.cmd(2, "RNTO %s", newpath)
+	return b.client.classifyMissing(b.ctx, err, path.Dir(newpath))
+}
+
+func (b *ftpBackend) Remove(path string) error {
+	_, _, err := b.client.cmd(2, "DELE %s", path)
+	return b.client.classifyMissing(b.ctx, err, path)
+}
+
+func (b *ftpBackend) Mkdir(path string) error {
+	_, _, err := b.client.cmd(2, "MKD %s", path)
+	return err
+}
+
+func (b *ftpBackend) ReadDir(path string) ([]os.FileInfo, error) {
+	entries, err := b.client.list(b.ctx, path)
+	if err != nil {
+		return nil, b.client.classifyMissing(b.ctx, err, path)
+	}
+	return entries, nil
+}
+
+func (b *ftpBackend) Close() error {
+	return b.client.Close()
+}
+
+// ftpFile is a download or an upload over a data connection
+type ftpFile struct {
+	ctx    context.Context
+	client *client
+	conn   net.Conn
+
+	// path, offset and appending describe the upload, started on the first
+	// write
+	path      string
+	offset    int64
+	appending bool
+	writing   bool
+
+	eof    bool
+	closed bool
+}
+
+var _ io.Seeker = &ftpFile{}
+
+func (f *ftpFile) Read(p []byte) (int, error) {
+	if f.writing {
+		return 0, errors.New("file was opened for writing")
+	}
+	n, err := f.conn.Read(p)
+	if err == io.EOF {
+		f.eof = true
+	}
+	return n, err
+}
+
+func (f *ftpFile) Write(p []byte) (int, error) {
+	if !f.writing {
+		return 0, errors.New("file was opened for reading")
+	}
+	if err := f.start(); err != nil {
+		return 0, err
+	}
+	return f.conn.Write(p)
+}
+
+// Seek sets where an upload that hasn't started yet resumes
+func (f *ftpFile) Seek(offset int64, whence int) (int64, error) {
+	if !f.writing || f.conn != nil || whence != io.SeekStart || offset < 0 {
+		return 0, errors.New("ftp files can only be seeked from the start before writing")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// start opens the data connection of an upload, which fails for a missing
+// file if the directory of the file is
+func (f *ftpFile) start() error {
+	if f.conn != nil {
+		return nil
+	}
+	conn, err := f.client.store(f.ctx, f.path, f.offset, f.appending)
+	if err != nil {
+		return f.client.classifyMissing(f.ctx, err, path.Dir(f.path))
+	}
+	f.conn = conn
+	return nil
+}
+
+// Close completes the transfer. A download closed before it reached the end
+// is aborted, the server's complaint about that is ignored.
+func (f *ftpFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if f.writing {
+		if err := f.start(); err != nil {
+			return err
+		}
+		return f.client.finishTransfer(f.conn)
+	}
+
+	err := f.client.finishTransfer(f.conn)
+	var replyErr *ReplyError
+	if !f.eof && errors.As(err, &replyErr) && errors.Is(err, remote.ErrConnect) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,384 @@
+package ftp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend/backendtest"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// newTestModel returns a model logging in to server, with nothing else set
+func newTestModel(server *testServer) *backendtest.Model {
+	data := backendtest.NullModel("127.0.0.1")
+	data.Port = types.Int64Value(server.port())
+	data.User = types.StringValue(server.user)
+	data.Password = types.StringValue(server.password)
+	data.Timeout = types.StringValue("5s")
+	return data
+}
+
+// testDialer returns the dialer for data
+func testDialer(t *testing.T, data *backendtest.Model) func(context.Context) (*ftpBackend, error) {
+	t.Helper()
+	return backendtest.Dialer[*ftpBackend, ModelSubset](t, data, CreateConnectionParameters, Dialer)
+}
+
+func TestDialerModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		tls     string
+		options []testServerOption
+	}{
+		{name: "passive", mode: ModePassive, tls: TLSNone},
+		{name: "active", mode: ModeActive, tls: TLSNone},
+		{name: "passive explicit TLS", mode: ModePassive, tls: TLSExplicit, options: []testServerOption{withTLS(false)}},
+		{name: "active explicit TLS", mode: ModeActive, tls: TLSExplicit, options: []testServerOption{withTLS(false)}},
+		{name: "passive implicit TLS", mode: ModePassive, tls: TLSImplicit, options: []testServerOption{withTLS(true)}},
+		{name: "active implicit TLS", mode: ModeActive, tls: TLSImplicit, options: []testServerOption{withTLS(true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.options...)
+			data := newTestModel(server)
+			data.Ftp.Mode = types.StringValue(tt.mode)
+			data.Ftp.Tls = types.StringValue(tt.tls)
+			if server.caCert != "" {
+				data.Ftp.CaCert = types.StringValue(server.caCert)
+			}
+			data.Path = types.StringValue("/motd")
+			data.Permissions = types.StringValue("0600")
+			data.Contents = types.StringValue("welcome\n")
+
+			params, err := CreateConnectionParameters(data)
+			if err != nil {
+				t.Fatalf("CreateConnectionParameters() error = %v", err)
+			}
+			dial := Dialer(params)
+			ctx := context.Background()
+
+			if err := remote.ConnectAndWrite(dial, data, data)(ctx); err != nil {
+				t.Fatalf("ConnectAndWrite() error = %v", err)
+			}
+			info, err := os.Stat(filepath.Join(server.root, "motd"))
+			if err != nil {
+				t.Fatalf("Failed to stat written file: %v", err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("mode = %o, expected 0600", info.Mode().Perm())
+			}
+
+			read := newTestModel(server)
+			read.Path = data.Path
+			if err := remote.ConnectAndCopy(dial, read, read)(ctx); err != nil {
+				t.Fatalf("ConnectAndCopy() error = %v", err)
+			}
+			if read.Contents.ValueString() != "welcome\n" {
+				t.Errorf("contents = %q, expected %q", read.Contents.ValueString(), "welcome\n")
+			}
+			if read.Size.ValueInt64() != 8 {
+				t.Errorf("size = %d, expected 8", read.Size.ValueInt64())
+			}
+			if read.ID.ValueString() != "motd" {
+				t.Errorf("id = %q, expected %q", read.ID.ValueString(), "motd")
+			}
+
+			if err := remote.ConnectAndDelete(dial, data)(ctx); err != nil {
+				t.Fatalf("ConnectAndDelete() error = %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(server.root, "motd")); !os.IsNotExist(err) {
+				t.Errorf("file still exists after delete, stat error = %v", err)
+			}
+
+			if tt.mode == ModeActive && !server.received("PORT ") {
+				t.Error("expected the data connections to be opened with PORT")
+			}
+			if tt.mode == ModePassive && !server.received("EPSV") {
+				t.Error("expected the data connections to be opened with EPSV")
+			}
+		})
+	}
+}
+
+func TestDialerWithoutExtensions(t *testing.T) {
+	server := newTestServer(t, withoutExtensions())
+	modTime := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	target := filepath.Join(server.root, "version")
+	if err := os.WriteFile(target, []byte("1.2.3"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(target, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set file time: %v", err)
+	}
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/version")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	if err := remote.ConnectAndCopy(Dialer(params), data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if data.Contents.ValueString() != "1.2.3" {
+		t.Errorf("contents = %q, expected %q", data.Contents.ValueString(), "1.2.3")
+	}
+	if data.Size.ValueInt64() != 5 {
+		t.Errorf("size = %d, expected 5", data.Size.ValueInt64())
+	}
+	if data.LastModified.ValueString() != modTime.Format(time.RFC3339) {
+		t.Errorf("last_modified = %q, expected %q", data.LastModified.ValueString(), modTime.Format(time.RFC3339))
+	}
+	if !server.received("PASV") || !server.received("SIZE ") || !server.received("MDTM ") {
+		t.Error("expected the client to fall back to PASV, SIZE and MDTM")
+	}
+}
+
+func TestDialerMissingFile(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/missing")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndCopy(Dialer(params), data, data)(context.Background())
+	if !remote.IsFileNotFound(err) {
+		t.Fatalf("ConnectAndCopy() error = %v, expected a not found error", err)
+	}
+
+	data.AllowMissing = types.BoolValue(true)
+	if err := remote.ConnectAndCopy(Dialer(params), data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() with allow_missing error = %v", err)
+	}
+	if data.ID.ValueString() != "missing" || data.Size.ValueInt64() != -1 {
+		t.Errorf("id = %q, size = %d, expected a missing file", data.ID.ValueString(), data.Size.ValueInt64())
+	}
+}
+
+func TestBackendUnavailableFile(t *testing.T) {
+	for _, extensions := range []bool{true, false} {
+		options := []testServerOption{withUnavailable("/locked.conf")}
+		if !extensions {
+			options = append(options, withoutExtensions())
+		}
+		server := newTestServer(t, options...)
+		if err := os.WriteFile(filepath.Join(server.root, "locked.conf"), []byte("secret"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		dial := testDialer(t, newTestModel(server))
+
+		b, err := dial(context.Background())
+		if err != nil {
+			t.Fatalf("Dialer() error = %v", err)
+		}
+		defer b.Close()
+
+		// A file that is there but refused with 550 isn't missing
+		_, err = b.Open("/locked.conf")
+		if err == nil || remote.IsFileNotFound(err) {
+			t.Errorf("Open() of a refused file error = %v, expected it not to be a not found error", err)
+		}
+
+		_, err = b.Open("/missing.conf")
+		if !remote.IsFileNotFound(err) {
+			t.Errorf("Open() of a missing file error = %v, expected a not found error", err)
+		}
+		_, err = b.Stat("/missing/app.conf")
+		if !remote.IsFileNotFound(err) {
+			t.Errorf("Stat() in a missing directory error = %v, expected a not found error", err)
+		}
+
+		// Uploads start with the first write
+		file, err := b.OpenFile("/missing/app.conf", os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			t.Fatalf("OpenFile() error = %v", err)
+		}
+		_, err = file.Write([]byte("listen 80"))
+		if !remote.IsFileNotFound(err) {
+			t.Errorf("Write() in a missing directory error = %v, expected a not found error", err)
+		}
+	}
+}
+
+func TestDialerAuthFailed(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Password = types.StringValue("wrong")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	_, err = Dialer(params)(context.Background())
+	if !errors.Is(err, remote.ErrAuthFailed) {
+		t.Fatalf("Dialer() error = %v, expected %v", err, remote.ErrAuthFailed)
+	}
+}
+
+func TestDialerUntrustedCertificate(t *testing.T) {
+	server := newTestServer(t, withTLS(false))
+	data := newTestModel(server)
+	data.Ftp.Tls = types.StringValue(TLSExplicit)
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	_, err = Dialer(params)(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("Dialer() error = %v, expected a certificate error", err)
+	}
+	if server.received("USER ") {
+		t.Error("the user was sent before the server was verified")
+	}
+}
+
+func TestDialerResumable(t *testing.T) {
+	server := newTestServer(t, withoutOverwrite())
+	contents := strings.Repeat("0123456789", 100)
+	if err := os.WriteFile(filepath.Join(server.root, ".large.partial"), []byte(contents[:400]), 0644); err != nil {
+		t.Fatalf("Failed to write partial file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(server.root, "large"), []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write target file: %v", err)
+	}
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/large")
+	data.Contents = types.StringValue(contents)
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(Dialer(params), data, data, remote.WithResumable(true))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(server.root, "large"))
+	if err != nil {
+		t.Fatalf("Failed to read written file: %v", err)
+	}
+	if string(written) != contents {
+		t.Errorf("contents differ after resumed upload, got %d bytes", len(written))
+	}
+	if !server.received("REST 400") {
+		t.Error("expected the upload to resume from byte 400")
+	}
+	if _, err := os.Stat(filepath.Join(server.root, ".large.partial")); !os.IsNotExist(err) {
+		t.Errorf("partial file still exists, stat error = %v", err)
+	}
+}
+
+func TestDialerCommandsUnavailable(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/nginx.conf")
+	data.Contents = types.StringValue("events {}\n")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(Dialer(params), data, data, remote.WithValidate("nginx -t -c %s"))(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "commands can't be run") {
+		t.Fatalf("ConnectAndWrite() error = %v, expected commands to be unavailable", err)
+	}
+	if _, err := os.Stat(filepath.Join(server.root, "nginx.conf")); !os.IsNotExist(err) {
+		t.Errorf("unvalidated file was written, stat error = %v", err)
+	}
+}
+
+func TestBackendReadDir(t *testing.T) {
+	for _, extensions := range []bool{true, false} {
+		var options []testServerOption
+		if !extensions {
+			options = append(options, withoutExtensions())
+		}
+		server := newTestServer(t, options...)
+		dial := testDialer(t, newTestModel(server))
+
+		b, err := dial(context.Background())
+		if err != nil {
+			t.Fatalf("Dialer() error = %v", err)
+		}
+		defer b.Close()
+
+		if err := b.Mkdir("/conf.d"); err != nil {
+			t.Fatalf("Mkdir() error = %v", err)
+		}
+		for _, name := range []string{"a.conf", "b.conf"} {
+			if err := os.WriteFile(filepath.Join(server.root, "conf.d", name), []byte(name), 0640); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+
+		entries, err := b.ReadDir("/conf.d")
+		if err != nil {
+			t.Fatalf("ReadDir() error = %v", err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+			if entry.Size() != 6 {
+				t.Errorf("%s size = %d, expected 6", entry.Name(), entry.Size())
+			}
+			if extensions && entry.Mode().Perm() != 0640 {
+				t.Errorf("%s mode = %o, expected 0640", entry.Name(), entry.Mode().Perm())
+			}
+		}
+		sort.Strings(names)
+		if strings.Join(names, ",") != "a.conf,b.conf" {
+			t.Errorf("ReadDir() names = %v", names)
+		}
+
+		info, err := b.Stat("/conf.d")
+		if extensions {
+			if err != nil || !info.IsDir() {
+				t.Errorf("Stat() of a directory = %v, %v, expected a directory", info, err)
+			}
+		}
+	}
+}
+
+func TestReplyError(t *testing.T) {
+	tests := []struct {
+		code     int
+		message  string
+		missing  bool
+		expected error
+	}{
+		{530, "Login incorrect.", false, remote.ErrAuthFailed},
+		{421, "Timeout.", false, remote.ErrConnect},
+		{425, "Can't open data connection.", false, remote.ErrConnect},
+		{550, "Failed to open file.", false, nil},
+		{550, "Failed to open file.", true, os.ErrNotExist},
+		{550, "Permission denied.", false, os.ErrPermission},
+		{553, "Could not create file.", false, os.ErrPermission},
+		{552, "Exceeded storage allocation.", false, syscall.EDQUOT},
+		{452, "Insufficient storage space.", false, syscall.ENOSPC},
+		{500, "Unknown command.", false, nil},
+	}
+
+	for _, tt := range tests {
+		err := &ReplyError{Code: tt.code, Message: tt.message, Missing: tt.missing}
+		if got := err.Unwrap(); got != tt.expected {
+			t.Errorf("%v wraps %v, expected %v", err, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,610 @@
+package ftp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// Subsystem is the tflog subsystem the FTP client logs to
+const Subsystem = "ftp"
+
+// ReplyError is a reply refusing a command. Unwrap maps the reply code, and
+// the wording of servers that answer 550 to everything, to the os, syscall
+// or typed error the remote operations look for.
+type ReplyError struct {
+	Code    int
+	Message string
+	// Missing is set once a 550 reply was confirmed to be about a file that
+	// doesn't exist, see client.classifyMissing
+	Missing bool
+}
+
+func (e *ReplyError) Error() string {
+	return fmt.Sprintf("ftp: %d %s", e.Code, e.Message)
+}
+
+func (e *ReplyError) Unwrap() error {
+	message := strings.ToLower(e.Message)
+	switch {
+	case e.Code == 530:
+		return remote.ErrAuthFailed
+	case e.Code == 421, e.Code == 425, e.Code == 426:
+		return remote.ErrConnect
+	case e.Code == 452:
+		return syscall.ENOSPC
+	case e.Code == 552:
+		return syscall.EDQUOT
+	case strings.Contains(message, "permission denied"), strings.Contains(message, "not permitted"):
+		return os.ErrPermission
+	case e.Code == 553:
+		// the file name is not allowed, mostly outside of where the user may write
+		return os.ErrPermission
+	case e.Code == 550 && e.Missing:
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// client is a logged in control connection. It carries one transfer at a
+// time, the data connection of which is open between startTransfer and
+// finishTransfer.
+type client struct {
+	params *ConnectionParameters
+	conn   net.Conn
+	text   *textproto.Conn
+
+	// features are the FEAT lines of the server, keyed by upper case name
+	features map[string]string
+	// noEpsv is set once the server refused EPSV
+	noEpsv bool
+	// transferring is set while a data connection is open
+	transferring bool
+	// stopCloseData undoes closing the data connection when the context is done
+	stopCloseData func() bool
+	// stopClose undoes closing the connection when the context is done
+	stopClose func() bool
+}
+
+// dial connects and logs in to the FTP server described by params. The
+// connection is torn down as soon as ctx is done, so a cancelled apply aborts
+// any transfer in flight.
+func dial(ctx context.Context, params *ConnectionParameters) (*client, error) {
+	tflog.SubsystemDebug(ctx, Subsystem, "dialing FTP server", map[string]interface{}{
+		"user":    params.user,
+		"mode":    params.mode,
+		"tls":     params.tls,
+		"timeout": params.timeout.String(),
+	})
+
+	start := time.Now()
+	dialer := net.Dialer{Timeout: params.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", params.address)
+	if err != nil {
+		tflog.SubsystemDebug(ctx, Subsystem, "TCP dial failed", map[string]interface{}{
+			"duration_ms": time.Since(start).Milliseconds(),
+			"error":       err.Error(),
+		})
+		return nil, fmt.Errorf("%w: %w", remote.ErrConnect, err)
+	}
+	if params.tls == TLSImplicit {
+		conn = tls.Client(conn, params.tlsConfig)
+	}
+
+	c := &client{
+		params:    params,
+		conn:      conn,
+		text:      textproto.NewConn(conn),
+		features:  map[string]string{},
+		stopClose: context.AfterFunc(ctx, func() { conn.Close() }),
+	}
+
+	// Logging in is bounded by the connect timeout, transfers only by ctx
+	_ = conn.SetDeadline(time.Now().Add(params.timeout))
+	err = c.login(ctx)
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		c.Close()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to connect to FTP server: %w", ctx.Err())
+		}
+		tflog.SubsystemDebug(ctx, Subsystem, "FTP login failed", map[string]interface{}{
+			"duration_ms": time.Since(start).Milliseconds(),
+			"error":       err.Error(),
+		})
+		return nil, fmt.Errorf("failed to connect to FTP server: %w", err)
+	}
+
+	tflog.SubsystemDebug(ctx, Subsystem, "FTP login complete", map[string]interface{}{
+		"duration_ms": time.Since(start).Milliseconds(),
+		"features":    len(c.features),
+	})
+
+	return c, nil
+}
+
+// login reads the greeting, protects the connection as configured, logs in
+// and switches to binary transfers
+func (c *client) login(ctx context.Context) error {
+	if _, _, err := c.reply(2); err != nil {
+		return err
+	}
+
+	if c.params.tls == TLSExplicit {
+		if _, _, err := c.cmd(2, "AUTH TLS"); err != nil {
+			return fmt.Errorf("server refused TLS: %w", err)
+		}
+		tlsConn := tls.Client(c.conn, c.params.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn = tlsConn
+		c.text = textproto.NewConn(tlsConn)
+	}
+
+	code, _, err := c.cmd(0, "USER %s", c.params.user)
+	if err == nil && code == 331 {
+		code, _, err = c.cmd(0, "PASS %s", c.params.password)
+	}
+	if err != nil {
+		return err
+	}
+	if code != 230 && code != 202 {
+		return fmt.Errorf("%w: login answered with %d", remote.ErrAuthFailed, code)
+	}
+
+	if c.params.tls != TLSNone {
+		if _, _, err := c.cmd(2, "PBSZ 0"); err != nil {
+			return err
+		}
+		if _, _, err := c.cmd(2, "PROT P"); err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := c.cmd(2, "TYPE I"); err != nil {
+		return err
+	}
+
+	// Servers that don't know FEAT support none of the extensions. The
+	// features are listed between the first and the last line of the reply.
+	if _, message, err := c.cmd(2, "FEAT"); err == nil {
+		lines := strings.Split(message, "\n")
+		for i := 1; i < len(lines)-1; i++ {
+			name, params, _ := strings.Cut(strings.TrimSpace(lines[i]), " ")
+			if name != "" {
+				c.features[strings.ToUpper(name)] = params
+			}
+		}
+	}
+
+	return nil
+}
+
+// reply reads the next reply, failing unless its code matches expect the
+// way textproto.Reader.ReadResponse does, where 0 accepts any code
+func (c *client) reply(expect int) (int, string, error) {
+	code, message, err := c.text.ReadResponse(expect)
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return code, message, &ReplyError{Code: protoErr.Code, Message: protoErr.Msg}
+	}
+	return code, message, err
+}
+
+// cmd sends a command and reads its reply
+func (c *client) cmd(expect int, format string, args ...any) (int, string, error) {
+	if c.transferring {
+		return 0, "", errors.New("ftp: the control connection is busy with a transfer")
+	}
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.reply(expect)
+}
+
+// startTransfer opens a data connection and sends command over the control
+// connection, e.g. RETR or STOR. The caller must hand the data connection to
+// finishTransfer once done with it.
+func (c *client) startTransfer(ctx context.Context, command string) (net.Conn, error) {
+	var conn net.Conn
+	if c.params.mode == ModeActive {
+		listener, err := c.listen()
+		if err != nil {
+			return nil, err
+		}
+		defer listener.Close()
+
+		if _, _, err := c.cmd(1, "%s", command); err != nil {
+			return nil, err
+		}
+
+		stopAccept := context.AfterFunc(ctx, func() { listener.Close() })
+		defer stopAccept()
+		_ = listener.SetDeadline(time.Now().Add(c.params.timeout))
+		conn, err = listener.Accept()
+		if err != nil {
+			_, _, _ = c.reply(0)
+			return nil, fmt.Errorf("%w: server did not open the data connection: %w", remote.ErrConnect, err)
+		}
+	} else {
+		address, err := c.passive()
+		if err != nil {
+			return nil, err
+		}
+
+		dialer := net.Dialer{Timeout: c.params.timeout}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", remote.ErrConnect, err)
+		}
+
+		if _, _, err := c.cmd(1, "%s", command); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if c.params.tlsConfig != nil {
+		conn = tls.Client(conn, c.params.tlsConfig)
+	}
+	c.transferring = true
+	c.stopCloseData = context.AfterFunc(ctx, func() { conn.Close() })
+	return conn, nil
+}
+
+// finishTransfer closes the data connection and reads the reply of the server
+// to the transfer
+func (c *client) finishTransfer(conn net.Conn) error {
+	c.stopCloseData()
+	closeErr := conn.Close()
+	c.transferring = false
+	if _, _, err := c.reply(2); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// passive asks the server for a port to open the data connection to. The
+// address the server advertises with PASV is ignored in favour of the one
+// already connected to, it is often wrong behind NAT.
+func (c *client) passive() (string, error) {
+	host, _, err := net.SplitHostPort(c.conn.RemoteAddr().String())
+	if err != nil {
+		return "", err
+	}
+
+	if !c.noEpsv {
+		_, message, err := c.cmd(229, "EPSV")
+		if err == nil {
+			// Entering Extended Passive Mode (|||6446|)
+			start, end := strings.Index(message, "("), strings.LastIndex(message, ")")
+			if start < 0 || end < start {
+				return "", fmt.Errorf("malformed EPSV reply %q", message)
+			}
+			fields := strings.Split(message[start+1:end], message[start+1:start+2])
+			if len(fields) != 5 {
+				return "", fmt.Errorf("malformed EPSV reply %q", message)
+			}
+			port, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return "", fmt.Errorf("malformed EPSV reply %q", message)
+			}
+			return net.JoinHostPort(host, strconv.Itoa(port)), nil
+		}
+
+		var replyErr *ReplyError
+		if !errors.As(err, &replyErr) || replyErr.Code/100 != 5 {
+			return "", err
+		}
+		c.noEpsv = true
+	}
+
+	_, message, err := c.cmd(227, "PASV")
+	if err != nil {
+		return "", err
+	}
+
+	// Entering Passive Mode (h1,h2,h3,h4,p1,p2)
+	start := strings.IndexAny(message, "0123456789")
+	end := strings.LastIndexAny(message, "0123456789")
+	if start < 0 {
+		return "", fmt.Errorf("malformed PASV reply %q", message)
+	}
+	fields := strings.Split(message[start:end+1], ",")
+	if len(fields) != 6 {
+		return "", fmt.Errorf("malformed PASV reply %q", message)
+	}
+	high, err1 := strconv.Atoi(fields[4])
+	low, err2 := strconv.Atoi(fields[5])
+	if err1 != nil || err2 != nil {
+		return "", fmt.Errorf("malformed PASV reply %q", message)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(high<<8|low)), nil
+}
+
+// listen opens a port on the address of the control connection and tells
+// the server to connect to it
+func (c *client) listen() (*net.TCPListener, error) {
+	local, ok := c.conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected local address %s", c.conn.LocalAddr())
+	}
+
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: local.IP})
+	if err != nil {
+		return nil, fmt.Errorf("error opening port for active mode: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if ip := local.IP.To4(); ip != nil {
+		_, _, err = c.cmd(2, "PORT %d,%d,%d,%d,%d,%d", ip[0], ip[1], ip[2], ip[3], port>>8, port&0xff)
+	} else {
+		_, _, err = c.cmd(2, "EPRT |2|%s|%d|", local.IP, port)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// retrieve downloads remotePath
+func (c *client) retrieve(ctx context.Context, remotePath string) (net.Conn, error) {
+	return c.startTransfer(ctx, "RETR "+remotePath)
+}
+
+// store uploads to remotePath, starting at offset or appending
+func (c *client) store(ctx context.Context, remotePath string, offset int64, appending bool) (net.Conn, error) {
+	command := "STOR "
+	switch {
+	case appending:
+		command = "APPE "
+	case offset > 0:
+		if _, _, err := c.cmd(3, "REST %d", offset); err != nil {
+			return nil, err
+		}
+	}
+	return c.startTransfer(ctx, command+remotePath)
+}
+
+// stat describes remotePath, from MLST where the server supports it and
+// otherwise from SIZE and MDTM, which only work on files
+func (c *client) stat(remotePath string) (*fileInfo, error) {
+	if _, ok := c.features["MLST"]; ok {
+		_, message, err := c.cmd(2, "MLST %s", remotePath)
+		if err != nil {
+			return nil, err
+		}
+		// the facts are on the one line between the first and the last,
+		// indented by a space
+		lines := strings.Split(message, "\n")
+		if len(lines) < 2 {
+			return nil, fmt.Errorf("malformed MLST reply %q", message)
+		}
+		return parseFacts(strings.TrimPrefix(lines[1], " "), path.Base(remotePath))
+	}
+
+	_, message, err := c.cmd(213, "SIZE %s", remotePath)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(message), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed SIZE reply %q", message)
+	}
+
+	info := &fileInfo{name: path.Base(remotePath), size: size}
+	if _, message, err := c.cmd(213, "MDTM %s", remotePath); err == nil {
+		info.modTime, _ = parseTime(strings.TrimSpace(message))
+	}
+	return info, nil
+}
+
+// list returns the entries of dir, from MLSD where the server supports it and
+// otherwise by stat'ing every name NLST returns
+func (c *client) list(ctx context.Context, dir string) ([]os.FileInfo, error) {
+	if _, mlsd := c.features["MLST"]; !mlsd {
+		names, err := c.names(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []os.FileInfo
+		for _, name := range names {
+			info, err := c.stat(path.Join(dir, name))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, info)
+		}
+		return entries, nil
+	}
+
+	lines, err := c.listing(ctx, "MLSD "+dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []os.FileInfo
+	for _, line := range lines {
+		facts, name, _ := strings.Cut(line, " ")
+		if name == "." || name == ".." {
+			continue
+		}
+		info, err := parseFacts(facts+" "+name, name)
+		if err != nil {
+			return nil, err
+		}
+		if info.kind == "cdir" || info.kind == "pdir" {
+			continue
+		}
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+// names returns the names NLST lists in dir, some servers list them as paths
+func (c *client) names(ctx context.Context, dir string) ([]string, error) {
+	lines, err := c.listing(ctx, "NLST "+dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range lines {
+		name := path.Base(line)
+		if name != "." && name != ".." {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// listing returns the non-empty lines command transfers
+func (c *client) listing(ctx context.Context, command string) ([]string, error) {
+	conn, err := c.startTransfer(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	listing, readErr := io.ReadAll(conn)
+	if err := c.finishTransfer(conn); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(listing), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// classifyMissing returns err, marked as being about a missing file if it is
+// a 550 reply to a command on remotePath and missing confirms remotePath
+// doesn't exist. Servers reply 550 to every kind of unavailable file, a file
+// that is there but may not be read or replaced must not be taken as missing.
+func (c *client) classifyMissing(ctx context.Context, err error, remotePath string) error {
+	var replyErr *ReplyError
+	if errors.As(err, &replyErr) && replyErr.Code == 550 && !c.transferring {
+		replyErr.Missing = c.missing(ctx, remotePath)
+	}
+	return err
+}
+
+// missing tells whether remotePath doesn't exist: MLST, or SIZE without it,
+// fail for it with 550 and the listing of its directory, or of a directory
+// above where that is missing too, shows it isn't there
+func (c *client) missing(ctx context.Context, remotePath string) bool {
+	dir := path.Dir(remotePath)
+	if dir == remotePath {
+		return false
+	}
+
+	_, err := c.stat(remotePath)
+	var replyErr *ReplyError
+	if !errors.As(err, &replyErr) || replyErr.Code != 550 {
+		return false
+	}
+
+	names, err := c.names(ctx, dir)
+	if err == nil {
+		return !slices.Contains(names, path.Base(remotePath))
+	}
+	return errors.As(err, &replyErr) && replyErr.Code == 550 && c.missing(ctx, dir)
+}
+
+// Close logs out and closes the control connection
+func (c *client) Close() error {
+	c.stopClose()
+	if !c.transferring {
+		_ = c.text.PrintfLine("QUIT")
+	}
+	return c.conn.Close()
+}
+
+// parseFacts parses an MLST or MLSD line, facts separated by semicolons
+// followed by a space and the name
+func parseFacts(line string, name string) (*fileInfo, error) {
+	facts, _, ok := strings.Cut(line, " ")
+	if !ok {
+		return nil, fmt.Errorf("malformed MLST facts %q", line)
+	}
+
+	info := &fileInfo{name: name, kind: "file"}
+	for _, fact := range strings.Split(facts, ";") {
+		key, value, ok := strings.Cut(fact, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "type":
+			info.kind = strings.ToLower(value)
+		case "size":
+			info.size, _ = strconv.ParseInt(value, 10, 64)
+		case "modify":
+			info.modTime, _ = parseTime(value)
+		case "unix.mode":
+			mode, err := strconv.ParseUint(value, 8, 32)
+			if err == nil {
+				info.mode = os.FileMode(mode).Perm()
+			}
+		}
+	}
+	return info, nil
+}
+
+// parseTime parses the UTC timestamps of MDTM and MLST, YYYYMMDDHHMMSS with
+// optional fractional seconds
+func parseTime(value string) (time.Time, error) {
+	value, _, _ = strings.Cut(value, ".")
+	return time.ParseInLocation("20060102150405", value, time.UTC)
+}
+
+// fileInfo describes a file from what MLST or SIZE and MDTM tell. Servers
+// without MLST don't tell the mode, it is reported as zero.
+type fileInfo struct {
+	name    string
+	kind    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) IsDir() bool {
+	return i.kind == "dir" || i.kind == "cdir" || i.kind == "pdir"
+}
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.IsDir() {
+		return os.ModeDir | i.mode
+	}
+	return i.mode
+}
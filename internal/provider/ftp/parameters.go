@@ -0,0 +1,198 @@
+package ftp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Data connection modes
+const (
+	// ModePassive has the client connect to a port the server opens, with
+	// EPSV or PASV
+	ModePassive = "passive"
+	// ModeActive has the server connect back to a port the client opens, with
+	// EPRT or PORT
+	ModeActive = "active"
+)
+
+// Modes are the supported values of mode
+var Modes = []string{ModePassive, ModeActive}
+
+// TLS settings
+const (
+	// TLSNone transfers everything, the password included, in clear text
+	TLSNone = "none"
+	// TLSExplicit upgrades the connection with AUTH TLS after connecting
+	TLSExplicit = "explicit"
+	// TLSImplicit speaks TLS from the start, usually on port 990
+	TLSImplicit = "implicit"
+)
+
+// TLSModes are the supported values of tls
+var TLSModes = []string{TLSNone, TLSExplicit, TLSImplicit}
+
+// Default ports, implicit TLS has its own
+const (
+	DefaultPort            = 21
+	DefaultImplicitTLSPort = 990
+)
+
+// anonymousUser is who logs in when no user is configured
+const anonymousUser = "anonymous"
+
+// the subset of terraform schema fields that are needed to connect to an FTP
+// server
+type ModelSubset interface {
+	GetFtpCaCert() types.String
+	GetFtpMode() types.String
+	GetFtpTls() types.String
+	GetHost() types.String
+	GetHostKey() types.String
+	GetPassword() types.String
+	GetPort() types.Int64
+	GetPrivateKey() types.String
+	GetTimeout() types.String
+	GetUser() types.String
+}
+
+// ConnectionParameters describes how to connect and log in to an FTP server
+type ConnectionParameters struct {
+	address  string
+	user     string
+	password string
+	timeout  time.Duration
+	mode     string
+	tls      string
+
+	// tlsConfig is nil without TLS
+	tlsConfig *tls.Config
+}
+
+func (p *ConnectionParameters) GetAddress() string {
+	return p.address
+}
+
+func (p *ConnectionParameters) GetUser() string {
+	return p.user
+}
+
+func (p *ConnectionParameters) GetPassword() string {
+	return p.password
+}
+
+func (p *ConnectionParameters) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// GetMode returns how data connections are opened, ModePassive or ModeActive
+func (p *ConnectionParameters) GetMode() string {
+	return p.mode
+}
+
+// GetTLS returns TLSNone, TLSExplicit or TLSImplicit
+func (p *ConnectionParameters) GetTLS() string {
+	return p.tls
+}
+
+// GetTLSConfig returns the configuration both the control and the data
+// connections are protected with, nil without TLS
+func (p *ConnectionParameters) GetTLSConfig() *tls.Config {
+	return p.tlsConfig
+}
+
+func CreateConnectionParameters(data ModelSubset) (*ConnectionParameters, error) {
+	if !data.GetHostKey().IsNull() {
+		return nil, errors.New("host_key only applies to SSH, set ftp.ca_cert to verify an FTPS server")
+	}
+	if !data.GetPrivateKey().IsNull() {
+		return nil, errors.New("private_key only applies to SSH, FTP logs in with user and password")
+	}
+
+	mode := ModePassive
+	if value := data.GetFtpMode(); !value.IsNull() {
+		mode = value.ValueString()
+	}
+	if !slices.Contains(Modes, mode) {
+		return nil, fmt.Errorf("ftp.mode must be one of %s, got %q", strings.Join(Modes, ", "), mode)
+	}
+
+	tlsMode := TLSNone
+	if value := data.GetFtpTls(); !value.IsNull() {
+		tlsMode = value.ValueString()
+	}
+	if !slices.Contains(TLSModes, tlsMode) {
+		return nil, fmt.Errorf("ftp.tls must be one of %s, got %q", strings.Join(TLSModes, ", "), tlsMode)
+	}
+
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+
+	port := int64(DefaultPort)
+	if tlsMode == TLSImplicit {
+		port = DefaultImplicitTLSPort
+	}
+	if !data.GetPort().IsNull() {
+		port = data.GetPort().ValueInt64()
+	}
+
+	user := anonymousUser
+	if !data.GetUser().IsNull() && data.GetUser().ValueString() != "" {
+		user = data.GetUser().ValueString()
+	}
+
+	// Anonymous servers ask for an email address, any value does
+	password := data.GetPassword().ValueString()
+	if data.GetPassword().IsNull() && user == anonymousUser {
+		password = anonymousUser + "@"
+	}
+
+	host := data.GetHost().ValueString()
+	params := &ConnectionParameters{
+		address:  net.JoinHostPort(host, strconv.FormatInt(port, 10)),
+		user:     user,
+		password: password,
+		timeout:  timeoutDuration,
+		mode:     mode,
+		tls:      tlsMode,
+	}
+
+	caCert := data.GetFtpCaCert()
+	if tlsMode == TLSNone {
+		if !caCert.IsNull() {
+			return nil, errors.New("ftp.ca_cert only applies when ftp.tls is explicit or implicit")
+		}
+		return params, nil
+	}
+
+	// Data connections resume the session of the control connection, which
+	// most FTPS servers insist on
+	params.tlsConfig = &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+	if !caCert.IsNull() {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert.ValueString())) {
+			return nil, errors.New("failed to parse ftp.ca_cert: no PEM certificates found")
+		}
+		params.tlsConfig.RootCAs = pool
+	}
+
+	return params, nil
+}
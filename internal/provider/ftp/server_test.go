@@ -0,0 +1,529 @@
+package ftp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer is an FTP server serving a temporary directory, just complete
+// enough for the backend
+type testServer struct {
+	t        *testing.T
+	listener net.Listener
+	root     string
+
+	user     string
+	password string
+
+	// tlsConfig is set for servers offering TLS, implicit ones speak it from
+	// the start
+	tlsConfig *tls.Config
+	implicit  bool
+	// caCert is the PEM certificate the server certificate is signed with
+	caCert string
+
+	// noMlst and noEpsv make the server look older than it is, noOverwrite
+	// makes RNTO refuse existing targets
+	noMlst      bool
+	noEpsv      bool
+	noOverwrite bool
+	// unavailable is a path RETR refuses with a bare 550, as servers do for
+	// files they won't serve
+	unavailable string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+type testServerOption func(*testServer)
+
+func withTLS(implicit bool) testServerOption {
+	return func(s *testServer) {
+		s.caCert, s.tlsConfig = testCertificate(s.t)
+		s.implicit = implicit
+	}
+}
+
+func withoutExtensions() testServerOption {
+	return func(s *testServer) {
+		s.noMlst = true
+		s.noEpsv = true
+	}
+}
+
+func withUnavailable(path string) testServerOption {
+	return func(s *testServer) {
+		s.unavailable = path
+	}
+}
+
+func withoutOverwrite() testServerOption {
+	return func(s *testServer) {
+		s.noOverwrite = true
+	}
+}
+
+// newTestServer starts a server on a random local port, stopped when the
+// test ends
+func newTestServer(t *testing.T, opts ...testServerOption) *testServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	s := &testServer{
+		t:        t,
+		listener: listener,
+		root:     t.TempDir(),
+		user:     "testuser",
+		password: "testpass",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+
+	return s
+}
+
+// port returns the port the server listens on
+func (s *testServer) port() int64 {
+	return int64(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// received returns whether a command starting with prefix was received
+func (s *testServer) received(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, command := range s.commands {
+		if strings.HasPrefix(command, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// local maps a path the client sent into the served directory
+func (s *testServer) local(remotePath string) string {
+	return filepath.Join(s.root, filepath.FromSlash(filepath.Clean("/"+remotePath)))
+}
+
+// testSession is the state of one control connection
+type testSession struct {
+	server *testServer
+	conn   net.Conn
+	text   *textproto.Conn
+
+	user       string
+	loggedIn   bool
+	protected  bool
+	passive    net.Listener
+	active     string
+	restart    int64
+	renameFrom string
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	if s.implicit {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+	session := &testSession{server: s, conn: conn, text: textproto.NewConn(conn)}
+	defer func() {
+		if session.passive != nil {
+			session.passive.Close()
+		}
+		session.conn.Close()
+	}()
+
+	session.reply(220, "test server ready")
+	for {
+		line, err := session.text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		command = strings.ToUpper(command)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, command+" "+arg)
+		s.mu.Unlock()
+
+		if command == "QUIT" {
+			session.reply(221, "bye")
+			return
+		}
+		session.handle(command, arg)
+	}
+}
+
+func (c *testSession) reply(code int, message string) {
+	_ = c.text.PrintfLine("%d %s", code, message)
+}
+
+// replyError answers a failed filesystem operation
+func (c *testSession) replyError(err error) {
+	switch {
+	case os.IsPermission(err):
+		c.reply(550, "Permission denied")
+	case os.IsNotExist(err):
+		c.reply(550, "No such file or directory")
+	default:
+		c.reply(451, err.Error())
+	}
+}
+
+func (c *testSession) handle(command string, arg string) {
+	s := c.server
+
+	switch command {
+	case "AUTH":
+		if s.tlsConfig == nil || s.implicit {
+			c.reply(502, "TLS not available")
+			return
+		}
+		c.reply(234, "AUTH TLS ok")
+		c.conn = tls.Server(c.conn, s.tlsConfig)
+		c.text = textproto.NewConn(c.conn)
+		return
+	case "USER":
+		c.user = arg
+		c.reply(331, "password required")
+		return
+	case "PASS":
+		if c.user != s.user || arg != s.password {
+			c.reply(530, "Login incorrect")
+			return
+		}
+		c.loggedIn = true
+		c.reply(230, "logged in")
+		return
+	case "FEAT":
+		features := []string{"211-Features:", " SIZE", " MDTM", " REST STREAM"}
+		if !s.noMlst {
+			features = append(features, " MLST type*;size*;modify*;UNIX.mode*;")
+		}
+		if !s.noEpsv {
+			features = append(features, " EPSV")
+		}
+		if s.tlsConfig != nil {
+			features = append(features, " PBSZ", " PROT")
+		}
+		_ = c.text.PrintfLine("%s\r\n211 End", strings.Join(features, "\r\n"))
+		return
+	}
+
+	if !c.loggedIn {
+		c.reply(530, "Please login with USER and PASS")
+		return
+	}
+
+	switch command {
+	case "PBSZ":
+		c.reply(200, "PBSZ=0")
+	case "PROT":
+		c.protected = arg == "P"
+		c.reply(200, "protection level set")
+	case "TYPE":
+		c.reply(200, "type set")
+	case "EPSV", "PASV":
+		if command == "EPSV" && s.noEpsv {
+			c.reply(502, "EPSV not implemented")
+			return
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			c.reply(425, err.Error())
+			return
+		}
+		if c.passive != nil {
+			c.passive.Close()
+		}
+		c.passive, c.active = listener, ""
+		port := listener.Addr().(*net.TCPAddr).Port
+		if command == "EPSV" {
+			c.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+		} else {
+			// the advertised address is deliberately wrong, clients use the
+			// address of the control connection
+			c.reply(227, fmt.Sprintf("Entering Passive Mode (10,0,0,1,%d,%d)", port>>8, port&0xff))
+		}
+	case "PORT":
+		fields := strings.Split(arg, ",")
+		if len(fields) != 6 {
+			c.reply(501, "bad PORT")
+			return
+		}
+		high, _ := strconv.Atoi(fields[4])
+		low, _ := strconv.Atoi(fields[5])
+		c.active = net.JoinHostPort(strings.Join(fields[:4], "."), strconv.Itoa(high<<8|low))
+		c.reply(200, "PORT ok")
+	case "EPRT":
+		if arg == "" {
+			c.reply(501, "bad EPRT")
+			return
+		}
+		fields := strings.Split(arg, arg[:1])
+		if len(fields) != 5 {
+			c.reply(501, "bad EPRT")
+			return
+		}
+		c.active = net.JoinHostPort(fields[2], fields[3])
+		c.reply(200, "EPRT ok")
+	case "REST":
+		offset, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			c.reply(501, "bad offset")
+			return
+		}
+		c.restart = offset
+		c.reply(350, "restarting")
+	case "RETR":
+		if arg == s.unavailable {
+			c.reply(550, "Failed to open file.")
+			return
+		}
+		file, err := os.Open(s.local(arg))
+		if err != nil {
+			c.replyError(err)
+			return
+		}
+		defer file.Close()
+		c.transfer(func(data net.Conn) error {
+			_, err := io.Copy(data, file)
+			return err
+		})
+	case "STOR", "APPE":
+		flags := os.O_WRONLY | os.O_CREATE
+		switch {
+		case command == "APPE":
+			flags |= os.O_APPEND
+		case c.restart == 0:
+			flags |= os.O_TRUNC
+		}
+		file, err := os.OpenFile(s.local(arg), flags, 0644)
+		if err != nil {
+			c.replyError(err)
+			return
+		}
+		defer file.Close()
+		if c.restart > 0 {
+			if err := file.Truncate(c.restart); err != nil {
+				c.replyError(err)
+				return
+			}
+			if _, err := file.Seek(c.restart, io.SeekStart); err != nil {
+				c.replyError(err)
+				return
+			}
+			c.restart = 0
+		}
+		c.transfer(func(data net.Conn) error {
+			_, err := io.Copy(file, data)
+			return err
+		})
+	case "SIZE", "MDTM":
+		info, err := os.Stat(s.local(arg))
+		if err != nil {
+			c.replyError(err)
+			return
+		}
+		if info.IsDir() {
+			c.reply(550, "not a regular file")
+			return
+		}
+		if command == "SIZE" {
+			c.reply(213, strconv.FormatInt(info.Size(), 10))
+		} else {
+			c.reply(213, info.ModTime().UTC().Format("20060102150405"))
+		}
+	case "MLST":
+		if s.noMlst {
+			c.reply(502, "MLST not implemented")
+			return
+		}
+		info, err := os.Stat(s.local(arg))
+		if err != nil {
+			c.replyError(err)
+			return
+		}
+		_ = c.text.PrintfLine("250-Listing %s\r\n %s %s\r\n250 End", arg, facts(info), arg)
+	case "MLSD", "NLST":
+		if command == "MLSD" && s.noMlst {
+			c.reply(502, "MLSD not implemented")
+			return
+		}
+		entries, err := os.ReadDir(s.local(arg))
+		if err != nil {
+			c.replyError(err)
+			return
+		}
+		c.transfer(func(data net.Conn) error {
+			for _, entry := range entries {
+				line := entry.Name()
+				if command == "MLSD" {
+					info, err := entry.Info()
+					if err != nil {
+						return err
+					}
+					line = facts(info) + " " + entry.Name()
+				}
+				if _, err := fmt.Fprintf(data, "%s\r\n", line); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "DELE":
+		if err := os.Remove(s.local(arg)); err != nil {
+			c.replyError(err)
+			return
+		}
+		c.reply(250, "deleted")
+	case "RNFR":
+		if _, err := os.Stat(s.local(arg)); err != nil {
+			c.replyError(err)
+			return
+		}
+		c.renameFrom = arg
+		c.reply(350, "ready for RNTO")
+	case "RNTO":
+		if _, err := os.Stat(s.local(arg)); err == nil && s.noOverwrite {
+			c.reply(553, "File exists")
+			return
+		}
+		if err := os.Rename(s.local(c.renameFrom), s.local(arg)); err != nil {
+			c.replyError(err)
+			return
+		}
+		c.reply(250, "renamed")
+	case "MKD":
+		if err := os.Mkdir(s.local(arg), 0755); err != nil {
+			c.replyError(err)
+			return
+		}
+		c.reply(257, fmt.Sprintf("%q created", arg))
+	case "SITE":
+		fields := strings.SplitN(arg, " ", 3)
+		if len(fields) != 3 || !strings.EqualFold(fields[0], "CHMOD") {
+			c.reply(502, "SITE command not implemented")
+			return
+		}
+		mode, err := strconv.ParseUint(fields[1], 8, 32)
+		if err != nil {
+			c.reply(501, "bad mode")
+			return
+		}
+		if err := os.Chmod(s.local(fields[2]), os.FileMode(mode)); err != nil {
+			c.replyError(err)
+			return
+		}
+		c.reply(200, "SITE CHMOD ok")
+	default:
+		c.reply(502, "command not implemented")
+	}
+}
+
+// transfer opens the data connection the client asked for and hands it to
+// copy, then reports the outcome
+func (c *testSession) transfer(copy func(net.Conn) error) {
+	var data net.Conn
+	var err error
+
+	c.reply(150, "opening data connection")
+	switch {
+	case c.passive != nil:
+		data, err = c.passive.Accept()
+		c.passive.Close()
+		c.passive = nil
+	case c.active != "":
+		data, err = net.Dial("tcp", c.active)
+		c.active = ""
+	default:
+		c.reply(425, "use PORT or PASV first")
+		return
+	}
+	if err != nil {
+		c.reply(425, err.Error())
+		return
+	}
+
+	if c.protected {
+		data = tls.Server(data, c.server.tlsConfig)
+	}
+	err = copy(data)
+	data.Close()
+	if err != nil {
+		c.reply(426, err.Error())
+		return
+	}
+	c.reply(226, "transfer complete")
+}
+
+// facts describes info the way MLST and MLSD do
+func facts(info os.FileInfo) string {
+	kind := "file"
+	if info.IsDir() {
+		kind = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s;UNIX.mode=%04o;",
+		kind, info.Size(), info.ModTime().UTC().Format("20060102150405"), info.Mode().Perm())
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1, as PEM to
+// trust it and as the server's TLS configuration
+func testCertificate(t *testing.T) (string, *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	certificate := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return string(caCert), &tls.Config{Certificates: []tls.Certificate{certificate}}
+}
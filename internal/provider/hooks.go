@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// What a failed hook command does to the operation it follows
+const (
+	// hookFailureFail reports an error, the file change itself is kept
+	hookFailureFail = "fail"
+	// hookFailureWarn reports a warning
+	hookFailureWarn = "warn"
+)
+
+// hookFailures lists the supported on_command_failure values
+var hookFailures = []string{hookFailureFail, hookFailureWarn}
+
+// setHookResult stores the outcome of a hook that ran in data
+func setHookResult(data *model.RemoteFileResourceModel, result remote.HookResult) {
+	if !result.Ran {
+		return
+	}
+
+	data.CommandStdout = types.StringValue(result.Stdout)
+	data.CommandStderr = types.StringValue(result.Stderr)
+	data.CommandExitCode = types.Int64Value(int64(result.ExitCode))
+}
+
+// addHookDiagnostic reports err from the hook configured in attribute as an
+// error, or as a warning if on_command_failure is warn
+func addHookDiagnostic(diags *diag.Diagnostics, attribute string, data *model.RemoteFileResourceModel, err error) {
+	detail := fmt.Sprintf("The remote file %s was changed, but %s failed. Commands only run when the file "+
+		"changes, so it will not run again on its own.\n\n%s", data.Path.ValueString(), attribute, err)
+
+	if data.OnCommandFailure.ValueString() == hookFailureWarn {
+		diags.AddAttributeWarning(path.Root(attribute), "hook command failed", detail)
+		return
+	}
+	diags.AddAttributeError(path.Root(attribute), "hook command failed", detail)
+}
@@ -0,0 +1,110 @@
+package httpfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// cacheEntry is how a response is stored in the cache directory
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// responseCache keeps the last response for a request in a file of the
+// cache directory, so the next request can be made conditional on it
+type responseCache struct {
+	url  string
+	path string
+}
+
+// newResponseCache returns the cache of the request described by params.
+// The credentials and headers are part of the key, as the server may answer
+// differently depending on who asks.
+func newResponseCache(params *RequestParameters) *responseCache {
+	target := params.GetURL().String()
+
+	hash := sha256.New()
+	hash.Write([]byte(target + "\n" + params.GetUser() + ":" + params.GetPassword() + "\n"))
+	header := params.GetHeader()
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			hash.Write([]byte(name + ": " + value + "\n"))
+		}
+	}
+
+	return &responseCache{
+		url:  target,
+		path: filepath.Join(params.GetCacheDir(), hex.EncodeToString(hash.Sum(nil))+".json"),
+	}
+}
+
+// load returns the cached response, nil if there is none
+func (c *responseCache) load() (*Response, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	// the key is a hash, make sure it is not one of another URL
+	if entry.URL != c.url || entry.Header == nil {
+		return nil, nil
+	}
+
+	return &Response{
+		StatusCode: entry.StatusCode,
+		Header:     entry.Header,
+		Body:       entry.Body,
+	}, nil
+}
+
+// store replaces the cached response with response. The files are only
+// readable by the user, responses may hold secrets.
+func (c *responseCache) store(response *Response) error {
+	data, err := json.Marshal(cacheEntry{
+		URL:        c.url,
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       response.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(c.path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), c.path)
+}
@@ -0,0 +1,199 @@
+package httpfile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// Subsystem is the tflog subsystem the HTTP client logs to
+const Subsystem = "http"
+
+// StatusError is a response refusing the request. It wraps the connect error
+// its status code corresponds to, so it is retried and reported like the
+// errors of the other protocols.
+type StatusError struct {
+	URL  string
+	Code int
+	// Header are the response headers, allow_missing reports them for a 404
+	Header http.Header
+	// Body is the start of the response body, servers explain themselves there
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	message := fmt.Sprintf("http: GET %s: %d %s", e.URL, e.Code, http.StatusText(e.Code))
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+	return message
+}
+
+func (e *StatusError) Unwrap() error {
+	switch e.Code {
+	case http.StatusUnauthorized:
+		return remote.ErrAuthFailed
+	case http.StatusForbidden:
+		return remote.ErrPermissionDenied
+	case http.StatusNotFound, http.StatusGone:
+		return remote.ErrNotFound
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return remote.ErrConnect
+	}
+	return nil
+}
+
+// maxErrorBody is how much of the body of a failed response is kept
+const maxErrorBody = 512
+
+// Response is a file fetched from a server
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Cached is whether the server answered 304 Not Modified and Body is the
+	// one cached from an earlier response
+	Cached bool
+}
+
+// LastModified returns the Last-Modified header, the zero time if it is
+// missing or malformed
+func (r *Response) LastModified() time.Time {
+	lastModified, err := http.ParseTime(r.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return lastModified
+}
+
+// newClient returns the client requests for params are sent with
+func newClient(params *RequestParameters) *http.Client {
+	dialer := &net.Dialer{Timeout: params.GetTimeout()}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			TLSClientConfig:     params.GetTLSConfig(),
+			TLSHandshakeTimeout: params.GetTimeout(),
+			ForceAttemptHTTP2:   true,
+		},
+		Timeout: params.GetTimeout(),
+	}
+}
+
+// Get fetches the file described by params. With a cache directory the
+// request is conditional on the ETag and Last-Modified of the cached
+// response, which is returned again if the server answers 304 Not Modified.
+// Statuses other than 2xx are returned as *StatusError.
+func Get(ctx context.Context, params *RequestParameters) (*Response, error) {
+	target := params.GetURL().String()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header = params.GetHeader()
+	if params.GetUser() != "" {
+		request.SetBasicAuth(params.GetUser(), params.GetPassword())
+	}
+
+	var cache *responseCache
+	var cached *Response
+	if params.GetCacheDir() != "" {
+		cache = newResponseCache(params)
+		cached, err = cache.load()
+		if err != nil {
+			tflog.SubsystemWarn(ctx, Subsystem, "ignoring unreadable cached response", map[string]interface{}{
+				"url":   target,
+				"error": err.Error(),
+			})
+		}
+		if cached != nil {
+			if etag := cached.Header.Get("ETag"); etag != "" {
+				request.Header.Set("If-None-Match", etag)
+			}
+			if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+				request.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	start := time.Now()
+	response, err := newClient(params).Do(request)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+	defer response.Body.Close()
+
+	tflog.SubsystemTrace(ctx, Subsystem, "HTTP request", map[string]interface{}{
+		"url":         target,
+		"status":      response.StatusCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && cached != nil:
+		// a 304 carries the headers that changed since the cached response
+		for name, values := range response.Header {
+			cached.Header[name] = values
+		}
+		cached.Cached = true
+		if err := cache.store(cached); err != nil {
+			tflog.SubsystemWarn(ctx, Subsystem, "failed to cache response", map[string]interface{}{
+				"url":   target,
+				"error": err.Error(),
+			})
+		}
+		tflog.SubsystemDebug(ctx, Subsystem, "file not modified, using the cached response", map[string]interface{}{
+			"url":   target,
+			"bytes": len(cached.Body),
+		})
+		return cached, nil
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		message, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+		return nil, &StatusError{
+			URL:    target,
+			Code:   response.StatusCode,
+			Header: response.Header,
+			Body:   strings.TrimSpace(string(message)),
+		}
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, requestError(ctx, fmt.Errorf("error reading response body: %w", err))
+	}
+
+	fetched := &Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+	}
+	if cache != nil && (response.Header.Get("ETag") != "" || response.Header.Get("Last-Modified") != "") {
+		if err := cache.store(fetched); err != nil {
+			tflog.SubsystemWarn(ctx, Subsystem, "failed to cache response", map[string]interface{}{
+				"url":   target,
+				"error": err.Error(),
+			})
+		}
+	}
+
+	return fetched, nil
+}
+
+// requestError wraps err, a request that got no response, with
+// remote.ErrConnect so it is retried
+func requestError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", remote.ErrConnect, err)
+}
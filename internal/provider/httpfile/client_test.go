@@ -0,0 +1,341 @@
+package httpfile
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// newCertificate returns a self-signed certificate named name and its key,
+// both PEM encoded, usable as a CA and as a client certificate
+func newCertificate(t *testing.T, name string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshalling key: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+// serverCertificate returns the certificate of an httptest TLS server as
+// ca_cert
+func serverCertificate(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+func TestGet(t *testing.T) {
+	lastModified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if user, password, ok := r.BasicAuth(); !ok || user != "deploy" || password != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		_, _ = w.Write([]byte("release=1.4.2\n"))
+	}))
+	defer server.Close()
+
+	model := nullModel(server.URL + "/release.txt")
+	model.user = types.StringValue("deploy")
+	model.password = types.StringValue("hunter2")
+	model.requestHeaders = stringMap(map[string]string{"X-Api-Key": "secret"})
+	params, err := CreateRequestParameters(model)
+	if err != nil {
+		t.Fatalf("CreateRequestParameters() error = %v", err)
+	}
+
+	response, err := Get(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if response.StatusCode != http.StatusOK || string(response.Body) != "release=1.4.2\n" || response.Cached {
+		t.Errorf("response = %d %q (cached %t)", response.StatusCode, response.Body, response.Cached)
+	}
+	if response.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("header = %v", response.Header)
+	}
+	if !response.LastModified().Equal(lastModified) {
+		t.Errorf("last modified = %s, expected %s", response.LastModified(), lastModified)
+	}
+}
+
+func TestGetStatusError(t *testing.T) {
+	tests := []struct {
+		code      int
+		kind      error
+		retryable bool
+	}{
+		{http.StatusNotFound, remote.ErrNotFound, false},
+		{http.StatusGone, remote.ErrNotFound, false},
+		{http.StatusUnauthorized, remote.ErrAuthFailed, false},
+		{http.StatusForbidden, remote.ErrPermissionDenied, false},
+		{http.StatusServiceUnavailable, remote.ErrConnect, true},
+		{http.StatusTooManyRequests, remote.ErrConnect, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "abc")
+				w.WriteHeader(tt.code)
+				_, _ = w.Write([]byte("go away\n"))
+			}))
+			defer server.Close()
+
+			params, err := CreateRequestParameters(nullModel(server.URL + "/file"))
+			if err != nil {
+				t.Fatalf("CreateRequestParameters() error = %v", err)
+			}
+
+			_, err = Get(context.Background(), params)
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("Get() error = %v, expected a StatusError", err)
+			}
+			if statusErr.Code != tt.code || statusErr.Body != "go away" || statusErr.Header.Get("X-Request-Id") != "abc" {
+				t.Errorf("status error = %+v", statusErr)
+			}
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("Get() error = %v, expected it to wrap %v", err, tt.kind)
+			}
+			if remote.IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable() = %t, expected %t", remote.IsRetryable(err), tt.retryable)
+			}
+		})
+	}
+}
+
+func TestGetUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	params, err := CreateRequestParameters(nullModel(server.URL + "/file"))
+	if err != nil {
+		t.Fatalf("CreateRequestParameters() error = %v", err)
+	}
+
+	_, err = Get(context.Background(), params)
+	if !errors.Is(err, remote.ErrConnect) {
+		t.Errorf("Get() error = %v, expected %v", err, remote.ErrConnect)
+	}
+}
+
+func TestGetConditional(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  func(http.Header)
+		matches func(*http.Request) bool
+	}{
+		{
+			name: "etag",
+			header: func(h http.Header) {
+				h.Set("ETag", `"v1"`)
+			},
+			matches: func(r *http.Request) bool { return r.Header.Get("If-None-Match") == `"v1"` },
+		},
+		{
+			name: "last modified",
+			header: func(h http.Header) {
+				h.Set("Last-Modified", "Fri, 01 Mar 2024 12:00:00 GMT")
+			},
+			matches: func(r *http.Request) bool {
+				return r.Header.Get("If-Modified-Since") == "Fri, 01 Mar 2024 12:00:00 GMT"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests, full atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				tt.header(w.Header())
+				if tt.matches(r) {
+					w.Header().Set("X-Revalidated", "yes")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full.Add(1)
+				_, _ = w.Write([]byte("cached contents"))
+			}))
+			defer server.Close()
+
+			model := nullModel(server.URL + "/file")
+			model.cacheDir = types.StringValue(filepath.Join(t.TempDir(), "cache"))
+			params, err := CreateRequestParameters(model)
+			if err != nil {
+				t.Fatalf("CreateRequestParameters() error = %v", err)
+			}
+
+			first, err := Get(context.Background(), params)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if first.Cached {
+				t.Error("first response is cached, expected it to be fetched")
+			}
+
+			second, err := Get(context.Background(), params)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if !second.Cached || second.StatusCode != http.StatusOK || string(second.Body) != "cached contents" {
+				t.Errorf("second response = %d %q (cached %t), expected the cached one",
+					second.StatusCode, second.Body, second.Cached)
+			}
+			if second.Header.Get("X-Revalidated") != "yes" {
+				t.Errorf("header = %v, expected the headers of the 304 to be merged", second.Header)
+			}
+			if requests.Load() != 2 || full.Load() != 1 {
+				t.Errorf("%d requests with %d full responses, expected 2 and 1", requests.Load(), full.Load())
+			}
+
+			entries, err := os.ReadDir(params.GetCacheDir())
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("cache dir holds %v (%v), expected one entry", entries, err)
+			}
+			info, err := entries[0].Info()
+			if err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("cache entry mode = %v (%v), expected 0600", info.Mode().Perm(), err)
+			}
+		})
+	}
+}
+
+func TestGetCacheKeyedByCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("for " + r.Header.Get("X-Tenant")))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	get := func(tenant string) *Response {
+		model := nullModel(server.URL + "/file")
+		model.cacheDir = types.StringValue(cacheDir)
+		model.requestHeaders = stringMap(map[string]string{"X-Tenant": tenant})
+		params, err := CreateRequestParameters(model)
+		if err != nil {
+			t.Fatalf("CreateRequestParameters() error = %v", err)
+		}
+		response, err := Get(context.Background(), params)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		return response
+	}
+
+	if response := get("a"); string(response.Body) != "for a" || response.Cached {
+		t.Errorf("response = %q (cached %t)", response.Body, response.Cached)
+	}
+	if response := get("b"); string(response.Body) != "for b" || response.Cached {
+		t.Errorf("response = %q (cached %t), expected another cache entry", response.Body, response.Cached)
+	}
+	if response := get("a"); string(response.Body) != "for a" || !response.Cached {
+		t.Errorf("response = %q (cached %t)", response.Body, response.Cached)
+	}
+}
+
+func TestGetMutualTLS(t *testing.T) {
+	clientCert, clientKey := newCertificate(t, "client")
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(clientCert))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello " + r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	model := nullModel(server.URL + "/file")
+	model.caCert = types.StringValue(serverCertificate(server))
+	model.clientCert = types.StringValue(clientCert)
+	model.clientKey = types.StringValue(clientKey)
+	params, err := CreateRequestParameters(model)
+	if err != nil {
+		t.Fatalf("CreateRequestParameters() error = %v", err)
+	}
+
+	response, err := Get(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(response.Body) != "hello client" {
+		t.Errorf("body = %q, expected %q", response.Body, "hello client")
+	}
+
+	// without the client certificate the handshake fails
+	model.clientCert = types.StringNull()
+	model.clientKey = types.StringNull()
+	params, err = CreateRequestParameters(model)
+	if err != nil {
+		t.Fatalf("CreateRequestParameters() error = %v", err)
+	}
+	if _, err := Get(context.Background(), params); err == nil {
+		t.Error("Get() succeeded without a client certificate")
+	}
+}
+
+func TestGetUntrustedServer(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	params, err := CreateRequestParameters(nullModel(server.URL + "/file"))
+	if err != nil {
+		t.Fatalf("CreateRequestParameters() error = %v", err)
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if _, err := Get(context.Background(), params); !errors.As(err, &certErr) {
+		t.Errorf("Get() error = %v, expected a certificate verification error", err)
+	}
+}
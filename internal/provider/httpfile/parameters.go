@@ -0,0 +1,169 @@
+package httpfile
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// the subset of terraform schema fields that are needed to fetch a file over
+// HTTP
+type ModelSubset interface {
+	GetCacheDir() types.String
+	GetCaCert() types.String
+	GetClientCert() types.String
+	GetClientKey() types.String
+	GetPassword() types.String
+	GetRequestHeaders() types.Map
+	GetTimeout() types.String
+	GetURL() types.String
+	GetUser() types.String
+}
+
+// RequestParameters describes how to fetch a file and authenticate to the
+// server serving it
+type RequestParameters struct {
+	url      *url.URL
+	header   http.Header
+	user     string
+	password string
+	timeout  time.Duration
+	cacheDir string
+
+	// tlsConfig is nil over plain HTTP
+	tlsConfig *tls.Config
+}
+
+// GetURL returns the URL of the file
+func (p *RequestParameters) GetURL() *url.URL {
+	u := *p.url
+	return &u
+}
+
+// GetAddress returns host:port of the server
+func (p *RequestParameters) GetAddress() string {
+	if p.url.Port() != "" {
+		return p.url.Host
+	}
+	port := "443"
+	if p.url.Scheme == "http" {
+		port = "80"
+	}
+	return net.JoinHostPort(p.url.Hostname(), port)
+}
+
+// GetHeader returns the headers sent along with every request
+func (p *RequestParameters) GetHeader() http.Header {
+	return p.header.Clone()
+}
+
+func (p *RequestParameters) GetUser() string {
+	return p.user
+}
+
+func (p *RequestParameters) GetPassword() string {
+	return p.password
+}
+
+func (p *RequestParameters) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// GetCacheDir returns the directory responses are cached in, empty to not
+// cache them
+func (p *RequestParameters) GetCacheDir() string {
+	return p.cacheDir
+}
+
+// GetTLSConfig returns the configuration HTTPS connections are made with, nil
+// over plain HTTP
+func (p *RequestParameters) GetTLSConfig() *tls.Config {
+	return p.tlsConfig
+}
+
+func CreateRequestParameters(data ModelSubset) (*RequestParameters, error) {
+	u, err := url.Parse(data.GetURL().ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("url must start with https:// or http://, got %q", u.String())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("url has no host, got %q", u.String())
+	}
+	if u.User != nil {
+		return nil, errors.New("url must not contain credentials, set user and password instead")
+	}
+
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+
+	params := &RequestParameters{
+		url:      u,
+		header:   http.Header{},
+		user:     data.GetUser().ValueString(),
+		password: data.GetPassword().ValueString(),
+		timeout:  timeoutDuration,
+		cacheDir: data.GetCacheDir().ValueString(),
+	}
+	if params.user == "" && params.password != "" {
+		return nil, errors.New("password requires user, basic auth sends both")
+	}
+
+	for name, value := range data.GetRequestHeaders().Elements() {
+		header, ok := value.(types.String)
+		if !ok || header.IsNull() {
+			return nil, fmt.Errorf("request_headers[%q] must be a string", name)
+		}
+		params.header.Add(name, header.ValueString())
+	}
+	if params.user != "" && params.header.Get("Authorization") != "" {
+		return nil, errors.New("user conflicts with an Authorization request header, set either")
+	}
+
+	caCert, clientCert, clientKey := data.GetCaCert(), data.GetClientCert(), data.GetClientKey()
+	if u.Scheme == "http" {
+		if !caCert.IsNull() || !clientCert.IsNull() || !clientKey.IsNull() {
+			return nil, errors.New("ca_cert, client_cert and client_key only apply to https URLs")
+		}
+		return params, nil
+	}
+
+	params.tlsConfig = &tls.Config{
+		ServerName: u.Hostname(),
+		MinVersion: tls.VersionTLS12,
+	}
+	if !caCert.IsNull() {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert.ValueString())) {
+			return nil, errors.New("failed to parse ca_cert: no PEM certificates found")
+		}
+		params.tlsConfig.RootCAs = pool
+	}
+	if clientCert.IsNull() != clientKey.IsNull() {
+		return nil, errors.New("client_cert and client_key must be set together")
+	}
+	if !clientCert.IsNull() {
+		certificate, err := tls.X509KeyPair([]byte(clientCert.ValueString()), []byte(clientKey.ValueString()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client_cert and client_key: %w", err)
+		}
+		params.tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return params, nil
+}
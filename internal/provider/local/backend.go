@@ -0,0 +1,151 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// Subsystem is the tflog subsystem the local backend logs to
+const Subsystem = "local"
+
+// Address is what the local backend reports as the address of its files
+const Address = "localhost"
+
+// Shell runs the hook and validate commands
+const Shell = "/bin/sh"
+
+// localBackend is the filesystem of the machine running Terraform, accessed
+// as the user running it. There is no connection, so the connection
+// attributes are ignored, and commands run with Shell.
+type localBackend struct{}
+
+var (
+	_ backend.Backend   = &localBackend{}
+	_ backend.Commander = &localBackend{}
+)
+
+// Dialer returns a Dialer for the local filesystem
+func Dialer() backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		return &localBackend{}, nil
+	}
+}
+
+func (b *localBackend) Address() string {
+	return Address
+}
+
+func (b *localBackend) Open(path string) (backend.File, error) {
+	return os.Open(path)
+}
+
+// OpenFile opens path with flag, files it creates get 0666 less the umask
+// like they do over SFTP
+func (b *localBackend) OpenFile(path string, flag int) (backend.File, error) {
+	return os.OpenFile(path, flag, 0666)
+}
+
+func (b *localBackend) Stat(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return withOwnership(info), nil
+}
+
+func (b *localBackend) Lstat(path string) (os.FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	return withOwnership(info), nil
+}
+
+func (b *localBackend) Chmod(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode)
+}
+
+func (b *localBackend) Chown(path string, uid int, gid int) error {
+	return os.Chown(path, uid, gid)
+}
+
+// Rename replaces newpath atomically, as long as both paths are on the same
+// filesystem
+func (b *localBackend) Rename(oldpath string, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (b *localBackend) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (b *localBackend) Mkdir(path string) error {
+	return os.Mkdir(path, 0755)
+}
+
+func (b *localBackend) ReadDir(path string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			// removed since the directory was read
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, withOwnership(info))
+	}
+	return infos, nil
+}
+
+// Run runs command with Shell. A command that ran and exited non-zero is not
+// an error, its exit code is in the result.
+func (b *localBackend) Run(ctx context.Context, command string) (backend.CommandResult, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, Shell, "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+
+	result := backend.CommandResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return result, fmt.Errorf("error running %q: %w", command, err)
+	}
+
+	tflog.SubsystemDebug(ctx, Subsystem, "ran local command", map[string]interface{}{
+		"command":     command,
+		"exit_code":   result.ExitCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	return result, nil
+}
+
+func (b *localBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,245 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// testModel is the resource model as far as the local backend and the
+// remote operations are concerned
+type testModel struct {
+	path         types.String
+	permissions  types.String
+	allowMissing types.Bool
+
+	id           types.String
+	contents     types.String
+	lastModified types.String
+	size         types.Int64
+}
+
+func (m *testModel) GetPath() types.String         { return m.path }
+func (m *testModel) GetPermissions() types.String  { return m.permissions }
+func (m *testModel) GetAllowMissing() types.Bool   { return m.allowMissing }
+func (m *testModel) GetID() types.String           { return m.id }
+func (m *testModel) GetContents() types.String     { return m.contents }
+func (m *testModel) GetLastModified() types.String { return m.lastModified }
+func (m *testModel) GetSize() types.Int64          { return m.size }
+
+func (m *testModel) SetID(id types.String)                     { m.id = id }
+func (m *testModel) SetContents(contents types.String)         { m.contents = contents }
+func (m *testModel) SetLastModified(lastModified types.String) { m.lastModified = lastModified }
+func (m *testModel) SetSize(size types.Int64)                  { m.size = size }
+
+// newTestModel returns a model for path with nothing else set
+func newTestModel(path string) *testModel {
+	return &testModel{
+		path:         types.StringValue(path),
+		permissions:  types.StringNull(),
+		allowMissing: types.BoolNull(),
+	}
+}
+
+func TestDialerRoundTrip(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "app.conf")
+	data := newTestModel(target)
+	data.contents = types.StringValue("listen 8080\n")
+	data.permissions = types.StringValue("0640")
+	ctx := context.Background()
+
+	if err := remote.ConnectAndWrite(Dialer(), data, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("file was not written: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %o, expected 0640", info.Mode().Perm())
+	}
+
+	read := newTestModel(target)
+	if err := remote.ConnectAndCopy(Dialer(), read, read)(ctx); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if read.contents.ValueString() != "listen 8080\n" {
+		t.Errorf("contents = %q, expected the written contents", read.contents.ValueString())
+	}
+	if read.id.ValueString() != "app.conf" {
+		t.Errorf("id = %q, expected app.conf", read.id.ValueString())
+	}
+	if read.size.ValueInt64() != int64(len("listen 8080\n")) {
+		t.Errorf("size = %d, expected %d", read.size.ValueInt64(), len("listen 8080\n"))
+	}
+
+	if err := remote.ConnectAndDelete(Dialer(), data)(ctx); err != nil {
+		t.Fatalf("ConnectAndDelete() error = %v", err)
+	}
+	if _, err := os.Stat(target); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after delete: %v", err)
+	}
+}
+
+func TestDialerMissingFile(t *testing.T) {
+	data := newTestModel(filepath.Join(t.TempDir(), "missing"))
+
+	err := remote.ConnectAndCopy(Dialer(), data, data)(context.Background())
+	if !remote.IsFileNotFound(err) {
+		t.Errorf("ConnectAndCopy() error = %v, expected a missing file", err)
+	}
+}
+
+func TestDialerPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root may write anywhere")
+	}
+
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+
+	data := newTestModel(filepath.Join(dir, "file"))
+	data.contents = types.StringValue("contents")
+	err := remote.ConnectAndWrite(Dialer(), data, data)(context.Background())
+	if !errors.Is(err, remote.ErrPermissionDenied) {
+		t.Errorf("ConnectAndWrite() error = %v, expected %v", err, remote.ErrPermissionDenied)
+	}
+}
+
+func TestDialerValidateAndHook(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app.conf")
+	marker := filepath.Join(dir, "reloaded")
+
+	data := newTestModel(target)
+	data.contents = types.StringValue("valid\n")
+	var result remote.HookResult
+	write := remote.ConnectAndWrite(Dialer(), data, data,
+		remote.WithValidate("grep -q valid %s"),
+		remote.WithHook("echo reloaded > "+marker, &result),
+	)
+	if err := write(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if !result.Ran || result.ExitCode != 0 {
+		t.Errorf("hook result = %+v, expected it to have run", result)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("hook did not run: %v", err)
+	}
+
+	data.contents = types.StringValue("broken\n")
+	err := remote.ConnectAndWrite(Dialer(), data, data, remote.WithValidate("grep -q valid %s"))(context.Background())
+	if !errors.Is(err, remote.ErrValidation) {
+		t.Fatalf("ConnectAndWrite() error = %v, expected %v", err, remote.ErrValidation)
+	}
+	contents, err := os.ReadFile(target)
+	if err != nil || string(contents) != "valid\n" {
+		t.Errorf("contents = %q, %v, expected the validated contents to be kept", contents, err)
+	}
+}
+
+func TestDialerResumable(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "large.bin")
+	contents := strings.Repeat("0123456789", 1000)
+	if err := os.WriteFile(filepath.Join(dir, ".large.bin.partial"), []byte(contents[:4000]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := newTestModel(target)
+	data.contents = types.StringValue(contents)
+	if err := remote.ConnectAndWrite(Dialer(), data, data, remote.WithResumable(true))(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	written, err := os.ReadFile(target)
+	if err != nil || string(written) != contents {
+		t.Errorf("contents = %d bytes, %v, expected %d bytes", len(written), err, len(contents))
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".large.bin.partial")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial file was left behind: %v", err)
+	}
+}
+
+func TestDialerResumableKeepsMode(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data := newTestModel(target)
+	data.contents = types.StringValue("new")
+	if err := remote.ConnectAndWrite(Dialer(), data, data, remote.WithResumable(true))(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %o, expected 0600", info.Mode().Perm())
+	}
+}
+
+func TestBackendRun(t *testing.T) {
+	b, err := Dialer()(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	defer b.Close()
+
+	result, err := b.(*localBackend).Run(context.Background(), "echo out; echo err >&2; exit 3")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Stdout != "out\n" || result.Stderr != "err\n" || result.ExitCode != 3 {
+		t.Errorf("Run() = %+v, expected out, err and exit code 3", result)
+	}
+}
+
+func TestBackendReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Dialer()(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	infos, err := b.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	var names []string
+	for _, info := range infos {
+		if info.IsDir() {
+			names = append(names, info.Name()+"/")
+		} else {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a,b,sub/" {
+		t.Errorf("ReadDir() = %v, expected a, b and sub/", names)
+	}
+}
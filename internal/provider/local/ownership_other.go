@@ -0,0 +1,10 @@
+//go:build !unix
+
+package local
+
+import "os"
+
+// withOwnership returns info as it is, files have no uid and gid here
+func withOwnership(info os.FileInfo) os.FileInfo {
+	return info
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package local
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInfo reports the owner of a file through backend.Owned, so owner and
+// group are only changed when they differ
+type fileInfo struct {
+	os.FileInfo
+	owner owner
+}
+
+func (i *fileInfo) Sys() any {
+	return i.owner
+}
+
+// owner is the uid and gid of a file
+type owner struct {
+	uid int
+	gid int
+}
+
+func (o owner) Ownership() (uid int, gid int) {
+	return o.uid, o.gid
+}
+
+// withOwnership returns info with its owner, if the platform reports it
+func withOwnership(info os.FileInfo) os.FileInfo {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info
+	}
+	return &fileInfo{FileInfo: info, owner: owner{uid: int(stat.Uid), gid: int(stat.Gid)}}
+}
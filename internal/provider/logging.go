@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/azureblob"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/exec"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/ftp"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/httpfile"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/local"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/s3"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/webdav"
+)
+
+// sensitiveLogFields are never logged in clear text, whichever subsystem
+// logs them
+var sensitiveLogFields = []string{
+	"password",
+	"private_key",
+	"contents",
+	"contents_wo",
+}
+
+// the subset of terraform schema fields holding secrets that must not leak
+// into logs through error messages
+type secretsModel interface {
+	GetPassword() types.String
+	GetPrivateKey() types.String
+}
+
+// sudoPasswordModel is implemented by the models that take sudo_password
+type sudoPasswordModel interface {
+	GetSudoPassword() types.String
+}
+
+// bearerTokenModel is implemented by the models that take webdav.bearer_token
+type bearerTokenModel interface {
+	GetWebdavBearerToken() types.String
+}
+
+// sessionTokenModel is implemented by the models that take s3.session_token
+type sessionTokenModel interface {
+	GetS3SessionToken() types.String
+}
+
+// sasTokenModel is implemented by the models that take azureblob.sas_token
+type sasTokenModel interface {
+	GetAzureblobSasToken() types.String
+}
+
+// requestHeadersModel is implemented by the models that take request_headers,
+// whose values often hold tokens
+type requestHeadersModel interface {
+	GetRequestHeaders() types.Map
+}
+
+// withLogging registers the provider's tflog subsystems on ctx and masks
+// secrets from data in every log entry they emit. data is the model as read,
+// with the settings of its protocol, which hold some of the secrets.
+func withLogging(ctx context.Context, data any) context.Context {
+	var secrets []string
+	var candidates []types.String
+	if credentials, ok := data.(secretsModel); ok {
+		candidates = append(candidates, credentials.GetPassword(), credentials.GetPrivateKey())
+	}
+	if sudoModel, ok := data.(sudoPasswordModel); ok {
+		candidates = append(candidates, sudoModel.GetSudoPassword())
+	}
+	if tokenModel, ok := data.(bearerTokenModel); ok {
+		candidates = append(candidates, tokenModel.GetWebdavBearerToken())
+	}
+	if tokenModel, ok := data.(sessionTokenModel); ok {
+		candidates = append(candidates, tokenModel.GetS3SessionToken())
+	}
+	if tokenModel, ok := data.(sasTokenModel); ok {
+		candidates = append(candidates, tokenModel.GetAzureblobSasToken())
+	}
+	if headersModel, ok := data.(requestHeadersModel); ok {
+		for _, value := range headersModel.GetRequestHeaders().Elements() {
+			if header, ok := value.(types.String); ok {
+				candidates = append(candidates, header)
+			}
+		}
+	}
+	for _, secret := range candidates {
+		if !secret.IsNull() && !secret.IsUnknown() && secret.ValueString() != "" {
+			secrets = append(secrets, secret.ValueString())
+		}
+	}
+
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, sensitiveLogFields...)
+	ctx = tflog.MaskAllFieldValuesStrings(ctx, secrets...)
+	ctx = tflog.MaskMessageStrings(ctx, secrets...)
+
+	for _, subsystem := range []string{remote.SubsystemSSH, remote.SubsystemSFTP, ftp.Subsystem, webdav.Subsystem, s3.Subsystem, azureblob.Subsystem, httpfile.Subsystem, local.Subsystem, exec.Subsystem, retry.Subsystem} {
+		ctx = tflog.NewSubsystem(ctx, subsystem)
+		ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, subsystem, sensitiveLogFields...)
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, subsystem, secrets...)
+		ctx = tflog.SubsystemMaskMessageStrings(ctx, subsystem, secrets...)
+	}
+
+	return ctx
+}
@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestWithLogging(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	data := &model.WebdavResourceModel{}
+	data.Password = types.StringNull()
+	data.PrivateKey = types.StringNull()
+	data.SudoPassword = types.StringValue("sudo-secret")
+	data.Webdav = &model.WebdavModel{BearerToken: types.StringValue("token-secret")}
+
+	ctx = withLogging(ctx, data)
+	tflog.SubsystemError(ctx, remote.SubsystemSSH, "sudo failed: sudo-secret token-secret")
+
+	for _, secret := range []string{"sudo-secret", "token-secret"} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("log output contains %s: %s", secret, output.String())
+		}
+	}
+	if !strings.Contains(output.String(), "sudo failed") {
+		t.Errorf("log output = %s, expected the message", output.String())
+	}
+}
@@ -0,0 +1,108 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// AzureblobModel holds the azureblob attribute, the settings only
+// remotefile_azureblob takes. It is nil when the attribute isn't set, its
+// getters then return null.
+type AzureblobModel struct {
+	BlockSize   types.Int64  `tfsdk:"block_size"`
+	CaCert      types.String `tfsdk:"ca_cert"`
+	Container   types.String `tfsdk:"container"`
+	ContentType types.String `tfsdk:"content_type"`
+	Metadata    types.Map    `tfsdk:"metadata"`
+	PathStyle   types.Bool   `tfsdk:"path_style"`
+	SasToken    types.String `tfsdk:"sas_token"`
+	Scheme      types.String `tfsdk:"scheme"`
+}
+
+func (a *AzureblobModel) GetBlockSize() types.Int64 {
+	if a == nil {
+		return types.Int64Null()
+	}
+	return a.BlockSize
+}
+
+func (a *AzureblobModel) GetCaCert() types.String {
+	if a == nil {
+		return types.StringNull()
+	}
+	return a.CaCert
+}
+
+func (a *AzureblobModel) GetContainer() types.String {
+	if a == nil {
+		return types.StringNull()
+	}
+	return a.Container
+}
+
+func (a *AzureblobModel) GetContentType() types.String {
+	if a == nil {
+		return types.StringNull()
+	}
+	return a.ContentType
+}
+
+func (a *AzureblobModel) GetMetadata() types.Map {
+	if a == nil {
+		return types.MapNull(types.StringType)
+	}
+	return a.Metadata
+}
+
+func (a *AzureblobModel) GetPathStyle() types.Bool {
+	if a == nil {
+		return types.BoolNull()
+	}
+	return a.PathStyle
+}
+
+func (a *AzureblobModel) GetSasToken() types.String {
+	if a == nil {
+		return types.StringNull()
+	}
+	return a.SasToken
+}
+
+func (a *AzureblobModel) GetScheme() types.String {
+	if a == nil {
+		return types.StringNull()
+	}
+	return a.Scheme
+}
+
+// AzureblobSettings embeds the azureblob attribute in the models of remotefile_azureblob
+type AzureblobSettings struct {
+	Azureblob *AzureblobModel `tfsdk:"azureblob"`
+}
+
+func (s *AzureblobSettings) GetAzureblobBlockSize() types.Int64  { return s.Azureblob.GetBlockSize() }
+func (s *AzureblobSettings) GetAzureblobCaCert() types.String    { return s.Azureblob.GetCaCert() }
+func (s *AzureblobSettings) GetAzureblobContainer() types.String { return s.Azureblob.GetContainer() }
+func (s *AzureblobSettings) GetAzureblobContentType() types.String {
+	return s.Azureblob.GetContentType()
+}
+func (s *AzureblobSettings) GetAzureblobMetadata() types.Map    { return s.Azureblob.GetMetadata() }
+func (s *AzureblobSettings) GetAzureblobPathStyle() types.Bool  { return s.Azureblob.GetPathStyle() }
+func (s *AzureblobSettings) GetAzureblobSasToken() types.String { return s.Azureblob.GetSasToken() }
+func (s *AzureblobSettings) GetAzureblobScheme() types.String   { return s.Azureblob.GetScheme() }
+
+// AzureblobResourceModel is the model of the remotefile_azureblob resource
+type AzureblobResourceModel struct {
+	RemoteFileResourceModel
+	AzureblobSettings
+}
+
+// AzureblobDataSourceModel is the model of the remotefile_azureblob data source
+type AzureblobDataSourceModel struct {
+	RemoteFileDataSourceModel
+	AzureblobSettings
+}
+
+// AzureblobEphemeralResourceModel is the model of the remotefile_azureblob ephemeral
+// resource
+type AzureblobEphemeralResourceModel struct {
+	RemoteFileEphemeralResourceModel
+	AzureblobSettings
+}
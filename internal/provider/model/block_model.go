@@ -0,0 +1,53 @@
+package model
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type RemoteFileBlockResourceModel struct {
+	Content          types.String   `tfsdk:"content"`
+	Host             types.String   `tfsdk:"host"`
+	HostKey          types.String   `tfsdk:"host_key"`
+	MarkerPrefix     types.String   `tfsdk:"marker_prefix"`
+	Name             types.String   `tfsdk:"name"`
+	Password         types.String   `tfsdk:"password"`
+	Path             types.String   `tfsdk:"path"`
+	Permissions      types.String   `tfsdk:"permissions"`
+	Port             types.Int64    `tfsdk:"port"`
+	PrivateKey       types.String   `tfsdk:"private_key"`
+	SystemSshPath    types.String   `tfsdk:"system_ssh_path"`
+	Timeout          types.String   `tfsdk:"timeout"`
+	Timeouts         timeouts.Value `tfsdk:"timeouts"`
+	UseSystemSsh     types.Bool     `tfsdk:"use_system_ssh"`
+	User             types.String   `tfsdk:"user"`
+	ID               types.String   `tfsdk:"id"`
+	RetryCount       types.Int64    `tfsdk:"retry_count"`
+	RetryInterval    types.String   `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String   `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64  `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64  `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String   `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileBlockResourceModel) GetContent() types.String          { return r.Content }
+func (r *RemoteFileBlockResourceModel) GetHost() types.String             { return r.Host }
+func (r *RemoteFileBlockResourceModel) GetHostKey() types.String          { return r.HostKey }
+func (r *RemoteFileBlockResourceModel) GetMarkerPrefix() types.String     { return r.MarkerPrefix }
+func (r *RemoteFileBlockResourceModel) GetName() types.String             { return r.Name }
+func (r *RemoteFileBlockResourceModel) GetPassword() types.String         { return r.Password }
+func (r *RemoteFileBlockResourceModel) GetPath() types.String             { return r.Path }
+func (r *RemoteFileBlockResourceModel) GetPermissions() types.String      { return r.Permissions }
+func (r *RemoteFileBlockResourceModel) GetPort() types.Int64              { return r.Port }
+func (r *RemoteFileBlockResourceModel) GetPrivateKey() types.String       { return r.PrivateKey }
+func (r *RemoteFileBlockResourceModel) GetSystemSshPath() types.String    { return r.SystemSshPath }
+func (r *RemoteFileBlockResourceModel) GetTimeout() types.String          { return r.Timeout }
+func (r *RemoteFileBlockResourceModel) GetUseSystemSsh() types.Bool       { return r.UseSystemSsh }
+func (r *RemoteFileBlockResourceModel) GetUser() types.String             { return r.User }
+func (r *RemoteFileBlockResourceModel) GetID() types.String               { return r.ID }
+func (r *RemoteFileBlockResourceModel) GetRetryCount() types.Int64        { return r.RetryCount }
+func (r *RemoteFileBlockResourceModel) GetRetryInterval() types.String    { return r.RetryInterval }
+func (r *RemoteFileBlockResourceModel) GetRetryMaxInterval() types.String { return r.RetryMaxInterval }
+func (r *RemoteFileBlockResourceModel) GetRetryMultiplier() types.Float64 { return r.RetryMultiplier }
+func (r *RemoteFileBlockResourceModel) GetRetryJitter() types.Float64     { return r.RetryJitter }
+func (r *RemoteFileBlockResourceModel) GetRetryMaxElapsed() types.String  { return r.RetryMaxElapsed }
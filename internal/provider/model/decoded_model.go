@@ -0,0 +1,55 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+type RemoteFileDecodedDataSourceModel struct {
+	AllowMissing     types.Bool    `tfsdk:"allow_missing"`
+	Format           types.String  `tfsdk:"format"`
+	Host             types.String  `tfsdk:"host"`
+	HostKey          types.String  `tfsdk:"host_key"`
+	Password         types.String  `tfsdk:"password"`
+	Path             types.String  `tfsdk:"path"`
+	Port             types.Int64   `tfsdk:"port"`
+	PrivateKey       types.String  `tfsdk:"private_key"`
+	Query            types.String  `tfsdk:"query"`
+	SystemSshPath    types.String  `tfsdk:"system_ssh_path"`
+	Timeout          types.String  `tfsdk:"timeout"`
+	UseSystemSsh     types.Bool    `tfsdk:"use_system_ssh"`
+	User             types.String  `tfsdk:"user"`
+	Value            types.Dynamic `tfsdk:"value"`
+	ID               types.String  `tfsdk:"id"`
+	RetryCount       types.Int64   `tfsdk:"retry_count"`
+	RetryInterval    types.String  `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String  `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64 `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64 `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String  `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileDecodedDataSourceModel) GetAllowMissing() types.Bool    { return r.AllowMissing }
+func (r *RemoteFileDecodedDataSourceModel) GetFormat() types.String        { return r.Format }
+func (r *RemoteFileDecodedDataSourceModel) GetHost() types.String          { return r.Host }
+func (r *RemoteFileDecodedDataSourceModel) GetHostKey() types.String       { return r.HostKey }
+func (r *RemoteFileDecodedDataSourceModel) GetPassword() types.String      { return r.Password }
+func (r *RemoteFileDecodedDataSourceModel) GetPath() types.String          { return r.Path }
+func (r *RemoteFileDecodedDataSourceModel) GetPort() types.Int64           { return r.Port }
+func (r *RemoteFileDecodedDataSourceModel) GetPrivateKey() types.String    { return r.PrivateKey }
+func (r *RemoteFileDecodedDataSourceModel) GetQuery() types.String         { return r.Query }
+func (r *RemoteFileDecodedDataSourceModel) GetSystemSshPath() types.String { return r.SystemSshPath }
+func (r *RemoteFileDecodedDataSourceModel) GetTimeout() types.String       { return r.Timeout }
+func (r *RemoteFileDecodedDataSourceModel) GetUseSystemSsh() types.Bool    { return r.UseSystemSsh }
+func (r *RemoteFileDecodedDataSourceModel) GetUser() types.String          { return r.User }
+func (r *RemoteFileDecodedDataSourceModel) GetValue() types.Dynamic        { return r.Value }
+func (r *RemoteFileDecodedDataSourceModel) GetID() types.String            { return r.ID }
+func (r *RemoteFileDecodedDataSourceModel) GetRetryCount() types.Int64     { return r.RetryCount }
+func (r *RemoteFileDecodedDataSourceModel) GetRetryInterval() types.String { return r.RetryInterval }
+func (r *RemoteFileDecodedDataSourceModel) GetRetryMaxInterval() types.String {
+	return r.RetryMaxInterval
+}
+func (r *RemoteFileDecodedDataSourceModel) GetRetryMultiplier() types.Float64 {
+	return r.RetryMultiplier
+}
+func (r *RemoteFileDecodedDataSourceModel) GetRetryJitter() types.Float64 { return r.RetryJitter }
+func (r *RemoteFileDecodedDataSourceModel) GetRetryMaxElapsed() types.String {
+	return r.RetryMaxElapsed
+}
@@ -0,0 +1,51 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// ExecModel holds the exec attribute, the settings only remotefile_exec
+// takes. It is nil when the attribute isn't set, its getters then return null.
+type ExecModel struct {
+	Args    types.Map    `tfsdk:"args"`
+	Command types.String `tfsdk:"command"`
+}
+
+func (e *ExecModel) GetArgs() types.Map {
+	if e == nil {
+		return types.MapNull(types.StringType)
+	}
+	return e.Args
+}
+
+func (e *ExecModel) GetCommand() types.String {
+	if e == nil {
+		return types.StringNull()
+	}
+	return e.Command
+}
+
+// ExecSettings embeds the exec attribute in the models of remotefile_exec
+type ExecSettings struct {
+	Exec *ExecModel `tfsdk:"exec"`
+}
+
+func (s *ExecSettings) GetExecArgs() types.Map       { return s.Exec.GetArgs() }
+func (s *ExecSettings) GetExecCommand() types.String { return s.Exec.GetCommand() }
+
+// ExecResourceModel is the model of the remotefile_exec resource
+type ExecResourceModel struct {
+	RemoteFileResourceModel
+	ExecSettings
+}
+
+// ExecDataSourceModel is the model of the remotefile_exec data source
+type ExecDataSourceModel struct {
+	RemoteFileDataSourceModel
+	ExecSettings
+}
+
+// ExecEphemeralResourceModel is the model of the remotefile_exec ephemeral
+// resource
+type ExecEphemeralResourceModel struct {
+	RemoteFileEphemeralResourceModel
+	ExecSettings
+}
@@ -0,0 +1,60 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// FtpModel holds the ftp attribute, the settings only remotefile_ftp takes.
+// It is nil when the attribute isn't set, its getters then return null.
+type FtpModel struct {
+	CaCert types.String `tfsdk:"ca_cert"`
+	Mode   types.String `tfsdk:"mode"`
+	Tls    types.String `tfsdk:"tls"`
+}
+
+func (f *FtpModel) GetCaCert() types.String {
+	if f == nil {
+		return types.StringNull()
+	}
+	return f.CaCert
+}
+
+func (f *FtpModel) GetMode() types.String {
+	if f == nil {
+		return types.StringNull()
+	}
+	return f.Mode
+}
+
+func (f *FtpModel) GetTls() types.String {
+	if f == nil {
+		return types.StringNull()
+	}
+	return f.Tls
+}
+
+// FtpSettings embeds the ftp attribute in the models of remotefile_ftp
+type FtpSettings struct {
+	Ftp *FtpModel `tfsdk:"ftp"`
+}
+
+func (s *FtpSettings) GetFtpCaCert() types.String { return s.Ftp.GetCaCert() }
+func (s *FtpSettings) GetFtpMode() types.String   { return s.Ftp.GetMode() }
+func (s *FtpSettings) GetFtpTls() types.String    { return s.Ftp.GetTls() }
+
+// FtpResourceModel is the model of the remotefile_ftp resource
+type FtpResourceModel struct {
+	RemoteFileResourceModel
+	FtpSettings
+}
+
+// FtpDataSourceModel is the model of the remotefile_ftp data source
+type FtpDataSourceModel struct {
+	RemoteFileDataSourceModel
+	FtpSettings
+}
+
+// FtpEphemeralResourceModel is the model of the remotefile_ftp ephemeral
+// resource
+type FtpEphemeralResourceModel struct {
+	RemoteFileEphemeralResourceModel
+	FtpSettings
+}
@@ -0,0 +1,53 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// RemoteFileHttpDataSourceModel is the remotefile_http data source, which
+// fetches a file with an HTTP GET rather than over a remote file protocol
+type RemoteFileHttpDataSourceModel struct {
+	AllowMissing     types.Bool    `tfsdk:"allow_missing"`
+	CaCert           types.String  `tfsdk:"ca_cert"`
+	CacheDir         types.String  `tfsdk:"cache_dir"`
+	ClientCert       types.String  `tfsdk:"client_cert"`
+	ClientKey        types.String  `tfsdk:"client_key"`
+	Contents         types.String  `tfsdk:"contents"`
+	LastModified     types.String  `tfsdk:"last_modified"`
+	Password         types.String  `tfsdk:"password"`
+	RequestHeaders   types.Map     `tfsdk:"request_headers"`
+	ResponseHeaders  types.Map     `tfsdk:"response_headers"`
+	Size             types.Int64   `tfsdk:"size"`
+	StatusCode       types.Int64   `tfsdk:"status_code"`
+	Timeout          types.String  `tfsdk:"timeout"`
+	URL              types.String  `tfsdk:"url"`
+	User             types.String  `tfsdk:"user"`
+	ID               types.String  `tfsdk:"id"`
+	RetryCount       types.Int64   `tfsdk:"retry_count"`
+	RetryInterval    types.String  `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String  `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64 `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64 `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String  `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileHttpDataSourceModel) GetAllowMissing() types.Bool    { return r.AllowMissing }
+func (r *RemoteFileHttpDataSourceModel) GetCaCert() types.String        { return r.CaCert }
+func (r *RemoteFileHttpDataSourceModel) GetCacheDir() types.String      { return r.CacheDir }
+func (r *RemoteFileHttpDataSourceModel) GetClientCert() types.String    { return r.ClientCert }
+func (r *RemoteFileHttpDataSourceModel) GetClientKey() types.String     { return r.ClientKey }
+func (r *RemoteFileHttpDataSourceModel) GetPassword() types.String      { return r.Password }
+func (r *RemoteFileHttpDataSourceModel) GetRequestHeaders() types.Map   { return r.RequestHeaders }
+func (r *RemoteFileHttpDataSourceModel) GetTimeout() types.String       { return r.Timeout }
+func (r *RemoteFileHttpDataSourceModel) GetURL() types.String           { return r.URL }
+func (r *RemoteFileHttpDataSourceModel) GetUser() types.String          { return r.User }
+func (r *RemoteFileHttpDataSourceModel) GetRetryCount() types.Int64     { return r.RetryCount }
+func (r *RemoteFileHttpDataSourceModel) GetRetryInterval() types.String { return r.RetryInterval }
+func (r *RemoteFileHttpDataSourceModel) GetRetryMaxInterval() types.String {
+	return r.RetryMaxInterval
+}
+func (r *RemoteFileHttpDataSourceModel) GetRetryMultiplier() types.Float64 { return r.RetryMultiplier }
+func (r *RemoteFileHttpDataSourceModel) GetRetryJitter() types.Float64     { return r.RetryJitter }
+func (r *RemoteFileHttpDataSourceModel) GetRetryMaxElapsed() types.String  { return r.RetryMaxElapsed }
+
+// GetPrivateKey returns client_key, so it is masked in logs like the SSH
+// private key
+func (r *RemoteFileHttpDataSourceModel) GetPrivateKey() types.String { return r.ClientKey }
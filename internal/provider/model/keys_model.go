@@ -0,0 +1,55 @@
+package model
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type RemoteFileKeysResourceModel struct {
+	Create           types.Bool     `tfsdk:"create"`
+	Format           types.String   `tfsdk:"format"`
+	Host             types.String   `tfsdk:"host"`
+	HostKey          types.String   `tfsdk:"host_key"`
+	Password         types.String   `tfsdk:"password"`
+	Path             types.String   `tfsdk:"path"`
+	Permissions      types.String   `tfsdk:"permissions"`
+	Port             types.Int64    `tfsdk:"port"`
+	PrivateKey       types.String   `tfsdk:"private_key"`
+	Remove           types.Set      `tfsdk:"remove"`
+	Set              types.Map      `tfsdk:"set"`
+	SystemSshPath    types.String   `tfsdk:"system_ssh_path"`
+	Timeout          types.String   `tfsdk:"timeout"`
+	Timeouts         timeouts.Value `tfsdk:"timeouts"`
+	UseSystemSsh     types.Bool     `tfsdk:"use_system_ssh"`
+	User             types.String   `tfsdk:"user"`
+	ID               types.String   `tfsdk:"id"`
+	RetryCount       types.Int64    `tfsdk:"retry_count"`
+	RetryInterval    types.String   `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String   `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64  `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64  `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String   `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileKeysResourceModel) GetCreate() types.Bool             { return r.Create }
+func (r *RemoteFileKeysResourceModel) GetFormat() types.String           { return r.Format }
+func (r *RemoteFileKeysResourceModel) GetHost() types.String             { return r.Host }
+func (r *RemoteFileKeysResourceModel) GetHostKey() types.String          { return r.HostKey }
+func (r *RemoteFileKeysResourceModel) GetPassword() types.String         { return r.Password }
+func (r *RemoteFileKeysResourceModel) GetPath() types.String             { return r.Path }
+func (r *RemoteFileKeysResourceModel) GetPermissions() types.String      { return r.Permissions }
+func (r *RemoteFileKeysResourceModel) GetPort() types.Int64              { return r.Port }
+func (r *RemoteFileKeysResourceModel) GetPrivateKey() types.String       { return r.PrivateKey }
+func (r *RemoteFileKeysResourceModel) GetRemove() types.Set              { return r.Remove }
+func (r *RemoteFileKeysResourceModel) GetSet() types.Map                 { return r.Set }
+func (r *RemoteFileKeysResourceModel) GetSystemSshPath() types.String    { return r.SystemSshPath }
+func (r *RemoteFileKeysResourceModel) GetTimeout() types.String          { return r.Timeout }
+func (r *RemoteFileKeysResourceModel) GetUseSystemSsh() types.Bool       { return r.UseSystemSsh }
+func (r *RemoteFileKeysResourceModel) GetUser() types.String             { return r.User }
+func (r *RemoteFileKeysResourceModel) GetID() types.String               { return r.ID }
+func (r *RemoteFileKeysResourceModel) GetRetryCount() types.Int64        { return r.RetryCount }
+func (r *RemoteFileKeysResourceModel) GetRetryInterval() types.String    { return r.RetryInterval }
+func (r *RemoteFileKeysResourceModel) GetRetryMaxInterval() types.String { return r.RetryMaxInterval }
+func (r *RemoteFileKeysResourceModel) GetRetryMultiplier() types.Float64 { return r.RetryMultiplier }
+func (r *RemoteFileKeysResourceModel) GetRetryJitter() types.Float64     { return r.RetryJitter }
+func (r *RemoteFileKeysResourceModel) GetRetryMaxElapsed() types.String  { return r.RetryMaxElapsed }
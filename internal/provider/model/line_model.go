@@ -0,0 +1,59 @@
+package model
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type RemoteFileLineResourceModel struct {
+	Create           types.Bool     `tfsdk:"create"`
+	Host             types.String   `tfsdk:"host"`
+	HostKey          types.String   `tfsdk:"host_key"`
+	InsertAfter      types.String   `tfsdk:"insert_after"`
+	InsertBefore     types.String   `tfsdk:"insert_before"`
+	Line             types.String   `tfsdk:"line"`
+	Password         types.String   `tfsdk:"password"`
+	Path             types.String   `tfsdk:"path"`
+	Permissions      types.String   `tfsdk:"permissions"`
+	Port             types.Int64    `tfsdk:"port"`
+	PrivateKey       types.String   `tfsdk:"private_key"`
+	Regexp           types.String   `tfsdk:"regexp"`
+	State            types.String   `tfsdk:"state"`
+	SystemSshPath    types.String   `tfsdk:"system_ssh_path"`
+	Timeout          types.String   `tfsdk:"timeout"`
+	Timeouts         timeouts.Value `tfsdk:"timeouts"`
+	UseSystemSsh     types.Bool     `tfsdk:"use_system_ssh"`
+	User             types.String   `tfsdk:"user"`
+	ID               types.String   `tfsdk:"id"`
+	RetryCount       types.Int64    `tfsdk:"retry_count"`
+	RetryInterval    types.String   `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String   `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64  `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64  `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String   `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileLineResourceModel) GetCreate() types.Bool             { return r.Create }
+func (r *RemoteFileLineResourceModel) GetHost() types.String             { return r.Host }
+func (r *RemoteFileLineResourceModel) GetHostKey() types.String          { return r.HostKey }
+func (r *RemoteFileLineResourceModel) GetInsertAfter() types.String      { return r.InsertAfter }
+func (r *RemoteFileLineResourceModel) GetInsertBefore() types.String     { return r.InsertBefore }
+func (r *RemoteFileLineResourceModel) GetLine() types.String             { return r.Line }
+func (r *RemoteFileLineResourceModel) GetPassword() types.String         { return r.Password }
+func (r *RemoteFileLineResourceModel) GetPath() types.String             { return r.Path }
+func (r *RemoteFileLineResourceModel) GetPermissions() types.String      { return r.Permissions }
+func (r *RemoteFileLineResourceModel) GetPort() types.Int64              { return r.Port }
+func (r *RemoteFileLineResourceModel) GetPrivateKey() types.String       { return r.PrivateKey }
+func (r *RemoteFileLineResourceModel) GetRegexp() types.String           { return r.Regexp }
+func (r *RemoteFileLineResourceModel) GetState() types.String            { return r.State }
+func (r *RemoteFileLineResourceModel) GetSystemSshPath() types.String    { return r.SystemSshPath }
+func (r *RemoteFileLineResourceModel) GetTimeout() types.String          { return r.Timeout }
+func (r *RemoteFileLineResourceModel) GetUseSystemSsh() types.Bool       { return r.UseSystemSsh }
+func (r *RemoteFileLineResourceModel) GetUser() types.String             { return r.User }
+func (r *RemoteFileLineResourceModel) GetID() types.String               { return r.ID }
+func (r *RemoteFileLineResourceModel) GetRetryCount() types.Int64        { return r.RetryCount }
+func (r *RemoteFileLineResourceModel) GetRetryInterval() types.String    { return r.RetryInterval }
+func (r *RemoteFileLineResourceModel) GetRetryMaxInterval() types.String { return r.RetryMaxInterval }
+func (r *RemoteFileLineResourceModel) GetRetryMultiplier() types.Float64 { return r.RetryMultiplier }
+func (r *RemoteFileLineResourceModel) GetRetryJitter() types.Float64     { return r.RetryJitter }
+func (r *RemoteFileLineResourceModel) GetRetryMaxElapsed() types.String  { return r.RetryMaxElapsed }
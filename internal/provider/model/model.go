@@ -2,57 +2,97 @@ package model
 
 import "github.com/hashicorp/terraform-plugin-framework/types"
 
+// RemoteFileDataSourceModel is the model of the data sources reading whole
+// files, the triggers re-read the file when they change
 type RemoteFileDataSourceModel struct {
-	AllowMissing  types.Bool   `tfsdk:"allow_missing"`
-	Contents      types.String `tfsdk:"contents"`
-	Host          types.String `tfsdk:"host"`
-	HostKey       types.String `tfsdk:"host_key"`
-	LastModified  types.String `tfsdk:"last_modified"`
-	Password      types.String `tfsdk:"password"`
-	Path          types.String `tfsdk:"path"`
-	Permissions   types.String `tfsdk:"permissions"`
-	Port          types.Int64  `tfsdk:"port"`
-	PrivateKey    types.String `tfsdk:"private_key"`
-	Size          types.Int64  `tfsdk:"size"`
-	Timeout       types.String `tfsdk:"timeout"`
-	Triggers      types.Map    `tfsdk:"triggers"`
-	User          types.String `tfsdk:"user"`
-	ID            types.String `tfsdk:"id"`
-	RetryCount    types.Int64  `tfsdk:"retry_count"`
-	RetryInterval types.String `tfsdk:"retry_interval"`
-}
-
-func (r *RemoteFileDataSourceModel) GetAllowMissing() types.Bool    { return r.AllowMissing }
-func (r *RemoteFileDataSourceModel) GetContents() types.String      { return r.Contents }
-func (r *RemoteFileDataSourceModel) GetHost() types.String          { return r.Host }
-func (r *RemoteFileDataSourceModel) GetHostKey() types.String       { return r.HostKey }
-func (r *RemoteFileDataSourceModel) GetLastModified() types.String  { return r.LastModified }
-func (r *RemoteFileDataSourceModel) GetPassword() types.String      { return r.Password }
-func (r *RemoteFileDataSourceModel) GetPath() types.String          { return r.Path }
-func (r *RemoteFileDataSourceModel) GetPermissions() types.String   { return r.Permissions }
-func (r *RemoteFileDataSourceModel) GetPort() types.Int64           { return r.Port }
-func (r *RemoteFileDataSourceModel) GetPrivateKey() types.String    { return r.PrivateKey }
-func (r *RemoteFileDataSourceModel) GetSize() types.Int64           { return r.Size }
-func (r *RemoteFileDataSourceModel) GetTimeout() types.String       { return r.Timeout }
-func (r *RemoteFileDataSourceModel) GetTriggers() types.Map         { return r.Triggers }
-func (r *RemoteFileDataSourceModel) GetUser() types.String          { return r.User }
-func (r *RemoteFileDataSourceModel) GetID() types.String            { return r.ID }
-func (r *RemoteFileDataSourceModel) GetRetryCount() types.Int64     { return r.RetryCount }
-func (r *RemoteFileDataSourceModel) GetRetryInterval() types.String { return r.RetryInterval }
+	RemoteFileEphemeralResourceModel
+	Triggers types.Map `tfsdk:"triggers"`
+}
+
+// RemoteFileEphemeralResourceModel is the model of the ephemeral resources
+// reading whole files
+type RemoteFileEphemeralResourceModel struct {
+	AllowMissing     types.Bool    `tfsdk:"allow_missing"`
+	Contents         types.String  `tfsdk:"contents"`
+	Host             types.String  `tfsdk:"host"`
+	HostKey          types.String  `tfsdk:"host_key"`
+	LastModified     types.String  `tfsdk:"last_modified"`
+	Password         types.String  `tfsdk:"password"`
+	Path             types.String  `tfsdk:"path"`
+	Port             types.Int64   `tfsdk:"port"`
+	PrivateKey       types.String  `tfsdk:"private_key"`
+	Size             types.Int64   `tfsdk:"size"`
+	SystemSshPath    types.String  `tfsdk:"system_ssh_path"`
+	Timeout          types.String  `tfsdk:"timeout"`
+	UseSystemSsh     types.Bool    `tfsdk:"use_system_ssh"`
+	User             types.String  `tfsdk:"user"`
+	ID               types.String  `tfsdk:"id"`
+	RetryCount       types.Int64   `tfsdk:"retry_count"`
+	RetryInterval    types.String  `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String  `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64 `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64 `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String  `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileEphemeralResourceModel) GetAllowMissing() types.Bool    { return r.AllowMissing }
+func (r *RemoteFileEphemeralResourceModel) GetContents() types.String      { return r.Contents }
+func (r *RemoteFileEphemeralResourceModel) GetHost() types.String          { return r.Host }
+func (r *RemoteFileEphemeralResourceModel) GetHostKey() types.String       { return r.HostKey }
+func (r *RemoteFileEphemeralResourceModel) GetLastModified() types.String  { return r.LastModified }
+func (r *RemoteFileEphemeralResourceModel) GetPassword() types.String      { return r.Password }
+func (r *RemoteFileEphemeralResourceModel) GetPath() types.String          { return r.Path }
+func (r *RemoteFileEphemeralResourceModel) GetPort() types.Int64           { return r.Port }
+func (r *RemoteFileEphemeralResourceModel) GetPrivateKey() types.String    { return r.PrivateKey }
+func (r *RemoteFileEphemeralResourceModel) GetSize() types.Int64           { return r.Size }
+func (r *RemoteFileEphemeralResourceModel) GetSystemSshPath() types.String { return r.SystemSshPath }
+func (r *RemoteFileEphemeralResourceModel) GetTimeout() types.String       { return r.Timeout }
+func (r *RemoteFileEphemeralResourceModel) GetUseSystemSsh() types.Bool    { return r.UseSystemSsh }
+func (r *RemoteFileEphemeralResourceModel) GetUser() types.String          { return r.User }
+func (r *RemoteFileEphemeralResourceModel) GetID() types.String            { return r.ID }
+func (r *RemoteFileEphemeralResourceModel) GetRetryCount() types.Int64     { return r.RetryCount }
+func (r *RemoteFileEphemeralResourceModel) GetRetryInterval() types.String { return r.RetryInterval }
+func (r *RemoteFileEphemeralResourceModel) GetRetryMaxInterval() types.String {
+	return r.RetryMaxInterval
+}
+func (r *RemoteFileEphemeralResourceModel) GetRetryMultiplier() types.Float64 {
+	return r.RetryMultiplier
+}
+func (r *RemoteFileEphemeralResourceModel) GetRetryJitter() types.Float64 { return r.RetryJitter }
+func (r *RemoteFileEphemeralResourceModel) GetRetryMaxElapsed() types.String {
+	return r.RetryMaxElapsed
+}
 
 // write methods to set ID, Contents, LastModified, Size
-func (r *RemoteFileDataSourceModel) SetID(id types.String) {
+func (r *RemoteFileEphemeralResourceModel) SetID(id types.String) {
 	r.ID = id
 }
 
-func (r *RemoteFileDataSourceModel) SetContents(contents types.String) {
+func (r *RemoteFileEphemeralResourceModel) SetContents(contents types.String) {
 	r.Contents = contents
 }
 
-func (r *RemoteFileDataSourceModel) SetLastModified(lastModified types.String) {
+func (r *RemoteFileEphemeralResourceModel) SetLastModified(lastModified types.String) {
 	r.LastModified = lastModified
 }
 
-func (r *RemoteFileDataSourceModel) SetSize(size types.Int64) {
+func (r *RemoteFileEphemeralResourceModel) SetSize(size types.Int64) {
 	r.Size = size
 }
+
+// DataSourceModel is the model of a remotefile_<protocol> data source, the
+// shared model possibly embedded with the settings of the protocol
+type DataSourceModel interface {
+	Shared() *RemoteFileDataSourceModel
+}
+
+func (r *RemoteFileDataSourceModel) Shared() *RemoteFileDataSourceModel { return r }
+
+// EphemeralResourceModel is the model of a remotefile_<protocol> ephemeral
+// resource, the shared model possibly embedded with the settings of the
+// protocol
+type EphemeralResourceModel interface {
+	Shared() *RemoteFileEphemeralResourceModel
+}
+
+func (r *RemoteFileEphemeralResourceModel) Shared() *RemoteFileEphemeralResourceModel { return r }
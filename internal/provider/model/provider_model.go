@@ -0,0 +1,21 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// ProviderModel holds the provider-level defaults inherited by every data
+// source and resource
+type ProviderModel struct {
+	RetryCount       types.Int64   `tfsdk:"retry_count"`
+	RetryInterval    types.String  `tfsdk:"retry_interval"`
+	RetryMaxInterval types.String  `tfsdk:"retry_max_interval"`
+	RetryMultiplier  types.Float64 `tfsdk:"retry_multiplier"`
+	RetryJitter      types.Float64 `tfsdk:"retry_jitter"`
+	RetryMaxElapsed  types.String  `tfsdk:"retry_max_elapsed"`
+}
+
+func (p *ProviderModel) GetRetryCount() types.Int64        { return p.RetryCount }
+func (p *ProviderModel) GetRetryInterval() types.String    { return p.RetryInterval }
+func (p *ProviderModel) GetRetryMaxInterval() types.String { return p.RetryMaxInterval }
+func (p *ProviderModel) GetRetryMultiplier() types.Float64 { return p.RetryMultiplier }
+func (p *ProviderModel) GetRetryJitter() types.Float64     { return p.RetryJitter }
+func (p *ProviderModel) GetRetryMaxElapsed() types.String  { return p.RetryMaxElapsed }
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type RemoteFileResourceModel struct {
+	AllowMissing      types.Bool     `tfsdk:"allow_missing"`
+	CommandExitCode   types.Int64    `tfsdk:"command_exit_code"`
+	CommandStderr     types.String   `tfsdk:"command_stderr"`
+	CommandStdout     types.String   `tfsdk:"command_stdout"`
+	Contents          types.String   `tfsdk:"contents"`
+	ContentsHash      types.String   `tfsdk:"contents_hash"`
+	ContentsWo        types.String   `tfsdk:"contents_wo"`
+	ContentsWoVersion types.Int64    `tfsdk:"contents_wo_version"`
+	Group             types.String   `tfsdk:"group"`
+	Host              types.String   `tfsdk:"host"`
+	HostKey           types.String   `tfsdk:"host_key"`
+	LastModified      types.String   `tfsdk:"last_modified"`
+	OnCommandFailure  types.String   `tfsdk:"on_command_failure"`
+	OnCreateCommand   types.String   `tfsdk:"on_create_command"`
+	OnDeleteCommand   types.String   `tfsdk:"on_delete_command"`
+	OnUpdateCommand   types.String   `tfsdk:"on_update_command"`
+	Owner             types.String   `tfsdk:"owner"`
+	Password          types.String   `tfsdk:"password"`
+	Path              types.String   `tfsdk:"path"`
+	Permissions       types.String   `tfsdk:"permissions"`
+	Port              types.Int64    `tfsdk:"port"`
+	PrivateKey        types.String   `tfsdk:"private_key"`
+	Resumable         types.Bool     `tfsdk:"resumable"`
+	Size              types.Int64    `tfsdk:"size"`
+	Sudo              types.String   `tfsdk:"sudo"`
+	SudoPassword      types.String   `tfsdk:"sudo_password"`
+	SudoSftpServer    types.String   `tfsdk:"sudo_sftp_server"`
+	SystemSshPath     types.String   `tfsdk:"system_ssh_path"`
+	Template          types.String   `tfsdk:"template"`
+	TemplateFormat    types.String   `tfsdk:"template_format"`
+	Timeout           types.String   `tfsdk:"timeout"`
+	Timeouts          timeouts.Value `tfsdk:"timeouts"`
+	Triggers          types.Map      `tfsdk:"triggers"`
+	UseSystemSsh      types.Bool     `tfsdk:"use_system_ssh"`
+	User              types.String   `tfsdk:"user"`
+	ValidateCommand   types.String   `tfsdk:"validate_command"`
+	Vars              types.Map      `tfsdk:"vars"`
+	ID                types.String   `tfsdk:"id"`
+	RetryCount        types.Int64    `tfsdk:"retry_count"`
+	RetryInterval     types.String   `tfsdk:"retry_interval"`
+	RetryMaxInterval  types.String   `tfsdk:"retry_max_interval"`
+	RetryMultiplier   types.Float64  `tfsdk:"retry_multiplier"`
+	RetryJitter       types.Float64  `tfsdk:"retry_jitter"`
+	RetryMaxElapsed   types.String   `tfsdk:"retry_max_elapsed"`
+}
+
+func (r *RemoteFileResourceModel) GetAllowMissing() types.Bool       { return r.AllowMissing }
+func (r *RemoteFileResourceModel) GetCommandExitCode() types.Int64   { return r.CommandExitCode }
+func (r *RemoteFileResourceModel) GetCommandStderr() types.String    { return r.CommandStderr }
+func (r *RemoteFileResourceModel) GetCommandStdout() types.String    { return r.CommandStdout }
+func (r *RemoteFileResourceModel) GetContents() types.String         { return r.Contents }
+func (r *RemoteFileResourceModel) GetContentsHash() types.String     { return r.ContentsHash }
+func (r *RemoteFileResourceModel) GetContentsWoVersion() types.Int64 { return r.ContentsWoVersion }
+func (r *RemoteFileResourceModel) GetGroup() types.String            { return r.Group }
+func (r *RemoteFileResourceModel) GetHost() types.String             { return r.Host }
+func (r *RemoteFileResourceModel) GetHostKey() types.String          { return r.HostKey }
+func (r *RemoteFileResourceModel) GetLastModified() types.String     { return r.LastModified }
+func (r *RemoteFileResourceModel) GetOnCommandFailure() types.String { return r.OnCommandFailure }
+func (r *RemoteFileResourceModel) GetOnCreateCommand() types.String  { return r.OnCreateCommand }
+func (r *RemoteFileResourceModel) GetOnDeleteCommand() types.String  { return r.OnDeleteCommand }
+func (r *RemoteFileResourceModel) GetOnUpdateCommand() types.String  { return r.OnUpdateCommand }
+func (r *RemoteFileResourceModel) GetOwner() types.String            { return r.Owner }
+func (r *RemoteFileResourceModel) GetPassword() types.String         { return r.Password }
+func (r *RemoteFileResourceModel) GetPath() types.String             { return r.Path }
+func (r *RemoteFileResourceModel) GetPermissions() types.String      { return r.Permissions }
+func (r *RemoteFileResourceModel) GetPort() types.Int64              { return r.Port }
+func (r *RemoteFileResourceModel) GetPrivateKey() types.String       { return r.PrivateKey }
+func (r *RemoteFileResourceModel) GetResumable() types.Bool          { return r.Resumable }
+func (r *RemoteFileResourceModel) GetSize() types.Int64              { return r.Size }
+func (r *RemoteFileResourceModel) GetSudo() types.String             { return r.Sudo }
+func (r *RemoteFileResourceModel) GetSudoPassword() types.String     { return r.SudoPassword }
+func (r *RemoteFileResourceModel) GetSudoSftpServer() types.String   { return r.SudoSftpServer }
+func (r *RemoteFileResourceModel) GetSystemSshPath() types.String    { return r.SystemSshPath }
+func (r *RemoteFileResourceModel) GetTemplate() types.String         { return r.Template }
+func (r *RemoteFileResourceModel) GetTemplateFormat() types.String   { return r.TemplateFormat }
+func (r *RemoteFileResourceModel) GetTimeout() types.String          { return r.Timeout }
+func (r *RemoteFileResourceModel) GetTriggers() types.Map            { return r.Triggers }
+func (r *RemoteFileResourceModel) GetUseSystemSsh() types.Bool       { return r.UseSystemSsh }
+func (r *RemoteFileResourceModel) GetUser() types.String             { return r.User }
+func (r *RemoteFileResourceModel) GetValidateCommand() types.String  { return r.ValidateCommand }
+func (r *RemoteFileResourceModel) GetVars() types.Map                { return r.Vars }
+func (r *RemoteFileResourceModel) GetID() types.String               { return r.ID }
+func (r *RemoteFileResourceModel) GetRetryCount() types.Int64        { return r.RetryCount }
+func (r *RemoteFileResourceModel) GetRetryInterval() types.String    { return r.RetryInterval }
+func (r *RemoteFileResourceModel) GetRetryMaxInterval() types.String { return r.RetryMaxInterval }
+func (r *RemoteFileResourceModel) GetRetryMultiplier() types.Float64 { return r.RetryMultiplier }
+func (r *RemoteFileResourceModel) GetRetryJitter() types.Float64     { return r.RetryJitter }
+func (r *RemoteFileResourceModel) GetRetryMaxElapsed() types.String  { return r.RetryMaxElapsed }
+
+// write methods to set ID, Contents, LastModified, Size
+func (r *RemoteFileResourceModel) SetID(id types.String) {
+	r.ID = id
+}
+
+func (r *RemoteFileResourceModel) SetContents(contents types.String) {
+	r.Contents = contents
+}
+
+func (r *RemoteFileResourceModel) SetLastModified(lastModified types.String) {
+	r.LastModified = lastModified
+}
+
+func (r *RemoteFileResourceModel) SetSize(size types.Int64) {
+	r.Size = size
+}
+
+// ResourceModel is the model of a remotefile_<protocol> resource, the shared
+// model possibly embedded with the settings of the protocol
+type ResourceModel interface {
+	Shared() *RemoteFileResourceModel
+}
+
+func (r *RemoteFileResourceModel) Shared() *RemoteFileResourceModel { return r }
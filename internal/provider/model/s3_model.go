@@ -0,0 +1,105 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// S3Model holds the s3 attribute, the settings only remotefile_s3 takes. It
+// is nil when the attribute isn't set, its getters then return null.
+type S3Model struct {
+	Bucket       types.String `tfsdk:"bucket"`
+	CaCert       types.String `tfsdk:"ca_cert"`
+	ContentType  types.String `tfsdk:"content_type"`
+	Metadata     types.Map    `tfsdk:"metadata"`
+	PathStyle    types.Bool   `tfsdk:"path_style"`
+	Region       types.String `tfsdk:"region"`
+	Scheme       types.String `tfsdk:"scheme"`
+	SessionToken types.String `tfsdk:"session_token"`
+}
+
+func (s *S3Model) GetBucket() types.String {
+	if s == nil {
+		return types.StringNull()
+	}
+	return s.Bucket
+}
+
+func (s *S3Model) GetCaCert() types.String {
+	if s == nil {
+		return types.StringNull()
+	}
+	return s.CaCert
+}
+
+func (s *S3Model) GetContentType() types.String {
+	if s == nil {
+		return types.StringNull()
+	}
+	return s.ContentType
+}
+
+func (s *S3Model) GetMetadata() types.Map {
+	if s == nil {
+		return types.MapNull(types.StringType)
+	}
+	return s.Metadata
+}
+
+func (s *S3Model) GetPathStyle() types.Bool {
+	if s == nil {
+		return types.BoolNull()
+	}
+	return s.PathStyle
+}
+
+func (s *S3Model) GetRegion() types.String {
+	if s == nil {
+		return types.StringNull()
+	}
+	return s.Region
+}
+
+func (s *S3Model) GetScheme() types.String {
+	if s == nil {
+		return types.StringNull()
+	}
+	return s.Scheme
+}
+
+func (s *S3Model) GetSessionToken() types.String {
+	if s == nil {
+		return types.StringNull()
+	}
+	return s.SessionToken
+}
+
+// S3Settings embeds the s3 attribute in the models of remotefile_s3
+type S3Settings struct {
+	S3 *S3Model `tfsdk:"s3"`
+}
+
+func (s *S3Settings) GetS3Bucket() types.String       { return s.S3.GetBucket() }
+func (s *S3Settings) GetS3CaCert() types.String       { return s.S3.GetCaCert() }
+func (s *S3Settings) GetS3ContentType() types.String  { return s.S3.GetContentType() }
+func (s *S3Settings) GetS3Metadata() types.Map        { return s.S3.GetMetadata() }
+func (s *S3Settings) GetS3PathStyle() types.Bool      { return s.S3.GetPathStyle() }
+func (s *S3Settings) GetS3Region() types.String       { return s.S3.GetRegion() }
+func (s *S3Settings) GetS3Scheme() types.String       { return s.S3.GetScheme() }
+func (s *S3Settings) GetS3SessionToken() types.String { return s.S3.GetSessionToken() }
+
+// S3ResourceModel is the model of the remotefile_s3 resource
+type S3ResourceModel struct {
+	RemoteFileResourceModel
+	S3Settings
+}
+
+// S3DataSourceModel is the model of the remotefile_s3 data source
+type S3DataSourceModel struct {
+	RemoteFileDataSourceModel
+	S3Settings
+}
+
+// S3EphemeralResourceModel is the model of the remotefile_s3 ephemeral
+// resource
+type S3EphemeralResourceModel struct {
+	RemoteFileEphemeralResourceModel
+	S3Settings
+}
@@ -0,0 +1,69 @@
+package model
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// WebdavModel holds the webdav attribute, the settings only remotefile_webdav
+// takes. It is nil when the attribute isn't set, its getters then return null.
+type WebdavModel struct {
+	BasePath    types.String `tfsdk:"base_path"`
+	BearerToken types.String `tfsdk:"bearer_token"`
+	CaCert      types.String `tfsdk:"ca_cert"`
+	Scheme      types.String `tfsdk:"scheme"`
+}
+
+func (w *WebdavModel) GetBasePath() types.String {
+	if w == nil {
+		return types.StringNull()
+	}
+	return w.BasePath
+}
+
+func (w *WebdavModel) GetBearerToken() types.String {
+	if w == nil {
+		return types.StringNull()
+	}
+	return w.BearerToken
+}
+
+func (w *WebdavModel) GetCaCert() types.String {
+	if w == nil {
+		return types.StringNull()
+	}
+	return w.CaCert
+}
+
+func (w *WebdavModel) GetScheme() types.String {
+	if w == nil {
+		return types.StringNull()
+	}
+	return w.Scheme
+}
+
+// WebdavSettings embeds the webdav attribute in the models of remotefile_webdav
+type WebdavSettings struct {
+	Webdav *WebdavModel `tfsdk:"webdav"`
+}
+
+func (s *WebdavSettings) GetWebdavBasePath() types.String    { return s.Webdav.GetBasePath() }
+func (s *WebdavSettings) GetWebdavBearerToken() types.String { return s.Webdav.GetBearerToken() }
+func (s *WebdavSettings) GetWebdavCaCert() types.String      { return s.Webdav.GetCaCert() }
+func (s *WebdavSettings) GetWebdavScheme() types.String      { return s.Webdav.GetScheme() }
+
+// WebdavResourceModel is the model of the remotefile_webdav resource
+type WebdavResourceModel struct {
+	RemoteFileResourceModel
+	WebdavSettings
+}
+
+// WebdavDataSourceModel is the model of the remotefile_webdav data source
+type WebdavDataSourceModel struct {
+	RemoteFileDataSourceModel
+	WebdavSettings
+}
+
+// WebdavEphemeralResourceModel is the model of the remotefile_webdav ephemeral
+// resource
+type WebdavEphemeralResourceModel struct {
+	RemoteFileEphemeralResourceModel
+	WebdavSettings
+}
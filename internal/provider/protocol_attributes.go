@@ -0,0 +1,377 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	datasourceschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	ephemeralschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	resourceschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/azureblob"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/exec"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/ftp"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/s3"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/webdav"
+)
+
+// The settings of a single protocol are grouped in an attribute named after
+// it, which only the schemas of that protocol have
+
+const (
+	execDescription        = "Settings only remotefile_exec takes"
+	execArgsDescription    = "Values of the placeholders of command besides {host}, {user} and {script}, e.g. { container = \"web\" } for {container}"
+	execCommandDescription = "The command running a script on the target, run with /bin/sh on the machine running Terraform. " +
+		"{script} is replaced with the quoted script, {host} and {user} with the quoted attributes and every other " +
+		"{name} with the quoted value of args, e.g. 'docker exec -i {host} sh -c {script}'. The target needs cat, stat, " +
+		"chmod, chown, mv, rm, mkdir and find."
+
+	ftpDescription       = "Settings only remotefile_ftp takes"
+	ftpCaCertDescription = "The PEM certificate of the CA the server certificate is verified against, defaults to the system roots"
+
+	s3Description             = "Settings only remotefile_s3 takes, user and password are the access key ID and the secret access key"
+	s3BucketDescription       = "The bucket the object is stored in, path is its key"
+	s3CaCertDescription       = "The PEM certificate of the CA the endpoint certificate is verified against, defaults to the system roots"
+	s3ContentTypeDescription  = "The Content-Type the object is stored with when written, defaults to 'application/octet-stream'"
+	s3MetadataDescription     = "User metadata the object is stored with when written, sent as x-amz-meta-* headers"
+	s3PathStyleDescription    = "Address the bucket as a path of host rather than as a subdomain, as MinIO and Ceph RGW usually expect"
+	s3SessionTokenDescription = "The session token of temporary credentials"
+
+	webdavDescription         = "Settings only remotefile_webdav takes"
+	webdavBasePathDescription = "The path of the WebDAV root on the server file paths are resolved against (e.g. " +
+		"'/remote.php/dav/files/deploy'), defaults to '/'"
+	webdavBearerTokenDescription = "A token sent as 'Authorization: Bearer', instead of user and password"
+	webdavCaCertDescription      = "The PEM certificate of the CA the server certificate is verified against, defaults to the system roots"
+
+	azureblobDescription = "Settings only remotefile_azureblob takes, user is the storage account name and password its " +
+		"base64 encoded account key"
+	azureblobCaCertDescription      = "The PEM certificate of the CA the endpoint certificate is verified against, defaults to the system roots"
+	azureblobContainerDescription   = "The container the blob is stored in, path is its name"
+	azureblobContentTypeDescription = "The Content-Type the blob is stored with when written, defaults to 'application/octet-stream'"
+	azureblobMetadataDescription    = "User metadata the blob is stored with when written, sent as x-ms-meta-* headers"
+	azureblobPathStyleDescription   = "Address the account as the first path segment rather than as a subdomain of host, " +
+		"as Azurite expects. Implied when host is an IP address or localhost."
+	azureblobSasTokenDescription = "A shared access signature (e.g. 'sv=...&sp=racwd&sig=...') authorizing the requests " +
+		"instead of password"
+)
+
+var (
+	ftpModeDescription = fmt.Sprintf("How data connections are opened, '%s' (default) connects to the server, '%s' has the server connect back",
+		ftp.ModePassive, ftp.ModeActive)
+	ftpTlsDescription = fmt.Sprintf("'%s' (default) transfers in clear text, '%s' upgrades the connection with AUTH TLS, "+
+		"'%s' speaks TLS from the start and defaults port to %d",
+		ftp.TLSNone, ftp.TLSExplicit, ftp.TLSImplicit, ftp.DefaultImplicitTLSPort)
+	s3RegionDescription = fmt.Sprintf("The region requests are signed for, defaults to '%s'", s3.DefaultRegion)
+	s3SchemeDescription = fmt.Sprintf("'%s' (default) or '%s', which sends the contents in clear text",
+		s3.SchemeHTTPS, s3.SchemeHTTP)
+	webdavSchemeDescription = fmt.Sprintf("'%s' (default) or '%s', which sends the credentials in clear text",
+		webdav.SchemeHTTPS, webdav.SchemeHTTP)
+	azureblobBlockSizeDescription = fmt.Sprintf("Blobs larger than this many bytes are uploaded in blocks of it and "+
+		"committed together, defaults to %d (4 MiB)", azureblob.DefaultBlockSize)
+	azureblobSchemeDescription = fmt.Sprintf("'%s' (default) or '%s', which sends the contents in clear text",
+		azureblob.SchemeHTTPS, azureblob.SchemeHTTP)
+)
+
+var (
+	execSettings = &backend.Settings{
+		Description: execDescription,
+		Attributes: map[string]backend.Setting{
+			"args":    {Description: execArgsDescription, Type: types.MapType{ElemType: types.StringType}},
+			"command": {Description: execCommandDescription, Type: types.StringType},
+		},
+		Validate:                  validateExecSettings,
+		NewResourceModel:          func() any { return &model.ExecResourceModel{} },
+		NewDataSourceModel:        func() any { return &model.ExecDataSourceModel{} },
+		NewEphemeralResourceModel: func() any { return &model.ExecEphemeralResourceModel{} },
+	}
+	ftpSettings = &backend.Settings{
+		Description: ftpDescription,
+		Attributes: map[string]backend.Setting{
+			"ca_cert": {Description: ftpCaCertDescription, Type: types.StringType},
+			"mode":    {Description: ftpModeDescription, Type: types.StringType},
+			"tls":     {Description: ftpTlsDescription, Type: types.StringType},
+		},
+		Validate:                  validateFtpSettings,
+		NewResourceModel:          func() any { return &model.FtpResourceModel{} },
+		NewDataSourceModel:        func() any { return &model.FtpDataSourceModel{} },
+		NewEphemeralResourceModel: func() any { return &model.FtpEphemeralResourceModel{} },
+	}
+	s3Settings = &backend.Settings{
+		Description: s3Description,
+		Attributes: map[string]backend.Setting{
+			"bucket":        {Description: s3BucketDescription, Type: types.StringType},
+			"ca_cert":       {Description: s3CaCertDescription, Type: types.StringType},
+			"content_type":  {Description: s3ContentTypeDescription, Type: types.StringType},
+			"metadata":      {Description: s3MetadataDescription, Type: types.MapType{ElemType: types.StringType}},
+			"path_style":    {Description: s3PathStyleDescription, Type: types.BoolType},
+			"region":        {Description: s3RegionDescription, Type: types.StringType},
+			"scheme":        {Description: s3SchemeDescription, Type: types.StringType},
+			"session_token": {Description: s3SessionTokenDescription, Type: types.StringType, Sensitive: true},
+		},
+		Validate:                  validateS3Settings,
+		NewResourceModel:          func() any { return &model.S3ResourceModel{} },
+		NewDataSourceModel:        func() any { return &model.S3DataSourceModel{} },
+		NewEphemeralResourceModel: func() any { return &model.S3EphemeralResourceModel{} },
+	}
+	webdavSettings = &backend.Settings{
+		Description: webdavDescription,
+		Attributes: map[string]backend.Setting{
+			"base_path":    {Description: webdavBasePathDescription, Type: types.StringType},
+			"bearer_token": {Description: webdavBearerTokenDescription, Type: types.StringType, Sensitive: true},
+			"ca_cert":      {Description: webdavCaCertDescription, Type: types.StringType},
+			"scheme":       {Description: webdavSchemeDescription, Type: types.StringType},
+		},
+		Validate:                  validateWebdavSettings,
+		Credential:                webdavCredential,
+		NewResourceModel:          func() any { return &model.WebdavResourceModel{} },
+		NewDataSourceModel:        func() any { return &model.WebdavDataSourceModel{} },
+		NewEphemeralResourceModel: func() any { return &model.WebdavEphemeralResourceModel{} },
+	}
+	azureblobSettings = &backend.Settings{
+		Description: azureblobDescription,
+		Attributes: map[string]backend.Setting{
+			"block_size":   {Description: azureblobBlockSizeDescription, Type: types.Int64Type},
+			"ca_cert":      {Description: azureblobCaCertDescription, Type: types.StringType},
+			"container":    {Description: azureblobContainerDescription, Type: types.StringType},
+			"content_type": {Description: azureblobContentTypeDescription, Type: types.StringType},
+			"metadata":     {Description: azureblobMetadataDescription, Type: types.MapType{ElemType: types.StringType}},
+			"path_style":   {Description: azureblobPathStyleDescription, Type: types.BoolType},
+			"sas_token":    {Description: azureblobSasTokenDescription, Type: types.StringType, Sensitive: true},
+			"scheme":       {Description: azureblobSchemeDescription, Type: types.StringType},
+		},
+		Validate:                  validateAzureblobSettings,
+		Credential:                azureblobCredential,
+		NewResourceModel:          func() any { return &model.AzureblobResourceModel{} },
+		NewDataSourceModel:        func() any { return &model.AzureblobDataSourceModel{} },
+		NewEphemeralResourceModel: func() any { return &model.AzureblobEphemeralResourceModel{} },
+	}
+)
+
+// settingsResourceAttribute returns the resource schema attribute of settings
+func settingsResourceAttribute(settings *backend.Settings) resourceschema.Attribute {
+	attributes := make(map[string]resourceschema.Attribute, len(settings.Attributes))
+	for name, setting := range settings.Attributes {
+		switch t := setting.Type.(type) {
+		case basetypes.BoolType:
+			attributes[name] = resourceschema.BoolAttribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		case basetypes.Int64Type:
+			attributes[name] = resourceschema.Int64Attribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		case basetypes.MapType:
+			attributes[name] = resourceschema.MapAttribute{Description: setting.Description, ElementType: t.ElemType, Optional: true, Sensitive: setting.Sensitive}
+		default:
+			attributes[name] = resourceschema.StringAttribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		}
+	}
+	return resourceschema.SingleNestedAttribute{Description: settings.Description, Optional: true, Attributes: attributes}
+}
+
+// settingsDataSourceAttribute returns the data source schema attribute of
+// settings
+func settingsDataSourceAttribute(settings *backend.Settings) datasourceschema.Attribute {
+	attributes := make(map[string]datasourceschema.Attribute, len(settings.Attributes))
+	for name, setting := range settings.Attributes {
+		switch t := setting.Type.(type) {
+		case basetypes.BoolType:
+			attributes[name] = datasourceschema.BoolAttribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		case basetypes.Int64Type:
+			attributes[name] = datasourceschema.Int64Attribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		case basetypes.MapType:
+			attributes[name] = datasourceschema.MapAttribute{Description: setting.Description, ElementType: t.ElemType, Optional: true, Sensitive: setting.Sensitive}
+		default:
+			attributes[name] = datasourceschema.StringAttribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		}
+	}
+	return datasourceschema.SingleNestedAttribute{Description: settings.Description, Optional: true, Attributes: attributes}
+}
+
+// settingsEphemeralAttribute returns the ephemeral resource schema attribute
+// of settings
+func settingsEphemeralAttribute(settings *backend.Settings) ephemeralschema.Attribute {
+	attributes := make(map[string]ephemeralschema.Attribute, len(settings.Attributes))
+	for name, setting := range settings.Attributes {
+		switch t := setting.Type.(type) {
+		case basetypes.BoolType:
+			attributes[name] = ephemeralschema.BoolAttribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		case basetypes.Int64Type:
+			attributes[name] = ephemeralschema.Int64Attribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		case basetypes.MapType:
+			attributes[name] = ephemeralschema.MapAttribute{Description: setting.Description, ElementType: t.ElemType, Optional: true, Sensitive: setting.Sensitive}
+		default:
+			attributes[name] = ephemeralschema.StringAttribute{Description: setting.Description, Optional: true, Sensitive: setting.Sensitive}
+		}
+	}
+	return ephemeralschema.SingleNestedAttribute{Description: settings.Description, Optional: true, Attributes: attributes}
+}
+
+// validateSupported reports the attributes set in config that protocol can't
+// honour. Attributes the schema doesn't have are skipped, so data sources and
+// resources share the list.
+func validateSupported(ctx context.Context, protocol backend.Protocol, config tfsdk.Config, diags *diag.Diagnostics) {
+	attributes := config.Schema.GetAttributes()
+	for _, name := range protocol.Unsupported {
+		if _, ok := attributes[name]; !ok {
+			continue
+		}
+
+		var value attr.Value
+		diags.Append(config.GetAttribute(ctx, path.Root(name), &value)...)
+		if value == nil || value.IsNull() {
+			continue
+		}
+
+		diags.AddAttributeError(
+			path.Root(name),
+			"unsupported attribute",
+			fmt.Sprintf("%s is not supported over %s, remove it or manage the file over another protocol.",
+				name, protocol.Description),
+		)
+	}
+}
+
+// validateExecSettings checks the values of the exec attribute of data, if set
+func validateExecSettings(data any, readOnly bool, diags *diag.Diagnostics) {
+	command := data.(exec.ModelSubset).GetExecCommand()
+	if command.IsNull() || command.IsUnknown() || strings.Contains(command.ValueString(), "{"+exec.ScriptPlaceholder+"}") {
+		return
+	}
+	diags.AddAttributeError(
+		path.Root("exec").AtName("command"),
+		"missing script placeholder",
+		fmt.Sprintf("exec.command must contain {%s}, where the script to run on the target goes, e.g. "+
+			"'docker exec -i {host} sh -c {%s}'.", exec.ScriptPlaceholder, exec.ScriptPlaceholder),
+	)
+}
+
+// validateFtpSettings checks the values of the ftp attribute of data, if set
+func validateFtpSettings(data any, readOnly bool, diags *diag.Diagnostics) {
+	settings := data.(ftp.ModelSubset)
+	for name, value := range map[string]struct {
+		value     types.String
+		supported []string
+	}{
+		"mode": {settings.GetFtpMode(), ftp.Modes},
+		"tls":  {settings.GetFtpTls(), ftp.TLSModes},
+	} {
+		if value.value.IsNull() || value.value.IsUnknown() || slices.Contains(value.supported, value.value.ValueString()) {
+			continue
+		}
+		diags.AddAttributeError(
+			path.Root("ftp").AtName(name),
+			"unsupported ftp "+name,
+			fmt.Sprintf("ftp.%s must be one of %s, got %q.", name, strings.Join(value.supported, ", "), value.value.ValueString()),
+		)
+	}
+}
+
+// validateWebdavSettings checks the values of the webdav attribute of data, if
+// set
+func validateWebdavSettings(data any, readOnly bool, diags *diag.Diagnostics) {
+	scheme := data.(webdav.ModelSubset).GetWebdavScheme()
+	if scheme.IsNull() || scheme.IsUnknown() || slices.Contains(webdav.Schemes, scheme.ValueString()) {
+		return
+	}
+	diags.AddAttributeError(
+		path.Root("webdav").AtName("scheme"),
+		"unsupported webdav scheme",
+		fmt.Sprintf("webdav.scheme must be one of %s, got %q.", strings.Join(webdav.Schemes, ", "), scheme.ValueString()),
+	)
+}
+
+// webdavCredential names bearer_token if data authenticates with it
+func webdavCredential(data any) string {
+	if data.(webdav.ModelSubset).GetWebdavBearerToken().IsNull() {
+		return ""
+	}
+	return "bearer_token"
+}
+
+// validateS3Settings checks the values of the s3 attribute of data, if set.
+// content_type and metadata only apply to writes, readOnly rejects them.
+func validateS3Settings(data any, readOnly bool, diags *diag.Diagnostics) {
+	settings := data.(s3.ModelSubset)
+	scheme := settings.GetS3Scheme()
+	if !scheme.IsNull() && !scheme.IsUnknown() && !slices.Contains(s3.Schemes, scheme.ValueString()) {
+		diags.AddAttributeError(
+			path.Root("s3").AtName("scheme"),
+			"unsupported s3 scheme",
+			fmt.Sprintf("s3.scheme must be one of %s, got %q.", strings.Join(s3.Schemes, ", "), scheme.ValueString()),
+		)
+	}
+
+	if !readOnly {
+		return
+	}
+	for name, value := range map[string]attr.Value{
+		"content_type": settings.GetS3ContentType(),
+		"metadata":     settings.GetS3Metadata(),
+	} {
+		if value.IsNull() {
+			continue
+		}
+		diags.AddAttributeError(
+			path.Root("s3").AtName(name),
+			"unsupported attribute",
+			fmt.Sprintf("s3.%s only applies when writing an object, remove it.", name),
+		)
+	}
+}
+
+// validateAzureblobSettings checks the values of the azureblob attribute of
+// data, if set. block_size, content_type and metadata only apply to writes,
+// readOnly rejects them.
+func validateAzureblobSettings(data any, readOnly bool, diags *diag.Diagnostics) {
+	settings := data.(azureblob.ModelSubset)
+	scheme := settings.GetAzureblobScheme()
+	if !scheme.IsNull() && !scheme.IsUnknown() && !slices.Contains(azureblob.Schemes, scheme.ValueString()) {
+		diags.AddAttributeError(
+			path.Root("azureblob").AtName("scheme"),
+			"unsupported azureblob scheme",
+			fmt.Sprintf("azureblob.scheme must be one of %s, got %q.", strings.Join(azureblob.Schemes, ", "), scheme.ValueString()),
+		)
+	}
+
+	blockSize := settings.GetAzureblobBlockSize()
+	if !blockSize.IsNull() && !blockSize.IsUnknown() && (blockSize.ValueInt64() < 1 || blockSize.ValueInt64() > azureblob.MaxBlockSize) {
+		diags.AddAttributeError(
+			path.Root("azureblob").AtName("block_size"),
+			"invalid azureblob block size",
+			fmt.Sprintf("azureblob.block_size must be between 1 and %d bytes, got %d.", int64(azureblob.MaxBlockSize), blockSize.ValueInt64()),
+		)
+	}
+
+	if !readOnly {
+		return
+	}
+	for name, value := range map[string]attr.Value{
+		"block_size":   settings.GetAzureblobBlockSize(),
+		"content_type": settings.GetAzureblobContentType(),
+		"metadata":     settings.GetAzureblobMetadata(),
+	} {
+		if value.IsNull() {
+			continue
+		}
+		diags.AddAttributeError(
+			path.Root("azureblob").AtName(name),
+			"unsupported attribute",
+			fmt.Sprintf("azureblob.%s only applies when writing a blob, remove it.", name),
+		)
+	}
+}
+
+// azureblobCredential names sas_token if data authenticates with it
+func azureblobCredential(data any) string {
+	if data.(azureblob.ModelSubset).GetAzureblobSasToken().IsNull() {
+		return ""
+	}
+	return "sas_token"
+}
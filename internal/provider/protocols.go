@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/azureblob"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/exec"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/ftp"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/local"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/s3"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/connect"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/parameters"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/webdav"
+)
+
+func init() {
+	backend.Register(backend.Protocol{
+		Name:        "sftp",
+		Description: "SFTP",
+		Dialer:      sshDialer(connect.SftpDialer),
+	})
+	backend.Register(backend.Protocol{
+		Name:        "scp",
+		Description: "SCP",
+		Dialer:      sshDialer(connect.ScpDialer),
+		Unsupported: []string{"sudo", "sudo_password", "sudo_sftp_server"},
+	})
+	backend.Register(backend.Protocol{
+		Name:        "exec",
+		Description: "a command template",
+		Dialer:      execDialer,
+		Unsupported: []string{
+			"host_key", "password", "port", "private_key",
+			"sudo", "sudo_password", "sudo_sftp_server", "system_ssh_path", "use_system_ssh",
+		},
+		Settings: execSettings,
+	})
+	backend.Register(backend.Protocol{
+		Name:        "ftp",
+		Description: "FTP",
+		Dialer:      ftpDialer,
+		Unsupported: []string{
+			"host_key", "private_key", "system_ssh_path", "use_system_ssh",
+			"sudo", "sudo_password", "sudo_sftp_server", "owner", "group",
+			"validate_command", "on_create_command", "on_update_command", "on_delete_command",
+		},
+		Settings: ftpSettings,
+	})
+	backend.Register(backend.Protocol{
+		Name:        "webdav",
+		Description: "WebDAV",
+		Dialer:      webdavDialer,
+		Unsupported: []string{
+			"host_key", "private_key", "permissions", "system_ssh_path", "use_system_ssh",
+			"sudo", "sudo_password", "sudo_sftp_server", "owner", "group",
+			"validate_command", "on_create_command", "on_update_command", "on_delete_command",
+		},
+		Settings: webdavSettings,
+	})
+	// The connection attributes are ignored rather than unsupported, so a
+	// module can switch between a remote host and the local machine by
+	// changing only the resource type
+	backend.Register(backend.Protocol{
+		Name:        "local",
+		Description: "the local filesystem",
+		Dialer:      localDialer,
+		Unsupported: []string{"sudo", "sudo_password", "sudo_sftp_server"},
+	})
+	backend.Register(backend.Protocol{
+		Name:        "s3",
+		Description: "S3",
+		Dialer:      s3Dialer,
+		Unsupported: []string{
+			"host_key", "private_key", "permissions", "system_ssh_path", "use_system_ssh",
+			"sudo", "sudo_password", "sudo_sftp_server", "owner", "group",
+			"validate_command", "on_create_command", "on_update_command", "on_delete_command",
+		},
+		Settings: s3Settings,
+	})
+	backend.Register(backend.Protocol{
+		Name:        "azureblob",
+		Description: "Azure Blob Storage",
+		Dialer:      azureblobDialer,
+		Unsupported: []string{
+			"host_key", "private_key", "permissions", "system_ssh_path", "use_system_ssh",
+			"sudo", "sudo_password", "sudo_sftp_server", "owner", "group",
+			"validate_command", "on_create_command", "on_update_command", "on_delete_command",
+		},
+		Settings: azureblobSettings,
+	})
+}
+
+// sshDialer connects over SSH with the connection attributes of data and
+// transfers files with the dialer newDialer returns
+func sshDialer(newDialer func(connect.SshConnectionParameters) backend.Dialer) func(data any) (backend.Dialer, error) {
+	return func(data any) (backend.Dialer, error) {
+		sshModel, ok := data.(parameters.SshModelSubset)
+		if !ok {
+			return nil, fmt.Errorf("%T has no SSH connection attributes", data)
+		}
+
+		sshConnParams, err := parameters.CreateSSHConnectionParameters(sshModel)
+		if err != nil {
+			return nil, err
+		}
+
+		return newDialer(sshConnParams), nil
+	}
+}
+
+// execDialer runs scripts on the target with the command template and the
+// host and user of data
+func execDialer(data any) (backend.Dialer, error) {
+	execModel, ok := data.(exec.ModelSubset)
+	if !ok {
+		return nil, fmt.Errorf("%T has no exec settings", data)
+	}
+
+	params, err := exec.CreateConnectionParameters(execModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.Dialer(params), nil
+}
+
+// ftpDialer logs in to an FTP server with the connection attributes and the
+// ftp settings of data
+func ftpDialer(data any) (backend.Dialer, error) {
+	ftpModel, ok := data.(ftp.ModelSubset)
+	if !ok {
+		return nil, fmt.Errorf("%T has no FTP connection attributes", data)
+	}
+
+	params, err := ftp.CreateConnectionParameters(ftpModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return ftp.Dialer(params), nil
+}
+
+// webdavDialer reaches a WebDAV server with the connection attributes and
+// the webdav settings of data
+func webdavDialer(data any) (backend.Dialer, error) {
+	webdavModel, ok := data.(webdav.ModelSubset)
+	if !ok {
+		return nil, fmt.Errorf("%T has no WebDAV connection attributes", data)
+	}
+
+	params, err := webdav.CreateConnectionParameters(webdavModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return webdav.Dialer(params), nil
+}
+
+// localDialer accesses the filesystem of the machine running Terraform, data
+// has nothing to connect with
+func localDialer(data any) (backend.Dialer, error) {
+	return local.Dialer(), nil
+}
+
+// s3Dialer signs requests to an S3-compatible endpoint with the connection
+// attributes and the s3 settings of data
+func s3Dialer(data any) (backend.Dialer, error) {
+	s3Model, ok := data.(s3.ModelSubset)
+	if !ok {
+		return nil, fmt.Errorf("%T has no S3 connection attributes", data)
+	}
+
+	params, err := s3.CreateConnectionParameters(s3Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.Dialer(params), nil
+}
+
+// azureblobDialer authorizes requests to an Azure Blob Storage account with
+// the connection attributes and the azureblob settings of data
+func azureblobDialer(data any) (backend.Dialer, error) {
+	azureblobModel, ok := data.(azureblob.ModelSubset)
+	if !ok {
+		return nil, fmt.Errorf("%T has no Azure Blob Storage connection attributes", data)
+	}
+
+	params, err := azureblob.CreateConnectionParameters(azureblobModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return azureblob.Dialer(params), nil
+}
@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+)
+
+// configValue returns config as a value of the schema with the required host
+// and path, the attributes missing from it are null
+func configValue(t *testing.T, schema *tfprotov6.Schema, config map[string]tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+
+	objectType := schema.ValueType().(tftypes.Object)
+	attributes := map[string]tftypes.Value{
+		"host": tftypes.NewValue(tftypes.String, "example.com"),
+		"path": tftypes.NewValue(tftypes.String, "file.txt"),
+	}
+	for name, attributeType := range objectType.AttributeTypes {
+		if _, ok := attributes[name]; !ok {
+			attributes[name] = tftypes.NewValue(attributeType, nil)
+		}
+		if value, ok := config[name]; ok {
+			attributes[name] = value
+		}
+	}
+	value, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, attributes))
+	if err != nil {
+		t.Fatalf("NewDynamicValue() error = %v", err)
+	}
+	return &value
+}
+
+// settingsValue returns the settings attribute of schema with the values of
+// settings, the settings missing from it are null
+func settingsValue(schema *tfprotov6.Schema, name string, settings map[string]tftypes.Value) tftypes.Value {
+	objectType := schema.ValueType().(tftypes.Object).AttributeTypes[name].(tftypes.Object)
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+		if value, ok := settings[name]; ok {
+			attributes[name] = value
+		}
+	}
+	return tftypes.NewValue(objectType, attributes)
+}
+
+func TestProtocolSettings(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("NewProtocol6WithError() error = %v", err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema() error = %v", err)
+	}
+
+	for _, protocol := range backend.Protocols() {
+		t.Run(protocol.Name, func(t *testing.T) {
+			typeName := "remotefile_" + protocol.Name
+			resourceSchema := schemas.ResourceSchemas[typeName]
+			dataSourceSchema := schemas.DataSourceSchemas[typeName]
+			ephemeralSchema := schemas.EphemeralResourceSchemas[typeName]
+
+			// Only the protocol's own settings are in its schemas
+			for _, other := range backend.Protocols() {
+				for kind, schema := range map[string]*tfprotov6.Schema{
+					"resource":           resourceSchema,
+					"data source":        dataSourceSchema,
+					"ephemeral resource": ephemeralSchema,
+				} {
+					_, ok := schema.ValueType().(tftypes.Object).AttributeTypes[other.Name]
+					if expected := other.Name == protocol.Name && protocol.Settings != nil; ok != expected {
+						t.Errorf("%s %s has attribute %s = %t, expected %t", typeName, kind, other.Name, ok, expected)
+					}
+				}
+			}
+			if protocol.Settings == nil {
+				return
+			}
+
+			// Configuring the settings decodes them into the models
+			resourceResp, err := server.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{
+				TypeName: typeName,
+				Config: configValue(t, resourceSchema, map[string]tftypes.Value{
+					"contents":    tftypes.NewValue(tftypes.String, "contents"),
+					protocol.Name: settingsValue(resourceSchema, protocol.Name, nil),
+				}),
+			})
+			if err != nil {
+				t.Fatalf("ValidateResourceConfig() error = %v", err)
+			}
+			for _, diagnostic := range resourceResp.Diagnostics {
+				t.Errorf("ValidateResourceConfig() diagnostic = %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			dataSourceResp, err := server.ValidateDataResourceConfig(ctx, &tfprotov6.ValidateDataResourceConfigRequest{
+				TypeName: typeName,
+				Config: configValue(t, dataSourceSchema, map[string]tftypes.Value{
+					protocol.Name: settingsValue(dataSourceSchema, protocol.Name, nil),
+				}),
+			})
+			if err != nil {
+				t.Fatalf("ValidateDataResourceConfig() error = %v", err)
+			}
+			for _, diagnostic := range dataSourceResp.Diagnostics {
+				t.Errorf("ValidateDataResourceConfig() diagnostic = %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			ephemeralResp, err := server.ValidateEphemeralResourceConfig(ctx, &tfprotov6.ValidateEphemeralResourceConfigRequest{
+				TypeName: typeName,
+				Config: configValue(t, ephemeralSchema, map[string]tftypes.Value{
+					protocol.Name: settingsValue(ephemeralSchema, protocol.Name, nil),
+				}),
+			})
+			if err != nil {
+				t.Fatalf("ValidateEphemeralResourceConfig() error = %v", err)
+			}
+			for _, diagnostic := range ephemeralResp.Diagnostics {
+				t.Errorf("ValidateEphemeralResourceConfig() diagnostic = %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+		})
+	}
+}
+
+func TestProtocolSettings_Validate(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("NewProtocol6WithError() error = %v", err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema() error = %v", err)
+	}
+	schema := schemas.DataSourceSchemas["remotefile_ftp"]
+
+	resp, err := server.ValidateDataResourceConfig(ctx, &tfprotov6.ValidateDataResourceConfigRequest{
+		TypeName: "remotefile_ftp",
+		Config: configValue(t, schema, map[string]tftypes.Value{
+			"ftp": settingsValue(schema, "ftp", map[string]tftypes.Value{
+				"mode": tftypes.NewValue(tftypes.String, "sideways"),
+			}),
+		}),
+	})
+	if err != nil {
+		t.Fatalf("ValidateDataResourceConfig() error = %v", err)
+	}
+	if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Summary != "unsupported ftp mode" {
+		t.Errorf("ValidateDataResourceConfig() diagnostics = %v, expected an unsupported ftp mode", resp.Diagnostics)
+	}
+}
+
+func TestProtocolCredential(t *testing.T) {
+	webdavProtocol, _ := backend.Lookup("webdav")
+	sftpProtocol, _ := backend.Lookup("sftp")
+
+	withToken := &model.WebdavResourceModel{}
+	withToken.Webdav = &model.WebdavModel{BearerToken: types.StringValue("token")}
+
+	tests := []struct {
+		name     string
+		protocol backend.Protocol
+		data     any
+		expected path.Path
+	}{
+		{"token set", webdavProtocol, withToken, path.Root("webdav").AtName("bearer_token")},
+		{"token not set", webdavProtocol, &model.WebdavResourceModel{}, path.Empty()},
+		{"no settings", sftpProtocol, &model.RemoteFileResourceModel{}, path.Empty()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protocolCredential(tt.protocol, tt.data); !got.Equal(tt.expected) {
+				t.Errorf("protocolCredential() = %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
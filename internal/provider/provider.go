@@ -4,14 +4,20 @@ import (
 	"context"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ provider.Provider = &sftpProvider{}
+	_ provider.Provider                       = &sftpProvider{}
+	_ provider.ProviderWithEphemeralResources = &sftpProvider{}
 )
 
 // New is a helper function to simplify provider server and testing implementation.
@@ -31,6 +37,13 @@ type sftpProvider struct {
 	version string
 }
 
+// providerData is handed to every data source and resource on Configure
+type providerData struct {
+	// retryPolicy is the provider-level retry behaviour, which data sources
+	// and resources may override attribute by attribute
+	retryPolicy retry.Policy
+}
+
 // Metadata returns the provider type name.
 func (p *sftpProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "remotefile"
@@ -39,23 +52,97 @@ func (p *sftpProvider) Metadata(_ context.Context, _ provider.MetadataRequest, r
 
 // Schema defines the provider-level schema for configuration data.
 func (p *sftpProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
-	resp.Schema = schema.Schema{}
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"retry_count": schema.Int64Attribute{
+				Description: "Default number of times to retry on failure",
+				Optional:    true,
+			},
+			"retry_interval": schema.StringAttribute{
+				Description: "Default time to wait between retries (e.g. '10s')",
+				Optional:    true,
+			},
+			"retry_max_interval": schema.StringAttribute{
+				Description: "Default upper bound for the time to wait between retries when a multiplier is set (e.g. '1m')",
+				Optional:    true,
+			},
+			"retry_multiplier": schema.Float64Attribute{
+				Description: "Default factor the wait between retries grows by after every retry, 1 keeps it fixed",
+				Optional:    true,
+			},
+			"retry_jitter": schema.Float64Attribute{
+				Description: "Default fraction (0 to 1) by which every wait between retries is randomized",
+				Optional:    true,
+			},
+			"retry_max_elapsed": schema.StringAttribute{
+				Description: "Default total time after which no further retries are attempted (e.g. '5m')",
+				Optional:    true,
+			},
+		},
+	}
 }
 
-// Configure prepares a HashiCups API client for data sources and resources.
+// Configure prepares the provider-level defaults for data sources and resources.
 func (p *sftpProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config model.ProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	retryPolicy, diags := buildRetryPolicy(defaultRetryPolicy, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := &providerData{
+		retryPolicy: retryPolicy,
+	}
+	resp.DataSourceData = data
+	resp.EphemeralResourceData = data
+	resp.ResourceData = data
 }
 
 // DataSources defines the data sources implemented in the provider.
 func (p *sftpProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{
-		NewRemoteFileDataSource,
+	var dataSources []func() datasource.DataSource
+
+	// Every registered protocol gets a data source reading whole files
+	for _, protocol := range backend.Protocols() {
+		dataSources = append(dataSources, NewRemoteFileDataSource(protocol))
 	}
+
+	return append(dataSources,
+		NewDecodedFileDataSource,
+		NewHttpFileDataSource,
+	)
 }
 
 // Resources defines the resources implemented in the provider.
 func (p *sftpProvider) Resources(_ context.Context) []func() resource.Resource {
-	return []func() resource.Resource{
-		NewRemoteFileResource,
+	var resources []func() resource.Resource
+
+	// Every registered protocol gets a resource managing whole files
+	for _, protocol := range backend.Protocols() {
+		resources = append(resources, NewRemoteFileResource(protocol))
 	}
+
+	return append(resources,
+		NewRemoteFileLineResource,
+		NewRemoteFileBlockResource,
+		NewRemoteFileKeysResource,
+	)
+}
+
+// EphemeralResources defines the ephemeral resources implemented in the provider.
+func (p *sftpProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	var ephemeralResources []func() ephemeral.EphemeralResource
+
+	// Every registered protocol gets an ephemeral resource reading whole files
+	for _, protocol := range backend.Protocols() {
+		ephemeralResources = append(ephemeralResources, NewRemoteFileEphemeralResource(protocol))
+	}
+
+	return ephemeralResources
 }
@@ -0,0 +1,23 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// run runs command on the host b is connected to, if b can run commands
+func run(ctx context.Context, b backend.Backend, command string) (backend.CommandResult, error) {
+	commander, ok := b.(backend.Commander)
+	if !ok {
+		return backend.CommandResult{}, fmt.Errorf("commands can't be run on %s", b.Address())
+	}
+	return commander.Run(ctx, command)
+}
+
+// ShellQuote quotes s as a single argument for a POSIX shell
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
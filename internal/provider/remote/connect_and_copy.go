@@ -1,20 +1,17 @@
-package connect
+package remote
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/pkg/sftp"
-	"golang.org/x/crypto/ssh"
-)
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-type SshConnectionParameters interface {
-	GetSshConfig() *ssh.ClientConfig
-	GetAddress() string
-}
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
 
 type InputModel interface {
 	GetPath() types.String
@@ -32,24 +29,27 @@ type OutputModel interface {
 	GetSize() types.Int64
 }
 
-func ConnectAndCopy(sshConnParams SshConnectionParameters, input InputModel, output OutputModel) func() error {
-	return func() error {
-		sshClient, err := ssh.Dial("tcp", sshConnParams.GetAddress(), sshConnParams.GetSshConfig())
-		if err != nil {
-			return fmt.Errorf("failed to connect to SSH server: %w", err)
-		}
-		defer sshClient.Close()
+// ConnectAndCopy creates an operation to read a remote file into output
+func ConnectAndCopy(dial backend.Dialer, input InputModel, output OutputModel) func(context.Context) error {
+	return func(ctx context.Context) (err error) {
+		defer func() { err = classifyError(err) }()
 
-		sftpClient, err := sftp.NewClient(sshClient)
+		b, err := dial(ctx)
 		if err != nil {
-			return fmt.Errorf("error creating SFTP client: %w", err)
+			return err
 		}
-		defer sftpClient.Close()
+		defer b.Close()
+		ctx = WithAddress(ctx, b.Address())
 
 		// Get file info and contents
-		fileInfo, err := sftpClient.Lstat(input.GetPath().ValueString())
+		start := time.Now()
+		fileInfo, err := b.Lstat(input.GetPath().ValueString())
 		if err != nil {
 			if input.GetAllowMissing().ValueBool() {
+				tflog.SubsystemDebug(ctx, SubsystemSFTP, "remote file missing, allowed by allow_missing", map[string]interface{}{
+					"path":  input.GetPath().ValueString(),
+					"error": err.Error(),
+				})
 				output.SetID(types.StringValue("missing"))
 				output.SetContents(types.StringValue(""))
 				output.SetLastModified(types.StringValue(time.Now().Format(time.RFC3339)))
@@ -59,18 +59,25 @@ func ConnectAndCopy(sshConnParams SshConnectionParameters, input InputModel, out
 			return fmt.Errorf("error reading remote file info: %w", err)
 		}
 
-		remoteFile, err := sftpClient.Open(input.GetPath().ValueString())
+		remoteFile, err := b.Open(input.GetPath().ValueString())
 		if err != nil {
 			return fmt.Errorf("error opening remote file: %w", err)
 		}
 		defer remoteFile.Close()
 
 		buffer := bytes.NewBuffer(nil)
-		_, err = io.Copy(buffer, remoteFile)
+		transferred, err := io.Copy(buffer, remoteFile)
 		if err != nil {
 			return fmt.Errorf("error reading remote file contents: %w", err)
 		}
 
+		tflog.SubsystemDebug(ctx, SubsystemSFTP, "read remote file", map[string]interface{}{
+			"path":        input.GetPath().ValueString(),
+			"bytes":       transferred,
+			"sha256":      contentHash(buffer.Bytes()),
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+
 		// Set model values
 		output.SetID(types.StringValue(fileInfo.Name()))
 		output.SetContents(types.StringValue(buffer.String()))
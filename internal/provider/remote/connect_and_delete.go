@@ -0,0 +1,69 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// DeleteInputModel interface defines the methods required for deleting a remote file
+type DeleteInputModel interface {
+	GetPath() types.String
+}
+
+// IsFileNotFound checks if the error is related to file not found
+func IsFileNotFound(err error) bool {
+	return errors.Is(classifyError(err), ErrNotFound)
+}
+
+// ConnectAndDelete creates an operation to delete a file from a remote server
+func ConnectAndDelete(dial backend.Dialer, input DeleteInputModel, opts ...DeleteOption) func(context.Context) error {
+	var options deleteOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(ctx context.Context) (err error) {
+		defer func() { err = classifyError(err) }()
+
+		b, err := dial(ctx)
+		if err != nil {
+			return err
+		}
+		defer b.Close()
+		ctx = WithAddress(ctx, b.Address())
+
+		// Delete the file
+		if installer, ok := b.(backend.Installer); ok {
+			err = installer.Uninstall(ctx, input.GetPath().ValueString())
+		} else {
+			err = b.Remove(input.GetPath().ValueString())
+		}
+		if err != nil {
+			// Check if the file is already gone
+			if IsFileNotFound(err) {
+				// File doesn't exist, that's fine for a delete operation
+				tflog.SubsystemDebug(ctx, SubsystemSFTP, "remote file already absent", map[string]interface{}{
+					"path": input.GetPath().ValueString(),
+				})
+				return nil
+			}
+			return fmt.Errorf("error deleting remote file: %w", err)
+		}
+
+		tflog.SubsystemDebug(ctx, SubsystemSFTP, "deleted remote file", map[string]interface{}{
+			"path": input.GetPath().ValueString(),
+		})
+
+		if options.hook != "" {
+			return runHook(ctx, b, options.hook, options.hookResult)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestIsFileNotFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "typed not found error",
+			err:      fmt.Errorf("error reading remote file info: %w", ErrNotFound),
+			expected: true,
+		},
+		{
+			name:     "os not exist error",
+			err:      fmt.Errorf("error deleting remote file: %w", os.ErrNotExist),
+			expected: true,
+		},
+		{
+			name:     "untyped message is not matched",
+			err:      fmt.Errorf("no such file or directory"),
+			expected: false,
+		},
+		{
+			name:     "permission denied error",
+			err:      fmt.Errorf("error deleting remote file: %w", os.ErrPermission),
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := IsFileNotFound(tc.err)
+			if result != tc.expected {
+				t.Errorf("IsFileNotFound() = %v, expected %v for error: %v", result, tc.expected, tc.err)
+			}
+		})
+	}
+}
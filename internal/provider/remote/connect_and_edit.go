@@ -0,0 +1,170 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// defaultEditMode is given to files an edit creates when no permissions are set
+const defaultEditMode os.FileMode = 0644
+
+// EditInputModel interface defines the methods required for editing a remote file
+type EditInputModel interface {
+	GetPath() types.String
+	// GetPermissions is only applied to files the edit creates, existing
+	// files keep their mode
+	GetPermissions() types.String
+}
+
+// EditFunc returns the new contents of a file given its current contents,
+// which are empty when the file doesn't exist yet. Returning the current
+// contents leaves the file untouched, as does returning empty contents for
+// a missing file. Errors are reported as ErrEdit, unless they already wrap
+// one of the other typed errors.
+type EditFunc func(current string, exists bool) (string, error)
+
+// ConnectAndEdit creates an operation that reads a remote file, applies edit
+// to its contents and writes the result back atomically. The new contents are
+// staged next to the file and only renamed into place if the file still
+// matches what edit was given, otherwise the operation fails with ErrConflict
+// and can be retried against the new contents.
+func ConnectAndEdit(dial backend.Dialer, input EditInputModel, edit EditFunc) func(context.Context) error {
+	return func(ctx context.Context) (err error) {
+		defer func() { err = classifyError(err) }()
+
+		b, err := dial(ctx)
+		if err != nil {
+			return err
+		}
+		defer b.Close()
+		ctx = WithAddress(ctx, b.Address())
+
+		targetPath := input.GetPath().ValueString()
+		start := time.Now()
+
+		current, exists, err := readIfExists(b, targetPath)
+		if err != nil {
+			return err
+		}
+
+		edited, err := edit(current, exists)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrEdit, err)
+		}
+
+		if edited == current {
+			tflog.SubsystemDebug(ctx, SubsystemSFTP, "remote file already up to date", map[string]interface{}{
+				"path": targetPath,
+			})
+			return nil
+		}
+
+		mode := defaultEditMode
+		if exists {
+			fileInfo, err := b.Stat(targetPath)
+			if err != nil {
+				return fmt.Errorf("error reading remote file info: %w", err)
+			}
+			mode = fileInfo.Mode().Perm()
+		} else if !input.GetPermissions().IsNull() && input.GetPermissions().ValueString() != "" {
+			modeStr := input.GetPermissions().ValueString()
+			modeInt, err := strconv.ParseUint(modeStr, 8, 32)
+			if err != nil {
+				return fmt.Errorf("error parsing file permissions %s: %w", modeStr, err)
+			}
+			mode = os.FileMode(modeInt)
+		}
+
+		err = writeAtomic(b, targetPath, []byte(edited), mode, func() error {
+			latest, stillExists, err := readIfExists(b, targetPath)
+			if err != nil {
+				return err
+			}
+			if stillExists != exists || latest != current {
+				return fmt.Errorf("%w: %s was modified while it was being edited", ErrConflict, targetPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		tflog.SubsystemDebug(ctx, SubsystemSFTP, "edited remote file", map[string]interface{}{
+			"path":        targetPath,
+			"created":     !exists,
+			"bytes":       len(edited),
+			"sha256":      contentHash([]byte(edited)),
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+
+		return nil
+	}
+}
+
+// readIfExists returns the contents of a remote file and whether it exists
+func readIfExists(b backend.Backend, targetPath string) (string, bool, error) {
+	contents, err := readRemoteFile(b, targetPath)
+	if err != nil {
+		if IsFileNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return contents, true, nil
+}
+
+// stagingPath returns the hidden sibling file an atomic write is staged in
+func stagingPath(target string) string {
+	dir, file := path.Split(target)
+	return dir + "." + file + ".tmp"
+}
+
+// writeAtomic stages contents with mode next to target and renames the staged
+// file into place once verify, if set, succeeds. The staged file takes the
+// owner and group of target, and is removed if anything fails before the
+// rename. A backend that can't replace target atomically fails.
+func writeAtomic(b backend.Backend, target string, contents []byte, mode os.FileMode, verify func() error) (err error) {
+	staged := stagingPath(target)
+	defer func() {
+		if err != nil {
+			_ = b.Remove(staged)
+		}
+	}()
+
+	stagedFile, err := b.OpenFile(staged, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("error creating staged file: %w", err)
+	}
+
+	_, err = stagedFile.Write(contents)
+	closeErr := stagedFile.Close()
+	if err != nil {
+		return fmt.Errorf("error writing to staged file: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("error closing staged file: %w", closeErr)
+	}
+
+	err = b.Chmod(staged, mode)
+	if err != nil {
+		return fmt.Errorf("error setting file permissions: %w", err)
+	}
+
+	if verify != nil {
+		err = verify()
+		if err != nil {
+			return err
+		}
+	}
+
+	return renameIntoPlace(b, staged, target, true)
+}
@@ -0,0 +1,314 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// WriteInputModel interface defines the methods required for writing to a remote file
+type WriteInputModel interface {
+	GetPath() types.String
+	GetContents() types.String
+	GetPermissions() types.String
+}
+
+// writeOptions holds the optional behaviour of ConnectAndWrite
+type writeOptions struct {
+	resumable bool
+	render    RenderFunc
+	validate  string
+
+	hook       string
+	hookResult *HookResult
+
+	owner string
+	group string
+}
+
+// WriteOption configures optional behaviour of ConnectAndWrite
+type WriteOption func(*writeOptions)
+
+// WithResumable stages the upload in a partial file next to the target so that
+// a retried operation continues from the bytes already transferred, renaming
+// the partial file into place once it is complete
+func WithResumable(resumable bool) WriteOption {
+	return func(o *writeOptions) {
+		o.resumable = resumable
+	}
+}
+
+// ConnectAndWrite creates an operation to write file content to a remote server
+func ConnectAndWrite(dial backend.Dialer, input WriteInputModel, output OutputModel, opts ...WriteOption) func(context.Context) error {
+	var options writeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Whether the write changes the file is only decided on the first attempt
+	// that gets to look, a retry after a write that succeeded would otherwise
+	// find the file unchanged and skip the hook
+	changed, compared := true, false
+
+	return func(ctx context.Context) (err error) {
+		defer func() { err = classifyError(err) }()
+
+		b, err := dial(ctx)
+		if err != nil {
+			return err
+		}
+		defer b.Close()
+		ctx = WithAddress(ctx, b.Address())
+
+		targetPath := input.GetPath().ValueString()
+		contentBytes := []byte(input.GetContents().ValueString())
+
+		if options.render != nil {
+			facts, err := gatherFacts(ctx, b, targetPath)
+			if err != nil {
+				return err
+			}
+
+			rendered, err := options.render(facts)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrRender, err)
+			}
+			contentBytes = []byte(rendered)
+		}
+
+		// Parse permission string (e.g., "0644") to os.FileMode up front so
+		// a bad value doesn't leave a half-written file behind
+		var mode os.FileMode
+		hasMode := !input.GetPermissions().IsNull() && input.GetPermissions().ValueString() != ""
+		if hasMode {
+			modeStr := input.GetPermissions().ValueString()
+			modeInt, err := strconv.ParseUint(modeStr, 8, 32)
+			if err != nil {
+				return fmt.Errorf("error parsing file permissions %s: %w", modeStr, err)
+			}
+			mode = os.FileMode(modeInt)
+		}
+
+		// Backends that write with elevated privileges install the contents
+		installer, installs := b.(backend.Installer)
+
+		// Hooks only follow an actual change, so note what was there before.
+		// A file only an installer can read is taken to change.
+		if options.hook != "" && !compared {
+			previous, existed, err := readIfExists(b, targetPath)
+			switch {
+			case err == nil:
+				changed = !existed || previous != string(contentBytes)
+			case !installs || !errors.Is(classifyError(err), ErrPermissionDenied):
+				return err
+			}
+			compared = true
+		}
+
+		start := time.Now()
+		switch {
+		case installs:
+			// The target may only be writable by the installer, so it is given
+			// the mode the target would otherwise have ended up with
+			if !hasMode {
+				mode = defaultEditMode
+				fileInfo, err := b.Stat(targetPath)
+				if err == nil {
+					mode = fileInfo.Mode().Perm()
+				} else if !IsFileNotFound(err) {
+					return fmt.Errorf("error reading remote file info: %w", err)
+				}
+			}
+
+			err = writeWithInstaller(ctx, b, installer, targetPath, contentBytes, mode, options)
+			if err != nil {
+				return err
+			}
+		case options.resumable:
+			// The partial file is moved over the target, so it keeps the mode
+			// of an existing target
+			keepMode := false
+			if !hasMode {
+				fileInfo, err := b.Stat(targetPath)
+				if err == nil {
+					mode, keepMode = fileInfo.Mode().Perm(), true
+				} else if !IsFileNotFound(err) {
+					return fmt.Errorf("error reading remote file info: %w", err)
+				}
+			}
+
+			partial, err := writeResumable(ctx, b, targetPath, contentBytes)
+			if err != nil {
+				return err
+			}
+
+			// Backends without permissions report the same mode for every
+			// file, so they are only asked to change a mode that differs
+			if keepMode {
+				fileInfo, err := b.Stat(partial)
+				if err != nil {
+					return fmt.Errorf("error reading partial file info: %w", err)
+				}
+				keepMode = fileInfo.Mode().Perm() != mode
+			}
+
+			if hasMode || keepMode {
+				err = b.Chmod(partial, mode)
+				if err != nil {
+					return fmt.Errorf("error setting file permissions: %w", err)
+				}
+			}
+
+			if options.validate != "" {
+				err = validateStaged(ctx, b, options.validate, partial)
+				if err != nil {
+					_ = b.Remove(partial)
+					return err
+				}
+			}
+
+			// Validated contents must never leave the target missing, a
+			// resumed upload on its own may replace it in two steps
+			err = renameIntoPlace(b, partial, targetPath, options.validate != "")
+			if err != nil {
+				return err
+			}
+		case options.validate != "":
+			// The contents have to be staged to be validated, the staged file
+			// gets the mode the target would have ended up with
+			if !hasMode {
+				mode = defaultEditMode
+				fileInfo, err := b.Stat(targetPath)
+				if err == nil {
+					mode = fileInfo.Mode().Perm()
+				} else if !IsFileNotFound(err) {
+					return fmt.Errorf("error reading remote file info: %w", err)
+				}
+			}
+
+			err = writeAtomic(b, targetPath, contentBytes, mode, func() error {
+				return validateStaged(ctx, b, options.validate, stagingPath(targetPath))
+			})
+			if err != nil {
+				return err
+			}
+		default:
+			err = writeInPlace(b, targetPath, contentBytes)
+			if err != nil {
+				return err
+			}
+
+			if hasMode {
+				err = b.Chmod(targetPath, mode)
+				if err != nil {
+					return fmt.Errorf("error setting file permissions: %w", err)
+				}
+			}
+		}
+
+		// The installer already set the ownership
+		if !installs {
+			err = chown(b, targetPath, options.owner, options.group)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Get updated file info
+		fileInfo, err := b.Lstat(targetPath)
+		if err != nil {
+			return fmt.Errorf("error reading remote file info after write: %w", err)
+		}
+
+		tflog.SubsystemDebug(ctx, SubsystemSFTP, "wrote remote file", map[string]interface{}{
+			"path":        targetPath,
+			"bytes":       len(contentBytes),
+			"sha256":      contentHash(contentBytes),
+			"permissions": input.GetPermissions().ValueString(),
+			"resumable":   options.resumable,
+			"validated":   options.validate != "",
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+
+		// Set model values
+		output.SetID(types.StringValue(fileInfo.Name()))
+		output.SetContents(types.StringValue(string(contentBytes)))
+		output.SetLastModified(types.StringValue(fileInfo.ModTime().Format(time.RFC3339)))
+		output.SetSize(types.Int64Value(fileInfo.Size()))
+
+		if options.hook != "" && changed {
+			return runHook(ctx, b, options.hook, options.hookResult)
+		}
+
+		return nil
+	}
+}
+
+// writeInPlace creates or truncates the target and writes contents to it
+func writeInPlace(b backend.Backend, targetPath string, contents []byte) error {
+	// Create or overwrite the file
+	remoteFile, err := b.OpenFile(targetPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("error creating remote file: %w", err)
+	}
+
+	// Write the file contents, some backends only upload them on close
+	_, err = remoteFile.Write(contents)
+	closeErr := remoteFile.Close()
+	if err != nil {
+		return fmt.Errorf("error writing to remote file: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("error closing remote file: %w", closeErr)
+	}
+
+	return nil
+}
+
+// writeWithInstaller stages contents where installer says, validates them if
+// asked to and has installer move them over target
+func writeWithInstaller(ctx context.Context, b backend.Backend, installer backend.Installer, target string, contents []byte, mode os.FileMode, options writeOptions) error {
+	staged, cleanup, err := installer.Stage(target)
+	if err != nil {
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			cleanup()
+		}
+	}()
+
+	if options.resumable {
+		partial, err := writeResumable(ctx, b, staged, contents)
+		if err != nil {
+			// The next attempt resumes from the partial file
+			keep = true
+			return err
+		}
+		staged = partial
+	} else {
+		err := writeInPlace(b, staged, contents)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() { _ = b.Remove(staged) }()
+
+	if options.validate != "" {
+		err := validateStaged(ctx, b, options.validate, staged)
+		if err != nil {
+			return err
+		}
+	}
+
+	return installer.Install(ctx, staged, target, mode, options.owner, options.group)
+}
@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Errors returned by the remote operations wrap one of these, so callers can
+// tell failures apart with errors.Is rather than by inspecting messages.
+// Backends report protocol failures by wrapping them, or the os and syscall
+// errors they correspond to.
+var (
+	// ErrNotFound means the remote file or one of its parent directories does not exist
+	ErrNotFound = errors.New("remote file not found")
+	// ErrPermissionDenied means the user may not access the remote file
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrAuthFailed means the server rejected every authentication method offered
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrHostKeyMismatch means the server presented a host key that failed verification
+	ErrHostKeyMismatch = errors.New("SSH host key mismatch")
+	// ErrConnect means the server could not be reached or dropped the connection
+	ErrConnect = errors.New("unable to connect to server")
+	// ErrQuotaExceeded means the remote filesystem is out of space or quota
+	ErrQuotaExceeded = errors.New("remote quota exceeded")
+	// ErrRender means the contents could not be rendered from their template
+	ErrRender = errors.New("error rendering contents")
+	// ErrEdit means the edit of a remote file could not be applied to its contents
+	ErrEdit = errors.New("error editing contents")
+	// ErrConflict means the remote file changed between reading and writing it
+	ErrConflict = errors.New("remote file changed concurrently")
+	// ErrValidation means the validate command rejected the new contents
+	ErrValidation = errors.New("validation command failed")
+	// ErrHook means a command run after the remote file changed failed
+	ErrHook = errors.New("hook command failed")
+	// ErrSudo means sudo refused to run a command as root
+	ErrSudo = errors.New("sudo failed")
+	// ErrUnsupported means the server does not implement the requested operation
+	ErrUnsupported = errors.New("operation not supported by server")
+)
+
+var errorKinds = []error{
+	ErrNotFound,
+	ErrPermissionDenied,
+	ErrAuthFailed,
+	ErrHostKeyMismatch,
+	ErrConnect,
+	ErrQuotaExceeded,
+	ErrRender,
+	ErrEdit,
+	ErrConflict,
+	ErrValidation,
+	ErrHook,
+	ErrSudo,
+	ErrUnsupported,
+}
+
+// errorKind returns which of the typed errors err corresponds to, or nil
+func errorKind(err error) error {
+	// A context deadline also satisfies net.Error, it is not a connect failure
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, os.ErrPermission):
+		return ErrPermissionDenied
+	case errors.Is(err, syscall.EDQUOT), errors.Is(err, syscall.ENOSPC):
+		return ErrQuotaExceeded
+	case errors.Is(err, errors.ErrUnsupported):
+		return ErrUnsupported
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrConnect
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrConnect
+	}
+
+	return nil
+}
+
+// classifyError wraps err with the typed error it corresponds to, if any
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := errorKind(err)
+	if kind == nil || errors.Is(err, kind) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", kind, err)
+}
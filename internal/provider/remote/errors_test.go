@@ -0,0 +1,75 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "os not exist",
+			err:      fmt.Errorf("error opening remote file: %w", os.ErrNotExist),
+			expected: ErrNotFound,
+		},
+		{
+			name:     "os permission",
+			err:      fmt.Errorf("error creating remote file: %w", os.ErrPermission),
+			expected: ErrPermissionDenied,
+		},
+		{
+			name:     "disk quota",
+			err:      fmt.Errorf("error writing to remote file: %w", syscall.EDQUOT),
+			expected: ErrQuotaExceeded,
+		},
+		{
+			name:     "unsupported",
+			err:      fmt.Errorf("error renaming remote file: %w", errors.ErrUnsupported),
+			expected: ErrUnsupported,
+		},
+		{
+			name:     "connection reset",
+			err:      fmt.Errorf("error reading remote file contents: %w", syscall.ECONNRESET),
+			expected: ErrConnect,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      context.DeadlineExceeded,
+			expected: nil,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("something unexpected"),
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			classified := classifyError(tc.err)
+
+			if !errors.Is(classified, tc.err) {
+				t.Errorf("classifyError() = %v, expected it to wrap %v", classified, tc.err)
+			}
+			if tc.expected == nil {
+				for _, kind := range errorKinds {
+					if errors.Is(classified, kind) {
+						t.Errorf("classifyError() = %v, expected no typed error", classified)
+					}
+				}
+				return
+			}
+			if !errors.Is(classified, tc.expected) {
+				t.Errorf("classifyError() = %v, expected it to wrap %v", classified, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// RemoteFacts describes the remote host and the file about to be written,
+// gathered over the same connection the write uses
+type RemoteFacts struct {
+	// Hostname is the output of `uname -n`, or the host connected to if the
+	// server doesn't allow running commands
+	Hostname string
+	// Exists is whether the target file exists before it is written
+	Exists bool
+	// Existing is the current contents of the target file, empty if missing
+	Existing string
+	// ReadFile reads another file over the connection
+	ReadFile func(path string) (string, error)
+}
+
+// RenderFunc produces the contents to write from the gathered facts
+type RenderFunc func(facts RemoteFacts) (string, error)
+
+// WithRender replaces the input contents with the output of render, which is
+// called with facts gathered over the connection right before writing
+func WithRender(render RenderFunc) WriteOption {
+	return func(o *writeOptions) {
+		o.render = render
+	}
+}
+
+// gatherFacts collects the facts about the remote host and targetPath that
+// are available to a RenderFunc
+func gatherFacts(ctx context.Context, b backend.Backend, targetPath string) (RemoteFacts, error) {
+	facts := RemoteFacts{
+		Hostname: remoteHostname(ctx, b),
+		ReadFile: func(path string) (string, error) {
+			return readRemoteFile(b, path)
+		},
+	}
+
+	existing, err := readRemoteFile(b, targetPath)
+	if err != nil && !IsFileNotFound(err) {
+		return RemoteFacts{}, err
+	}
+	facts.Exists = err == nil
+	facts.Existing = existing
+
+	tflog.SubsystemDebug(ctx, SubsystemSFTP, "gathered remote facts", map[string]interface{}{
+		"hostname": facts.Hostname,
+		"exists":   facts.Exists,
+	})
+
+	return facts, nil
+}
+
+// remoteHostname asks the remote host for its name, falling back to the host
+// part of its address when commands can't be run
+func remoteHostname(ctx context.Context, b backend.Backend) string {
+	fallback, _, err := net.SplitHostPort(b.Address())
+	if err != nil {
+		fallback = b.Address()
+	}
+
+	result, err := run(ctx, b, "uname -n")
+	hostname := strings.TrimSpace(result.Stdout)
+	if err != nil || result.ExitCode != 0 || hostname == "" {
+		tflog.SubsystemDebug(ctx, SubsystemSSH, "cannot run uname for hostname, using address", map[string]interface{}{
+			"error":     fmt.Sprint(err),
+			"exit_code": result.ExitCode,
+		})
+		return fallback
+	}
+
+	return hostname
+}
+
+// readRemoteFile returns the contents of path on the remote host
+func readRemoteFile(b backend.Backend, path string) (string, error) {
+	remoteFile, err := b.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening remote file %s: %w", path, err)
+	}
+	defer remoteFile.Close()
+
+	contents, err := io.ReadAll(remoteFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading remote file %s: %w", path, err)
+	}
+
+	return string(contents), nil
+}
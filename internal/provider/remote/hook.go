@@ -0,0 +1,69 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// HookResult is the outcome of a command run after a remote file changed
+type HookResult struct {
+	// Ran is whether the command ran, it only runs when the file changed
+	Ran bool
+	backend.CommandResult
+}
+
+// WithHook runs command over the same connection once the contents are in
+// place, if they differ from what the file held before, and stores its
+// outcome in result. A command that exits non-zero or can't be run fails the
+// operation with ErrHook, after the file has been written.
+func WithHook(command string, result *HookResult) WriteOption {
+	return func(o *writeOptions) {
+		o.hook = command
+		o.hookResult = result
+	}
+}
+
+// deleteOptions holds the optional behaviour of ConnectAndDelete
+type deleteOptions struct {
+	hook       string
+	hookResult *HookResult
+}
+
+// DeleteOption configures optional behaviour of ConnectAndDelete
+type DeleteOption func(*deleteOptions)
+
+// WithDeleteHook runs command over the same connection once the file has
+// been deleted, unless it was already gone, and stores its outcome in
+// result unless it is nil. A command that exits non-zero or can't be run fails the operation
+// with ErrHook.
+func WithDeleteHook(command string, result *HookResult) DeleteOption {
+	return func(o *deleteOptions) {
+		o.hook = command
+		o.hookResult = result
+	}
+}
+
+// runHook runs command and records its outcome in result
+func runHook(ctx context.Context, b backend.Backend, command string, result *HookResult) error {
+	commandResult, err := run(ctx, b, command)
+	if result != nil {
+		result.Ran = err == nil
+		result.CommandResult = commandResult
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrHook, err)
+	}
+
+	if commandResult.ExitCode != 0 {
+		output := strings.TrimSpace(commandResult.Stderr)
+		if output == "" {
+			output = strings.TrimSpace(commandResult.Stdout)
+		}
+		return fmt.Errorf("%w: %q exited with status %d: %s", ErrHook, command, commandResult.ExitCode, output)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// tflog subsystems the remote operations log to. The provider registers them
+// on the context, logging to a subsystem that wasn't registered is a no-op.
+const (
+	SubsystemSSH  = "ssh"
+	SubsystemSFTP = "sftp"
+)
+
+// WithAddress tags every ssh and sftp log entry made with the returned
+// context with the server address
+func WithAddress(ctx context.Context, address string) context.Context {
+	ctx = tflog.SubsystemSetField(ctx, SubsystemSSH, "address", address)
+	return tflog.SubsystemSetField(ctx, SubsystemSFTP, "address", address)
+}
+
+// contentHash identifies contents in logs without revealing them
+func contentHash(contents []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(contents))
+}
@@ -0,0 +1,91 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// WithOwnership sets the owner and group of the written file, each given as
+// a name or a numeric id. An empty owner or group is left as it is.
+func WithOwnership(owner string, group string) WriteOption {
+	return func(o *writeOptions) {
+		o.owner = owner
+		o.group = group
+	}
+}
+
+// chown changes the owner and group of path, resolving names against the
+// remote /etc/passwd and /etc/group
+func chown(b backend.Backend, path string, owner string, group string) error {
+	if owner == "" && group == "" {
+		return nil
+	}
+
+	fileInfo, err := b.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading remote file info: %w", err)
+	}
+	uid, gid, ok := ownership(fileInfo)
+	if !ok {
+		return fmt.Errorf("%s did not report the ownership of %s", b.Address(), path)
+	}
+
+	if owner != "" {
+		uid, err = lookupID(b, "/etc/passwd", owner)
+		if err != nil {
+			return err
+		}
+	}
+	if group != "" {
+		gid, err = lookupID(b, "/etc/group", group)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := b.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("error setting file ownership to %d:%d: %w", uid, gid, err)
+	}
+	return nil
+}
+
+// ownership returns the owner and group of the file described by fileInfo,
+// if the backend reported them
+func ownership(fileInfo os.FileInfo) (uid int, gid int, ok bool) {
+	if stat, ok := fileInfo.Sys().(backend.Owned); ok {
+		uid, gid = stat.Ownership()
+		return uid, gid, true
+	}
+	return 0, 0, false
+}
+
+// lookupID returns the id of name in database, /etc/passwd or /etc/group,
+// whose entries hold the id in their third field. Numeric names are ids.
+func lookupID(b backend.Backend, database string, name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+
+	entries, err := readRemoteFile(b, database)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range strings.Split(entries, "\n") {
+		fields := strings.Split(entry, ":")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid id for %s in %s: %w", name, database, err)
+		}
+		return id, nil
+	}
+
+	return 0, fmt.Errorf("%s not found in %s on the remote host", name, database)
+}
@@ -0,0 +1,166 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// partialPath returns the hidden sibling file a resumable upload is staged in
+func partialPath(target string) string {
+	dir, file := path.Split(target)
+	return dir + "." + file + ".partial"
+}
+
+// resumeOffset returns how many bytes of contents have already been
+// transferred to the partial file. A partial file whose prefix doesn't hash
+// to the same value as the start of contents is treated as unusable.
+func resumeOffset(b backend.Backend, partial string, contents []byte) (int64, error) {
+	fileInfo, err := b.Stat(partial)
+	if err != nil {
+		if IsFileNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error reading partial file info: %w", err)
+	}
+
+	size := fileInfo.Size()
+	if size == 0 || size > int64(len(contents)) {
+		return 0, nil
+	}
+
+	partialFile, err := b.Open(partial)
+	if err != nil {
+		return 0, fmt.Errorf("error opening partial file: %w", err)
+	}
+	defer partialFile.Close()
+
+	remoteHash := sha256.New()
+	if _, err := io.CopyN(remoteHash, partialFile, size); err != nil {
+		return 0, fmt.Errorf("error hashing partial file: %w", err)
+	}
+	localHash := sha256.Sum256(contents[:size])
+
+	if !bytes.Equal(remoteHash.Sum(nil), localHash[:]) {
+		return 0, nil
+	}
+
+	return size, nil
+}
+
+// writeResumable uploads contents to the partial file for target, continuing
+// from a previous attempt where possible, and returns the staged path. The
+// caller is responsible for renaming the partial file into place.
+func writeResumable(ctx context.Context, b backend.Backend, target string, contents []byte) (string, error) {
+	partial := partialPath(target)
+
+	offset, err := resumeOffset(b, partial, contents)
+	if err != nil {
+		return "", err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	partialFile, err := b.OpenFile(partial, flags)
+	if err != nil {
+		return "", fmt.Errorf("error opening partial file: %w", err)
+	}
+
+	// Files that can't seek are rewritten from the start
+	seeker, seekable := partialFile.(io.Seeker)
+	if offset > 0 && !seekable {
+		partialFile.Close()
+		offset = 0
+		partialFile, err = b.OpenFile(partial, flags|os.O_TRUNC)
+		if err != nil {
+			return "", fmt.Errorf("error opening partial file: %w", err)
+		}
+	}
+
+	if offset > 0 {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			partialFile.Close()
+			return "", fmt.Errorf("error seeking partial file to offset %d: %w", offset, err)
+		}
+	}
+
+	tflog.SubsystemDebug(ctx, SubsystemSFTP, "staging resumable upload", map[string]interface{}{
+		"path":         partial,
+		"resumed_from": offset,
+		"remaining":    int64(len(contents)) - offset,
+	})
+
+	_, err = partialFile.Write(contents[offset:])
+	closeErr := partialFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("error writing to partial file at offset %d: %w", offset, err)
+	}
+	if closeErr != nil {
+		return "", fmt.Errorf("error closing partial file: %w", closeErr)
+	}
+
+	return partial, nil
+}
+
+// renameIntoPlace moves a staged file over target
+func renameIntoPlace(b backend.Backend, staged string, target string, atomic bool) error {
+	err := keepOwnership(b, staged, target)
+	if err != nil {
+		return err
+	}
+
+	err = b.Rename(staged, target)
+	if errors.Is(err, errors.ErrUnsupported) && !atomic {
+		err = b.Remove(target)
+		if err != nil && !IsFileNotFound(err) {
+			return fmt.Errorf("error removing %s to replace it: %w", target, err)
+		}
+		err = b.Rename(staged, target)
+	}
+	if err != nil {
+		return fmt.Errorf("error renaming %s to %s: %w", staged, target, err)
+	}
+	return nil
+}
+
+// keepOwnership gives staged the owner and group of target, if it exists, so
+// replacing target doesn't hand it over to the user connecting
+func keepOwnership(b backend.Backend, staged string, target string) error {
+	targetInfo, err := b.Lstat(target)
+	if err != nil {
+		if IsFileNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading remote file info: %w", err)
+	}
+	uid, gid, ok := ownership(targetInfo)
+	if !ok {
+		return nil
+	}
+
+	stagedInfo, err := b.Lstat(staged)
+	if err != nil {
+		return fmt.Errorf("error reading staged file info: %w", err)
+	}
+	if stagedUID, stagedGID, ok := ownership(stagedInfo); ok && stagedUID == uid && stagedGID == gid {
+		return nil
+	}
+
+	err = b.Chown(staged, uid, gid)
+	if err != nil {
+		return fmt.Errorf("error giving the staged file the ownership %d:%d of %s: %w", uid, gid, target, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/local"
+)
+
+func TestPartialPath(t *testing.T) {
+	tests := map[string]string{
+		"file.txt":          ".file.txt.partial",
+		"/etc/app/file.txt": "/etc/app/.file.txt.partial",
+		"dir/file":          "dir/.file.partial",
+	}
+
+	for target, expected := range tests {
+		if got := partialPath(target); got != expected {
+			t.Errorf("partialPath(%q) = %q, expected %q", target, got, expected)
+		}
+	}
+}
+
+func TestResumeOffset(t *testing.T) {
+	dir := t.TempDir()
+	b, err := local.Dialer()(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	defer b.Close()
+	contents := []byte("0123456789abcdefghij")
+
+	tests := []struct {
+		name     string
+		partial  []byte
+		expected int64
+	}{
+		{
+			name:     "no partial file",
+			partial:  nil,
+			expected: 0,
+		},
+		{
+			name:     "matching prefix",
+			partial:  contents[:8],
+			expected: 8,
+		},
+		{
+			name:     "mismatched prefix",
+			partial:  []byte("XXXXXXXX"),
+			expected: 0,
+		},
+		{
+			name:     "partial longer than contents",
+			partial:  append(append([]byte{}, contents...), []byte("extra")...),
+			expected: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			partialFile := filepath.Join(dir, ".resume.txt.partial")
+			os.Remove(partialFile)
+			if tc.partial != nil {
+				if err := os.WriteFile(partialFile, tc.partial, 0644); err != nil {
+					t.Fatalf("Failed to create partial file: %v", err)
+				}
+			}
+
+			offset, err := resumeOffset(b, partialFile, contents)
+			if err != nil {
+				t.Fatalf("resumeOffset() error = %v, expected no error", err)
+			}
+			if offset != tc.expected {
+				t.Errorf("resumeOffset() = %d, expected %d", offset, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// IsRetryable reports whether err is a transient failure worth another
+// attempt. Missing files, authentication, host key, permission, quota,
+// template, edit, validation and sudo problems, and operations the server
+// doesn't support, will not resolve themselves between attempts, while dial
+// timeouts, dropped connections and concurrent edits usually do. Failed hooks
+// aren't retried either, the change they follow has already been made. Errors
+// that aren't recognised are retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	switch errorKind(err) {
+	case ErrConnect, ErrConflict:
+		return true
+	case ErrNotFound, ErrPermissionDenied, ErrAuthFailed, ErrHostKeyMismatch, ErrQuotaExceeded, ErrRender, ErrEdit, ErrValidation, ErrHook, ErrSudo,
+		ErrUnsupported:
+		return false
+	}
+
+	// Invalid configuration, such as unparseable permissions
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	_, numErr := strconv.ParseUint("invalid", 8, 32)
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "connection reset",
+			err:      fmt.Errorf("error reading remote file contents: %w", syscall.ECONNRESET),
+			expected: true,
+		},
+		{
+			name:     "connection refused",
+			err:      fmt.Errorf("failed to connect to SSH server: %w", syscall.ECONNREFUSED),
+			expected: true,
+		},
+		{
+			name:     "unexpected EOF",
+			err:      fmt.Errorf("ssh: handshake failed: %w", io.EOF),
+			expected: true,
+		},
+		{
+			name:     "authentication failure",
+			err:      fmt.Errorf("%w: ssh: handshake failed: ssh: unable to authenticate", ErrAuthFailed),
+			expected: false,
+		},
+		{
+			name:     "host key mismatch",
+			err:      fmt.Errorf("ssh: handshake failed: %w", fmt.Errorf("%w: ssh: host key mismatch", ErrHostKeyMismatch)),
+			expected: false,
+		},
+		{
+			name:     "concurrent edit",
+			err:      fmt.Errorf("%w: hosts was modified while it was being edited", ErrConflict),
+			expected: true,
+		},
+		{
+			name:     "template error",
+			err:      fmt.Errorf("%w: undefined variable", ErrRender),
+			expected: false,
+		},
+		{
+			name:     "validation failure",
+			err:      fmt.Errorf("%w: \"nginx -t\" exited with status 1", ErrValidation),
+			expected: false,
+		},
+		{
+			name:     "permission denied",
+			err:      fmt.Errorf("error creating remote file: %w", os.ErrPermission),
+			expected: false,
+		},
+		{
+			name:     "operation unsupported",
+			err:      fmt.Errorf("error setting file permissions: %w", ErrUnsupported),
+			expected: false,
+		},
+		{
+			name:     "invalid permissions",
+			err:      fmt.Errorf("error parsing file permissions invalid: %w", numErr),
+			expected: false,
+		},
+		{
+			name:     "cancelled",
+			err:      context.Canceled,
+			expected: false,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("something unexpected"),
+			expected: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := IsRetryable(tc.err); result != tc.expected {
+				t.Errorf("IsRetryable() = %v, expected %v for error: %v", result, tc.expected, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+)
+
+// ValidatePlaceholder is replaced with the path of the staged file in a
+// validate command
+const ValidatePlaceholder = "%s"
+
+// WithValidate runs command against the staged contents before they are
+// renamed into place, e.g. `nginx -t -c %s`. Every %s in command is replaced
+// with the quoted path of the staged file. If the command exits non-zero the
+// staged file is removed, the target is left untouched and the operation
+// fails with ErrValidation. A command that can't be run at all, e.g. because
+// the connection dropped, fails with that error instead, so it is retried.
+func WithValidate(command string) WriteOption {
+	return func(o *writeOptions) {
+		o.validate = command
+	}
+}
+
+// validateStaged runs the validate command against the staged file
+func validateStaged(ctx context.Context, b backend.Backend, command string, staged string) error {
+	command = strings.ReplaceAll(command, ValidatePlaceholder, ShellQuote(staged))
+
+	result, err := run(ctx, b, command)
+	if err != nil {
+		return err
+	}
+
+	if result.ExitCode != 0 {
+		output := strings.TrimSpace(result.Stderr)
+		if output == "" {
+			output = strings.TrimSpace(result.Stdout)
+		}
+		return fmt.Errorf("%w: %q exited with status %d: %s", ErrValidation, command, result.ExitCode, output)
+	}
+
+	return nil
+}
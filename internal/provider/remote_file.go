@@ -2,10 +2,14 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -13,49 +17,113 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
 	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/render"
 	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
 	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/connect"
-	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/sftp/parameters"
 )
 
 // Ensure the implementation satisfies the expected interfaces
 var (
-	_ resource.Resource              = &remoteFileResource{}
-	_ resource.ResourceWithConfigure = &remoteFileResource{}
+	_ resource.Resource                   = &remoteFileResource{}
+	_ resource.ResourceWithConfigure      = &remoteFileResource{}
+	_ resource.ResourceWithValidateConfig = &remoteFileResource{}
+	_ resource.ResourceWithModifyPlan     = &remoteFileResource{}
 )
 
-// NewRemoteFileResource is a helper function to simplify the provider implementation
-func NewRemoteFileResource() resource.Resource {
-	return &remoteFileResource{}
+// NewRemoteFileResource returns the constructor of the resource managing
+// whole files over protocol, named remotefile_<protocol>
+func NewRemoteFileResource(protocol backend.Protocol) func() resource.Resource {
+	return func() resource.Resource {
+		return &remoteFileResource{
+			protocol:    protocol,
+			retryPolicy: defaultRetryPolicy,
+		}
+	}
 }
 
+// defaultOperationTimeout bounds each CRUD operation, retries included, when
+// no timeouts block is configured
+const defaultOperationTimeout = 20 * time.Minute
+
 // remoteFileResource is the resource implementation
-type remoteFileResource struct{}
+type remoteFileResource struct {
+	protocol    backend.Protocol
+	retryPolicy retry.Policy
+}
 
-// Configure adds the provider configured client to the resource.
+// Configure adds the provider-level defaults to the resource.
 func (r *remoteFileResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	// Nothing to configure
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected resource configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.retryPolicy = data.retryPolicy
 }
 
 // Metadata returns the resource type name
 func (r *remoteFileResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
-	resp.TypeName = req.ProviderTypeName + "_sftp"
+	resp.TypeName = req.ProviderTypeName + "_" + r.protocol.Name
 }
 
 // Schema defines the schema for the resource
-func (r *remoteFileResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *remoteFileResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "Manages a file on a remote system using SFTP.",
+		Description: "Manages a file on a remote system using " + r.protocol.Description + ".",
 		Attributes: map[string]schema.Attribute{
 			"allow_missing": schema.BoolAttribute{
 				Description: "If true, missing remote files will not cause an error",
 				Optional:    true,
 			},
+			"command_exit_code": schema.Int64Attribute{
+				Description: "The exit code of the last hook command that ran",
+				Computed:    true,
+			},
+			"command_stderr": schema.StringAttribute{
+				Description: "The standard error of the last hook command that ran",
+				Computed:    true,
+			},
+			"command_stdout": schema.StringAttribute{
+				Description: "The standard output of the last hook command that ran",
+				Computed:    true,
+			},
 			"contents": schema.StringAttribute{
-				Description: "The file contents",
-				Required:    true,
+				Description: "The file contents, conflicts with contents_wo and template",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"contents_hash": schema.StringAttribute{
+				Description: "The SHA-256 hash of the file contents, compared against the remote file to detect drift",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"contents_wo": schema.StringAttribute{
+				Description: "The file contents, written without ever being stored in the plan or state, conflicts with contents and template",
+				Optional:    true,
 				Sensitive:   true,
+				WriteOnly:   true,
+			},
+			"contents_wo_version": schema.Int64Attribute{
+				Description: "Changing this rewrites the file from contents_wo, which Terraform cannot diff itself",
+				Optional:    true,
+			},
+			"group": schema.StringAttribute{
+				Description: "The group owning the file, by name or numeric id, which usually requires sudo",
+				Optional:    true,
 			},
 			"host": schema.StringAttribute{
 				Description: "The hostname",
@@ -69,6 +137,29 @@ func (r *remoteFileResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Description: "The last modified timestamp",
 				Computed:    true,
 			},
+			"on_command_failure": schema.StringAttribute{
+				Description: "What a failing hook command does, 'fail' (default) reports an error, which taints a newly " +
+					"created file, 'warn' only reports a warning",
+				Optional: true,
+			},
+			"on_create_command": schema.StringAttribute{
+				Description: "A command run on the remote host over the same connection after the file was created, " +
+					"unless it already held the contents",
+				Optional: true,
+			},
+			"on_delete_command": schema.StringAttribute{
+				Description: "A command run on the remote host over the same connection after the file was deleted",
+				Optional:    true,
+			},
+			"on_update_command": schema.StringAttribute{
+				Description: "A command run on the remote host over the same connection after the file contents " +
+					"changed (e.g. 'systemctl reload nginx')",
+				Optional: true,
+			},
+			"owner": schema.StringAttribute{
+				Description: "The user owning the file, by name or numeric id, which usually requires sudo",
+				Optional:    true,
+			},
 			"password": schema.StringAttribute{
 				Description: "The password",
 				Optional:    true,
@@ -94,10 +185,41 @@ func (r *remoteFileResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"resumable": schema.BoolAttribute{
+				Description: "If true, uploads are staged in a partial file next to the target and resumed from the bytes already transferred when retried, then renamed into place",
+				Optional:    true,
+			},
 			"size": schema.Int64Attribute{
 				Description: "The file size (in bytes)",
 				Computed:    true,
 			},
+			"sudo": schema.StringAttribute{
+				Description: "Escalate to root with sudo to write protected paths: 'install' uploads the file to /tmp and " +
+					"moves it into place with `sudo install`, 'sftp_server' runs the SFTP server as root with sudo. " +
+					"sudo must not require a TTY.",
+				Optional: true,
+			},
+			"sudo_password": schema.StringAttribute{
+				Description: "The password sudo asks for, defaults to password",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"sudo_sftp_server": schema.StringAttribute{
+				Description: "The path of the SFTP server started with sudo = 'sftp_server' (default '" + connect.DefaultSftpServer + "')",
+				Optional:    true,
+			},
+			"template": schema.StringAttribute{
+				Description: "A template the file contents are rendered from on the remote host, the rendered contents are not kept in state, only their hash, conflicts with contents and contents_wo",
+				Optional:    true,
+			},
+			"template_format": schema.StringAttribute{
+				Description: "The template syntax, 'go' for Go text/template (default) or 'terraform' for ${...} interpolation",
+				Optional:    true,
+			},
+			"system_ssh_path": schema.StringAttribute{
+				Description: "The ssh binary run with use_system_ssh, defaults to ssh from PATH",
+				Optional:    true,
+			},
 			"timeout": schema.StringAttribute{
 				Description: "The connect timeout",
 				Optional:    true,
@@ -107,10 +229,26 @@ func (r *remoteFileResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Optional:    true,
 				ElementType: types.StringType,
 			},
+			"use_system_ssh": schema.BoolAttribute{
+				Description: "Connect by running the system ssh binary instead of the built in client, which honours " +
+					"~/.ssh/config, ControlMaster, ProxyCommand and the agent. It can't authenticate with a password",
+				Optional: true,
+			},
 			"user": schema.StringAttribute{
 				Description: "The username",
 				Optional:    true,
 			},
+			"validate_command": schema.StringAttribute{
+				Description: "A command run on the remote host against the new contents before they replace the file, " +
+					"with %s standing for the path of the staged file (e.g. 'nginx -t -c %s' or 'visudo -cf %s'). " +
+					"If it exits non-zero the file is left untouched.",
+				Optional: true,
+			},
+			"vars": schema.MapAttribute{
+				Description: "Variables available to template",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
 			"id": schema.StringAttribute{
 				Description: "The ID of the remote file",
 				Computed:    true,
@@ -123,111 +261,378 @@ func (r *remoteFileResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Description: "Time to wait between retries (e.g. '10s')",
 				Optional:    true,
 			},
+			"retry_max_interval": schema.StringAttribute{
+				Description: "Upper bound for the time to wait between retries when a multiplier is set (e.g. '1m')",
+				Optional:    true,
+			},
+			"retry_multiplier": schema.Float64Attribute{
+				Description: "Factor the wait between retries grows by after every retry, 1 keeps it fixed",
+				Optional:    true,
+			},
+			"retry_jitter": schema.Float64Attribute{
+				Description: "Fraction (0 to 1) by which every wait between retries is randomized",
+				Optional:    true,
+			},
+			"retry_max_elapsed": schema.StringAttribute{
+				Description: "Total time after which no further retries are attempted (e.g. '5m')",
+				Optional:    true,
+			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+	if settings := r.protocol.Settings; settings != nil {
+		resp.Schema.Attributes[r.protocol.Name] = settingsResourceAttribute(settings)
+	}
+}
+
+// newModel returns an empty model of the resource, with the settings of its
+// protocol if it has any
+func (r *remoteFileResource) newModel() model.ResourceModel {
+	if r.protocol.Settings == nil {
+		return &model.RemoteFileResourceModel{}
+	}
+	return r.protocol.Settings.NewResourceModel().(model.ResourceModel)
+}
+
+// ValidateConfig ensures the file contents come from exactly one attribute
+// and that the protocol supports every attribute set
+func (r *remoteFileResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	protocolModel := r.newModel()
+	resp.Diagnostics.Append(req.Config.Get(ctx, protocolModel)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data := protocolModel.Shared()
+
+	validateSupported(ctx, r.protocol, req.Config, &resp.Diagnostics)
+	if settings := r.protocol.Settings; settings != nil {
+		settings.Validate(protocolModel, false, &resp.Diagnostics)
+	}
+
+	var sources []string
+	for name, value := range map[string]types.String{
+		"contents":    data.Contents,
+		"contents_wo": data.ContentsWo,
+		"template":    data.Template,
+	} {
+		if !value.IsNull() {
+			sources = append(sources, name)
+		}
+	}
+	slices.Sort(sources)
+
+	switch {
+	case len(sources) == 0:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("contents"),
+			"missing file contents",
+			"One of contents, contents_wo or template must be set.",
+		)
+	case len(sources) > 1:
+		resp.Diagnostics.AddAttributeError(
+			path.Root(sources[1]),
+			"conflicting file contents",
+			fmt.Sprintf("Only one of contents, contents_wo or template can be set, got %s.", strings.Join(sources, " and ")),
+		)
+	case !data.ContentsWo.IsNull() && data.ContentsWoVersion.IsNull():
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("contents_wo_version"),
+			"contents_wo without contents_wo_version",
+			"Terraform never sees the value of contents_wo in the plan, so changing it alone will not update "+
+				"the remote file. Set contents_wo_version and increment it whenever contents_wo changes.",
+		)
+	}
+
+	if data.Template.IsNull() {
+		for name, set := range map[string]bool{
+			"template_format": !data.TemplateFormat.IsNull(),
+			"vars":            !data.Vars.IsNull(),
+		} {
+			if set {
+				resp.Diagnostics.AddAttributeError(
+					path.Root(name),
+					"missing template",
+					fmt.Sprintf("%s only applies when template is set.", name),
+				)
+			}
+		}
+	}
+
+	format := data.TemplateFormat
+	if !format.IsNull() && !format.IsUnknown() && !slices.Contains(render.Formats, format.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("template_format"),
+			"unsupported template format",
+			fmt.Sprintf("template_format must be one of %s, got %q.", strings.Join(render.Formats, ", "), format.ValueString()),
+		)
+	}
+
+	failure := data.OnCommandFailure
+	if !failure.IsNull() && !failure.IsUnknown() && !slices.Contains(hookFailures, failure.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("on_command_failure"),
+			"unsupported command failure behaviour",
+			fmt.Sprintf("on_command_failure must be one of %s, got %q.", strings.Join(hookFailures, ", "), failure.ValueString()),
+		)
+	}
+
+	sudo := data.Sudo
+	if !sudo.IsNull() && !sudo.IsUnknown() && !slices.Contains(connect.SudoModes, sudo.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("sudo"),
+			"unsupported sudo mode",
+			fmt.Sprintf("sudo must be one of %s, got %q.", strings.Join(connect.SudoModes, ", "), sudo.ValueString()),
+		)
+	}
+	if sudo.IsNull() && !data.SudoPassword.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("sudo_password"),
+			"missing sudo",
+			"sudo_password only applies when sudo is set.",
+		)
+	}
+	if !sudo.IsUnknown() && sudo.ValueString() != connect.SudoSftpServer && !data.SudoSftpServer.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("sudo_sftp_server"),
+			"sudo_sftp_server without sudo = \"sftp_server\"",
+			fmt.Sprintf("sudo_sftp_server only applies when sudo is %q.", connect.SudoSftpServer),
+		)
+	}
+
+	validate := data.ValidateCommand
+	if !validate.IsNull() && !validate.IsUnknown() && !strings.Contains(validate.ValueString(), remote.ValidatePlaceholder) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("validate_command"),
+			"validate_command without file",
+			fmt.Sprintf("validate_command must reference the file to validate as %s, e.g. 'nginx -t -c %s'.",
+				remote.ValidatePlaceholder, remote.ValidatePlaceholder),
+		)
+	}
+}
+
+// ModifyPlan plans contents_hash, which is kept from state unless the file
+// is rewritten. A refresh that found the file no longer matching what was
+// last written from contents_wo or template leaves it unknown, so Terraform
+// plans the update rewriting the file.
+func (r *remoteFileResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing is planned on create or destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
 	}
+
+	planModel, stateModel := r.newModel(), r.newModel()
+	resp.Diagnostics.Append(req.Plan.Get(ctx, planModel)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, stateModel)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan, state := planModel.Shared(), stateModel.Shared()
+
+	switch {
+	case !plan.Contents.IsNull():
+		plan.ContentsHash = types.StringUnknown()
+		if !plan.Contents.IsUnknown() {
+			plan.ContentsHash = hashContents(plan.Contents)
+		}
+	case !req.Plan.Raw.Equal(req.State.Raw):
+		// The file is rewritten from contents the plan doesn't hold
+		plan.ContentsHash = types.StringUnknown()
+	default:
+		written, diags := writtenHash(ctx, req.Private)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() || written.IsNull() || written.Equal(state.ContentsHash) {
+			return
+		}
+
+		// Nothing else changed, so everything the rewrite sets is unknown
+		plan.ContentsHash = types.StringUnknown()
+		plan.LastModified = types.StringUnknown()
+		plan.Size = types.Int64Unknown()
+		plan.CommandStdout = types.StringUnknown()
+		plan.CommandStderr = types.StringUnknown()
+		plan.CommandExitCode = types.Int64Unknown()
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, planModel)...)
+}
+
+// remoteFileWriteOptions returns the options data configures for writing
+// the file
+func remoteFileWriteOptions(ctx context.Context, data *model.RemoteFileResourceModel) ([]remote.WriteOption, diag.Diagnostics) {
+	writeOptions := []remote.WriteOption{remote.WithResumable(data.Resumable.ValueBool())}
+
+	renderer, diags := templateRenderer(ctx, data)
+	if diags.HasError() {
+		return nil, diags
+	}
+	if renderer != nil {
+		writeOptions = append(writeOptions, remote.WithRender(renderer))
+	}
+
+	if command := data.ValidateCommand.ValueString(); command != "" {
+		writeOptions = append(writeOptions, remote.WithValidate(command))
+	}
+
+	if owner, group := data.Owner.ValueString(), data.Group.ValueString(); owner != "" || group != "" {
+		writeOptions = append(writeOptions, remote.WithOwnership(owner, group))
+	}
+
+	return writeOptions, diags
 }
 
 // Create creates the resource and sets the initial Terraform state
 func (r *remoteFileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	// Read Terraform plan data into the model
-	var data model.RemoteFileDataSourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	protocolModel := r.newModel()
+	resp.Diagnostics.Append(req.Plan.Get(ctx, protocolModel)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	data := protocolModel.Shared()
 
-	// Set default retry values if not specified
-	retryCount := int64(10)
-	if !data.RetryCount.IsNull() {
-		retryCount = data.RetryCount.ValueInt64()
+	ctx = withLogging(ctx, protocolModel)
+
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	retryInterval := 10 * time.Second
-	if !data.RetryInterval.IsNull() {
-		interval, err := time.ParseDuration(data.RetryInterval.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"invalid retry interval",
-				fmt.Sprintf("unable to parse retry interval: %s", err),
-			)
-			return
-		}
-		retryInterval = interval
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	retryPolicy, retryDiags := buildRetryPolicy(r.retryPolicy, data)
+	resp.Diagnostics.Append(retryDiags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	sshConnParams, err := parameters.CreateSSHConnectionParameters(&data)
+	dial, err := r.protocol.Dialer(protocolModel)
 	if err != nil {
 		resp.Diagnostics.AddError(
-			"error creating SSH connection parameters",
+			fmt.Sprintf("error creating %s connection parameters", r.protocol.Description),
 			err.Error(),
 		)
 		return
 	}
 
+	// Write-only contents are only ever present in the configuration
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &data.ContentsWo)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	contents := newContentsModel(data, data.Contents.IsNull())
+
+	writeOptions, diags := remoteFileWriteOptions(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var hook remote.HookResult
+	if command := data.OnCreateCommand.ValueString(); command != "" {
+		writeOptions = append(writeOptions, remote.WithHook(command, &hook))
+	}
+
 	// Write the file to the remote server
-	operation := connect.ConnectAndWrite(sshConnParams, &data, &data)
+	operation := remote.ConnectAndWrite(dial, contents, contents, writeOptions...)
 
 	// Wrap the entire operation in the retry logic
-	err = retry.WithRetry(retryCount, retryInterval, operation)
+	err = retry.Do(ctx, retryPolicy, operation)
+	if errors.Is(err, remote.ErrHook) {
+		// The file was written, so it is saved to state either way
+		addHookDiagnostic(&resp.Diagnostics, "on_create_command", data, err)
+		err = nil
+	}
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"error creating remote file",
-			err.Error(),
-		)
+		addOperationError(&resp.Diagnostics, "error creating remote file", err, data, protocolCredential(r.protocol, protocolModel))
 		return
 	}
 
+	// Only the hash of write-only or rendered contents is kept in state
+	data.ContentsHash = hashContents(contents.GetContents())
+	data.ContentsWo = types.StringNull()
+	resp.Diagnostics.Append(setWrittenHash(ctx, resp.Private, data.ContentsHash)...)
+
+	data.CommandStdout = types.StringNull()
+	data.CommandStderr = types.StringNull()
+	data.CommandExitCode = types.Int64Null()
+	setHookResult(data, hook)
+
 	// Generate an ID for the resource
 	data.ID = types.StringValue(fmt.Sprintf("%s:%s", data.Host.ValueString(), data.Path.ValueString()))
 
 	// Save data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, protocolModel)...)
 }
 
 // Read refreshes the Terraform state with the latest data
 func (r *remoteFileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	// Read Terraform prior state data into the model
-	var data model.RemoteFileDataSourceModel
-	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	protocolModel := r.newModel()
+	resp.Diagnostics.Append(req.State.Get(ctx, protocolModel)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	data := protocolModel.Shared()
+
+	ctx = withLogging(ctx, protocolModel)
 
-	// Set default retry values if not specified
-	retryCount := int64(10)
-	if !data.RetryCount.IsNull() {
-		retryCount = data.RetryCount.ValueInt64()
+	readTimeout, diags := data.Timeouts.Read(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	retryInterval := 10 * time.Second
-	if !data.RetryInterval.IsNull() {
-		interval, err := time.ParseDuration(data.RetryInterval.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"invalid retry interval",
-				fmt.Sprintf("unable to parse retry interval: %s", err),
-			)
-			return
-		}
-		retryInterval = interval
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	retryPolicy, retryDiags := buildRetryPolicy(r.retryPolicy, data)
+	resp.Diagnostics.Append(retryDiags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	sshConnParams, err := parameters.CreateSSHConnectionParameters(&data)
+	dial, err := r.protocol.Dialer(protocolModel)
 	if err != nil {
 		resp.Diagnostics.AddError(
-			"error creating SSH connection parameters",
+			fmt.Sprintf("error creating %s connection parameters", r.protocol.Description),
 			err.Error(),
 		)
 		return
 	}
 
+	// Files written from contents_wo or template have no contents in state,
+	// only their hash
+	hashOnly := data.Contents.IsNull() && !data.ContentsHash.IsNull()
+	contents := newContentsModel(data, hashOnly)
+
+	// State written before the hash was kept in private state still holds
+	// the hash of what was last written
+	written, diags := writtenHash(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if hashOnly && written.IsNull() {
+		resp.Diagnostics.Append(setWrittenHash(ctx, resp.Private, data.ContentsHash)...)
+		written = data.ContentsHash
+	}
+
 	// Read the file from the remote server
-	operation := connect.ConnectAndCopy(sshConnParams, &data, &data)
+	operation := remote.ConnectAndCopy(dial, data, contents)
 
 	// Wrap the entire operation in the retry logic
-	err = retry.WithRetry(retryCount, retryInterval, operation)
+	err = retry.Do(ctx, retryPolicy, operation)
 	if err != nil {
-		if connect.IsFileNotFound(err) {
+		if remote.IsFileNotFound(err) {
 			resp.Diagnostics.AddWarning(
 				"remote file not found",
 				fmt.Sprintf("remote file %s not found, removing from state", data.Path.ValueString()),
@@ -236,120 +641,164 @@ func (r *remoteFileResource) Read(ctx context.Context, req resource.ReadRequest,
 			return
 		}
 
-		resp.Diagnostics.AddError(
-			"error reading remote file",
-			err.Error(),
-		)
+		addOperationError(&resp.Diagnostics, "error reading remote file", err, data, protocolCredential(r.protocol, protocolModel))
 		return
 	}
 
+	remoteHash := hashContents(contents.GetContents())
+	if hashOnly && !remoteHash.Equal(written) && !data.ContentsHash.Equal(remoteHash) {
+		resp.Diagnostics.AddWarning(
+			"remote file changed outside of Terraform",
+			fmt.Sprintf("remote file %s no longer matches what was last written and will be rewritten on the next apply",
+				data.Path.ValueString()),
+		)
+	}
+	data.ContentsHash = remoteHash
+
 	// Save updated data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, protocolModel)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success
 func (r *remoteFileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	// Read Terraform plan data into the model
-	var data model.RemoteFileDataSourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	protocolModel := r.newModel()
+	resp.Diagnostics.Append(req.Plan.Get(ctx, protocolModel)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	data := protocolModel.Shared()
+
+	ctx = withLogging(ctx, protocolModel)
 
-	// Set default retry values if not specified
-	retryCount := int64(10)
-	if !data.RetryCount.IsNull() {
-		retryCount = data.RetryCount.ValueInt64()
+	updateTimeout, diags := data.Timeouts.Update(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	retryInterval := 10 * time.Second
-	if !data.RetryInterval.IsNull() {
-		interval, err := time.ParseDuration(data.RetryInterval.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"invalid retry interval",
-				fmt.Sprintf("unable to parse retry interval: %s", err),
-			)
-			return
-		}
-		retryInterval = interval
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	retryPolicy, retryDiags := buildRetryPolicy(r.retryPolicy, data)
+	resp.Diagnostics.Append(retryDiags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	sshConnParams, err := parameters.CreateSSHConnectionParameters(&data)
+	dial, err := r.protocol.Dialer(protocolModel)
 	if err != nil {
 		resp.Diagnostics.AddError(
-			"error creating SSH connection parameters",
+			fmt.Sprintf("error creating %s connection parameters", r.protocol.Description),
 			err.Error(),
 		)
 		return
 	}
 
+	// Write-only contents are only ever present in the configuration
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &data.ContentsWo)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	contents := newContentsModel(data, data.Contents.IsNull())
+
+	writeOptions, diags := remoteFileWriteOptions(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var hook remote.HookResult
+	if command := data.OnUpdateCommand.ValueString(); command != "" {
+		writeOptions = append(writeOptions, remote.WithHook(command, &hook))
+	}
+
 	// Write the file to the remote server
-	operation := connect.ConnectAndWrite(sshConnParams, &data, &data)
+	operation := remote.ConnectAndWrite(dial, contents, contents, writeOptions...)
 
 	// Wrap the entire operation in the retry logic
-	err = retry.WithRetry(retryCount, retryInterval, operation)
+	err = retry.Do(ctx, retryPolicy, operation)
+	if errors.Is(err, remote.ErrHook) {
+		// The file was written, so it is saved to state either way
+		addHookDiagnostic(&resp.Diagnostics, "on_update_command", data, err)
+		err = nil
+	}
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"error updating remote file",
-			err.Error(),
-		)
+		addOperationError(&resp.Diagnostics, "error updating remote file", err, data, protocolCredential(r.protocol, protocolModel))
 		return
 	}
 
+	// Only the hash of write-only or rendered contents is kept in state
+	data.ContentsHash = hashContents(contents.GetContents())
+	data.ContentsWo = types.StringNull()
+	resp.Diagnostics.Append(setWrittenHash(ctx, resp.Private, data.ContentsHash)...)
+
+	// The outcome of the last command that ran is kept until another runs
+	prior := r.newModel()
+	resp.Diagnostics.Append(req.State.Get(ctx, prior)...)
+	data.CommandStdout = prior.Shared().CommandStdout
+	data.CommandStderr = prior.Shared().CommandStderr
+	data.CommandExitCode = prior.Shared().CommandExitCode
+	setHookResult(data, hook)
+
 	// Save updated data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, protocolModel)...)
 }
 
 // Delete deletes the resource and removes the Terraform state on success
 func (r *remoteFileResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	// Read Terraform prior state data into the model
-	var data model.RemoteFileDataSourceModel
-	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	protocolModel := r.newModel()
+	resp.Diagnostics.Append(req.State.Get(ctx, protocolModel)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	data := protocolModel.Shared()
+
+	ctx = withLogging(ctx, protocolModel)
 
-	// Set default retry values if not specified
-	retryCount := int64(10)
-	if !data.RetryCount.IsNull() {
-		retryCount = data.RetryCount.ValueInt64()
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	retryInterval := 10 * time.Second
-	if !data.RetryInterval.IsNull() {
-		interval, err := time.ParseDuration(data.RetryInterval.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"invalid retry interval",
-				fmt.Sprintf("unable to parse retry interval: %s", err),
-			)
-			return
-		}
-		retryInterval = interval
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	retryPolicy, retryDiags := buildRetryPolicy(r.retryPolicy, data)
+	resp.Diagnostics.Append(retryDiags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	sshConnParams, err := parameters.CreateSSHConnectionParameters(&data)
+	dial, err := r.protocol.Dialer(protocolModel)
 	if err != nil {
 		resp.Diagnostics.AddError(
-			"error creating SSH connection parameters",
+			fmt.Sprintf("error creating %s connection parameters", r.protocol.Description),
 			err.Error(),
 		)
 		return
 	}
 
+	var deleteOptions []remote.DeleteOption
+	if command := data.OnDeleteCommand.ValueString(); command != "" {
+		deleteOptions = append(deleteOptions, remote.WithDeleteHook(command, nil))
+	}
+
 	// Delete the file from the remote server
-	operation := connect.ConnectAndDelete(sshConnParams, &data)
+	operation := remote.ConnectAndDelete(dial, data, deleteOptions...)
 
 	// Wrap the entire operation in the retry logic
-	err = retry.WithRetry(retryCount, retryInterval, operation)
+	err = retry.Do(ctx, retryPolicy, operation)
+	if errors.Is(err, remote.ErrHook) {
+		addHookDiagnostic(&resp.Diagnostics, "on_delete_command", data, err)
+		return
+	}
 	if err != nil {
 		// If the file doesn't exist, that's okay - we're deleting it anyway
-		if !connect.IsFileNotFound(err) {
-			resp.Diagnostics.AddError(
-				"error deleting remote file",
-				err.Error(),
-			)
+		if !remote.IsFileNotFound(err) {
+			addOperationError(&resp.Diagnostics, "error deleting remote file", err, data, protocolCredential(r.protocol, protocolModel))
 			return
 		}
 	}
@@ -372,4 +821,4 @@ func (r *remoteFileResource) ImportState(ctx context.Context, req resource.Impor
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("host"), host)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), filePath)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
-}
\ No newline at end of file
+}
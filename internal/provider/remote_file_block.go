@@ -0,0 +1,332 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/edit"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ resource.Resource              = &remoteFileBlockResource{}
+	_ resource.ResourceWithConfigure = &remoteFileBlockResource{}
+)
+
+// NewRemoteFileBlockResource is a helper function to simplify the provider implementation
+func NewRemoteFileBlockResource() resource.Resource {
+	return &remoteFileBlockResource{
+		retryPolicy: defaultRetryPolicy,
+	}
+}
+
+// remoteFileBlockResource manages a block of lines between marker lines in a
+// remote file. Changes go through remote.ConnectAndEdit rather than
+// ConnectAndCopy and ConnectAndWrite, so the file is read, has the block put
+// in and is renamed into place within one operation that notices concurrent
+// changes, instead of being overwritten with what an earlier read returned.
+type remoteFileBlockResource struct {
+	retryPolicy retry.Policy
+}
+
+// Configure adds the provider-level defaults to the resource.
+func (r *remoteFileBlockResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected resource configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.retryPolicy = data.retryPolicy
+}
+
+// Metadata returns the resource type name
+func (r *remoteFileBlockResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sftp_block"
+}
+
+// Schema defines the schema for the resource
+func (r *remoteFileBlockResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"content": schema.StringAttribute{
+			Description: "The lines between the markers",
+			Required:    true,
+		},
+		"marker_prefix": schema.StringAttribute{
+			Description: "What the marker lines start with, a comment in the file's syntax (default '#')",
+			Optional:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"name": schema.StringAttribute{
+			Description: "The name in the marker lines, unique within the file",
+			Required:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"path": schema.StringAttribute{
+			Description: "The file path",
+			Required:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"permissions": schema.StringAttribute{
+			Description: "The permissions of a file created to hold the block (e.g. '0644'), existing files keep theirs",
+			Optional:    true,
+		},
+		"id": schema.StringAttribute{
+			Description: "The ID of the block",
+			Computed:    true,
+		},
+	}
+	maps.Copy(attributes, connectionResourceAttributes())
+
+	resp.Schema = schema.Schema{
+		Description: "Manages a block of lines between '# BEGIN terraform managed: <name>' and '# END terraform managed: <name>' " +
+			"markers in a file on a remote system using SFTP, leaving the rest of the file untouched. The file is created if missing.",
+		Attributes: attributes,
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// blockOf returns the edit.Block described by data
+func blockOf(data *model.RemoteFileBlockResourceModel) edit.Block {
+	return edit.NewBlock(data.Name.ValueString(), data.Content.ValueString(), data.MarkerPrefix.ValueString())
+}
+
+// blockEdit returns the edit putting the block into a file
+func blockEdit(block edit.Block) remote.EditFunc {
+	return func(current string, _ bool) (string, error) {
+		edited, _, err := block.Ensure(current)
+		return edited, err
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state
+func (r *remoteFileBlockResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Read Terraform plan data into the model
+	var data model.RemoteFileBlockResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Edit the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, blockEdit(blockOf(&data)))
+
+	// Wrap the entire operation in the retry logic
+	err := retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = types.StringValue(fmt.Sprintf("%s:%s:%s", data.Host.ValueString(), data.Path.ValueString(), data.Name.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read refreshes the block content, or removes the resource from state when
+// the block is gone
+func (r *remoteFileBlockResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Read Terraform prior state data into the model
+	var data model.RemoteFileBlockResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	readTimeout, diags := data.Timeouts.Read(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	contents, _, err := readSnapshot(ctx, dial, retryPolicy, data.Path)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error reading remote file", err, &data, path.Empty())
+		return
+	}
+
+	content, found, err := blockOf(&data).Content(contents)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"error reading managed block",
+			fmt.Sprintf("The markers of block %q in %s are damaged, fix them on the host.\n\n%s",
+				data.Name.ValueString(), data.Path.ValueString(), err),
+		)
+		return
+	}
+
+	if !found {
+		resp.Diagnostics.AddWarning(
+			"managed block not found",
+			fmt.Sprintf("block %q not found in remote file %s, removing from state", data.Name.ValueString(), data.Path.ValueString()),
+		)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.Content = types.StringValue(refreshedBlockContent(data.Content.ValueString(), content))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// refreshedBlockContent returns the content to keep in state for a block
+// configured as configured and found holding content. Lines are compared
+// without the final line terminator, which the block always adds, so only
+// real changes show up as drift.
+func refreshedBlockContent(configured string, content string) string {
+	if strings.TrimSuffix(content, "\n") == strings.TrimSuffix(configured, "\n") {
+		return configured
+	}
+	if !strings.HasSuffix(configured, "\n") {
+		content = strings.TrimSuffix(content, "\n")
+	}
+	return content
+}
+
+// Update updates the resource and sets the updated Terraform state on success
+func (r *remoteFileBlockResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Read Terraform plan data into the model
+	var data model.RemoteFileBlockResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Edit the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, blockEdit(blockOf(&data)))
+
+	// Wrap the entire operation in the retry logic
+	err := retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = types.StringValue(fmt.Sprintf("%s:%s:%s", data.Host.ValueString(), data.Path.ValueString(), data.Name.ValueString()))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete removes the block and its markers from the remote file
+func (r *remoteFileBlockResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Read Terraform prior state data into the model
+	var data model.RemoteFileBlockResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Remove the block from the file on the remote server
+	block := blockOf(&data)
+	operation := remote.ConnectAndEdit(dial, &data, func(current string, _ bool) (string, error) {
+		edited, _, err := block.Remove(current)
+		return edited, err
+	})
+
+	// Wrap the entire operation in the retry logic
+	err := retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/edit"
+)
+
+func TestRefreshedBlockContent(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configured string
+		remote     string
+		expected   string
+	}{
+		{"unchanged", "listen 80", "# BEGIN terraform managed: web\nlisten 80\n# END terraform managed: web\n", "listen 80"},
+		{"unchanged with newline", "listen 80\n", "# BEGIN terraform managed: web\nlisten 80\n# END terraform managed: web\n", "listen 80\n"},
+		{"line changed", "listen 80", "# BEGIN terraform managed: web\nlisten 8080\n# END terraform managed: web\n", "listen 8080"},
+		{"line added", "listen 80\n", "# BEGIN terraform managed: web\nlisten 80\nlisten 443\n# END terraform managed: web\n", "listen 80\nlisten 443\n"},
+		{"emptied", "listen 80", "# BEGIN terraform managed: web\n# END terraform managed: web\n", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, found, err := edit.NewBlock("web", tc.configured, "#").Content("user nginx;\n" + tc.remote)
+			if err != nil || !found {
+				t.Fatalf("Content() = %q, %t, %v, expected the block to be found", content, found, err)
+			}
+
+			refreshed := refreshedBlockContent(tc.configured, content)
+			if refreshed != tc.expected {
+				t.Errorf("refreshedBlockContent() = %q, expected %q", refreshed, tc.expected)
+			}
+		})
+	}
+}
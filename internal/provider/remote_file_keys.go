@@ -0,0 +1,458 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/structured"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ resource.Resource                   = &remoteFileKeysResource{}
+	_ resource.ResourceWithConfigure      = &remoteFileKeysResource{}
+	_ resource.ResourceWithValidateConfig = &remoteFileKeysResource{}
+)
+
+// NewRemoteFileKeysResource is a helper function to simplify the provider implementation
+func NewRemoteFileKeysResource() resource.Resource {
+	return &remoteFileKeysResource{
+		retryPolicy: defaultRetryPolicy,
+	}
+}
+
+// remoteFileKeysResource manages individual keys of a structured remote file
+type remoteFileKeysResource struct {
+	retryPolicy retry.Policy
+}
+
+// Configure adds the provider-level defaults to the resource.
+func (r *remoteFileKeysResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected resource configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.retryPolicy = data.retryPolicy
+}
+
+// Metadata returns the resource type name
+func (r *remoteFileKeysResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sftp_keys"
+}
+
+// Schema defines the schema for the resource
+func (r *remoteFileKeysResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"create": schema.BoolAttribute{
+			Description: "If true, a missing file is created to hold the keys, otherwise a missing file is an error",
+			Optional:    true,
+		},
+		"format": schema.StringAttribute{
+			Description: "The file format, one of " + strings.Join(structured.PatchFormats, ", "),
+			Required:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"path": schema.StringAttribute{
+			Description: "The file path",
+			Required:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"permissions": schema.StringAttribute{
+			Description: "The permissions of a file created to hold the keys (e.g. '0644'), existing files keep theirs",
+			Optional:    true,
+		},
+		"remove": schema.SetAttribute{
+			Description: "Dotted paths of keys that must not be in the file",
+			Optional:    true,
+			ElementType: types.StringType,
+		},
+		"set": schema.MapAttribute{
+			Description: "Values by dotted path (e.g. 'server.port', or 'mysqld.max_connections' for a key in an INI section, " +
+				"escape dots within keys as '\\.'). Values that parse as JSON keep their type, so '8080' is a number and " +
+				"'\"8080\"' a string, anything else is a string. Keys dropped from set are removed from the file.",
+			Optional:    true,
+			ElementType: types.StringType,
+		},
+		"id": schema.StringAttribute{
+			Description: "The ID of the keys",
+			Computed:    true,
+		},
+	}
+	maps.Copy(attributes, connectionResourceAttributes())
+
+	resp.Schema = schema.Schema{
+		Description: "Manages individual keys of a JSON, YAML, TOML or INI file on a remote system using SFTP, leaving " +
+			"the rest of the file, and its comments where the format has them, untouched.",
+		Attributes: attributes,
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// ValidateConfig checks the format and that no path is both set and removed
+func (r *remoteFileKeysResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data model.RemoteFileKeysResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	format := data.Format
+	if !format.IsUnknown() && !slices.Contains(structured.PatchFormats, format.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("format"),
+			"unsupported file format",
+			fmt.Sprintf("format must be one of %s, got %q.", strings.Join(structured.PatchFormats, ", "), format.ValueString()),
+		)
+	}
+
+	if data.Set.IsNull() && data.Remove.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("set"),
+			"no keys to manage",
+			"At least one of set or remove must be given.",
+		)
+	}
+
+	if data.Set.IsUnknown() || data.Remove.IsUnknown() {
+		return
+	}
+	set, remove, diags := keysOf(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	for _, removed := range remove {
+		if _, ok := set[removed]; ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("remove"),
+				"conflicting keys",
+				fmt.Sprintf("%s cannot be both set and removed.", removed),
+			)
+		}
+	}
+}
+
+// keysOf returns the values to set by path and the paths to remove
+func keysOf(ctx context.Context, data *model.RemoteFileKeysResourceModel) (map[string]string, []string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	set := map[string]string{}
+	var remove []string
+
+	if !data.Set.IsNull() && !data.Set.IsUnknown() {
+		diags.Append(data.Set.ElementsAs(ctx, &set, false)...)
+	}
+	if !data.Remove.IsNull() && !data.Remove.IsUnknown() {
+		diags.Append(data.Remove.ElementsAs(ctx, &remove, false)...)
+	}
+
+	return set, remove, diags
+}
+
+// keysEdit returns the edit setting and removing keys in a file of format
+func keysEdit(data *model.RemoteFileKeysResourceModel, set map[string]string, remove []string) remote.EditFunc {
+	values := make(map[string]any, len(set))
+	for key, value := range set {
+		values[key] = structured.ParseValue(value)
+	}
+
+	return func(current string, exists bool) (string, error) {
+		if !exists && len(values) > 0 && !data.Create.ValueBool() {
+			return "", fmt.Errorf("%w: %s does not exist, set create to add the keys to a new file",
+				remote.ErrNotFound, data.Path.ValueString())
+		}
+		// Removing keys from a missing file leaves it missing, rather than
+		// creating an empty document
+		if !exists && len(values) == 0 {
+			return current, nil
+		}
+
+		return structured.Patch(data.Format.ValueString(), current, values, remove)
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state
+func (r *remoteFileKeysResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Read Terraform plan data into the model
+	var data model.RemoteFileKeysResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	set, remove, diags := keysOf(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Edit the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, keysEdit(&data, set, remove))
+
+	// Wrap the entire operation in the retry logic
+	err := retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = types.StringValue(fmt.Sprintf("%s:%s", data.Host.ValueString(), data.Path.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read refreshes the managed keys, leaving the rest of the file out of state
+func (r *remoteFileKeysResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Read Terraform prior state data into the model
+	var data model.RemoteFileKeysResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	readTimeout, diags := data.Timeouts.Read(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	contents, exists, err := readSnapshot(ctx, dial, retryPolicy, data.Path)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error reading remote file", err, &data, path.Empty())
+		return
+	}
+
+	if !exists {
+		resp.Diagnostics.AddWarning(
+			"remote file not found",
+			fmt.Sprintf("remote file %s not found, removing from state", data.Path.ValueString()),
+		)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	format := data.Format.ValueString()
+	document, err := structured.Decode(format, contents)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("path"),
+			"error reading remote file",
+			fmt.Sprintf("The remote file is no longer valid %s.\n\n%s", format, err),
+		)
+		return
+	}
+
+	set, remove, diags := keysOf(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only managed keys are compared, a key that drifted gets its current
+	// value, or is dropped when missing, so the next plan puts it back
+	if !data.Set.IsNull() {
+		current := map[string]string{}
+		for key, value := range set {
+			found, ok := structured.Lookup(document, structured.SplitPath(key))
+			switch {
+			case !ok:
+			case structured.ValueString(format, found) == structured.ValueString(format, structured.ParseValue(value)):
+				current[key] = value
+			default:
+				current[key] = structured.ValueString(format, found)
+			}
+		}
+
+		data.Set, diags = types.MapValueFrom(ctx, types.StringType, current)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	if !data.Remove.IsNull() {
+		var absent []string
+		for _, key := range remove {
+			if _, ok := structured.Lookup(document, structured.SplitPath(key)); !ok {
+				absent = append(absent, key)
+			}
+		}
+
+		data.Remove, diags = types.SetValueFrom(ctx, types.StringType, absent)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update updates the resource and sets the updated Terraform state on success
+func (r *remoteFileKeysResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Read Terraform plan data into the model
+	var data model.RemoteFileKeysResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var prior model.RemoteFileKeysResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	set, remove, diags := keysOf(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	priorSet, _, diags := keysOf(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Keys no longer set are no longer managed, and removed like on destroy
+	for key := range priorSet {
+		if _, ok := set[key]; !ok && !slices.Contains(remove, key) {
+			remove = append(remove, key)
+		}
+	}
+
+	// Edit the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, keysEdit(&data, set, remove))
+
+	// Wrap the entire operation in the retry logic
+	err := retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = types.StringValue(fmt.Sprintf("%s:%s", data.Host.ValueString(), data.Path.ValueString()))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete removes the keys in set from the remote file. Keys in remove stay
+// removed.
+func (r *remoteFileKeysResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Read Terraform prior state data into the model
+	var data model.RemoteFileKeysResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	set, _, diags := keysOf(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || len(set) == 0 {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	remove := make([]string, 0, len(set))
+	for key := range set {
+		remove = append(remove, key)
+	}
+	slices.Sort(remove)
+
+	// Remove the keys from the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, keysEdit(&data, nil, remove))
+
+	// Wrap the entire operation in the retry logic
+	err := retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/structured"
+)
+
+func TestKeysEditDeleteMissingFile(t *testing.T) {
+	for _, format := range structured.PatchFormats {
+		t.Run(format, func(t *testing.T) {
+			data := &model.RemoteFileKeysResourceModel{
+				Create: types.BoolValue(true),
+				Format: types.StringValue(format),
+				Path:   types.StringValue("/etc/app/config"),
+			}
+
+			// Delete removes every key the resource set
+			edited, err := keysEdit(data, nil, []string{"server.port"})("", false)
+			if err != nil {
+				t.Fatalf("keysEdit() error = %v", err)
+			}
+			if edited != "" {
+				t.Errorf("keysEdit() = %q, expected the missing file to be left missing", edited)
+			}
+		})
+	}
+}
+
+func TestKeysEditCreate(t *testing.T) {
+	data := &model.RemoteFileKeysResourceModel{
+		Create: types.BoolValue(true),
+		Format: types.StringValue(structured.FormatJSON),
+		Path:   types.StringValue("/etc/app/config.json"),
+	}
+
+	edited, err := keysEdit(data, map[string]string{"port": "8080"}, nil)("", false)
+	if err != nil {
+		t.Fatalf("keysEdit() error = %v", err)
+	}
+	if edited == "" {
+		t.Error("keysEdit() = \"\", expected create to add the keys to a new file")
+	}
+}
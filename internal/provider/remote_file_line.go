@@ -0,0 +1,438 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/edit"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+)
+
+// Values of the line resource's state attribute
+const (
+	lineStatePresent = "present"
+	lineStateAbsent  = "absent"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ resource.Resource                   = &remoteFileLineResource{}
+	_ resource.ResourceWithConfigure      = &remoteFileLineResource{}
+	_ resource.ResourceWithValidateConfig = &remoteFileLineResource{}
+)
+
+// NewRemoteFileLineResource is a helper function to simplify the provider implementation
+func NewRemoteFileLineResource() resource.Resource {
+	return &remoteFileLineResource{
+		retryPolicy: defaultRetryPolicy,
+	}
+}
+
+// remoteFileLineResource manages a single line in a remote file
+type remoteFileLineResource struct {
+	retryPolicy retry.Policy
+}
+
+// Configure adds the provider-level defaults to the resource.
+func (r *remoteFileLineResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Provider data is nil while Terraform validates the configuration
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"unexpected resource configure type",
+			fmt.Sprintf("expected *providerData, got %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.retryPolicy = data.retryPolicy
+}
+
+// Metadata returns the resource type name
+func (r *remoteFileLineResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sftp_line"
+}
+
+// Schema defines the schema for the resource
+func (r *remoteFileLineResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"create": schema.BoolAttribute{
+			Description: "If true, a missing file is created to hold the line, otherwise a missing file is an error",
+			Optional:    true,
+		},
+		"insert_after": schema.StringAttribute{
+			Description: "A regular expression, the line is added after the last line it matches when nothing matches regexp, or EOF to add it at the end of the file (default)",
+			Optional:    true,
+		},
+		"insert_before": schema.StringAttribute{
+			Description: "A regular expression, the line is added before the first line it matches when nothing matches regexp, or BOF to add it at the beginning of the file",
+			Optional:    true,
+		},
+		"line": schema.StringAttribute{
+			Description: "The full line, without a line terminator",
+			Required:    true,
+		},
+		"path": schema.StringAttribute{
+			Description: "The file path",
+			Required:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"permissions": schema.StringAttribute{
+			Description: "The permissions of a file created to hold the line (e.g. '0644'), existing files keep theirs",
+			Optional:    true,
+		},
+		"regexp": schema.StringAttribute{
+			Description: "A regular expression, the last line it matches is replaced by line when present, and every line it matches is removed when absent",
+			Optional:    true,
+		},
+		"state": schema.StringAttribute{
+			Description: "Whether the line should be 'present' (default) or 'absent'",
+			Optional:    true,
+		},
+		"id": schema.StringAttribute{
+			Description: "The ID of the line",
+			Computed:    true,
+		},
+	}
+	maps.Copy(attributes, connectionResourceAttributes())
+
+	resp.Schema = schema.Schema{
+		Description: "Manages a single line in a file on a remote system using SFTP, leaving the rest of the file untouched.",
+		Attributes:  attributes,
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// ValidateConfig checks the regular expressions and placement options
+func (r *remoteFileLineResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data model.RemoteFileLineResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for name, value := range map[string]types.String{
+		"regexp":        data.Regexp,
+		"insert_after":  data.InsertAfter,
+		"insert_before": data.InsertBefore,
+	} {
+		if value.IsNull() || value.IsUnknown() {
+			continue
+		}
+		if _, err := regexp.Compile(value.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"invalid regular expression",
+				err.Error(),
+			)
+		}
+	}
+
+	if !data.InsertAfter.IsNull() && !data.InsertBefore.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("insert_before"),
+			"conflicting line placement",
+			"Only one of insert_after or insert_before can be set.",
+		)
+	}
+
+	state := data.State
+	if !state.IsNull() && !state.IsUnknown() {
+		switch state.ValueString() {
+		case lineStatePresent:
+		case lineStateAbsent:
+			if !data.InsertAfter.IsNull() || !data.InsertBefore.IsNull() {
+				resp.Diagnostics.AddAttributeWarning(
+					path.Root("state"),
+					"line placement ignored",
+					"insert_after and insert_before only apply when state is present.",
+				)
+			}
+		default:
+			resp.Diagnostics.AddAttributeError(
+				path.Root("state"),
+				"invalid line state",
+				fmt.Sprintf("state must be %q or %q, got %q.", lineStatePresent, lineStateAbsent, state.ValueString()),
+			)
+		}
+	}
+}
+
+// lineOf returns the edit.Line described by data. When the line was changed
+// without a regexp, priorLine is matched instead so that it is replaced in
+// place rather than left behind.
+func lineOf(data *model.RemoteFileLineResourceModel, priorLine types.String) (edit.Line, error) {
+	expr := data.Regexp.ValueString()
+	if data.Regexp.IsNull() && !priorLine.IsNull() && !priorLine.Equal(data.Line) {
+		expr = "^" + regexp.QuoteMeta(priorLine.ValueString()) + "$"
+	}
+
+	return edit.NewLine(data.Line.ValueString(), expr, data.InsertAfter.ValueString(), data.InsertBefore.ValueString())
+}
+
+// lineAbsent reports whether data asks for the line to be removed
+func lineAbsent(data *model.RemoteFileLineResourceModel) bool {
+	return data.State.ValueString() == lineStateAbsent
+}
+
+// lineEdit returns the edit bringing a file to the state data describes
+func lineEdit(data *model.RemoteFileLineResourceModel, line edit.Line) remote.EditFunc {
+	return func(current string, exists bool) (string, error) {
+		if lineAbsent(data) {
+			edited, _ := line.Remove(current)
+			return edited, nil
+		}
+
+		if !exists && !data.Create.ValueBool() {
+			return "", fmt.Errorf("%w: %s does not exist, set create to add the line to a new file",
+				remote.ErrNotFound, data.Path.ValueString())
+		}
+
+		edited, _ := line.Ensure(current)
+		return edited, nil
+	}
+}
+
+// lineID identifies a line by the regexp that finds it, or by the line
+// itself, so several lines managed in one file get distinct IDs
+func lineID(data *model.RemoteFileLineResourceModel) types.String {
+	match := data.Line.ValueString()
+	if !data.Regexp.IsNull() {
+		match = data.Regexp.ValueString()
+	}
+	return types.StringValue(fmt.Sprintf("%s:%s:%s", data.Host.ValueString(), data.Path.ValueString(), match))
+}
+
+// Create creates the resource and sets the initial Terraform state
+func (r *remoteFileLineResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Read Terraform plan data into the model
+	var data model.RemoteFileLineResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	line, err := lineOf(&data, types.StringNull())
+	if err != nil {
+		resp.Diagnostics.AddError("invalid line", err.Error())
+		return
+	}
+
+	// Edit the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, lineEdit(&data, line))
+
+	// Wrap the entire operation in the retry logic
+	err = retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = lineID(&data)
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read removes the resource from state when the line is no longer as configured
+func (r *remoteFileLineResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Read Terraform prior state data into the model
+	var data model.RemoteFileLineResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	readTimeout, diags := data.Timeouts.Read(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	line, err := lineOf(&data, types.StringNull())
+	if err != nil {
+		resp.Diagnostics.AddError("invalid line", err.Error())
+		return
+	}
+
+	contents, exists, err := readSnapshot(ctx, dial, retryPolicy, data.Path)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error reading remote file", err, &data, path.Empty())
+		return
+	}
+
+	// A line that drifted is removed from state so the next apply puts it back
+	inSync := line.Absent(contents)
+	if !lineAbsent(&data) {
+		inSync = exists && line.Present(contents)
+	}
+	if !inSync {
+		resp.Diagnostics.AddWarning(
+			"remote line changed outside of Terraform",
+			fmt.Sprintf("remote file %s no longer matches the configured line, removing from state", data.Path.ValueString()),
+		)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update updates the resource and sets the updated Terraform state on success
+func (r *remoteFileLineResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Read Terraform plan data into the model
+	var data model.RemoteFileLineResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var priorLine types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("line"), &priorLine)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	line, err := lineOf(&data, priorLine)
+	if err != nil {
+		resp.Diagnostics.AddError("invalid line", err.Error())
+		return
+	}
+
+	// Edit the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, lineEdit(&data, line))
+
+	// Wrap the entire operation in the retry logic
+	err = retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+
+	data.ID = lineID(&data)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete removes the line from the remote file. Lines matched only by regexp
+// are left alone, and nothing is restored for a line that was absent.
+func (r *remoteFileLineResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Read Terraform prior state data into the model
+	var data model.RemoteFileLineResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if lineAbsent(&data) {
+		return
+	}
+
+	ctx = withLogging(ctx, &data)
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	dial, retryPolicy, diags := prepareConnection(r.retryPolicy, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	line, err := edit.NewLine(data.Line.ValueString(), "", "", "")
+	if err != nil {
+		resp.Diagnostics.AddError("invalid line", err.Error())
+		return
+	}
+
+	// Remove the line from the file on the remote server
+	operation := remote.ConnectAndEdit(dial, &data, func(current string, _ bool) (string, error) {
+		edited, _ := line.Remove(current)
+		return edited, nil
+	})
+
+	// Wrap the entire operation in the retry logic
+	err = retry.Do(ctx, retryPolicy, operation)
+	if err != nil {
+		addOperationError(&resp.Diagnostics, "error editing remote file", err, &data, path.Empty())
+		return
+	}
+}
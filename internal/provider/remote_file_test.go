@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// planRemoteFile plans remotefile_sftp from prior state with written as the
+// hash of the contents last written, and returns the planned attributes
+func planRemoteFile(t *testing.T, config map[string]tftypes.Value, state map[string]tftypes.Value, written string) map[string]tftypes.Value {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("NewProtocol6WithError() error = %v", err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema() error = %v", err)
+	}
+	objectType := schemas.ResourceSchemas["remotefile_sftp"].ValueType().(tftypes.Object)
+
+	// Attributes missing from values are null
+	dynamicValue := func(values map[string]tftypes.Value) *tfprotov6.DynamicValue {
+		attributes := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			attributes[name] = tftypes.NewValue(attributeType, nil)
+			if value, ok := values[name]; ok {
+				attributes[name] = value
+			}
+		}
+		value, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, attributes))
+		if err != nil {
+			t.Fatalf("NewDynamicValue() error = %v", err)
+		}
+		return &value
+	}
+
+	// Terraform proposes the configuration with computed attributes from state
+	proposed := map[string]tftypes.Value{}
+	for name, value := range state {
+		proposed[name] = value
+	}
+	for name, value := range config {
+		proposed[name] = value
+	}
+
+	var private []byte
+	if written != "" {
+		private = []byte(`{"written_hash":"` + base64.StdEncoding.EncodeToString([]byte(`"`+written+`"`)) + `"}`)
+	}
+
+	resp, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         "remotefile_sftp",
+		PriorState:       dynamicValue(state),
+		ProposedNewState: dynamicValue(proposed),
+		Config:           dynamicValue(config),
+		PriorPrivate:     private,
+	})
+	if err != nil {
+		t.Fatalf("PlanResourceChange() error = %v", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("PlanResourceChange() diagnostic = %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	planned, err := resp.PlannedState.Unmarshal(objectType)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	var attributes map[string]tftypes.Value
+	if err := planned.As(&attributes); err != nil {
+		t.Fatalf("As() error = %v", err)
+	}
+	return attributes
+}
+
+func TestRemoteFileModifyPlan(t *testing.T) {
+	str := func(value string) tftypes.Value { return tftypes.NewValue(tftypes.String, value) }
+	num := func(value int64) tftypes.Value { return tftypes.NewValue(tftypes.Number, value) }
+	unknown := tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+
+	state := func(extra map[string]tftypes.Value) map[string]tftypes.Value {
+		values := map[string]tftypes.Value{
+			"host":          str("example.com"),
+			"path":          str("/etc/app.conf"),
+			"id":            str("example.com:/etc/app.conf"),
+			"contents_hash": str("remote"),
+			"last_modified": str("2024-01-01T00:00:00Z"),
+			"size":          num(6),
+		}
+		for name, value := range extra {
+			values[name] = value
+		}
+		return values
+	}
+	config := func(extra map[string]tftypes.Value) map[string]tftypes.Value {
+		values := map[string]tftypes.Value{
+			"host": str("example.com"),
+			"path": str("/etc/app.conf"),
+		}
+		for name, value := range extra {
+			values[name] = value
+		}
+		return values
+	}
+
+	testCases := []struct {
+		name     string
+		config   map[string]tftypes.Value
+		state    map[string]tftypes.Value
+		written  string
+		expected tftypes.Value
+		rewrite  bool
+	}{
+		{
+			name:     "contents_wo unchanged",
+			config:   config(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			state:    state(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			written:  "remote",
+			expected: str("remote"),
+		},
+		{
+			name:     "contents_wo drifted",
+			config:   config(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			state:    state(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			written:  "written",
+			expected: unknown,
+			rewrite:  true,
+		},
+		{
+			name:     "contents_wo without written hash",
+			config:   config(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			state:    state(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			expected: str("remote"),
+		},
+		{
+			name:     "contents_wo_version changed",
+			config:   config(map[string]tftypes.Value{"contents_wo_version": num(2)}),
+			state:    state(map[string]tftypes.Value{"contents_wo_version": num(1)}),
+			written:  "remote",
+			expected: unknown,
+			rewrite:  true,
+		},
+		{
+			name:     "template unchanged",
+			config:   config(map[string]tftypes.Value{"template": str("{{ .vars.port }}")}),
+			state:    state(map[string]tftypes.Value{"template": str("{{ .vars.port }}")}),
+			written:  "remote",
+			expected: str("remote"),
+		},
+		{
+			name:     "template drifted",
+			config:   config(map[string]tftypes.Value{"template": str("{{ .vars.port }}")}),
+			state:    state(map[string]tftypes.Value{"template": str("{{ .vars.port }}")}),
+			written:  "written",
+			expected: unknown,
+			rewrite:  true,
+		},
+		{
+			name:     "contents changed",
+			config:   config(map[string]tftypes.Value{"contents": str("abc")}),
+			state:    state(map[string]tftypes.Value{"contents": str("abd")}),
+			expected: str("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"),
+			rewrite:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			planned := planRemoteFile(t, tc.config, tc.state, tc.written)
+
+			if !planned["contents_hash"].Equal(tc.expected) {
+				t.Errorf("planned contents_hash = %s, expected %s", planned["contents_hash"], tc.expected)
+			}
+			if rewrite := !planned["last_modified"].IsKnown(); rewrite != tc.rewrite {
+				t.Errorf("planned last_modified = %s, expected it to be unknown: %t", planned["last_modified"], tc.rewrite)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Supported template syntaxes
+const (
+	// FormatGo renders Go text/template templates
+	FormatGo = "go"
+	// FormatTerraform renders Terraform-style ${...} interpolations
+	FormatTerraform = "terraform"
+)
+
+// Formats lists the supported template syntaxes
+var Formats = []string{FormatGo, FormatTerraform}
+
+// Facts are gathered from the remote host while rendering
+type Facts struct {
+	// Hostname is the name the remote host reports for itself
+	Hostname string
+	// Exists is whether the target file exists before it is written
+	Exists bool
+	// Existing is the current contents of the target file, empty if missing
+	Existing string
+	// ReadFile reads another file from the remote host
+	ReadFile func(path string) (string, error)
+}
+
+// Data is what a Go template is executed against
+type Data struct {
+	Vars     map[string]string
+	Hostname string
+	Exists   bool
+	Existing string
+}
+
+// Render renders text in the given format with vars and facts
+func Render(format string, text string, vars map[string]string, facts Facts) (string, error) {
+	switch format {
+	case "", FormatGo:
+		return renderGo(text, vars, facts)
+	case FormatTerraform:
+		return renderTerraform(text, vars, facts)
+	default:
+		return "", fmt.Errorf("unsupported template format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// renderGo executes text as a Go template. Referencing a var that isn't set
+// is an error rather than rendering "<no value>".
+func renderGo(text string, vars map[string]string, facts Facts) (string, error) {
+	tmpl, err := template.New("template").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"remoteFile": readFileFunc(facts),
+		}).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, Data{
+		Vars:     vars,
+		Hostname: facts.Hostname,
+		Exists:   facts.Exists,
+		Existing: facts.Existing,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering template: %w", err)
+	}
+
+	return rendered.String(), nil
+}
+
+// remoteFileCall matches a remote_file("path") interpolation
+var remoteFileCall = regexp.MustCompile(`^remote_file\((".*")\)$`)
+
+// renderTerraform replaces every ${...} interpolation in text. Interpolations
+// may reference a var by name, a fact as facts.<name>, or read another remote
+// file with remote_file("path"). $${ and %%{ escape a literal ${ and %{, and
+// template directives (%{...}) are not supported.
+func renderTerraform(text string, vars map[string]string, facts Facts) (string, error) {
+	var rendered strings.Builder
+
+	for len(text) > 0 {
+		i := strings.IndexAny(text, "$%")
+		if i < 0 {
+			rendered.WriteString(text)
+			break
+		}
+		rendered.WriteString(text[:i])
+		text = text[i:]
+
+		switch {
+		case strings.HasPrefix(text, "$${"), strings.HasPrefix(text, "%%{"):
+			rendered.WriteString(text[1:3])
+			text = text[3:]
+		case strings.HasPrefix(text, "%{"):
+			return "", fmt.Errorf("template directives (%%{...}) are not supported, escape them as %%%%{")
+		case strings.HasPrefix(text, "${"):
+			end := interpolationEnd(text)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated interpolation %q", text)
+			}
+
+			value, err := interpolate(strings.TrimSpace(text[2:end]), vars, facts)
+			if err != nil {
+				return "", err
+			}
+			rendered.WriteString(value)
+			text = text[end+1:]
+		default:
+			rendered.WriteString(text[:1])
+			text = text[1:]
+		}
+	}
+
+	return rendered.String(), nil
+}
+
+// interpolationEnd returns the offset of the } closing the ${ text starts
+// with, skipping over quoted strings such as the path of remote_file("a}b"),
+// or -1 if it isn't closed
+func interpolationEnd(text string) int {
+	quoted := false
+	for i := 2; i < len(text); i++ {
+		switch {
+		case quoted && text[i] == '\\':
+			i++
+		case text[i] == '"':
+			quoted = !quoted
+		case !quoted && text[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// interpolate evaluates a single ${...} expression
+func interpolate(expr string, vars map[string]string, facts Facts) (string, error) {
+	if match := remoteFileCall.FindStringSubmatch(expr); match != nil {
+		path, err := strconv.Unquote(match[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid path in %s: %w", expr, err)
+		}
+		return readFileFunc(facts)(path)
+	}
+
+	switch expr {
+	case "facts.hostname":
+		return facts.Hostname, nil
+	case "facts.exists":
+		return strconv.FormatBool(facts.Exists), nil
+	case "facts.existing":
+		return facts.Existing, nil
+	}
+
+	value, ok := vars[expr]
+	if !ok {
+		return "", fmt.Errorf("undefined variable %q in ${%s}", expr, expr)
+	}
+	return value, nil
+}
+
+// readFileFunc returns facts.ReadFile, or a function reporting that remote
+// files can't be read when it is missing
+func readFileFunc(facts Facts) func(string) (string, error) {
+	if facts.ReadFile != nil {
+		return facts.ReadFile
+	}
+	return func(path string) (string, error) {
+		return "", fmt.Errorf("cannot read remote file %s while rendering", path)
+	}
+}
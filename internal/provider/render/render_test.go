@@ -0,0 +1,95 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testFacts() Facts {
+	return Facts{
+		Hostname: "web-1",
+		Exists:   true,
+		Existing: "old contents",
+		ReadFile: func(path string) (string, error) {
+			switch path {
+			case "/etc/machine-id":
+				return "abc123", nil
+			case `/srv/{app}/"id"`:
+				return "braced", nil
+			}
+			return "", fmt.Errorf("file does not exist: %s", path)
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	vars := map[string]string{"port": "8080", "name": "api"}
+
+	tests := []struct {
+		name     string
+		format   string
+		text     string
+		expected string
+	}{
+		{"go vars", FormatGo, "listen {{ .Vars.port }}", "listen 8080"},
+		{"go default format", "", "{{ .Vars.name }}", "api"},
+		{"go facts", FormatGo, "{{ .Hostname }} {{ .Exists }} {{ .Existing }}", "web-1 true old contents"},
+		{"go remote file", FormatGo, `{{ remoteFile "/etc/machine-id" }}`, "abc123"},
+		{"terraform vars", FormatTerraform, "listen ${port} for ${ name }", "listen 8080 for api"},
+		{"terraform facts", FormatTerraform, "${facts.hostname} ${facts.exists} ${facts.existing}", "web-1 true old contents"},
+		{"terraform remote file", FormatTerraform, `id=${remote_file("/etc/machine-id")}`, "id=abc123"},
+		{"terraform braces in paths", FormatTerraform, `id=${remote_file("/srv/{app}/\"id\"")}!`, "id=braced!"},
+		{"terraform escapes", FormatTerraform, "$${port} %%{if} 100% $HOME", "${port} %{if} 100% $HOME"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := Render(tt.format, tt.text, vars, testFacts())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rendered != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		text   string
+		errMsg string
+	}{
+		{"unknown format", "jinja", "{{ x }}", "unsupported template format"},
+		{"go parse error", FormatGo, "{{ .Vars.port ", "error parsing template"},
+		{"go missing var", FormatGo, "{{ .Vars.missing }}", "error rendering template"},
+		{"go missing remote file", FormatGo, `{{ remoteFile "/nope" }}`, "file does not exist"},
+		{"terraform missing var", FormatTerraform, "${missing}", `undefined variable "missing"`},
+		{"terraform unterminated", FormatTerraform, "${port", "unterminated interpolation"},
+		{"terraform unterminated string", FormatTerraform, `${remote_file("/srv/}`, "unterminated interpolation"},
+		{"terraform directive", FormatTerraform, "%{ if true }x%{ endif }", "not supported"},
+		{"terraform missing remote file", FormatTerraform, `${remote_file("/nope")}`, "file does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(tt.format, tt.text, map[string]string{"port": "8080"}, testFacts())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestRenderWithoutReadFile(t *testing.T) {
+	_, err := Render(FormatTerraform, `${remote_file("/etc/hosts")}`, nil, Facts{})
+	if err == nil || !strings.Contains(err.Error(), "cannot read remote file") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+}
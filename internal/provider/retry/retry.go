@@ -1,24 +1,153 @@
 package retry
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
 
-func WithRetry(retryCount int64, retryInterval time.Duration, operation func() error) error {
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Subsystem is the tflog subsystem retry decisions are logged to
+const Subsystem = "retry"
+
+// Policy describes how often and how long an operation is retried
+type Policy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int64
+	// InitialInterval is the wait before the first retry
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between attempts, zero means no cap
+	MaxInterval time.Duration
+	// Multiplier grows the wait after every retry, values below 1 are treated as 1
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction in either direction
+	Jitter float64
+	// MaxElapsed gives up once the next attempt would start after this much
+	// time has passed since the first one, zero means no deadline
+	MaxElapsed time.Duration
+	// Retryable decides whether an error is worth another attempt, nil
+	// retries every error
+	Retryable func(error) bool
+}
+
+// Interval returns the wait before the given retry (starting at zero),
+// without jitter applied
+func (p Policy) Interval(retry int64) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	interval := float64(p.InitialInterval)
+	for i := int64(0); i < retry; i++ {
+		interval *= multiplier
+		if p.MaxInterval > 0 && interval >= float64(p.MaxInterval) {
+			return p.MaxInterval
+		}
+	}
+
+	if p.MaxInterval > 0 && interval > float64(p.MaxInterval) {
+		return p.MaxInterval
+	}
+	return time.Duration(interval)
+}
+
+// jittered spreads interval by up to p.Jitter in either direction
+func (p Policy) jittered(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return interval
+	}
+	jitter := p.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	delta := (rand.Float64()*2 - 1) * jitter * float64(interval)
+	return interval + time.Duration(delta)
+}
+
+// Do runs operation until it succeeds, fails with an error the policy does not
+// consider retryable, runs out of retries or deadline, or ctx is done.
+// Cancelling ctx interrupts the wait between attempts, and the returned error
+// then wraps both ctx.Err() and the last failure.
+func Do(ctx context.Context, policy Policy, operation func(context.Context) error) error {
 	var lastErr error
+	start := time.Now()
+
+	for i := int64(0); i <= policy.MaxRetries; i++ {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, lastErr)
+		}
+
+		attemptCtx := tflog.SubsystemSetField(ctx, Subsystem, "attempt", i+1)
+		attemptStart := time.Now()
 
-	for i := int64(0); i <= retryCount; i++ {
-		err := operation()
+		err := operation(ctx)
 		if err == nil {
+			if i > 0 {
+				tflog.SubsystemDebug(attemptCtx, Subsystem, "operation succeeded after retrying", map[string]interface{}{
+					"elapsed_ms": time.Since(start).Milliseconds(),
+				})
+			}
 			return nil
 		}
 
 		lastErr = err
+		tflog.SubsystemDebug(attemptCtx, Subsystem, "attempt failed", map[string]interface{}{
+			"error":       err.Error(),
+			"duration_ms": time.Since(attemptStart).Milliseconds(),
+		})
 
-		if i == retryCount {
+		// An attempt torn down by cancellation is not worth retrying
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			tflog.SubsystemDebug(attemptCtx, Subsystem, "not retrying, context is done", map[string]interface{}{
+				"reason": ctxErr.Error(),
+			})
+			return errors.Join(ctxErr, lastErr)
+		}
+
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			tflog.SubsystemDebug(attemptCtx, Subsystem, "not retrying, error is permanent")
 			return lastErr
 		}
 
-		time.Sleep(retryInterval)
+		if i == policy.MaxRetries {
+			tflog.SubsystemDebug(attemptCtx, Subsystem, "not retrying, retries exhausted", map[string]interface{}{
+				"max_retries": policy.MaxRetries,
+			})
+			return lastErr
+		}
+
+		wait := policy.jittered(policy.Interval(i))
+		if policy.MaxElapsed > 0 && time.Since(start)+wait > policy.MaxElapsed {
+			tflog.SubsystemDebug(attemptCtx, Subsystem, "not retrying, retry deadline reached", map[string]interface{}{
+				"max_elapsed": policy.MaxElapsed.String(),
+			})
+			return fmt.Errorf("giving up after %s, retry deadline of %s reached: %w",
+				time.Since(start).Round(time.Millisecond), policy.MaxElapsed, lastErr)
+		}
+
+		tflog.SubsystemInfo(attemptCtx, Subsystem, "retrying after failure", map[string]interface{}{
+			"wait":  wait.String(),
+			"error": err.Error(),
+		})
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), lastErr)
+		case <-timer.C:
+		}
 	}
 
 	return lastErr
 }
+
+// WithRetry runs operation until it succeeds, it has been retried retryCount
+// times at a fixed retryInterval, or ctx is done
+func WithRetry(ctx context.Context, retryCount int64, retryInterval time.Duration, operation func(context.Context) error) error {
+	return Do(ctx, Policy{MaxRetries: retryCount, InitialInterval: retryInterval}, operation)
+}
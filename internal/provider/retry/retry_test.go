@@ -1,13 +1,14 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 )
 
 func TestWithRetrySuccessFirstTry(t *testing.T) {
-	err := WithRetry(3, time.Millisecond, func() error {
+	err := WithRetry(context.Background(), 3, time.Millisecond, func(context.Context) error {
 		return nil
 	})
 	if err != nil {
@@ -18,7 +19,7 @@ func TestWithRetrySuccessFirstTry(t *testing.T) {
 func TestWithRetrySuccessAfterRetries(t *testing.T) {
 	startTime := time.Now()
 	count := 0
-	err := WithRetry(3, time.Millisecond, func() error {
+	err := WithRetry(context.Background(), 3, time.Millisecond, func(context.Context) error {
 		if count < 2 {
 			count++
 			return errors.New("temporary error")
@@ -40,7 +41,7 @@ func TestWithRetrySuccessAfterRetries(t *testing.T) {
 func TestWithRetryFailureAfterAllRetries(t *testing.T) {
 	startTime := time.Now()
 	expectedErr := errors.New("persistent error")
-	err := WithRetry(2, time.Millisecond, func() error {
+	err := WithRetry(context.Background(), 2, time.Millisecond, func(context.Context) error {
 		return expectedErr
 	})
 
@@ -60,7 +61,7 @@ func TestWithRetryFailureAfterAllRetries(t *testing.T) {
 
 func TestWithRetryZeroRetries(t *testing.T) {
 	expectedErr := errors.New("immediate error")
-	err := WithRetry(0, time.Millisecond, func() error {
+	err := WithRetry(context.Background(), 0, time.Millisecond, func(context.Context) error {
 		return expectedErr
 	})
 
@@ -71,3 +72,163 @@ func TestWithRetryZeroRetries(t *testing.T) {
 		t.Errorf("expected error %v, got %v", expectedErr, err)
 	}
 }
+
+func TestWithRetryCancelledDuringWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	operationErr := errors.New("temporary error")
+	attempts := 0
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	startTime := time.Now()
+	err := WithRetry(ctx, 10, time.Hour, func(context.Context) error {
+		attempts++
+		return operationErr
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if !errors.Is(err, operationErr) {
+		t.Errorf("expected error to wrap the last failure, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+	if elapsed := time.Since(startTime); elapsed > time.Minute {
+		t.Errorf("cancellation did not interrupt the retry wait: %v", elapsed)
+	}
+}
+
+func TestWithRetryAlreadyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := WithRetry(ctx, 3, time.Millisecond, func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Error("operation should not run once the context is cancelled")
+	}
+}
+
+func TestPolicyIntervalFixed(t *testing.T) {
+	policy := Policy{InitialInterval: 10 * time.Second}
+	for i := int64(0); i < 5; i++ {
+		if got := policy.Interval(i); got != 10*time.Second {
+			t.Errorf("Interval(%d) = %v, expected %v", i, got, 10*time.Second)
+		}
+	}
+}
+
+func TestPolicyIntervalExponential(t *testing.T) {
+	policy := Policy{
+		InitialInterval: time.Second,
+		Multiplier:      2,
+		MaxInterval:     10 * time.Second,
+	}
+
+	expected := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	}
+	for i, want := range expected {
+		if got := policy.Interval(int64(i)); got != want {
+			t.Errorf("Interval(%d) = %v, expected %v", i, got, want)
+		}
+	}
+}
+
+func TestPolicyJitterStaysInBounds(t *testing.T) {
+	policy := Policy{Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		got := policy.jittered(time.Second)
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("jittered interval %v outside of [500ms, 1.5s]", got)
+		}
+	}
+}
+
+func TestDoStopsOnNonRetryableError(t *testing.T) {
+	permanentErr := errors.New("permission denied")
+	attempts := 0
+
+	err := Do(context.Background(), Policy{
+		MaxRetries:      5,
+		InitialInterval: time.Millisecond,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, permanentErr)
+		},
+	}, func(context.Context) error {
+		attempts++
+		return permanentErr
+	})
+
+	if !errors.Is(err, permanentErr) {
+		t.Errorf("expected %v, got %v", permanentErr, err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestDoRetriesRetryableErrors(t *testing.T) {
+	attempts := 0
+
+	err := Do(context.Background(), Policy{
+		MaxRetries:      5,
+		InitialInterval: time.Millisecond,
+		Retryable:       func(error) bool { return true },
+	}, func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDoGivesUpAtMaxElapsed(t *testing.T) {
+	operationErr := errors.New("timeout")
+	attempts := 0
+
+	startTime := time.Now()
+	err := Do(context.Background(), Policy{
+		MaxRetries:      100,
+		InitialInterval: 20 * time.Millisecond,
+		MaxElapsed:      50 * time.Millisecond,
+	}, func(context.Context) error {
+		attempts++
+		return operationErr
+	})
+
+	if !errors.Is(err, operationErr) {
+		t.Errorf("expected error to wrap %v, got %v", operationErr, err)
+	}
+	if attempts > 3 {
+		t.Errorf("expected the deadline to stop retries, got %d attempts", attempts)
+	}
+	if elapsed := time.Since(startTime); elapsed > time.Second {
+		t.Errorf("retries ran past the deadline: %v", elapsed)
+	}
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/retry"
+)
+
+// defaultRetryPolicy retries 10 times, 10 seconds apart, unless configured
+// otherwise on the provider or the data source/resource
+var defaultRetryPolicy = retry.Policy{
+	MaxRetries:      10,
+	InitialInterval: 10 * time.Second,
+	Multiplier:      1,
+	Retryable:       remote.IsRetryable,
+}
+
+// the subset of terraform schema fields that configure retries
+type retryModel interface {
+	GetRetryCount() types.Int64
+	GetRetryInterval() types.String
+	GetRetryMaxInterval() types.String
+	GetRetryMultiplier() types.Float64
+	GetRetryJitter() types.Float64
+	GetRetryMaxElapsed() types.String
+}
+
+// buildRetryPolicy overrides base with the retry attributes set on data
+func buildRetryPolicy(base retry.Policy, data retryModel) (retry.Policy, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	policy := base
+
+	if !data.GetRetryCount().IsNull() {
+		policy.MaxRetries = data.GetRetryCount().ValueInt64()
+		if policy.MaxRetries < 0 {
+			diags.AddAttributeError(
+				path.Root("retry_count"),
+				"invalid retry count",
+				fmt.Sprintf("retry count must not be negative, got %d", policy.MaxRetries),
+			)
+		}
+	}
+
+	parseDuration := func(attribute string, value types.String, target *time.Duration) {
+		if value.IsNull() || value.IsUnknown() {
+			return
+		}
+		duration, err := time.ParseDuration(value.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root(attribute),
+				"invalid "+attribute,
+				fmt.Sprintf("unable to parse %s: %s", attribute, err),
+			)
+			return
+		}
+		*target = duration
+	}
+	parseDuration("retry_interval", data.GetRetryInterval(), &policy.InitialInterval)
+	parseDuration("retry_max_interval", data.GetRetryMaxInterval(), &policy.MaxInterval)
+	parseDuration("retry_max_elapsed", data.GetRetryMaxElapsed(), &policy.MaxElapsed)
+
+	if !data.GetRetryMultiplier().IsNull() {
+		policy.Multiplier = data.GetRetryMultiplier().ValueFloat64()
+		if policy.Multiplier < 1 {
+			diags.AddAttributeError(
+				path.Root("retry_multiplier"),
+				"invalid retry multiplier",
+				fmt.Sprintf("retry multiplier must be at least 1, got %g", policy.Multiplier),
+			)
+		}
+	}
+
+	if !data.GetRetryJitter().IsNull() {
+		policy.Jitter = data.GetRetryJitter().ValueFloat64()
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			diags.AddAttributeError(
+				path.Root("retry_jitter"),
+				"invalid retry jitter",
+				fmt.Sprintf("retry jitter must be between 0 and 1, got %g", policy.Jitter),
+			)
+		}
+	}
+
+	return policy, diags
+}
@@ -0,0 +1,241 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// s3Backend is a bucket of an S3-compatible object store, paths are object
+// keys. Objects have no permissions or owners, and there are no commands to
+// run, so none of the attributes relying on them are available.
+//
+// last_modified and size come from HEAD, the contents from a GET sent with
+// If-Match set to the ETag HEAD returned, so an object replaced between both
+// requests fails with remote.ErrConflict and is read again rather than
+// stored with the properties of another version.
+type s3Backend struct {
+	// ctx is the one the backend was dialed with, the Backend methods don't
+	// take one
+	ctx     context.Context
+	client  *client
+	address string
+
+	// etags are the entity tags HEAD last returned, by key
+	etags map[string]string
+}
+
+var _ backend.Backend = &s3Backend{}
+
+// Dialer returns a Dialer for the bucket described by params. HTTP has no
+// session, so nothing is sent until the first operation, which reports a
+// missing bucket or rejected credentials.
+func Dialer(params *ConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		tflog.SubsystemDebug(ctx, Subsystem, "using S3 bucket", map[string]interface{}{
+			"url":           params.GetBucketURL().String(),
+			"region":        params.GetRegion(),
+			"access_key_id": params.GetAccessKeyID(),
+		})
+
+		return &s3Backend{
+			ctx:     ctx,
+			client:  newClient(params),
+			address: params.GetAddress(),
+			etags:   map[string]string{},
+		}, nil
+	}
+}
+
+func (b *s3Backend) Address() string {
+	return b.address
+}
+
+// Open starts downloading the object at path. It fails with
+// remote.ErrConflict if the object was replaced since the last Stat of path.
+func (b *s3Backend) Open(path string) (backend.File, error) {
+	key := objectKey(path)
+	header := http.Header{}
+	if etag := b.etags[key]; etag != "" {
+		header.Set("If-Match", etag)
+	}
+
+	response, err := b.client.do(b.ctx, http.MethodGet, key, nil, header, nil, http.StatusOK)
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) && responseErr.Status == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("%w: %s changed between reading its properties and its contents: %w",
+			remote.ErrConflict, path, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3File{body: response.Body}, nil
+}
+
+// OpenFile opens path for writing unless flag is os.O_RDONLY. The contents
+// are uploaded with a single PUT on close, with the configured content type
+// and metadata. Objects are always replaced as a whole, so appending isn't
+// supported.
+func (b *s3Backend) OpenFile(path string, flag int) (backend.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return b.Open(path)
+	}
+	if flag&os.O_APPEND != 0 {
+		return nil, errors.New("S3 can't append to objects")
+	}
+	return &s3File{backend: b, key: objectKey(path), upload: &bytes.Buffer{}}, nil
+}
+
+func (b *s3Backend) Stat(path string) (os.FileInfo, error) {
+	key := objectKey(path)
+	info, err := b.client.head(b.ctx, key)
+	if err != nil {
+		delete(b.etags, key)
+		return nil, err
+	}
+	b.etags[key] = info.ETag()
+	return info, nil
+}
+
+// Lstat is Stat, S3 has no symbolic links
+func (b *s3Backend) Lstat(path string) (os.FileInfo, error) {
+	return b.Stat(path)
+}
+
+func (b *s3Backend) Chmod(path string, mode os.FileMode) error {
+	return errors.New("S3 objects have no permissions")
+}
+
+func (b *s3Backend) Chown(path string, uid int, gid int) error {
+	return errors.New("S3 objects have no owner")
+}
+
+// Rename copies oldpath over newpath, keeping its content type and metadata,
+// and deletes oldpath. S3 has no atomic rename, but readers of newpath see
+// either object as a whole.
+func (b *s3Backend) Rename(oldpath string, newpath string) error {
+	oldKey, newKey := objectKey(oldpath), objectKey(newpath)
+	header := http.Header{
+		"X-Amz-Copy-Source":        {uriEncode("/"+b.client.params.GetBucket()+"/"+oldKey, false)},
+		"X-Amz-Metadata-Directive": {"COPY"},
+	}
+	response, err := b.client.do(b.ctx, http.MethodPut, newKey, nil, header, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	// a copy that fails after it started still answers 200, with an error
+	// document as body
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(data, []byte("<Error>")) {
+		return parseError(http.MethodPut, newKey, response.StatusCode, data)
+	}
+	delete(b.etags, newKey)
+
+	return b.Remove(oldpath)
+}
+
+// Remove deletes the object at path, deleting an object that doesn't exist
+// succeeds
+func (b *s3Backend) Remove(path string) error {
+	key := objectKey(path)
+	response, err := b.client.do(b.ctx, http.MethodDelete, key, nil, nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	delete(b.etags, key)
+	return response.Body.Close()
+}
+
+// Mkdir does nothing, directories only exist as the common prefix of keys
+func (b *s3Backend) Mkdir(path string) error {
+	return nil
+}
+
+func (b *s3Backend) ReadDir(path string) ([]os.FileInfo, error) {
+	infos, err := b.client.list(b.ctx, objectKey(path))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]os.FileInfo, len(infos))
+	for i, info := range infos {
+		entries[i] = info
+	}
+	return entries, nil
+}
+
+func (b *s3Backend) Close() error {
+	b.client.http.CloseIdleConnections()
+	return nil
+}
+
+// s3File is a download streaming the body of a GET, or an upload buffered
+// until it is sent with PUT on close
+type s3File struct {
+	body io.ReadCloser
+
+	backend *s3Backend
+	key     string
+	upload  *bytes.Buffer
+
+	closed bool
+}
+
+func (f *s3File) Read(p []byte) (int, error) {
+	if f.upload != nil {
+		return 0, errors.New("file was opened for writing")
+	}
+	return f.body.Read(p)
+}
+
+func (f *s3File) Write(p []byte) (int, error) {
+	if f.upload == nil {
+		return 0, errors.New("file was opened for reading")
+	}
+	return f.upload.Write(p)
+}
+
+// Close ends a download or sends the contents of an upload
+func (f *s3File) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if f.upload == nil {
+		return f.body.Close()
+	}
+
+	b := f.backend
+	header := http.Header{"Content-Type": {b.client.params.GetContentType()}}
+	for name, value := range b.client.params.GetMetadata() {
+		header.Set("X-Amz-Meta-"+strings.ToLower(name), value)
+	}
+
+	contents := f.upload.Bytes()
+	if contents == nil {
+		contents = []byte{}
+	}
+	response, err := b.client.do(b.ctx, http.MethodPut, f.key, nil, header, contents, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	delete(b.etags, f.key)
+	return response.Body.Close()
+}
@@ -0,0 +1,347 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend/backendtest"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// nullModel returns a model for host and the terraform bucket with every
+// other attribute null
+func nullModel(host string) *backendtest.Model {
+	data := backendtest.NullModel(host)
+	data.S3.Bucket = types.StringValue("terraform")
+	return data
+}
+
+// newTestModel returns a model signing requests to the bucket of server,
+// with nothing else set
+func newTestModel(server *testServer) *backendtest.Model {
+	host, port := server.host()
+	data := nullModel(host)
+	data.Port = types.Int64Value(port)
+	data.S3.Scheme = types.StringValue(SchemeHTTP)
+	data.Timeout = types.StringValue("5s")
+	data.S3.Bucket = types.StringValue(server.bucket)
+	data.User = types.StringValue(server.accessKey)
+	data.Password = types.StringValue(server.secretKey)
+	return data
+}
+
+// testDialer returns the dialer for data
+func testDialer(t *testing.T, data *backendtest.Model) func(context.Context) (*s3Backend, error) {
+	t.Helper()
+	return backendtest.Dialer[*s3Backend, ModelSubset](t, data, CreateConnectionParameters, Dialer)
+}
+
+func TestDialerRoundTrip(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/config/app v1.json")
+	data.Contents = types.StringValue(`{"environment":"production"}`)
+	data.S3.ContentType = types.StringValue("application/json")
+	data.S3.Metadata = types.MapValueMust(types.StringType, map[string]attr.Value{
+		"Team": types.StringValue("platform"),
+	})
+
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+	ctx := context.Background()
+
+	if err := remote.ConnectAndWrite(dial, data, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	object := server.object("config/app v1.json")
+	if object == nil {
+		t.Fatal("object was not written")
+	}
+	if string(object.data) != `{"environment":"production"}` {
+		t.Errorf("object = %q, expected the contents", object.data)
+	}
+	if object.contentType != "application/json" {
+		t.Errorf("content type = %q, expected application/json", object.contentType)
+	}
+	if object.metadata["team"] != "platform" {
+		t.Errorf("metadata = %v, expected team = platform", object.metadata)
+	}
+	if data.Size.ValueInt64() != int64(len(object.data)) {
+		t.Errorf("size after write = %d, expected %d", data.Size.ValueInt64(), len(object.data))
+	}
+
+	read := newTestModel(server)
+	read.Path = data.Path
+	if err := remote.ConnectAndCopy(dial, read, read)(ctx); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if read.Contents.ValueString() != data.Contents.ValueString() {
+		t.Errorf("contents = %q, expected %q", read.Contents.ValueString(), data.Contents.ValueString())
+	}
+	if read.ID.ValueString() != "app v1.json" {
+		t.Errorf("id = %q, expected %q", read.ID.ValueString(), "app v1.json")
+	}
+	if read.LastModified.ValueString() != data.LastModified.ValueString() {
+		t.Errorf("last_modified = %q, expected %q", read.LastModified.ValueString(), data.LastModified.ValueString())
+	}
+
+	if err := remote.ConnectAndDelete(dial, data)(ctx); err != nil {
+		t.Fatalf("ConnectAndDelete() error = %v", err)
+	}
+	if server.object("config/app v1.json") != nil {
+		t.Error("object still exists after delete")
+	}
+}
+
+func TestDialerLastModified(t *testing.T) {
+	server := newTestServer(t)
+	server.put("version", "1.2.3", time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/version")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	if err := remote.ConnectAndCopy(Dialer(params), data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if data.Contents.ValueString() != "1.2.3" || data.Size.ValueInt64() != 5 {
+		t.Errorf("contents = %q (%d bytes), expected %q", data.Contents.ValueString(), data.Size.ValueInt64(), "1.2.3")
+	}
+	if data.LastModified.ValueString() != "2024-05-01T12:30:00Z" {
+		t.Errorf("last_modified = %q, expected the upload time of the object", data.LastModified.ValueString())
+	}
+}
+
+func TestDialerChangedWhileReading(t *testing.T) {
+	var server *testServer
+	changes := 0
+	server = newTestServer(t, withBefore(func(r *http.Request) {
+		// the object is replaced between HEAD and GET, once
+		if r.Method == http.MethodGet && changes == 0 {
+			changes++
+			server.objects["counter"] = &testObject{data: []byte("2"), modTime: time.Now()}
+		}
+	}))
+	server.put("counter", "1", time.Now())
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/counter")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	operation := remote.ConnectAndCopy(Dialer(params), data, data)
+
+	err = operation(context.Background())
+	if !errors.Is(err, remote.ErrConflict) {
+		t.Fatalf("ConnectAndCopy() error = %v, expected ErrConflict", err)
+	}
+	if !remote.IsRetryable(err) {
+		t.Errorf("expected %v to be retried", err)
+	}
+
+	if err := operation(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() retry error = %v", err)
+	}
+	if data.Contents.ValueString() != "2" {
+		t.Errorf("contents = %q, expected the replaced object", data.Contents.ValueString())
+	}
+}
+
+func TestDialerMissingObject(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/missing")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+
+	err = remote.ConnectAndCopy(dial, data, data)(context.Background())
+	if !remote.IsFileNotFound(err) {
+		t.Errorf("ConnectAndCopy() error = %v, expected a missing file", err)
+	}
+
+	data.AllowMissing = types.BoolValue(true)
+	if err := remote.ConnectAndCopy(dial, data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() with allow_missing error = %v", err)
+	}
+	if data.Size.ValueInt64() != -1 {
+		t.Errorf("size = %d, expected -1 for a missing object", data.Size.ValueInt64())
+	}
+
+	if err := remote.ConnectAndDelete(dial, data)(context.Background()); err != nil {
+		t.Errorf("ConnectAndDelete() error = %v", err)
+	}
+}
+
+func TestDialerMissingBucket(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.S3.Bucket = types.StringValue("elsewhere")
+	data.Path = types.StringValue("/file")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndCopy(Dialer(params), data, data)(context.Background())
+	if !remote.IsFileNotFound(err) || !strings.Contains(err.Error(), "NoSuchBucket") {
+		t.Errorf("ConnectAndCopy() error = %v, expected a missing bucket", err)
+	}
+}
+
+func TestDialerAuthFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*backendtest.Model)
+		expected error
+	}{
+		{name: "wrong secret", modify: func(m *backendtest.Model) { m.Password = types.StringValue("wrong") }, expected: remote.ErrAuthFailed},
+		{name: "unknown key", modify: func(m *backendtest.Model) { m.User = types.StringValue("AKIAUNKNOWN") }, expected: remote.ErrAuthFailed},
+		{name: "unsigned", modify: func(m *backendtest.Model) { m.User, m.Password = types.StringNull(), types.StringNull() }, expected: remote.ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			data := newTestModel(server)
+			tt.modify(data)
+			data.Path = types.StringValue("/file")
+
+			params, err := CreateConnectionParameters(data)
+			if err != nil {
+				t.Fatalf("CreateConnectionParameters() error = %v", err)
+			}
+			err = remote.ConnectAndCopy(Dialer(params), data, data)(context.Background())
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("ConnectAndCopy() error = %v, expected %v", err, tt.expected)
+			}
+			if remote.IsRetryable(err) {
+				t.Errorf("expected %v not to be retried", err)
+			}
+		})
+	}
+}
+
+func TestDialerResumable(t *testing.T) {
+	server := newTestServer(t)
+	server.put("etc/.app.conf.partial", "stale", time.Now())
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/etc/app.conf")
+	data.Contents = types.StringValue("listen 8080\n")
+	data.S3.ContentType = types.StringValue("text/plain")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(Dialer(params), data, data, remote.WithResumable(true))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	object := server.object("etc/app.conf")
+	if object == nil || string(object.data) != "listen 8080\n" {
+		t.Fatalf("object = %+v, expected the new contents", object)
+	}
+	if object.contentType != "text/plain" {
+		t.Errorf("content type = %q, expected the copy to keep text/plain", object.contentType)
+	}
+	if server.object("etc/.app.conf.partial") != nil {
+		t.Error("partial object still exists")
+	}
+}
+
+func TestBackendReadDir(t *testing.T) {
+	server := newTestServer(t, withPageSize(2))
+	for _, key := range []string{"conf/a.conf", "conf/b.conf", "conf/c.conf", "conf/d/e.conf", "other"} {
+		server.put(key, key, time.Now())
+	}
+
+	b, err := testDialer(t, newTestModel(server))(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Mkdir("/conf/d"); err != nil {
+		t.Errorf("Mkdir() error = %v, expected directories to be implicit", err)
+	}
+
+	entries, err := b.ReadDir("/conf")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		if entry.Name() == "d" && !entry.IsDir() {
+			t.Error("expected d to be a directory")
+		}
+		if entry.Name() == "a.conf" && entry.Size() != int64(len("conf/a.conf")) {
+			t.Errorf("a.conf size = %d, expected %d", entry.Size(), len("conf/a.conf"))
+		}
+	}
+	sort.Strings(names)
+	expected := []string{"a.conf", "b.conf", "c.conf", "d"}
+	if len(names) != len(expected) {
+		t.Fatalf("entries = %v, expected %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("entries = %v, expected %v", names, expected)
+			break
+		}
+	}
+
+	if err := b.Chmod("/conf/a.conf", 0600); err == nil {
+		t.Error("Chmod() succeeded, expected S3 to refuse changing permissions")
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		code     string
+		expected error
+	}{
+		{name: "signature", status: http.StatusForbidden, code: "SignatureDoesNotMatch", expected: remote.ErrAuthFailed},
+		{name: "access denied", status: http.StatusForbidden, code: "AccessDenied", expected: os.ErrPermission},
+		{name: "head forbidden", status: http.StatusForbidden, expected: os.ErrPermission},
+		{name: "no such key", status: http.StatusNotFound, code: "NoSuchKey", expected: os.ErrNotExist},
+		{name: "head missing", status: http.StatusNotFound, expected: os.ErrNotExist},
+		{name: "precondition", status: http.StatusPreconditionFailed, code: "PreconditionFailed", expected: remote.ErrConflict},
+		{name: "quota", status: http.StatusInsufficientStorage, code: "XMinioStorageFull", expected: syscall.EDQUOT},
+		{name: "slow down", status: http.StatusServiceUnavailable, code: "SlowDown", expected: remote.ErrConnect},
+		{name: "copy failed", status: http.StatusOK, code: "InternalError", expected: remote.ErrConnect},
+		{name: "bad request", status: http.StatusBadRequest, code: "InvalidArgument", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &ResponseError{Method: http.MethodGet, Key: "file", Status: tt.status, Code: tt.code}
+			if unwrapped := err.Unwrap(); unwrapped != tt.expected {
+				t.Errorf("Unwrap() = %v, expected %v", unwrapped, tt.expected)
+			}
+		})
+	}
+}
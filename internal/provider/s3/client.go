@@ -0,0 +1,339 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// Subsystem is the tflog subsystem the S3 client logs to
+const Subsystem = "s3"
+
+// ResponseError is a response refusing a request. Unwrap maps the S3 error
+// code, or the status of responses without an error document, to the os,
+// syscall or typed error the remote operations look for.
+type ResponseError struct {
+	Method string
+	Key    string
+	Status int
+	// Code and Message come from the error document, responses to HEAD have
+	// none
+	Code    string
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	message := fmt.Sprintf("s3: %s %s: %d %s", e.Method, e.Key, e.Status, http.StatusText(e.Status))
+	if e.Code != "" {
+		message += ": " + e.Code
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+func (e *ResponseError) Unwrap() error {
+	switch e.Code {
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch", "InvalidToken", "ExpiredToken":
+		return remote.ErrAuthFailed
+	case "AccessDenied", "AllAccessDisabled":
+		return os.ErrPermission
+	case "NoSuchKey", "NoSuchBucket":
+		return os.ErrNotExist
+	case "PreconditionFailed":
+		return remote.ErrConflict
+	case "QuotaExceeded", "XMinioStorageFull", "XMinioAdminBucketQuotaExceeded":
+		return syscall.EDQUOT
+	case "SlowDown", "ServiceUnavailable", "InternalError", "RequestTimeout":
+		return remote.ErrConnect
+	}
+
+	switch e.Status {
+	case http.StatusUnauthorized:
+		return remote.ErrAuthFailed
+	case http.StatusForbidden:
+		return os.ErrPermission
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusPreconditionFailed:
+		return remote.ErrConflict
+	case http.StatusInsufficientStorage:
+		return syscall.EDQUOT
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return remote.ErrConnect
+	}
+	return nil
+}
+
+// errorDocument is the body of a failed response
+type errorDocument struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// maxErrorBody is how much of the body of a failed response is read
+const maxErrorBody = 64 << 10
+
+// client sends signed requests for the objects of a bucket
+type client struct {
+	params *ConnectionParameters
+	http   *http.Client
+	bucket *url.URL
+}
+
+func newClient(params *ConnectionParameters) *client {
+	dialer := &net.Dialer{Timeout: params.GetTimeout()}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     params.GetTLSConfig(),
+		TLSHandshakeTimeout: params.GetTimeout(),
+		ForceAttemptHTTP2:   true,
+	}
+
+	return &client{
+		params: params,
+		http: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		bucket: params.GetBucketURL(),
+	}
+}
+
+// objectKey returns the key of the object at remotePath, relative to the
+// bucket
+func objectKey(remotePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+remotePath), "/")
+}
+
+// url returns the URL of the object with key, the bucket itself if key is
+// empty
+func (c *client) url(key string, query url.Values) *url.URL {
+	u := *c.bucket
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do sends a signed request for key and returns the response if its status
+// is one of expected, the caller closes its body
+func (c *client) do(ctx context.Context, method string, key string, query url.Values, header http.Header, body []byte, expected ...int) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.url(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		request.Body = http.NoBody
+		request.GetBody = nil
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+
+	hash := emptyPayloadHash
+	if len(body) > 0 {
+		hash = payloadHash(body)
+	}
+	if c.params.credentials != nil {
+		c.params.credentials.sign(request, c.params.GetRegion(), hash, time.Now())
+	}
+
+	start := time.Now()
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, Subsystem, "S3 request", map[string]interface{}{
+		"method":      method,
+		"key":         key,
+		"status":      response.StatusCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	for _, status := range expected {
+		if response.StatusCode == status {
+			return response, nil
+		}
+	}
+
+	defer response.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	return nil, parseError(method, key, response.StatusCode, data)
+}
+
+// parseError returns the error a failed response with body reports
+func parseError(method string, key string, status int, body []byte) *ResponseError {
+	responseErr := &ResponseError{Method: method, Key: key, Status: status}
+
+	var document errorDocument
+	if len(body) > 0 && xml.Unmarshal(body, &document) == nil {
+		responseErr.Code = document.Code
+		responseErr.Message = document.Message
+	}
+	return responseErr
+}
+
+// head returns the properties of the object with key
+func (c *client) head(ctx context.Context, key string) (*objectInfo, error) {
+	response, err := c.do(ctx, http.MethodHead, key, nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, c.explain(ctx, key, err)
+	}
+	response.Body.Close()
+
+	info := &objectInfo{
+		name: path.Base(key),
+		size: response.ContentLength,
+		etag: response.Header.Get("ETag"),
+	}
+	if lastModified := response.Header.Get("Last-Modified"); lastModified != "" {
+		info.modTime, err = http.ParseTime(lastModified)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Last-Modified %q of %s: %w", lastModified, key, err)
+		}
+	}
+	return info, nil
+}
+
+// explain returns the error a GET of the first byte of key reports in place
+// of err, the error of a HEAD request. Responses to HEAD have no body, which
+// leaves e.g. a wrong secret key and a missing permission both a bare 403.
+func (c *client) explain(ctx context.Context, key string, err error) error {
+	var responseErr *ResponseError
+	if !errors.As(err, &responseErr) || responseErr.Code != "" {
+		return err
+	}
+
+	header := http.Header{"Range": {"bytes=0-0"}}
+	response, getErr := c.do(ctx, http.MethodGet, key, nil, header, nil, http.StatusOK, http.StatusPartialContent)
+	if getErr == nil {
+		response.Body.Close()
+		return err
+	}
+	if errors.As(getErr, &responseErr) && responseErr.Code != "" {
+		return getErr
+	}
+	return err
+}
+
+// listBucketResult is a page of a ListObjectsV2 response
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list returns the objects and the common prefixes, as directories, one level
+// below prefix
+func (c *client) list(ctx context.Context, prefix string) ([]*objectInfo, error) {
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+
+	var infos []*objectInfo
+	token := ""
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"delimiter": {"/"},
+			"prefix":    {prefix},
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		response, err := c.do(ctx, http.MethodGet, "", query, nil, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing object listing of %q: %w", prefix, err)
+		}
+
+		for _, object := range result.Contents {
+			if object.Key == prefix {
+				// the marker some tools create for directories
+				continue
+			}
+			infos = append(infos, &objectInfo{
+				name:    strings.TrimPrefix(object.Key, prefix),
+				size:    object.Size,
+				modTime: object.LastModified,
+				etag:    object.ETag,
+			})
+		}
+		for _, common := range result.CommonPrefixes {
+			infos = append(infos, &objectInfo{
+				name: strings.TrimSuffix(strings.TrimPrefix(common.Prefix, prefix), "/"),
+				dir:  true,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return infos, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// objectInfo is an object as described by HEAD or a listing. Objects have no
+// permissions, they report 0644 and common prefixes 0755.
+type objectInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	etag    string
+}
+
+func (i *objectInfo) Name() string       { return i.name }
+func (i *objectInfo) Size() int64        { return i.size }
+func (i *objectInfo) ModTime() time.Time { return i.modTime }
+func (i *objectInfo) IsDir() bool        { return i.dir }
+func (i *objectInfo) Sys() any           { return nil }
+
+func (i *objectInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ETag returns the entity tag of the object, empty for directories
+func (i *objectInfo) ETag() string {
+	return i.etag
+}
@@ -0,0 +1,252 @@
+package s3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// URL schemes
+const (
+	SchemeHTTPS = "https"
+	// SchemeHTTP is for endpoints on a trusted network, e.g. a local MinIO
+	SchemeHTTP = "http"
+)
+
+// Schemes are the supported values of scheme
+var Schemes = []string{SchemeHTTPS, SchemeHTTP}
+
+// DefaultRegion is the region requests are signed for unless configured,
+// which MinIO and Ceph RGW accept by default
+const DefaultRegion = "us-east-1"
+
+// defaultContentType is what objects are stored as unless configured
+const defaultContentType = "application/octet-stream"
+
+// the subset of terraform schema fields that are needed to connect to an
+// S3-compatible endpoint
+type ModelSubset interface {
+	GetHost() types.String
+	GetHostKey() types.String
+	GetPassword() types.String
+	GetPort() types.Int64
+	GetPrivateKey() types.String
+	GetS3Bucket() types.String
+	GetS3CaCert() types.String
+	GetS3ContentType() types.String
+	GetS3Metadata() types.Map
+	GetS3PathStyle() types.Bool
+	GetS3Region() types.String
+	GetS3Scheme() types.String
+	GetS3SessionToken() types.String
+	GetTimeout() types.String
+	GetUser() types.String
+}
+
+// ConnectionParameters describes the bucket objects are stored in and how
+// requests to it are signed
+type ConnectionParameters struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	pathStyle bool
+	timeout   time.Duration
+
+	// credentials is nil for unsigned requests
+	credentials *credentials
+
+	contentType string
+	metadata    map[string]string
+
+	// tlsConfig is nil over plain HTTP
+	tlsConfig *tls.Config
+}
+
+// GetAddress returns host:port of the endpoint
+func (p *ConnectionParameters) GetAddress() string {
+	if p.endpoint.Port() != "" {
+		return p.endpoint.Host
+	}
+	port := "443"
+	if p.endpoint.Scheme == SchemeHTTP {
+		port = "80"
+	}
+	return net.JoinHostPort(p.endpoint.Hostname(), port)
+}
+
+// GetBucketURL returns the URL of the bucket, the bucket is part of the host
+// unless path-style addressing is used
+func (p *ConnectionParameters) GetBucketURL() *url.URL {
+	u := *p.endpoint
+	if p.pathStyle {
+		u.Path = "/" + p.bucket
+	} else {
+		u.Host = p.bucket + "." + p.endpoint.Host
+		u.Path = ""
+	}
+	return &u
+}
+
+func (p *ConnectionParameters) GetBucket() string {
+	return p.bucket
+}
+
+func (p *ConnectionParameters) GetRegion() string {
+	return p.region
+}
+
+func (p *ConnectionParameters) GetPathStyle() bool {
+	return p.pathStyle
+}
+
+func (p *ConnectionParameters) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// GetAccessKeyID returns the key requests are signed with, empty for
+// unsigned requests
+func (p *ConnectionParameters) GetAccessKeyID() string {
+	if p.credentials == nil {
+		return ""
+	}
+	return p.credentials.accessKeyID
+}
+
+// GetContentType returns the Content-Type objects are written with
+func (p *ConnectionParameters) GetContentType() string {
+	return p.contentType
+}
+
+// GetMetadata returns the user metadata objects are written with, without
+// the x-amz-meta- prefix
+func (p *ConnectionParameters) GetMetadata() map[string]string {
+	return p.metadata
+}
+
+// GetTLSConfig returns the configuration HTTPS connections are made with, nil
+// over plain HTTP
+func (p *ConnectionParameters) GetTLSConfig() *tls.Config {
+	return p.tlsConfig
+}
+
+func CreateConnectionParameters(data ModelSubset) (*ConnectionParameters, error) {
+	if !data.GetHostKey().IsNull() {
+		return nil, errors.New("host_key only applies to SSH, set s3.ca_cert to verify an HTTPS endpoint")
+	}
+	if !data.GetPrivateKey().IsNull() {
+		return nil, errors.New("private_key only applies to SSH, S3 signs requests with user and password as access key")
+	}
+
+	bucket := data.GetS3Bucket().ValueString()
+	if bucket == "" {
+		return nil, errors.New("s3.bucket is required")
+	}
+
+	scheme := SchemeHTTPS
+	if value := data.GetS3Scheme(); !value.IsNull() {
+		scheme = value.ValueString()
+	}
+	if !slices.Contains(Schemes, scheme) {
+		return nil, fmt.Errorf("s3.scheme must be one of %s, got %q", strings.Join(Schemes, ", "), scheme)
+	}
+
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+
+	host := data.GetHost().ValueString()
+	endpointHost := host
+	if strings.Contains(host, ":") {
+		// IPv6 literals are bracketed in URLs
+		endpointHost = "[" + host + "]"
+	}
+	if !data.GetPort().IsNull() {
+		endpointHost = net.JoinHostPort(host, strconv.FormatInt(data.GetPort().ValueInt64(), 10))
+	}
+
+	region := DefaultRegion
+	if value := data.GetS3Region(); !value.IsNull() && value.ValueString() != "" {
+		region = value.ValueString()
+	}
+
+	// Buckets in the host name only work for names that are valid host
+	// labels, and not at all for endpoints addressed by IP
+	pathStyle := data.GetS3PathStyle().ValueBool()
+	if !pathStyle && (net.ParseIP(host) != nil || strings.Contains(bucket, ".") && scheme == SchemeHTTPS) {
+		pathStyle = true
+	}
+
+	params := &ConnectionParameters{
+		endpoint:    &url.URL{Scheme: scheme, Host: endpointHost},
+		bucket:      bucket,
+		region:      region,
+		pathStyle:   pathStyle,
+		timeout:     timeoutDuration,
+		contentType: defaultContentType,
+		metadata:    map[string]string{},
+	}
+
+	accessKeyID := data.GetUser().ValueString()
+	secretKey := data.GetPassword().ValueString()
+	sessionToken := data.GetS3SessionToken().ValueString()
+	switch {
+	case accessKeyID != "" && secretKey != "":
+		params.credentials = &credentials{
+			accessKeyID:  accessKeyID,
+			secretKey:    secretKey,
+			sessionToken: sessionToken,
+		}
+	case accessKeyID != "" || secretKey != "":
+		return nil, errors.New("user and password are the access key id and secret access key, set both or neither")
+	case sessionToken != "":
+		return nil, errors.New("s3.session_token requires user and password")
+	}
+
+	if value := data.GetS3ContentType(); !value.IsNull() && value.ValueString() != "" {
+		params.contentType = value.ValueString()
+	}
+	for name, value := range data.GetS3Metadata().Elements() {
+		s, ok := value.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			return nil, fmt.Errorf("s3.metadata[%q] must be a known string", name)
+		}
+		params.metadata[strings.ToLower(name)] = s.ValueString()
+	}
+
+	caCert := data.GetS3CaCert()
+	if scheme == SchemeHTTP {
+		if !caCert.IsNull() {
+			return nil, errors.New("s3.ca_cert only applies when s3.scheme is https")
+		}
+		return params, nil
+	}
+
+	// the server name is taken from the URL, which holds the bucket unless
+	// path-style addressing is used
+	params.tlsConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if !caCert.IsNull() {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert.ValueString())) {
+			return nil, errors.New("failed to parse s3.ca_cert: no PEM certificates found")
+		}
+		params.tlsConfig.RootCAs = pool
+	}
+
+	return params, nil
+}
@@ -0,0 +1,315 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer is an in-memory S3 endpoint with a single bucket, addressed
+// path-style. It checks the signature of every request like S3 does.
+type testServer struct {
+	*httptest.Server
+
+	bucket    string
+	accessKey string
+	secretKey string
+
+	mu      sync.Mutex
+	objects map[string]*testObject
+	// requests are "METHOD path" of every request received
+	requests []string
+	// before runs ahead of every request
+	before func(r *http.Request)
+	// pageSize is the maximum number of keys per listing page
+	pageSize int
+}
+
+type testObject struct {
+	data        []byte
+	contentType string
+	metadata    map[string]string
+	modTime     time.Time
+}
+
+func (o *testObject) etag() string {
+	sum := md5.Sum(o.data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+type testServerOption func(*testServer)
+
+// withBefore runs before ahead of every request, with the server locked
+func withBefore(before func(r *http.Request)) testServerOption {
+	return func(s *testServer) {
+		s.before = before
+	}
+}
+
+// withPageSize limits listings to size keys per page
+func withPageSize(size int) testServerOption {
+	return func(s *testServer) {
+		s.pageSize = size
+	}
+}
+
+func newTestServer(t *testing.T, opts ...testServerOption) *testServer {
+	t.Helper()
+
+	s := &testServer{
+		bucket:    "terraform",
+		accessKey: "AKIATESTEXAMPLE",
+		secretKey: "test/secret/key",
+		objects:   map[string]*testObject{},
+		pageSize:  1000,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// put stores an object as if it had been uploaded at modTime
+func (s *testServer) put(key string, data string, modTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = &testObject{data: []byte(data), contentType: defaultContentType, modTime: modTime}
+}
+
+// object returns the object stored under key, nil if there is none
+func (s *testServer) object(key string) *testObject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[key]
+}
+
+// host returns the host and port the server listens on
+func (s *testServer) host() (string, int64) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.ParseInt(u.Port(), 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return u.Hostname(), port
+}
+
+// received reports whether a request starting with prefix was received
+func (s *testServer) received(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, request := range s.requests {
+		if strings.HasPrefix(request, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeTestError(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if status, code := s.authenticate(r, body); status != 0 {
+		writeTestError(w, r, status, code, "request rejected")
+		return
+	}
+
+	if s.before != nil {
+		s.before(r)
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		writeTestError(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+	case key == "" && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		s.list(w, r)
+	case r.Method == http.MethodHead, r.Method == http.MethodGet:
+		object, ok := s.objects[key]
+		if !ok {
+			writeTestError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && match != object.etag() {
+			writeTestError(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("ETag", object.etag())
+		w.Header().Set("Last-Modified", object.modTime.UTC().Format(http.TimeFormat))
+		for name, value := range object.metadata {
+			w.Header().Set("X-Amz-Meta-"+name, value)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		if err != nil {
+			writeTestError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+			return
+		}
+		object, ok := s.objects[strings.TrimPrefix(source, "/"+s.bucket+"/")]
+		if !ok {
+			writeTestError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		copied := *object
+		copied.modTime = time.Now()
+		s.objects[key] = &copied
+		fmt.Fprintf(w, "<CopyObjectResult><ETag>%s</ETag></CopyObjectResult>", copied.etag())
+	case r.Method == http.MethodPut:
+		object := &testObject{
+			data:        body,
+			contentType: r.Header.Get("Content-Type"),
+			metadata:    map[string]string{},
+			modTime:     time.Now(),
+		}
+		for name, values := range r.Header {
+			if meta, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-meta-"); ok {
+				object.metadata[meta] = values[0]
+			}
+		}
+		s.objects[key] = object
+		w.Header().Set("ETag", object.etag())
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeTestError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+// authenticate returns the status and error code of a request whose
+// signature or payload hash is wrong, 0 if it is signed correctly
+func (s *testServer) authenticate(r *http.Request, body []byte) (int, string) {
+	authorization, ok := strings.CutPrefix(r.Header.Get("Authorization"), signingAlgorithm+" ")
+	if !ok {
+		return http.StatusForbidden, "AccessDenied"
+	}
+
+	fields := map[string]string{}
+	for _, field := range strings.Split(authorization, ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+	accessKey, scope, _ := strings.Cut(fields["Credential"], "/")
+	if accessKey != s.accessKey {
+		return http.StatusForbidden, "InvalidAccessKeyId"
+	}
+
+	hash := r.Header.Get("X-Amz-Content-Sha256")
+	if hash != payloadHash(body) {
+		return http.StatusBadRequest, "XAmzContentSHA256Mismatch"
+	}
+	canonical := canonicalRequest(r, strings.Split(fields["SignedHeaders"], ";"), hash)
+	if signature(s.secretKey, scope, r.Header.Get("X-Amz-Date"), canonical) != fields["Signature"] {
+		return http.StatusForbidden, "SignatureDoesNotMatch"
+	}
+	return 0, ""
+}
+
+// list answers a ListObjectsV2 request
+func (s *testServer) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if token := query.Get("continuation-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+
+	var result struct {
+		XMLName  xml.Name `xml:"ListBucketResult"`
+		Contents []struct {
+			Key          string
+			LastModified string
+			ETag         string
+			Size         int
+		}
+		CommonPrefixes []struct {
+			Prefix string
+		}
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}
+	seen := map[string]bool{}
+	for i := start; i < len(keys); i++ {
+		if i-start == s.pageSize {
+			result.IsTruncated = true
+			result.NextContinuationToken = strconv.Itoa(i)
+			break
+		}
+
+		key := keys[i]
+		if rest := strings.TrimPrefix(key, prefix); delimiter != "" && strings.Contains(rest, delimiter) {
+			common := prefix + rest[:strings.Index(rest, delimiter)+1]
+			if !seen[common] {
+				seen[common] = true
+				result.CommonPrefixes = append(result.CommonPrefixes, struct{ Prefix string }{common})
+			}
+			continue
+		}
+
+		object := s.objects[key]
+		result.Contents = append(result.Contents, struct {
+			Key          string
+			LastModified string
+			ETag         string
+			Size         int
+		}{key, object.modTime.UTC().Format(time.RFC3339), object.etag(), len(object.data)})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func writeTestError(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var buffer bytes.Buffer
+	xml.NewEncoder(&buffer).Encode(errorDocument{Code: code, Message: message})
+	w.Write(buffer.Bytes())
+}
@@ -0,0 +1,49 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// runCommand runs command in a new session on host. A command that ran and
+// exited non-zero is not an error, its exit code is in the result.
+func runCommand(ctx context.Context, host remoteHost, command string) (backend.CommandResult, error) {
+	session, err := host.newSession()
+	if err != nil {
+		return backend.CommandResult{}, err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.setStdout(&stdout)
+	session.setStderr(&stderr)
+
+	start := time.Now()
+	err = session.Run(command)
+
+	result := backend.CommandResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+
+	if status, exited := exitStatus(err); exited {
+		result.ExitCode = status
+	} else if err != nil {
+		return result, fmt.Errorf("error running %q: %w", command, err)
+	}
+
+	tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "ran remote command", map[string]interface{}{
+		"command":     command,
+		"exit_code":   result.ExitCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	return result, nil
+}
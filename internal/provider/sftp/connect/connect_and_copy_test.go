@@ -1,6 +1,8 @@
 package connect
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"errors"
@@ -9,11 +11,15 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
 )
 
 type testServer struct {
@@ -21,6 +27,60 @@ type testServer struct {
 	listener       net.Listener
 	testDir        string
 	hostPrivateKey ssh.Signer
+
+	execMu        sync.Mutex
+	exec          execHandler
+	scpOnly       bool
+	authorizedKey ssh.PublicKey
+}
+
+// execHandler answers an exec request on the test server in place of a shell
+type execHandler func(command string) (stdout string, stderr string, status uint32)
+
+// sftpServerCommand is what a handler returns as stdout to have the test
+// server serve SFTP on the channel after printing the rest of stdout, like a
+// command that ends in exec sftp-server
+const sftpServerCommand = "\x00sftp-server\x00"
+
+// handleExec makes the test server run commands with handler, without one
+// exec requests are rejected like on an SFTP-only server
+func (ts *testServer) handleExec(handler execHandler) {
+	ts.execMu.Lock()
+	defer ts.execMu.Unlock()
+	ts.exec = handler
+}
+
+func (ts *testServer) execHandler() execHandler {
+	ts.execMu.Lock()
+	defer ts.execMu.Unlock()
+	return ts.exec
+}
+
+// serveScpOnly makes the test server refuse the SFTP subsystem and answer
+// scp commands itself, like an embedded device running dropbear
+func (ts *testServer) serveScpOnly() {
+	ts.execMu.Lock()
+	defer ts.execMu.Unlock()
+	ts.scpOnly = true
+}
+
+func (ts *testServer) isScpOnly() bool {
+	ts.execMu.Lock()
+	defer ts.execMu.Unlock()
+	return ts.scpOnly
+}
+
+// authorize makes the test server accept key besides the test password
+func (ts *testServer) authorize(key ssh.PublicKey) {
+	ts.execMu.Lock()
+	defer ts.execMu.Unlock()
+	ts.authorizedKey = key
+}
+
+func (ts *testServer) isAuthorized(key ssh.PublicKey) bool {
+	ts.execMu.Lock()
+	defer ts.execMu.Unlock()
+	return ts.authorizedKey != nil && bytes.Equal(ts.authorizedKey.Marshal(), key.Marshal())
 }
 
 type mockInputModel struct {
@@ -110,6 +170,7 @@ func setupTestServer(t *testing.T) (*testServer, error) {
 	}
 
 	// Configure SSH server
+	server := &testServer{}
 	sshConfig := &ssh.ServerConfig{
 		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
 			if string(pass) == "testpass" {
@@ -117,6 +178,12 @@ func setupTestServer(t *testing.T) (*testServer, error) {
 			}
 			return nil, fmt.Errorf("password rejected for %q", c.User())
 		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if server.isAuthorized(key) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("public key rejected for %q", c.User())
+		},
 	}
 	sshConfig.AddHostKey(hostKey)
 
@@ -127,6 +194,11 @@ func setupTestServer(t *testing.T) (*testServer, error) {
 		return nil, fmt.Errorf("failed to listen for connection: %v", err)
 	}
 
+	server.sshServer = sshConfig
+	server.listener = listener
+	server.testDir = testDir
+	server.hostPrivateKey = hostKey
+
 	go func() {
 		for {
 			nConn, err := listener.Accept()
@@ -137,25 +209,21 @@ func setupTestServer(t *testing.T) (*testServer, error) {
 				return
 			}
 
-			go handleConnection(t, nConn, sshConfig, testDir)
+			go handleConnection(t, nConn, sshConfig, server)
 		}
 	}()
 
-	return &testServer{
-		sshServer:      sshConfig,
-		listener:       listener,
-		testDir:        testDir,
-		hostPrivateKey: hostKey,
-	}, nil
+	return server, nil
 }
 
-func handleConnection(t *testing.T, conn net.Conn, sshConfig *ssh.ServerConfig, rootDir string) {
+func handleConnection(t *testing.T, conn net.Conn, sshConfig *ssh.ServerConfig, server *testServer) {
 	defer conn.Close()
 
 	// Handle SSH connection
 	sshConn, chans, reqs, err := ssh.NewServerConn(conn, sshConfig)
 	if err != nil {
-		t.Errorf("failed to handshake: %v", err)
+		// Rejected credentials are an expected outcome in some tests, the
+		// client side reports the failure
 		return
 	}
 	defer sshConn.Close()
@@ -180,9 +248,21 @@ func handleConnection(t *testing.T, conn net.Conn, sshConfig *ssh.ServerConfig,
 				ok := false
 				switch req.Type {
 				case "subsystem":
-					if string(req.Payload[4:]) == "sftp" {
+					if string(req.Payload[4:]) == "sftp" && !server.isScpOnly() {
 						ok = true
-						go handleSftp(t, channel, rootDir)
+						go handleSftp(t, channel, server.testDir)
+					}
+				case "exec":
+					// Reply before any output so the client is ready for it
+					if command := string(req.Payload[4:]); server.isScpOnly() && strings.HasPrefix(command, "scp ") {
+						req.Reply(true, nil)
+						go handleScp(channel, command, server.testDir)
+						continue
+					}
+					if handler := server.execHandler(); handler != nil {
+						req.Reply(true, nil)
+						go handleExec(channel, string(req.Payload[4:]), handler, server.testDir)
+						continue
 					}
 				}
 				req.Reply(ok, nil)
@@ -191,6 +271,23 @@ func handleConnection(t *testing.T, conn net.Conn, sshConfig *ssh.ServerConfig,
 	}
 }
 
+func handleExec(channel ssh.Channel, command string, handler execHandler, rootDir string) {
+	defer channel.Close()
+
+	stdout, stderr, status := handler(command)
+	if banner, ok := strings.CutSuffix(stdout, sftpServerCommand); ok {
+		_, _ = io.WriteString(channel, banner)
+		server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(rootDir))
+		if err == nil {
+			_ = server.Serve()
+		}
+		return
+	}
+	_, _ = io.WriteString(channel, stdout)
+	_, _ = io.WriteString(channel.Stderr(), stderr)
+	_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+}
+
 func handleSftp(t *testing.T, channel ssh.Channel, rootDir string) {
 	server, err := sftp.NewServer(
 		channel,
@@ -273,13 +370,13 @@ func TestConnectAndCopyOperation_ExistingFile(t *testing.T) {
 	t.Logf("Test server directory: %s", server.testDir)
 	t.Logf("Attempting to access file: %s", input.path.ValueString())
 
-	operation := ConnectAndCopy(
-		sshParams,
+	operation := remote.ConnectAndCopy(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
-	err := operation()
+	err := operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndCopyOperation() error = %v, expected no error", err)
 		return
@@ -311,13 +408,13 @@ func TestConnectAndCopyOperation_MissingFileAllowed(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndCopy(
-		sshParams,
+	operation := remote.ConnectAndCopy(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
-	err := operation()
+	err := operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndCopyOperation() error = %v, expected no error", err)
 		return
@@ -349,14 +446,43 @@ func TestConnectAndCopyOperation_MissingFileNotAllowed(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndCopy(
-		sshParams,
+	operation := remote.ConnectAndCopy(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
-	err := operation()
+	err := operation(context.Background())
 	if err == nil {
 		t.Error("ConnectAndCopyOperation() expected error for missing file, got nil")
 	}
 }
+
+func TestConnectAndCopyOperation_CancelledContext(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	input := &mockInputModel{
+		path:         types.StringValue("test.txt"),
+		allowMissing: types.BoolValue(false),
+	}
+	output := &mockOutputModel{}
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	operation := remote.ConnectAndCopy(
+		SftpDialer(sshParams),
+		input,
+		output,
+	)
+
+	err := operation(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ConnectAndCopyOperation() error = %v, expected context.Canceled", err)
+	}
+}
@@ -1,15 +1,17 @@
 package connect
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
-	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
 )
 
 type mockDeleteInputModel struct {
@@ -30,7 +32,7 @@ func TestConnectAndDeleteOperation_ExistingFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test file for deletion: %v", err)
 	}
-	
+
 	// Verify the file exists before deletion
 	if _, err := os.Stat(testFilePath); os.IsNotExist(err) {
 		t.Fatalf("Test file does not exist before deletion test: %v", err)
@@ -46,13 +48,13 @@ func TestConnectAndDeleteOperation_ExistingFile(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndDelete(
-		sshParams,
+	operation := remote.ConnectAndDelete(
+		SftpDialer(sshParams),
 		input,
 	)
 
 	// Execute the delete operation
-	err = operation()
+	err = operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndDeleteOperation() error = %v, expected no error", err)
 		return
@@ -78,56 +80,18 @@ func TestConnectAndDeleteOperation_NonExistentFile(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndDelete(
-		sshParams,
+	operation := remote.ConnectAndDelete(
+		SftpDialer(sshParams),
 		input,
 	)
 
 	// Execute the delete operation
-	err := operation()
+	err := operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndDeleteOperation() error = %v, expected no error for non-existent file", err)
 	}
 }
 
-func TestIsFileNotFound(t *testing.T) {
-	tests := []struct {
-		name     string
-		err      error
-		expected bool
-	}{
-		{
-			name:     "nil error",
-			err:      nil,
-			expected: false,
-		},
-		{
-			name:     "file not found error",
-			err:      fmt.Errorf("no such file or directory"),
-			expected: true,
-		},
-		{
-			name:     "file does not exist error",
-			err:      fmt.Errorf("file does not exist"),
-			expected: true,
-		},
-		{
-			name:     "other error",
-			err:      fmt.Errorf("permission denied"),
-			expected: false,
-		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := IsFileNotFound(tc.err)
-			if result != tc.expected {
-				t.Errorf("IsFileNotFound() = %v, expected %v for error: %v", result, tc.expected, tc.err)
-			}
-		})
-	}
-}
-
 func TestConnectAndDeleteOperation_ConnectionFailure(t *testing.T) {
 	// Create a mock SSH connection parameters with an invalid address
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -155,13 +119,13 @@ func TestConnectAndDeleteOperation_ConnectionFailure(t *testing.T) {
 		path: types.StringValue("some_file.txt"),
 	}
 
-	operation := ConnectAndDelete(
-		sshParams,
+	operation := remote.ConnectAndDelete(
+		SftpDialer(sshParams),
 		input,
 	)
 
 	// Execute the delete operation, expect an error
-	err = operation()
+	err = operation(context.Background())
 	if err == nil {
 		t.Errorf("ConnectAndDeleteOperation() expected error for connection failure, got nil")
 	}
@@ -0,0 +1,325 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+type mockEditInputModel struct {
+	path        types.String
+	permissions types.String
+}
+
+func (m *mockEditInputModel) GetPath() types.String {
+	return m.path
+}
+
+func (m *mockEditInputModel) GetPermissions() types.String {
+	return m.permissions
+}
+
+func TestConnectAndEditOperation_ExistingFile(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	testFilePath := filepath.Join(server.testDir, "test.txt")
+	if err := os.Chmod(testFilePath, 0600); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockEditInputModel{path: types.StringValue("test.txt"), permissions: types.StringValue("0644")}
+
+	var given string
+	err := remote.ConnectAndEdit(SftpDialer(sshParams), input, func(current string, exists bool) (string, error) {
+		if !exists {
+			t.Errorf("expected the file to exist")
+		}
+		given = current
+		return current + "appended\n", nil
+	})(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndEdit() error = %v", err)
+	}
+
+	if given != testContent {
+		t.Errorf("edit was given %q, expected %q", given, testContent)
+	}
+
+	content, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read edited file: %v", err)
+	}
+	if string(content) != testContent+"appended\n" {
+		t.Errorf("File content = %q, expected %q", string(content), testContent+"appended\n")
+	}
+
+	// Existing files keep their mode rather than taking permissions
+	fileInfo, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Fatalf("Failed to get file info: %v", err)
+	}
+	if fileInfo.Mode().Perm() != 0600 {
+		t.Errorf("File permissions = %o, expected %o", fileInfo.Mode().Perm(), 0600)
+	}
+
+	if _, err := os.Stat(filepath.Join(server.testDir, ".test.txt.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the staged file to be gone, got %v", err)
+	}
+}
+
+func TestConnectAndEditOperation_MissingFile(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockEditInputModel{path: types.StringValue("created.txt"), permissions: types.StringValue("0640")}
+
+	err := remote.ConnectAndEdit(SftpDialer(sshParams), input, func(current string, exists bool) (string, error) {
+		if exists || current != "" {
+			t.Errorf("expected a missing file, got exists = %v, current = %q", exists, current)
+		}
+		return "created\n", nil
+	})(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndEdit() error = %v", err)
+	}
+
+	testFilePath := filepath.Join(server.testDir, "created.txt")
+	content, err := os.ReadFile(testFilePath)
+	if err != nil || string(content) != "created\n" {
+		t.Errorf("File content = %q (%v), expected %q", string(content), err, "created\n")
+	}
+
+	fileInfo, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Fatalf("Failed to get file info: %v", err)
+	}
+	if fileInfo.Mode().Perm() != 0640 {
+		t.Errorf("File permissions = %o, expected %o", fileInfo.Mode().Perm(), 0640)
+	}
+}
+
+func TestConnectAndEditOperation_Unchanged(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"existing file", "test.txt"},
+		{"missing file", "missing.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFilePath := filepath.Join(server.testDir, tt.path)
+			before, beforeErr := os.Stat(testFilePath)
+
+			input := &mockEditInputModel{path: types.StringValue(tt.path), permissions: types.StringNull()}
+			err := remote.ConnectAndEdit(SftpDialer(sshParams), input, func(current string, _ bool) (string, error) {
+				return current, nil
+			})(context.Background())
+			if err != nil {
+				t.Fatalf("ConnectAndEdit() error = %v", err)
+			}
+
+			after, afterErr := os.Stat(testFilePath)
+			if os.IsNotExist(beforeErr) != os.IsNotExist(afterErr) {
+				t.Fatalf("expected the file to keep existing or stay missing")
+			}
+			if beforeErr == nil && !before.ModTime().Equal(after.ModTime()) {
+				t.Errorf("expected the file not to be rewritten")
+			}
+		})
+	}
+}
+
+func TestConnectAndEditOperation_Conflict(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockEditInputModel{path: types.StringValue("test.txt"), permissions: types.StringNull()}
+	testFilePath := filepath.Join(server.testDir, "test.txt")
+
+	err := remote.ConnectAndEdit(SftpDialer(sshParams), input, func(current string, _ bool) (string, error) {
+		// Someone else changes the file while the edit is in flight
+		if err := os.WriteFile(testFilePath, []byte("concurrent\n"), 0644); err != nil {
+			t.Fatalf("Failed to modify test file: %v", err)
+		}
+		return current + "appended\n", nil
+	})(context.Background())
+	if !errors.Is(err, remote.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if !remote.IsRetryable(err) {
+		t.Errorf("expected conflicts to be retried")
+	}
+
+	content, err := os.ReadFile(testFilePath)
+	if err != nil || string(content) != "concurrent\n" {
+		t.Errorf("File content = %q (%v), expected the concurrent change to survive", string(content), err)
+	}
+	if _, err := os.Stat(filepath.Join(server.testDir, ".test.txt.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the staged file to be removed, got %v", err)
+	}
+}
+
+func TestConnectAndEditOperation_EditError(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockEditInputModel{path: types.StringValue("test.txt"), permissions: types.StringNull()}
+
+	err := remote.ConnectAndEdit(SftpDialer(sshParams), input, func(string, bool) (string, error) {
+		return "", errors.New("refusing to edit")
+	})(context.Background())
+	if !errors.Is(err, remote.ErrEdit) || !strings.Contains(err.Error(), "refusing to edit") {
+		t.Fatalf("expected the edit error, got %v", err)
+	}
+	if remote.IsRetryable(err) {
+		t.Errorf("expected edit errors not to be retried")
+	}
+
+	content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+	if err != nil || string(content) != testContent {
+		t.Errorf("File content = %q (%v), expected it untouched", string(content), err)
+	}
+}
+
+func TestConnectAndEditOperation_KeepsOwnership(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of a file requires root")
+	}
+
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	testFilePath := filepath.Join(server.testDir, "test.txt")
+	if err := os.Chown(testFilePath, 1234, 5678); err != nil {
+		t.Fatalf("Failed to chown test file: %v", err)
+	}
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockEditInputModel{path: types.StringValue("test.txt"), permissions: types.StringNull()}
+
+	err := remote.ConnectAndEdit(SftpDialer(sshParams), input, func(current string, exists bool) (string, error) {
+		return current + "appended\n", nil
+	})(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndEdit() error = %v", err)
+	}
+
+	b, err := SftpDialer(sshParams)(context.Background())
+	if err != nil {
+		t.Fatalf("SftpDialer() error = %v", err)
+	}
+	defer b.Close()
+	fileInfo, err := b.Lstat("test.txt")
+	if err != nil {
+		t.Fatalf("Failed to get file info: %v", err)
+	}
+	owned, ok := fileInfo.Sys().(backend.Owned)
+	if !ok {
+		t.Fatalf("File info %T doesn't report the ownership", fileInfo.Sys())
+	}
+	if uid, gid := owned.Ownership(); uid != 1234 || gid != 5678 {
+		t.Errorf("File ownership = %d:%d, expected the replaced file's 1234:5678", uid, gid)
+	}
+	if content, _ := os.ReadFile(testFilePath); string(content) != testContent+"appended\n" {
+		t.Errorf("File content = %q, expected the edited contents", string(content))
+	}
+}
+
+// plainRenameBackend renames like an SFTP server without the posix-rename
+// extension, which can't replace an existing file
+type plainRenameBackend struct {
+	*sftpBackend
+}
+
+func (b plainRenameBackend) Rename(oldpath string, newpath string) error {
+	if _, err := b.sftpBackend.Lstat(newpath); err == nil {
+		return fmt.Errorf("%w: %s exists", errors.ErrUnsupported, newpath)
+	}
+	return b.sftpBackend.Rename(oldpath, newpath)
+}
+
+func TestConnectAndEditOperation_WithoutAtomicRename(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	dial := func(ctx context.Context) (backend.Backend, error) {
+		b, err := SftpDialer(sshParams)(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return plainRenameBackend{b.(*sftpBackend)}, nil
+	}
+
+	// An edit never removes the file it replaces
+	input := &mockEditInputModel{path: types.StringValue("test.txt"), permissions: types.StringNull()}
+	err := remote.ConnectAndEdit(dial, input, func(current string, exists bool) (string, error) {
+		return current + "appended\n", nil
+	})(context.Background())
+	if !errors.Is(err, remote.ErrUnsupported) || remote.IsRetryable(err) {
+		t.Fatalf("expected a permanent ErrUnsupported, got %v", err)
+	}
+	testFilePath := filepath.Join(server.testDir, "test.txt")
+	if content, _ := os.ReadFile(testFilePath); string(content) != testContent {
+		t.Errorf("File content = %q, expected the untouched %q", string(content), testContent)
+	}
+	if _, err := os.Stat(filepath.Join(server.testDir, ".test.txt.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the staged file to be removed, got %v", err)
+	}
+
+	// A resumable upload may replace it in two steps
+	writeInput := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		contents:    types.StringValue("uploaded\n"),
+		permissions: types.StringNull(),
+	}
+	err = remote.ConnectAndWrite(dial, writeInput, &mockOutputModel{}, remote.WithResumable(true))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if content, _ := os.ReadFile(testFilePath); string(content) != "uploaded\n" {
+		t.Errorf("File content = %q, expected the uploaded contents", string(content))
+	}
+}
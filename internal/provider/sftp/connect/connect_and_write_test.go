@@ -1,6 +1,7 @@
 package connect
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"os"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
 )
 
 type mockWriteInputModel struct {
@@ -50,14 +53,14 @@ func TestConnectAndWriteOperation_NewFile(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndWrite(
-		sshParams,
+	operation := remote.ConnectAndWrite(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
 	// Execute the write operation
-	err := operation()
+	err := operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndWriteOperation() error = %v, expected no error", err)
 		return
@@ -129,14 +132,14 @@ func TestConnectAndWriteOperation_OverwriteExistingFile(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndWrite(
-		sshParams,
+	operation := remote.ConnectAndWrite(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
 	// Execute the write operation
-	err = operation()
+	err = operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndWriteOperation() error = %v, expected no error", err)
 		return
@@ -195,14 +198,14 @@ func TestConnectAndWriteOperation_WithoutPermissions(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndWrite(
-		sshParams,
+	operation := remote.ConnectAndWrite(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
 	// Execute the write operation
-	err := operation()
+	err := operation(context.Background())
 	if err != nil {
 		t.Errorf("ConnectAndWriteOperation() error = %v, expected no error", err)
 		return
@@ -238,14 +241,14 @@ func TestConnectAndWriteOperation_InvalidPermissions(t *testing.T) {
 		address: serverAddr,
 	}
 
-	operation := ConnectAndWrite(
-		sshParams,
+	operation := remote.ConnectAndWrite(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
 	// Execute the write operation, expect error due to invalid permissions
-	err := operation()
+	err := operation(context.Background())
 	if err == nil {
 		t.Errorf("ConnectAndWriteOperation() expected error for invalid permissions, got nil")
 	}
@@ -281,14 +284,14 @@ func TestConnectAndWriteOperation_ConnectionFailure(t *testing.T) {
 
 	output := &mockOutputModel{}
 
-	operation := ConnectAndWrite(
-		sshParams,
+	operation := remote.ConnectAndWrite(
+		SftpDialer(sshParams),
 		input,
 		output,
 	)
 
 	// Execute the write operation, expect error
-	err = operation()
+	err = operation(context.Background())
 	if err == nil {
 		t.Errorf("ConnectAndWriteOperation() expected error for connection failure, got nil")
 	}
@@ -0,0 +1,124 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+type SshConnectionParameters interface {
+	GetSshConfig() *ssh.ClientConfig
+	GetAddress() string
+}
+
+// dial opens an SSH connection that is torn down as soon as ctx is done, so a
+// cancelled apply aborts the handshake or any transfer in flight instead of
+// waiting for it to finish
+func dial(ctx context.Context, sshConnParams SshConnectionParameters) (*ssh.Client, error) {
+	config := *sshConnParams.GetSshConfig()
+	address := sshConnParams.GetAddress()
+
+	tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "dialing SSH server", map[string]interface{}{
+		"user":         config.User,
+		"auth_methods": authMethodNames(sshConnParams),
+		"timeout":      config.Timeout.String(),
+	})
+
+	// Tag host key verification failures so they can be told apart from
+	// other handshake errors
+	if verify := config.HostKeyCallback; verify != nil {
+		config.HostKeyCallback = func(hostname string, addr net.Addr, key ssh.PublicKey) error {
+			fields := map[string]interface{}{
+				"host_key_algorithm":   key.Type(),
+				"host_key_fingerprint": ssh.FingerprintSHA256(key),
+			}
+			if err := verify(hostname, addr, key); err != nil {
+				tflog.SubsystemWarn(ctx, remote.SubsystemSSH, "host key verification failed", fields)
+				return fmt.Errorf("%w: %w", remote.ErrHostKeyMismatch, err)
+			}
+			tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "host key verified", fields)
+			return nil
+		}
+	}
+
+	start := time.Now()
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "TCP dial failed", map[string]interface{}{
+			"duration_ms": time.Since(start).Milliseconds(),
+			"error":       err.Error(),
+		})
+		return nil, fmt.Errorf("%w: %w", remote.ErrConnect, err)
+	}
+	tflog.SubsystemTrace(ctx, remote.SubsystemSSH, "TCP connection established", map[string]interface{}{
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	// The handshake has no context of its own, closing the connection is the
+	// only way to interrupt it
+	handshakeStart := time.Now()
+	stopHandshake := context.AfterFunc(ctx, func() { conn.Close() })
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, &config)
+	if !stopHandshake() {
+		if err == nil {
+			clientConn.Close()
+		}
+		return nil, fmt.Errorf("failed to connect to SSH server: %w", ctx.Err())
+	}
+	if err != nil {
+		conn.Close()
+		tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "SSH handshake failed", map[string]interface{}{
+			"duration_ms": time.Since(handshakeStart).Milliseconds(),
+			"error":       err.Error(),
+		})
+		// golang.org/x/crypto/ssh only reports a failed authentication as text
+		if strings.Contains(err.Error(), "ssh: unable to authenticate") {
+			return nil, fmt.Errorf("%w: failed to connect to SSH server: %w", remote.ErrAuthFailed, err)
+		}
+		return nil, fmt.Errorf("failed to connect to SSH server: %w", err)
+	}
+
+	// golang.org/x/crypto/ssh doesn't expose the negotiated key exchange,
+	// cipher and MAC, the host key algorithm is logged on verification
+	tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "SSH handshake complete", map[string]interface{}{
+		"duration_ms":    time.Since(handshakeStart).Milliseconds(),
+		"server_version": string(clientConn.ServerVersion()),
+		"client_version": string(clientConn.ClientVersion()),
+	})
+
+	sshClient := ssh.NewClient(clientConn, chans, reqs)
+	context.AfterFunc(ctx, func() { sshClient.Close() })
+
+	return sshClient, nil
+}
+
+// openSftp starts the SFTP subsystem on an established SSH connection, or
+// the SFTP server as root when sshConnParams say so
+func openSftp(ctx context.Context, host remoteHost, sshConnParams SshConnectionParameters) (*sftp.Client, error) {
+	if mode, password, server := sudoSettings(sshConnParams); mode == SudoSftpServer {
+		return openSudoSftp(ctx, host, password, server)
+	}
+
+	start := time.Now()
+	sftpClient, err := host.newSftpClient()
+	if err != nil {
+		return nil, fmt.Errorf("error creating SFTP client: %w", err)
+	}
+
+	_, posixRename := sftpClient.HasExtension("posix-rename@openssh.com")
+	tflog.SubsystemDebug(ctx, remote.SubsystemSFTP, "SFTP session opened", map[string]interface{}{
+		"duration_ms":  time.Since(start).Milliseconds(),
+		"posix_rename": posixRename,
+	})
+
+	return sftpClient, nil
+}
@@ -0,0 +1,162 @@
+package connect
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "no such file",
+			err:      &sftp.StatusError{Code: uint32(sftp.ErrSSHFxNoSuchFile)},
+			expected: os.ErrNotExist,
+		},
+		{
+			name:     "permission denied",
+			err:      &sftp.StatusError{Code: uint32(sftp.ErrSSHFxPermissionDenied)},
+			expected: os.ErrPermission,
+		},
+		{
+			name:     "quota exceeded",
+			err:      &sftp.StatusError{Code: sshFxQuotaExceeded},
+			expected: remote.ErrQuotaExceeded,
+		},
+		{
+			name:     "no space on filesystem",
+			err:      &sftp.StatusError{Code: sshFxNoSpaceOnFilesystem},
+			expected: remote.ErrQuotaExceeded,
+		},
+		{
+			name:     "connection lost",
+			err:      &sftp.StatusError{Code: uint32(sftp.ErrSSHFxConnectionLost)},
+			expected: remote.ErrConnect,
+		},
+		{
+			name:     "operation unsupported",
+			err:      fmt.Errorf("error setting file permissions: %w", &sftp.StatusError{Code: uint32(sftp.ErrSSHFxOpUnsupported)}),
+			expected: remote.ErrUnsupported,
+		},
+		{
+			name:     "generic failure",
+			err:      &sftp.StatusError{Code: uint32(sftp.ErrSSHFxFailure)},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := statusError(tc.err)
+
+			if !errors.Is(wrapped, tc.err) {
+				t.Errorf("statusError() = %v, expected it to wrap %v", wrapped, tc.err)
+			}
+			if tc.expected == nil {
+				if wrapped != tc.err {
+					t.Errorf("statusError() = %v, expected the error unchanged", wrapped)
+				}
+				return
+			}
+			if !errors.Is(wrapped, tc.expected) {
+				t.Errorf("statusError() = %v, expected it to wrap %v", wrapped, tc.expected)
+			}
+			if wrapped.Error() != tc.err.Error() {
+				t.Errorf("statusError() = %q, expected the message %q", wrapped, tc.err)
+			}
+		})
+	}
+
+	// Renames that can't replace a file atomically report errors.ErrUnsupported,
+	// a server that doesn't implement an operation mustn't look like one
+	if err := statusError(&sftp.StatusError{Code: uint32(sftp.ErrSSHFxOpUnsupported)}); errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("statusError() = %v, expected it not to wrap errors.ErrUnsupported", err)
+	}
+}
+
+func TestConnectAndCopyOperation_TypedErrors(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate private key: %v", err)
+	}
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   *ssh.ClientConfig
+		address  string
+		path     string
+		expected error
+	}{
+		{
+			name:     "missing file",
+			config:   getTestClientConfig(server.hostPrivateKey.PublicKey()),
+			address:  serverAddr,
+			path:     "missing.txt",
+			expected: remote.ErrNotFound,
+		},
+		{
+			name: "wrong password",
+			config: &ssh.ClientConfig{
+				User:            "testuser",
+				Auth:            []ssh.AuthMethod{ssh.Password("wrongpass")},
+				HostKeyCallback: ssh.FixedHostKey(server.hostPrivateKey.PublicKey()),
+			},
+			address:  serverAddr,
+			path:     "test.txt",
+			expected: remote.ErrAuthFailed,
+		},
+		{
+			name:     "unexpected host key",
+			config:   getTestClientConfig(otherSigner.PublicKey()),
+			address:  serverAddr,
+			path:     "test.txt",
+			expected: remote.ErrHostKeyMismatch,
+		},
+		{
+			name:     "unreachable server",
+			config:   getTestClientConfig(server.hostPrivateKey.PublicKey()),
+			address:  "127.0.0.1:1",
+			path:     "test.txt",
+			expected: remote.ErrConnect,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := &mockInputModel{
+				path:         types.StringValue(tc.path),
+				allowMissing: types.BoolValue(false),
+			}
+			sshParams := &mockSSHParams{
+				config:  tc.config,
+				address: tc.address,
+			}
+
+			err := remote.ConnectAndCopy(SftpDialer(sshParams), input, &mockOutputModel{})(context.Background())
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("ConnectAndCopyOperation() error = %v, expected it to wrap %v", err, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestConnectAndWriteOperation_WithRender(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		contents:    types.StringNull(),
+		permissions: types.StringNull(),
+	}
+	output := &mockOutputModel{}
+
+	var gathered remote.RemoteFacts
+	render := func(facts remote.RemoteFacts) (string, error) {
+		gathered = facts
+		other, err := facts.ReadFile("test.txt")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("host=%s previous=%s", facts.Hostname, other), nil
+	}
+
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, output, remote.WithRender(render))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	// The test server doesn't run commands, so the hostname falls back to the address
+	if gathered.Hostname != "127.0.0.1" {
+		t.Errorf("Hostname = %q, expected the address", gathered.Hostname)
+	}
+	if !gathered.Exists || gathered.Existing != testContent {
+		t.Errorf("Exists = %v, Existing = %q, expected the existing file", gathered.Exists, gathered.Existing)
+	}
+
+	expected := "host=127.0.0.1 previous=" + testContent
+	content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read rendered file: %v", err)
+	}
+	if string(content) != expected {
+		t.Errorf("File content = %q, expected %q", string(content), expected)
+	}
+	if output.GetContents().ValueString() != expected {
+		t.Errorf("output.Contents = %q, expected %q", output.GetContents().ValueString(), expected)
+	}
+}
+
+func TestConnectAndWriteOperation_WithRenderMissingFile(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("rendered.txt"),
+		permissions: types.StringNull(),
+	}
+
+	var gathered remote.RemoteFacts
+	render := func(facts remote.RemoteFacts) (string, error) {
+		gathered = facts
+		return "rendered", nil
+	}
+
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithRender(render))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if gathered.Exists || gathered.Existing != "" {
+		t.Errorf("Exists = %v, Existing = %q, expected a missing file", gathered.Exists, gathered.Existing)
+	}
+}
+
+func TestConnectAndWriteOperation_RenderError(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		permissions: types.StringNull(),
+	}
+	render := func(facts remote.RemoteFacts) (string, error) {
+		return facts.ReadFile("missing.txt")
+	}
+
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithRender(render))(context.Background())
+	if !errors.Is(err, remote.ErrRender) {
+		t.Fatalf("expected ErrRender, got %v", err)
+	}
+	if remote.IsRetryable(err) {
+		t.Errorf("expected render errors not to be retried")
+	}
+
+	// The existing file is left untouched
+	content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+	if err != nil || string(content) != testContent {
+		t.Errorf("File content = %q (%v), expected %q", string(content), err, testContent)
+	}
+}
@@ -0,0 +1,205 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// recordCommands returns an exec handler that records the commands it is
+// given and answers them with stdout, stderr and status
+func recordCommands(commands *[]string, stdout string, stderr string, status uint32) execHandler {
+	return func(command string) (string, string, uint32) {
+		*commands = append(*commands, command)
+		return stdout, stderr, status
+	}
+}
+
+func TestConnectAndWriteOperation_Hook(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expected bool
+	}{
+		{"changed contents", "new contents\n", true},
+		{"unchanged contents", "test content\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverAddr, _, cleanup := setupIntegrationTest(t)
+			defer cleanup()
+
+			var commands []string
+			server.handleExec(recordCommands(&commands, "reloaded\n", "", 0))
+
+			sshParams := &mockSSHParams{
+				config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+				address: serverAddr,
+			}
+			input := &mockWriteInputModel{
+				path:        types.StringValue("test.txt"),
+				contents:    types.StringValue(tt.contents),
+				permissions: types.StringNull(),
+			}
+
+			var result remote.HookResult
+			err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithHook("systemctl reload nginx", &result))(context.Background())
+			if err != nil {
+				t.Fatalf("ConnectAndWrite() error = %v", err)
+			}
+
+			if result.Ran != tt.expected || (len(commands) == 1) != tt.expected {
+				t.Fatalf("Ran = %v after commands %q, expected %v", result.Ran, commands, tt.expected)
+			}
+			if tt.expected && (result.Stdout != "reloaded\n" || result.ExitCode != 0) {
+				t.Errorf("result = %+v, expected the command's output", result)
+			}
+		})
+	}
+}
+
+func TestConnectAndWriteOperation_HookFailure(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(recordCommands(&commands, "", "Job for nginx.service failed\n", 3))
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		contents:    types.StringValue("new contents\n"),
+		permissions: types.StringNull(),
+	}
+	output := &mockOutputModel{}
+
+	var result remote.HookResult
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, output, remote.WithHook("systemctl reload nginx", &result))(context.Background())
+	if !errors.Is(err, remote.ErrHook) {
+		t.Fatalf("expected ErrHook, got %v", err)
+	}
+	if remote.IsRetryable(err) {
+		t.Errorf("expected hook failures not to be retried")
+	}
+	if !result.Ran || result.ExitCode != 3 || result.Stderr != "Job for nginx.service failed\n" {
+		t.Errorf("result = %+v, expected the failed command's outcome", result)
+	}
+
+	// The file was still written before the hook ran
+	content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+	if err != nil || string(content) != "new contents\n" {
+		t.Errorf("File content = %q (%v), expected the new contents", string(content), err)
+	}
+	if output.GetContents().ValueString() != "new contents\n" {
+		t.Errorf("output.Contents = %q, expected the new contents", output.GetContents().ValueString())
+	}
+}
+
+// lstatFailingBackend fails the first Lstat, which follows the write, as if
+// the connection dropped right after the file was written
+type lstatFailingBackend struct {
+	*sftpBackend
+	failed *bool
+}
+
+func (b lstatFailingBackend) Lstat(name string) (os.FileInfo, error) {
+	if !*b.failed {
+		*b.failed = true
+		return nil, fmt.Errorf("error reading remote file info: %w", syscall.ECONNRESET)
+	}
+	return b.sftpBackend.Lstat(name)
+}
+
+func TestConnectAndWriteOperation_HookAfterRetriedWrite(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(recordCommands(&commands, "reloaded\n", "", 0))
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	failed := false
+	dial := func(ctx context.Context) (backend.Backend, error) {
+		b, err := SftpDialer(sshParams)(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return lstatFailingBackend{b.(*sftpBackend), &failed}, nil
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		contents:    types.StringValue("new contents\n"),
+		permissions: types.StringNull(),
+	}
+
+	var result remote.HookResult
+	operation := remote.ConnectAndWrite(dial, input, &mockOutputModel{}, remote.WithHook("systemctl reload nginx", &result))
+
+	// The first attempt writes the file, then fails
+	err := operation(context.Background())
+	if err == nil || !remote.IsRetryable(err) {
+		t.Fatalf("expected a retryable error after the write, got %v", err)
+	}
+	if len(commands) != 0 {
+		t.Fatalf("commands = %q, expected the hook not to have run yet", commands)
+	}
+
+	// The retry finds the new contents already in place, but the hook still
+	// has to follow the change the first attempt made
+	err = operation(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	if !result.Ran || len(commands) != 1 {
+		t.Errorf("Ran = %v after commands %q, expected the hook to run on the retry", result.Ran, commands)
+	}
+}
+
+func TestConnectAndDeleteOperation_Hook(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(recordCommands(&commands, "", "", 0))
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{path: types.StringValue("test.txt")}
+
+	var result remote.HookResult
+	err := remote.ConnectAndDelete(SftpDialer(sshParams), input, remote.WithDeleteHook("systemctl restart nginx", &result))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndDelete() error = %v", err)
+	}
+	if !result.Ran || len(commands) != 1 {
+		t.Errorf("Ran = %v after commands %q, expected the hook to run", result.Ran, commands)
+	}
+
+	// Deleting a file that's already gone changes nothing, so runs nothing
+	var again remote.HookResult
+	err = remote.ConnectAndDelete(SftpDialer(sshParams), input, remote.WithDeleteHook("systemctl restart nginx", &again))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndDelete() error = %v", err)
+	}
+	if again.Ran || len(commands) != 1 {
+		t.Errorf("Ran = %v after commands %q, expected the hook not to run", again.Ran, commands)
+	}
+}
@@ -0,0 +1,77 @@
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// remoteHost is a host commands and the SFTP subsystem are started on, over
+// the built in SSH client or the system ssh binary
+type remoteHost interface {
+	newSession() (session, error)
+	// newSftpClient starts the SFTP subsystem
+	newSftpClient() (*sftp.Client, error)
+	Close() error
+}
+
+// session is a single command on a remote host, used like an ssh.Session.
+// The pipes have to be requested and stdout and stderr set before the
+// command is started.
+type session interface {
+	StdinPipe() (io.WriteCloser, error)
+	StdoutPipe() (io.Reader, error)
+	setStdout(w io.Writer)
+	setStderr(w io.Writer)
+	Start(command string) error
+	Run(command string) error
+	Wait() error
+	Close() error
+}
+
+// exitStatus returns the exit status of the command that failed with err, if
+// it ran and exited
+func exitStatus(err error) (int, bool) {
+	var sshErr *ssh.ExitError
+	if errors.As(err, &sshErr) {
+		return sshErr.ExitStatus(), true
+	}
+	var execErr *exec.ExitError
+	if errors.As(err, &execErr) && execErr.Exited() {
+		return execErr.ExitCode(), true
+	}
+	return 0, false
+}
+
+// sshHost is a host reached with the built in SSH client
+type sshHost struct {
+	client *ssh.Client
+}
+
+func (h *sshHost) newSession() (session, error) {
+	s, err := h.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("error opening SSH session: %w", err)
+	}
+	return &sshSession{s}, nil
+}
+
+func (h *sshHost) newSftpClient() (*sftp.Client, error) {
+	return sftp.NewClient(h.client)
+}
+
+func (h *sshHost) Close() error {
+	return h.client.Close()
+}
+
+// sshSession is an ssh.Session with the setters of session
+type sshSession struct {
+	*ssh.Session
+}
+
+func (s *sshSession) setStdout(w io.Writer) { s.Stdout = w }
+func (s *sshSession) setStderr(w io.Writer) { s.Stderr = w }
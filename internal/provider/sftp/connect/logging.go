@@ -0,0 +1,16 @@
+package connect
+
+// authMethodNamer is implemented by connection parameters that can name the
+// authentication methods they offer
+type authMethodNamer interface {
+	GetAuthMethodNames() []string
+}
+
+// authMethodNames returns the names of the authentication methods offered by
+// sshConnParams, if it knows them
+func authMethodNames(sshConnParams SshConnectionParameters) []string {
+	if namer, ok := sshConnParams.(authMethodNamer); ok {
+		return namer.GetAuthMethodNames()
+	}
+	return nil
+}
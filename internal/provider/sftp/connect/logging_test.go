@@ -0,0 +1,65 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestConnectAndCopyOperation_Logging(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	ctx = tflog.NewSubsystem(ctx, remote.SubsystemSSH)
+	ctx = tflog.NewSubsystem(ctx, remote.SubsystemSFTP)
+
+	input := &mockInputModel{
+		path:         types.StringValue("test.txt"),
+		allowMissing: types.BoolValue(false),
+	}
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+
+	err := remote.ConnectAndCopy(SftpDialer(sshParams), input, &mockOutputModel{})(ctx)
+	if err != nil {
+		t.Fatalf("ConnectAndCopyOperation() error = %v, expected no error", err)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("Failed to decode log entries: %v", err)
+	}
+
+	messages := map[string]map[string]interface{}{}
+	for _, entry := range entries {
+		messages[entry["@message"].(string)] = entry
+	}
+
+	for _, expected := range []string{"dialing SSH server", "host key verified", "SSH handshake complete", "SFTP session opened", "read remote file"} {
+		if _, ok := messages[expected]; !ok {
+			t.Errorf("expected a %q log entry, got %v", expected, entries)
+		}
+	}
+
+	if entry, ok := messages["read remote file"]; ok {
+		if entry["@module"] != "provider."+remote.SubsystemSFTP {
+			t.Errorf("read remote file logged to %v, expected provider.%s", entry["@module"], remote.SubsystemSFTP)
+		}
+		if entry["bytes"] != float64(len(testContent)) {
+			t.Errorf("read remote file bytes = %v, expected %d", entry["bytes"], len(testContent))
+		}
+		if entry["address"] != serverAddr {
+			t.Errorf("read remote file address = %v, expected %s", entry["address"], serverAddr)
+		}
+	}
+}
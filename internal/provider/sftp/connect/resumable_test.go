@@ -0,0 +1,120 @@
+package connect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestConnectAndWriteOperation_ResumesPartialUpload(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	testContent := "the quick brown fox jumps over the lazy dog"
+	testFilename := "resumed.txt"
+	testFilePath := filepath.Join(server.testDir, testFilename)
+	partialFilePath := filepath.Join(server.testDir, ".resumed.txt.partial")
+
+	// Simulate an upload interrupted halfway through
+	err := os.WriteFile(partialFilePath, []byte(testContent[:20]), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create partial file: %v", err)
+	}
+
+	input := &mockWriteInputModel{
+		path:        types.StringValue(testFilename),
+		contents:    types.StringValue(testContent),
+		permissions: types.StringValue("0640"),
+	}
+
+	output := &mockOutputModel{}
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+
+	operation := remote.ConnectAndWrite(
+		SftpDialer(sshParams),
+		input,
+		output,
+		remote.WithResumable(true),
+	)
+
+	err = operation(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWriteOperation() error = %v, expected no error", err)
+	}
+
+	content, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read written file: %v", err)
+	}
+	if string(content) != testContent {
+		t.Errorf("File content = %q, expected %q", string(content), testContent)
+	}
+
+	if _, err := os.Stat(partialFilePath); !os.IsNotExist(err) {
+		t.Errorf("Expected partial file to be renamed into place, but it still exists")
+	}
+
+	fileInfo, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Fatalf("Failed to get file info: %v", err)
+	}
+	if fileInfo.Mode().Perm() != 0640 {
+		t.Errorf("File permissions = %o, expected %o", fileInfo.Mode().Perm(), 0640)
+	}
+
+	if output.GetSize().ValueInt64() != int64(len(testContent)) {
+		t.Errorf("output.Size = %d, expected %d", output.GetSize().ValueInt64(), len(testContent))
+	}
+}
+
+func TestConnectAndWriteOperation_ResumableDiscardsStalePartial(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	testContent := "fresh content"
+	testFilename := "stale.txt"
+	testFilePath := filepath.Join(server.testDir, testFilename)
+
+	// A partial file left over from different contents must not be reused
+	err := os.WriteFile(filepath.Join(server.testDir, ".stale.txt.partial"), []byte("stale"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create partial file: %v", err)
+	}
+	err = os.WriteFile(testFilePath, []byte("previous content that is longer"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create existing file: %v", err)
+	}
+
+	input := &mockWriteInputModel{
+		path:        types.StringValue(testFilename),
+		contents:    types.StringValue(testContent),
+		permissions: types.StringNull(),
+	}
+
+	output := &mockOutputModel{}
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+
+	err = remote.ConnectAndWrite(SftpDialer(sshParams), input, output, remote.WithResumable(true))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWriteOperation() error = %v, expected no error", err)
+	}
+
+	content, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read written file: %v", err)
+	}
+	if string(content) != testContent {
+		t.Errorf("File content = %q, expected %q", string(content), testContent)
+	}
+}
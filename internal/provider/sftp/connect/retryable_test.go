@@ -0,0 +1,38 @@
+package connect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestIsRetryable_WrongPassword(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config: &ssh.ClientConfig{
+			User:            "testuser",
+			Auth:            []ssh.AuthMethod{ssh.Password("wrongpass")},
+			HostKeyCallback: ssh.FixedHostKey(server.hostPrivateKey.PublicKey()),
+		},
+		address: serverAddr,
+	}
+
+	input := &mockInputModel{
+		path:         types.StringValue("test.txt"),
+		allowMissing: types.BoolValue(false),
+	}
+
+	err := remote.ConnectAndCopy(SftpDialer(sshParams), input, &mockOutputModel{})(context.Background())
+	if err == nil {
+		t.Fatal("ConnectAndCopyOperation() expected error for wrong password, got nil")
+	}
+	if remote.IsRetryable(err) {
+		t.Errorf("IsRetryable() = true, expected authentication failure to be permanent: %v", err)
+	}
+}
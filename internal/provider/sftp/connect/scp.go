@@ -0,0 +1,279 @@
+package connect
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// errNotRegular is how scp refuses to send a directory without -r
+var errNotRegular = errors.New("not a regular file")
+
+// scpHeader is what scp sends about a file ahead of its contents
+type scpHeader struct {
+	name    string
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+}
+
+// scpError turns a message scp or a command printed into an error wrapping
+// the matching os or syscall error, so the remote operations classify it
+func scpError(message string) error {
+	message = strings.TrimSpace(message)
+
+	var kind error
+	switch {
+	case strings.Contains(message, "No such file or directory"):
+		kind = os.ErrNotExist
+	case strings.Contains(message, "Permission denied"), strings.Contains(message, "Operation not permitted"):
+		kind = os.ErrPermission
+	case strings.Contains(message, "No space left on device"):
+		kind = syscall.ENOSPC
+	case strings.Contains(message, "Disk quota exceeded"):
+		kind = syscall.EDQUOT
+	case strings.Contains(message, "not a regular file"), strings.Contains(message, "Is a directory"):
+		kind = errNotRegular
+	default:
+		return errors.New(message)
+	}
+	return fmt.Errorf("%w: %s", kind, message)
+}
+
+// scpSession is an scp process on the remote host, spoken to over the
+// session's stdin and stdout
+type scpSession struct {
+	session session
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	stderr  bytes.Buffer
+}
+
+// startScp runs scp with args on the remote host
+func startScp(host remoteHost, args string) (*scpSession, error) {
+	session, err := host.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &scpSession{session: session}
+	s.stdin, err = session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error opening SSH session: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error opening SSH session: %w", err)
+	}
+	s.stdout = bufio.NewReader(stdout)
+	session.setStderr(&s.stderr)
+
+	if err := session.Start("scp " + args); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error starting scp: %w", err)
+	}
+	return s, nil
+}
+
+// fail explains why scp stopped talking, from what it printed to stderr
+func (s *scpSession) fail(err error) error {
+	_ = s.session.Wait()
+	if stderr := strings.TrimSpace(s.stderr.String()); stderr != "" {
+		return scpError(stderr)
+	}
+	return fmt.Errorf("scp stopped unexpectedly: %w", err)
+}
+
+// readResponse reads scp's reply to a message, a zero byte or an error
+func (s *scpSession) readResponse() error {
+	code, err := s.stdout.ReadByte()
+	if err != nil {
+		return s.fail(err)
+	}
+
+	switch code {
+	case 0:
+		return nil
+	case 1, 2:
+		message, _ := s.stdout.ReadString('\n')
+		return scpError(message)
+	}
+	return fmt.Errorf("unexpected scp response %q", code)
+}
+
+// ack tells scp to go on
+func (s *scpSession) ack() error {
+	_, err := s.stdin.Write([]byte{0})
+	return err
+}
+
+// close ends the transfer and waits for scp to exit
+func (s *scpSession) close() error {
+	_ = s.stdin.Close()
+	err := s.session.Wait()
+	s.session.Close()
+	if err != nil {
+		if stderr := strings.TrimSpace(s.stderr.String()); stderr != "" {
+			return scpError(stderr)
+		}
+		return fmt.Errorf("scp failed: %w", err)
+	}
+	return nil
+}
+
+// scpReceive fetches remotePath with `scp -p -f`. With headerOnly the
+// transfer is abandoned as soon as the header arrived, which is how files are
+// stat'ed without reading them.
+func scpReceive(ctx context.Context, host remoteHost, remotePath string, headerOnly bool) (scpHeader, []byte, error) {
+	start := time.Now()
+	s, err := startScp(host, "-p -f -- "+remote.ShellQuote(remotePath))
+	if err != nil {
+		return scpHeader{}, nil, err
+	}
+	defer s.session.Close()
+
+	if err := s.ack(); err != nil {
+		return scpHeader{}, nil, s.fail(err)
+	}
+
+	var header scpHeader
+	for {
+		code, err := s.stdout.ReadByte()
+		if err != nil {
+			return scpHeader{}, nil, s.fail(err)
+		}
+		line, err := s.stdout.ReadString('\n')
+		if err != nil {
+			return scpHeader{}, nil, s.fail(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch code {
+		case 1, 2:
+			return scpHeader{}, nil, scpError(line)
+		case 'T':
+			// T<mtime> 0 <atime> 0
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				return scpHeader{}, nil, fmt.Errorf("malformed scp time header %q", line)
+			}
+			mtime, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return scpHeader{}, nil, fmt.Errorf("malformed scp time header %q", line)
+			}
+			header.modTime = time.Unix(mtime, 0)
+			if err := s.ack(); err != nil {
+				return scpHeader{}, nil, s.fail(err)
+			}
+			continue
+		case 'C':
+			// C<mode> <size> <name>
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) != 3 {
+				return scpHeader{}, nil, fmt.Errorf("malformed scp file header %q", line)
+			}
+			mode, err := strconv.ParseUint(fields[0], 8, 32)
+			if err != nil {
+				return scpHeader{}, nil, fmt.Errorf("malformed scp file header %q", line)
+			}
+			size, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil || size < 0 {
+				return scpHeader{}, nil, fmt.Errorf("malformed scp file header %q", line)
+			}
+			header.mode = os.FileMode(mode).Perm()
+			header.size = size
+			header.name = fields[2]
+		default:
+			return scpHeader{}, nil, fmt.Errorf("unexpected scp message %q", string(code)+line)
+		}
+		break
+	}
+
+	if headerOnly {
+		return header, nil, nil
+	}
+
+	if err := s.ack(); err != nil {
+		return scpHeader{}, nil, s.fail(err)
+	}
+	contents := make([]byte, header.size)
+	if _, err := io.ReadFull(s.stdout, contents); err != nil {
+		return scpHeader{}, nil, s.fail(err)
+	}
+	if err := s.readResponse(); err != nil {
+		return scpHeader{}, nil, err
+	}
+	if err := s.ack(); err != nil {
+		return scpHeader{}, nil, s.fail(err)
+	}
+	if err := s.close(); err != nil {
+		return scpHeader{}, nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, remote.SubsystemSSH, "received file over scp", map[string]interface{}{
+		"path":        remotePath,
+		"bytes":       header.size,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	return header, contents, nil
+}
+
+// scpSend uploads contents to remotePath with `scp -t`. scp only applies
+// mode to files it creates, existing files keep theirs.
+func scpSend(ctx context.Context, host remoteHost, remotePath string, mode os.FileMode, contents []byte) error {
+	start := time.Now()
+	s, err := startScp(host, "-t -- "+remote.ShellQuote(remotePath))
+	if err != nil {
+		return err
+	}
+	defer s.session.Close()
+
+	if err := s.readResponse(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.stdin, "C%04o %d %s\n", mode.Perm(), len(contents), path.Base(remotePath))
+	if err != nil {
+		return s.fail(err)
+	}
+	if err := s.readResponse(); err != nil {
+		return err
+	}
+
+	if _, err := s.stdin.Write(contents); err != nil {
+		return s.fail(err)
+	}
+	if err := s.ack(); err != nil {
+		return s.fail(err)
+	}
+	if err := s.readResponse(); err != nil {
+		return err
+	}
+	if err := s.close(); err != nil {
+		return err
+	}
+
+	tflog.SubsystemTrace(ctx, remote.SubsystemSSH, "sent file over scp", map[string]interface{}{
+		"path":        remotePath,
+		"bytes":       len(contents),
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// defaultScpMode is given to files scp creates, like sftp.Client.Create
+const defaultScpMode os.FileMode = 0644
+
+// scpBackend is a host reached over SSH without an SFTP subsystem. Files are
+// transferred with `scp -f` and `scp -t` and everything else is done with
+// shell commands, so the host needs scp, chmod, chown, mv, rm, mkdir and ls.
+type scpBackend struct {
+	// ctx is the one the backend was dialed with, the Backend methods don't
+	// take one
+	ctx     context.Context
+	host    remoteHost
+	address string
+}
+
+var (
+	_ backend.Backend   = &scpBackend{}
+	_ backend.Commander = &scpBackend{}
+)
+
+// ScpDialer returns a Dialer that connects to the host described by
+// sshConnParams and transfers files with scp, for hosts that don't offer SFTP
+func ScpDialer(sshConnParams SshConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		if sudo, _, _ := sudoSettings(sshConnParams); sudo != "" {
+			return nil, fmt.Errorf("%w: sudo is only supported over SFTP", remote.ErrSudo)
+		}
+
+		ctx = remote.WithAddress(ctx, sshConnParams.GetAddress())
+		host, err := connectHost(ctx, sshConnParams)
+		if err != nil {
+			return nil, err
+		}
+
+		return &scpBackend{
+			ctx:     ctx,
+			host:    host,
+			address: sshConnParams.GetAddress(),
+		}, nil
+	}
+}
+
+func (b *scpBackend) Address() string {
+	return b.address
+}
+
+func (b *scpBackend) Open(path string) (backend.File, error) {
+	_, contents, err := scpReceive(b.ctx, b.host, path, false)
+	if err != nil {
+		return nil, err
+	}
+	return &scpFile{reader: bytes.NewReader(contents)}, nil
+}
+
+// OpenFile opens path for writing unless flag is os.O_RDONLY. Writes are
+// buffered and uploaded in full when the file is closed, replacing whatever
+// path held, so appending isn't supported.
+func (b *scpBackend) OpenFile(path string, flag int) (backend.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return b.Open(path)
+	}
+	if flag&os.O_APPEND != 0 {
+		return nil, errors.New("scp can't append to files")
+	}
+	return &scpFile{backend: b, path: path, writing: true}, nil
+}
+
+// Stat reads the header scp sends ahead of the contents. scp refuses to
+// send directories, they are reported without size, mode or time.
+func (b *scpBackend) Stat(remotePath string) (os.FileInfo, error) {
+	header, _, err := scpReceive(b.ctx, b.host, remotePath, true)
+	if errors.Is(err, errNotRegular) {
+		return &scpFileInfo{scpHeader{name: path.Base(remotePath)}, true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scpFileInfo{header, false}, nil
+}
+
+// Lstat is Stat, scp follows symbolic links
+func (b *scpBackend) Lstat(path string) (os.FileInfo, error) {
+	return b.Stat(path)
+}
+
+func (b *scpBackend) Chmod(path string, mode os.FileMode) error {
+	return b.shell(fmt.Sprintf("chmod %04o -- %s", mode.Perm(), remote.ShellQuote(path)))
+}
+
+func (b *scpBackend) Chown(path string, uid int, gid int) error {
+	return b.shell(fmt.Sprintf("chown %d:%d -- %s", uid, gid, remote.ShellQuote(path)))
+}
+
+func (b *scpBackend) Rename(oldpath string, newpath string) error {
+	return b.shell("mv -f -- " + remote.ShellQuote(oldpath) + " " + remote.ShellQuote(newpath))
+}
+
+func (b *scpBackend) Remove(path string) error {
+	return b.shell("rm -- " + remote.ShellQuote(path))
+}
+
+func (b *scpBackend) Mkdir(path string) error {
+	return b.shell("mkdir -- " + remote.ShellQuote(path))
+}
+
+// ReadDir lists path with ls and stats every entry with scp
+func (b *scpBackend) ReadDir(dir string) ([]os.FileInfo, error) {
+	result, err := b.Run(b.ctx, "ls -1A -- "+remote.ShellQuote(dir))
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, scpError(result.Stderr)
+	}
+
+	var entries []os.FileInfo
+	for _, name := range strings.Split(strings.TrimSuffix(result.Stdout, "\n"), "\n") {
+		if name == "" {
+			continue
+		}
+		fileInfo, err := b.Stat(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileInfo)
+	}
+	return entries, nil
+}
+
+func (b *scpBackend) Run(ctx context.Context, command string) (backend.CommandResult, error) {
+	return runCommand(ctx, b.host, command)
+}
+
+// shell runs command, failing with what it printed if it exits non-zero
+func (b *scpBackend) shell(command string) error {
+	result, err := b.Run(b.ctx, command)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		message := result.Stderr
+		if strings.TrimSpace(message) == "" {
+			message = fmt.Sprintf("%q exited with status %d", command, result.ExitCode)
+		}
+		return scpError(message)
+	}
+	return nil
+}
+
+func (b *scpBackend) Close() error {
+	return b.host.Close()
+}
+
+// scpFile is a file downloaded in full, or one being written that is
+// uploaded when closed
+type scpFile struct {
+	reader *bytes.Reader
+
+	backend *scpBackend
+	path    string
+	writing bool
+	buffer  bytes.Buffer
+}
+
+func (f *scpFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, errors.New("file was opened for writing")
+	}
+	return f.reader.Read(p)
+}
+
+func (f *scpFile) Write(p []byte) (int, error) {
+	if !f.writing {
+		return 0, errors.New("file was opened for reading")
+	}
+	return f.buffer.Write(p)
+}
+
+func (f *scpFile) Close() error {
+	if !f.writing {
+		return nil
+	}
+	f.writing = false
+	return scpSend(f.backend.ctx, f.backend.host, f.path, defaultScpMode, f.buffer.Bytes())
+}
+
+// scpFileInfo describes a file from its scp header
+type scpFileInfo struct {
+	header scpHeader
+	dir    bool
+}
+
+func (i *scpFileInfo) Name() string       { return i.header.name }
+func (i *scpFileInfo) Size() int64        { return i.header.size }
+func (i *scpFileInfo) ModTime() time.Time { return i.header.modTime }
+func (i *scpFileInfo) IsDir() bool        { return i.dir }
+func (i *scpFileInfo) Sys() any           { return nil }
+
+func (i *scpFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | i.header.mode
+	}
+	return i.header.mode
+}
@@ -0,0 +1,349 @@
+package connect
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// handleScp answers an scp command on the test server the way OpenSSH's scp
+// does, relative to rootDir
+func handleScp(channel ssh.Channel, command string, rootDir string) {
+	defer channel.Close()
+
+	status := serveScp(channel, command, rootDir)
+	_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+}
+
+func serveScp(channel ssh.Channel, command string, rootDir string) uint32 {
+	flags, quoted, ok := strings.Cut(strings.TrimPrefix(command, "scp "), " -- ")
+	if !ok {
+		_, _ = fmt.Fprintf(channel.Stderr(), "usage: scp [-p] -f|-t -- file\n")
+		return 1
+	}
+	name := strings.ReplaceAll(strings.Trim(quoted, "'"), `'\''`, "'")
+	target := filepath.Join(rootDir, name)
+	preserve := strings.Contains(flags, "-p")
+	in := bufio.NewReader(channel)
+
+	fail := func(message string) uint32 {
+		_, _ = fmt.Fprintf(channel, "\x01scp: %s: %s\n", name, message)
+		return 1
+	}
+
+	if strings.Contains(flags, "-f") {
+		if _, err := in.ReadByte(); err != nil {
+			return 1
+		}
+
+		fileInfo, err := os.Stat(target)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return fail("No such file or directory")
+		case err != nil:
+			return fail(err.Error())
+		case fileInfo.IsDir():
+			return fail("not a regular file")
+		}
+		contents, err := os.ReadFile(target)
+		if err != nil {
+			return fail("Permission denied")
+		}
+
+		if preserve {
+			mtime := fileInfo.ModTime().Unix()
+			_, _ = fmt.Fprintf(channel, "T%d 0 %d 0\n", mtime, mtime)
+			if code, err := in.ReadByte(); err != nil || code != 0 {
+				return 1
+			}
+		}
+		_, _ = fmt.Fprintf(channel, "C%04o %d %s\n", fileInfo.Mode().Perm(), len(contents), filepath.Base(name))
+		if code, err := in.ReadByte(); err != nil || code != 0 {
+			// The client only wanted the header
+			return 1
+		}
+		_, _ = channel.Write(append(contents, 0))
+		if _, err := in.ReadByte(); err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	_, _ = channel.Write([]byte{0})
+	header, err := in.ReadString('\n')
+	if err != nil || !strings.HasPrefix(header, "C") {
+		return 1
+	}
+	fields := strings.SplitN(strings.TrimSuffix(header[1:], "\n"), " ", 3)
+	mode, _ := strconv.ParseUint(fields[0], 8, 32)
+	size, _ := strconv.Atoi(fields[1])
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(mode))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fail("No such file or directory")
+	case err != nil:
+		return fail("Permission denied")
+	}
+	defer file.Close()
+	_, _ = channel.Write([]byte{0})
+
+	if _, err := io.CopyN(file, in, int64(size)); err != nil {
+		return 1
+	}
+	if _, err := in.ReadByte(); err != nil {
+		return 1
+	}
+	_, _ = channel.Write([]byte{0})
+	return 0
+}
+
+// shellHandler runs commands with the local shell in rootDir
+func shellHandler(rootDir string) execHandler {
+	return func(command string) (string, string, uint32) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = rootDir
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		var exitErr *exec.ExitError
+		if err := cmd.Run(); errors.As(err, &exitErr) {
+			return stdout.String(), stderr.String(), uint32(exitErr.ExitCode())
+		} else if err != nil {
+			return "", err.Error(), 127
+		}
+		return stdout.String(), stderr.String(), 0
+	}
+}
+
+// setupScpTest starts a test server that refuses SFTP but speaks scp and
+// runs other commands with the local shell
+func setupScpTest(t *testing.T) (*testServer, *mockSSHParams) {
+	t.Helper()
+
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	t.Cleanup(cleanup)
+	server.serveScpOnly()
+	server.handleExec(shellHandler(server.testDir))
+
+	return server, &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+}
+
+func TestScpDialer_WithoutSftp(t *testing.T) {
+	_, sshParams := setupScpTest(t)
+
+	input := &mockInputModel{path: types.StringValue("test.txt")}
+	err := remote.ConnectAndCopy(SftpDialer(sshParams), input, &mockOutputModel{})(context.Background())
+	if err == nil {
+		t.Fatal("ConnectAndCopy() over SFTP expected an error from a server without SFTP, got nil")
+	}
+
+	output := &mockOutputModel{}
+	err = remote.ConnectAndCopy(ScpDialer(sshParams), input, output)(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndCopy() over scp error = %v", err)
+	}
+	if output.contents.ValueString() != "test content\n" {
+		t.Errorf("contents = %q, expected %q", output.contents.ValueString(), "test content\n")
+	}
+	if output.size.ValueInt64() != int64(len("test content\n")) {
+		t.Errorf("size = %d, expected %d", output.size.ValueInt64(), len("test content\n"))
+	}
+	if output.id.ValueString() != "test.txt" {
+		t.Errorf("id = %q, expected %q", output.id.ValueString(), "test.txt")
+	}
+	lastModified, err := time.Parse(time.RFC3339, output.lastModified.ValueString())
+	if err != nil || time.Since(lastModified) > time.Hour {
+		t.Errorf("last_modified = %q, expected the time the file was written", output.lastModified.ValueString())
+	}
+}
+
+func TestScpDialer_Write(t *testing.T) {
+	server, sshParams := setupScpTest(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		permissions string
+		validate    string
+		expected    os.FileMode
+	}{
+		{name: "new file", path: "new.txt", permissions: "0600", expected: 0600},
+		{name: "existing file", path: "test.txt", permissions: "0640", expected: 0640},
+		{name: "default mode", path: "default.txt", expected: defaultScpMode},
+		{name: "validated", path: "validated.txt", permissions: "0600", validate: "test -s %s", expected: 0600},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := &mockWriteInputModel{
+				path:        types.StringValue(tc.path),
+				contents:    types.StringValue("written over scp\n"),
+				permissions: types.StringValue(tc.permissions),
+			}
+			if tc.permissions == "" {
+				input.permissions = types.StringNull()
+			}
+
+			var opts []remote.WriteOption
+			if tc.validate != "" {
+				opts = append(opts, remote.WithValidate(tc.validate))
+			}
+
+			output := &mockOutputModel{}
+			err := remote.ConnectAndWrite(ScpDialer(sshParams), input, output, opts...)(context.Background())
+			if err != nil {
+				t.Fatalf("ConnectAndWrite() error = %v", err)
+			}
+
+			fileInfo, err := os.Stat(filepath.Join(server.testDir, tc.path))
+			if err != nil {
+				t.Fatalf("Failed to stat written file: %v", err)
+			}
+			if fileInfo.Mode().Perm() != tc.expected {
+				t.Errorf("mode = %04o, expected %04o", fileInfo.Mode().Perm(), tc.expected)
+			}
+			contents, _ := os.ReadFile(filepath.Join(server.testDir, tc.path))
+			if string(contents) != "written over scp\n" {
+				t.Errorf("contents = %q, expected %q", contents, "written over scp\n")
+			}
+			if output.size.ValueInt64() != int64(len(contents)) {
+				t.Errorf("size = %d, expected %d", output.size.ValueInt64(), len(contents))
+			}
+			staged := filepath.Join(server.testDir, filepath.Dir(tc.path), "."+filepath.Base(tc.path)+".tmp")
+			if _, err := os.Stat(staged); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("staged file left behind, stat error = %v", err)
+			}
+		})
+	}
+}
+
+func TestScpDialer_EditAndDelete(t *testing.T) {
+	server, sshParams := setupScpTest(t)
+
+	input := &mockEditInputModel{path: types.StringValue("test.txt")}
+	err := remote.ConnectAndEdit(ScpDialer(sshParams), input, func(current string, exists bool) (string, error) {
+		return current + "appended\n", nil
+	})(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndEdit() error = %v", err)
+	}
+	contents, _ := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+	if string(contents) != "test content\nappended\n" {
+		t.Errorf("contents = %q, expected %q", contents, "test content\nappended\n")
+	}
+
+	deleteInput := &mockInputModel{path: types.StringValue("test.txt")}
+	for range 2 {
+		// Deleting a file that is already gone succeeds
+		if err := remote.ConnectAndDelete(ScpDialer(sshParams), deleteInput)(context.Background()); err != nil {
+			t.Fatalf("ConnectAndDelete() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(server.testDir, "test.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after delete, stat error = %v", err)
+	}
+}
+
+func TestScpDialer_Missing(t *testing.T) {
+	_, sshParams := setupScpTest(t)
+
+	input := &mockInputModel{path: types.StringValue("missing.txt")}
+	err := remote.ConnectAndCopy(ScpDialer(sshParams), input, &mockOutputModel{})(context.Background())
+	if !errors.Is(err, remote.ErrNotFound) {
+		t.Errorf("ConnectAndCopy() error = %v, expected ErrNotFound", err)
+	}
+
+	input.allowMissing = types.BoolValue(true)
+	output := &mockOutputModel{}
+	err = remote.ConnectAndCopy(ScpDialer(sshParams), input, output)(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndCopy() with allow_missing error = %v", err)
+	}
+	if output.size.ValueInt64() != -1 {
+		t.Errorf("size = %d, expected -1 for a missing file", output.size.ValueInt64())
+	}
+}
+
+func TestScpDialer_StatDirectory(t *testing.T) {
+	server, sshParams := setupScpTest(t)
+
+	if err := os.Mkdir(filepath.Join(server.testDir, "conf.d"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	b, err := ScpDialer(sshParams)(context.Background())
+	if err != nil {
+		t.Fatalf("ScpDialer() error = %v", err)
+	}
+	defer b.Close()
+
+	fileInfo, err := b.Stat("conf.d")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if !fileInfo.IsDir() {
+		t.Errorf("Stat() of a directory IsDir() = false")
+	}
+
+	entries, err := b.ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("ReadDir() returned %d entries, expected conf.d and test.txt", len(entries))
+	}
+}
+
+func TestScpDialer_RefusesSudo(t *testing.T) {
+	_, sshParams := setupScpTest(t)
+
+	params := &mockSudoSSHParams{mockSSHParams: *sshParams, sudo: SudoInstall}
+	input := &mockInputModel{path: types.StringValue("test.txt")}
+	err := remote.ConnectAndCopy(ScpDialer(params), input, &mockOutputModel{})(context.Background())
+	if !errors.Is(err, remote.ErrSudo) {
+		t.Errorf("ConnectAndCopy() error = %v, expected ErrSudo", err)
+	}
+}
+
+func TestScpError(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected error
+	}{
+		{"scp: /etc/app.conf: No such file or directory", os.ErrNotExist},
+		{"scp: /etc/app.conf: Permission denied", os.ErrPermission},
+		{"scp: /etc/app.conf: No space left on device", syscall.ENOSPC},
+		{"chown: /etc/app.conf: Operation not permitted", os.ErrPermission},
+	}
+
+	for _, tc := range tests {
+		if err := scpError(tc.message); !errors.Is(err, tc.expected) {
+			t.Errorf("scpError(%q) = %v, expected it to wrap %v", tc.message, err, tc.expected)
+		}
+	}
+
+	if err := scpError("scp: /etc: not a regular file"); !errors.Is(err, errNotRegular) {
+		t.Errorf("scpError() for a directory = %v, expected errNotRegular", err)
+	}
+}
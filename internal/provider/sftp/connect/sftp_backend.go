@@ -0,0 +1,252 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/sftp"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// sftpBackend is a host reached over SSH, its files transferred over SFTP and
+// its commands run in SSH sessions
+type sftpBackend struct {
+	host       remoteHost
+	sftpClient *sftp.Client
+	address    string
+}
+
+var (
+	_ backend.Backend   = &sftpBackend{}
+	_ backend.Commander = &sftpBackend{}
+)
+
+// SftpDialer returns a Dialer that connects to the host described by
+// sshConnParams and starts the SFTP subsystem, as root if they say so
+func SftpDialer(sshConnParams SshConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		ctx = remote.WithAddress(ctx, sshConnParams.GetAddress())
+		host, err := connectHost(ctx, sshConnParams)
+		if err != nil {
+			return nil, err
+		}
+
+		sftpClient, err := openSftp(ctx, host, sshConnParams)
+		if err != nil {
+			host.Close()
+			return nil, err
+		}
+
+		b := &sftpBackend{
+			host:       host,
+			sftpClient: sftpClient,
+			address:    sshConnParams.GetAddress(),
+		}
+		if sudo, sudoPassword, _ := sudoSettings(sshConnParams); sudo == SudoInstall {
+			return &sudoInstallBackend{sftpBackend: b, password: sudoPassword}, nil
+		}
+		return b, nil
+	}
+}
+
+func (b *sftpBackend) Address() string {
+	return b.address
+}
+
+func (b *sftpBackend) Open(path string) (backend.File, error) {
+	file, err := b.sftpClient.Open(path)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &sftpFile{file}, nil
+}
+
+func (b *sftpBackend) OpenFile(path string, flag int) (backend.File, error) {
+	file, err := b.sftpClient.OpenFile(path, flag)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &sftpFile{file}, nil
+}
+
+func (b *sftpBackend) Stat(path string) (os.FileInfo, error) {
+	fileInfo, err := b.sftpClient.Stat(path)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return withOwnership(fileInfo), nil
+}
+
+func (b *sftpBackend) Lstat(path string) (os.FileInfo, error) {
+	fileInfo, err := b.sftpClient.Lstat(path)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return withOwnership(fileInfo), nil
+}
+
+func (b *sftpBackend) Chmod(path string, mode os.FileMode) error {
+	return statusError(b.sftpClient.Chmod(path, mode))
+}
+
+func (b *sftpBackend) Chown(path string, uid int, gid int) error {
+	return statusError(b.sftpClient.Chown(path, uid, gid))
+}
+
+// Rename uses the atomic posix-rename extension when the server supports it.
+// Plain SFTP rename refuses to overwrite, so without it replacing an existing
+// newpath fails with errors.ErrUnsupported.
+func (b *sftpBackend) Rename(oldpath string, newpath string) error {
+	if _, ok := b.sftpClient.HasExtension("posix-rename@openssh.com"); ok {
+		return statusError(b.sftpClient.PosixRename(oldpath, newpath))
+	}
+
+	err := b.sftpClient.Rename(oldpath, newpath)
+	if err != nil {
+		if _, statErr := b.sftpClient.Lstat(newpath); statErr == nil {
+			return fmt.Errorf("%w: the server can't replace %s atomically without the posix-rename@openssh.com extension",
+				errors.ErrUnsupported, newpath)
+		}
+	}
+	return statusError(err)
+}
+
+func (b *sftpBackend) Remove(path string) error {
+	return statusError(b.sftpClient.Remove(path))
+}
+
+func (b *sftpBackend) Mkdir(path string) error {
+	return statusError(b.sftpClient.Mkdir(path))
+}
+
+func (b *sftpBackend) ReadDir(path string) ([]os.FileInfo, error) {
+	fileInfos, err := b.sftpClient.ReadDir(path)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	for i, fileInfo := range fileInfos {
+		fileInfos[i] = withOwnership(fileInfo)
+	}
+	return fileInfos, nil
+}
+
+func (b *sftpBackend) Run(ctx context.Context, command string) (backend.CommandResult, error) {
+	return runCommand(ctx, b.host, command)
+}
+
+func (b *sftpBackend) Close() error {
+	b.sftpClient.Close()
+	return b.host.Close()
+}
+
+// sftpFile is a remote file opened over SFTP, its errors carrying what their
+// status codes mean
+type sftpFile struct {
+	file *sftp.File
+}
+
+func (f *sftpFile) Read(p []byte) (int, error) {
+	n, err := f.file.Read(p)
+	return n, statusError(err)
+}
+
+// WriteTo keeps the concurrent reads of sftp.File for io.Copy
+func (f *sftpFile) WriteTo(w io.Writer) (int64, error) {
+	n, err := f.file.WriteTo(w)
+	return n, statusError(err)
+}
+
+func (f *sftpFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	return n, statusError(err)
+}
+
+func (f *sftpFile) Seek(offset int64, whence int) (int64, error) {
+	return f.file.Seek(offset, whence)
+}
+
+func (f *sftpFile) Close() error {
+	return statusError(f.file.Close())
+}
+
+// fileInfo reports the owner of a file through backend.Owned
+type fileInfo struct {
+	os.FileInfo
+	owner owner
+}
+
+func (i *fileInfo) Sys() any {
+	return i.owner
+}
+
+// owner is the uid and gid of a file
+type owner struct {
+	uid int
+	gid int
+}
+
+func (o owner) Ownership() (uid int, gid int) {
+	return o.uid, o.gid
+}
+
+// withOwnership returns info with its owner, if the server reported it
+func withOwnership(info os.FileInfo) os.FileInfo {
+	stat, ok := info.Sys().(*sftp.FileStat)
+	if !ok {
+		return info
+	}
+	return &fileInfo{FileInfo: info, owner: owner{uid: int(stat.UID), gid: int(stat.GID)}}
+}
+
+// SFTP v5/v6 status codes that pkg/sftp doesn't name, sent by servers that
+// can tell a full disk apart from a generic failure
+const (
+	sshFxNoSpaceOnFilesystem = 14
+	sshFxQuotaExceeded       = 15
+)
+
+// sftpStatusError is a failure reported by the SFTP server, wrapping the os
+// or typed error its status code means
+type sftpStatusError struct {
+	err  error
+	kind error
+}
+
+func (e *sftpStatusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sftpStatusError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
+
+// statusError returns err wrapping what its SFTP status code means, if the
+// server sent one that is known
+func statusError(err error) error {
+	var statusErr *sftp.StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	var kind error
+	switch statusErr.Code {
+	case uint32(sftp.ErrSSHFxNoSuchFile):
+		kind = os.ErrNotExist
+	case uint32(sftp.ErrSSHFxPermissionDenied):
+		kind = os.ErrPermission
+	case uint32(sftp.ErrSSHFxNoConnection), uint32(sftp.ErrSSHFxConnectionLost):
+		kind = remote.ErrConnect
+	case sshFxNoSpaceOnFilesystem, sshFxQuotaExceeded:
+		kind = remote.ErrQuotaExceeded
+	case uint32(sftp.ErrSSHFxOpUnsupported):
+		kind = remote.ErrUnsupported
+	default:
+		return err
+	}
+	return &sftpStatusError{err: err, kind: kind}
+}
@@ -0,0 +1,70 @@
+package connect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+func TestSftpDialer(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+
+	b, err := SftpDialer(sshParams)(context.Background())
+	if err != nil {
+		t.Fatalf("SftpDialer() error = %v", err)
+	}
+	defer b.Close()
+
+	if b.Address() != serverAddr {
+		t.Errorf("Address() = %q, expected %q", b.Address(), serverAddr)
+	}
+
+	if err := b.Mkdir("conf.d"); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(server.testDir, "conf.d", "staged"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create staged file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(server.testDir, "conf.d", "target"), []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+
+	// Rename replaces an existing target
+	if err := b.Rename("conf.d/staged", "conf.d/target"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	contents, err := os.ReadFile(filepath.Join(server.testDir, "conf.d", "target"))
+	if err != nil {
+		t.Fatalf("Failed to read target file: %v", err)
+	}
+	if string(contents) != "new" {
+		t.Errorf("target contents = %q, expected %q", contents, "new")
+	}
+
+	entries, err := b.ReadDir("conf.d")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 1 || names[0] != "target" {
+		t.Errorf("ReadDir() = %v, expected [target]", names)
+	}
+
+	if _, err := b.Stat("conf.d/staged"); !remote.IsFileNotFound(err) {
+		t.Errorf("Stat() of the renamed file error = %v, expected ErrNotFound", err)
+	}
+}
@@ -0,0 +1,362 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pkg/sftp"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// Ways of escalating to root with sudo
+const (
+	// SudoInstall uploads the file to a temporary path and moves it into
+	// place with `sudo install`
+	SudoInstall = "install"
+	// SudoSftpServer starts the SFTP server as root with sudo, so every
+	// operation runs as root
+	SudoSftpServer = "sftp_server"
+)
+
+// SudoModes lists the supported ways of escalating to root
+var SudoModes = []string{SudoInstall, SudoSftpServer}
+
+// DefaultSftpServer is where Debian and Ubuntu install the OpenSSH SFTP server
+const DefaultSftpServer = "/usr/lib/openssh/sftp-server"
+
+// sudoTempDir is where the private directories files are staged in for
+// `sudo install` are created
+const sudoTempDir = "/tmp"
+
+// sudoPrompt is the password prompt sudo is told to print, so it can be told
+// apart from other output
+const sudoPrompt = "[remotefile sudo password]"
+
+// sudoReady is printed once sudo succeeded, ahead of the command it runs
+const sudoReady = "remotefile-sudo-ready"
+
+// sudoExec is the script sudo runs, printing sudoReady and then running its
+// arguments as the command. The marker tells sudo refusing apart from the
+// command failing, and its output apart from the streams of the command.
+const sudoExec = `echo ` + sudoReady + ` && exec "$0" "$@"`
+
+// sudoer is implemented by connection parameters that escalate to root with
+// sudo
+type sudoer interface {
+	// GetSudo is one of SudoModes, or empty to not use sudo
+	GetSudo() string
+	GetSudoPassword() string
+	GetSudoSftpServer() string
+}
+
+// sudoSettings returns how sshConnParams escalates to root, with an empty
+// mode if it doesn't
+func sudoSettings(sshConnParams SshConnectionParameters) (mode string, password string, server string) {
+	s, ok := sshConnParams.(sudoer)
+	if !ok {
+		return "", "", ""
+	}
+
+	server = s.GetSudoSftpServer()
+	if server == "" {
+		server = DefaultSftpServer
+	}
+	return s.GetSudo(), s.GetSudoPassword(), server
+}
+
+// sudoCommand prefixes command with sudo and sudoExec, reading the password
+// from stdin if there is one and failing rather than prompting if there isn't
+func sudoCommand(password string, command string) string {
+	command = "sh -c " + remote.ShellQuote(sudoExec) + " " + command
+	if password == "" {
+		return "sudo -n " + command
+	}
+	return "sudo -S -p " + remote.ShellQuote(sudoPrompt) + " " + command
+}
+
+// sudoError explains why sudo failed from what it printed to stderr
+func sudoError(stderr string) error {
+	output := strings.TrimSpace(strings.ReplaceAll(stderr, sudoPrompt, ""))
+
+	switch {
+	case strings.Contains(output, "must have a tty"), strings.Contains(output, "terminal is required"):
+		return fmt.Errorf("%w: sudo requires a TTY, which can't be used for file transfers. Allow sudo without one "+
+			"for this user with `Defaults:<user> !requiretty` in sudoers: %s", remote.ErrSudo, output)
+	case strings.Contains(output, "a password is required"):
+		return fmt.Errorf("%w: sudo requires a password, set sudo_password or allow NOPASSWD for this user: %s", remote.ErrSudo, output)
+	case strings.Contains(output, "incorrect password"), strings.Contains(output, "no password was provided"),
+		strings.Contains(output, "Sorry, try again"):
+		return fmt.Errorf("%w: sudo rejected sudo_password: %s", remote.ErrSudo, output)
+	case strings.Contains(output, "not in the sudoers"), strings.Contains(output, "not allowed to execute"):
+		return fmt.Errorf("%w: the user may not run this command with sudo: %s", remote.ErrSudo, output)
+	case output == "":
+		return fmt.Errorf("%w: sudo exited without output", remote.ErrSudo)
+	}
+	return fmt.Errorf("%w: %s", remote.ErrSudo, output)
+}
+
+// promptWriter collects stderr and answers sudo's password prompt once, a
+// second prompt means the password was wrong and stdin is closed so sudo
+// gives up rather than waiting
+type promptWriter struct {
+	mu       sync.Mutex
+	stderr   bytes.Buffer
+	stdin    io.WriteCloser
+	password string
+	prompts  int
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stderr.Write(p)
+	for strings.Count(w.stderr.String(), sudoPrompt) > w.prompts {
+		w.prompts++
+		if w.prompts == 1 {
+			_, _ = io.WriteString(w.stdin, w.password+"\n")
+		} else {
+			_ = w.stdin.Close()
+		}
+	}
+	return len(p), nil
+}
+
+func (w *promptWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stderr.String()
+}
+
+// runSudo runs command as root, failing with ErrSudo if sudo refuses and
+// with the error the command printed if the command itself fails
+func runSudo(ctx context.Context, host remoteHost, password string, command string) error {
+	session, err := host.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error opening SSH session: %w", err)
+	}
+	var stdout bytes.Buffer
+	session.setStdout(&stdout)
+	stderr := &promptWriter{stdin: stdin, password: password}
+	session.setStderr(stderr)
+
+	start := time.Now()
+	err = session.Run(sudoCommand(password, command))
+
+	tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "ran remote command with sudo", map[string]interface{}{
+		"command":     command,
+		"duration_ms": time.Since(start).Milliseconds(),
+		"error":       fmt.Sprint(err),
+	})
+
+	if err != nil {
+		status, exited := exitStatus(err)
+		if !exited {
+			return fmt.Errorf("error running sudo: %w", err)
+		}
+		if !strings.HasPrefix(stdout.String(), sudoReady+"\n") {
+			return sudoError(stderr.String())
+		}
+		output := strings.ReplaceAll(stderr.String(), sudoPrompt, "")
+		return fmt.Errorf("%q exited with status %d: %w", command, status, scpError(output))
+	}
+	return nil
+}
+
+// openSudoSftp starts the SFTP server at server as root. The marker printed
+// once sudo let the server start tells its password prompt and errors apart
+// from the SFTP protocol that follows on the same streams.
+func openSudoSftp(ctx context.Context, host remoteHost, password string, server string) (*sftp.Client, error) {
+	session, err := host.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error opening SSH session: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error opening SSH session: %w", err)
+	}
+	stderr := &promptWriter{stdin: stdin, password: password}
+	session.setStderr(stderr)
+
+	start := time.Now()
+	if err := session.Start(sudoCommand(password, remote.ShellQuote(server))); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error starting SFTP server with sudo: %w", err)
+	}
+
+	// Read the marker a byte at a time so nothing of the SFTP stream that
+	// follows is consumed
+	var line []byte
+	buf := make([]byte, 1)
+	for !bytes.HasSuffix(line, []byte("\n")) {
+		if _, err := stdout.Read(buf); err != nil {
+			_ = session.Wait()
+			session.Close()
+			return nil, sudoError(stderr.String())
+		}
+		line = append(line, buf[0])
+	}
+	if strings.TrimSpace(string(line)) != sudoReady {
+		session.Close()
+		return nil, fmt.Errorf("%w: unexpected output before the SFTP server started: %q", remote.ErrSudo, line)
+	}
+
+	sftpClient, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("error starting SFTP server %s with sudo: %w: %s", server, err, strings.TrimSpace(stderr.String()))
+	}
+
+	tflog.SubsystemDebug(ctx, remote.SubsystemSFTP, "SFTP session opened as root with sudo", map[string]interface{}{
+		"duration_ms": time.Since(start).Milliseconds(),
+		"server":      server,
+	})
+
+	return sftpClient, nil
+}
+
+// sudoStagingDir returns the directory the contents for target are staged
+// in before `sudo install` moves them into place. The name only depends on
+// target so a resumable upload finds its partial file again.
+func sudoStagingDir(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return fmt.Sprintf("%s/.remotefile-%x", sudoTempDir, sum[:8])
+}
+
+// sudoStagingPath returns the file the contents for target are staged in
+func sudoStagingPath(target string) string {
+	return sudoStagingDir(target) + "/contents"
+}
+
+// errStagingNotPrivate is returned when the staging directory could be
+// written or read by other users, who could then swap the staged contents
+// before install runs or read them
+var errStagingNotPrivate = fmt.Errorf("%w: staging directory is not private", remote.ErrPermissionDenied)
+
+// createStagingDir creates dir readable by the user only, or checks that the
+// directory a previous attempt left behind still is
+func createStagingDir(b backend.Backend, dir string) error {
+	mkdirErr := b.Mkdir(dir)
+	if mkdirErr == nil {
+		if err := b.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("error setting permissions of %s: %w", dir, err)
+		}
+	}
+
+	fileInfo, err := b.Lstat(dir)
+	if err != nil {
+		if mkdirErr != nil {
+			err = mkdirErr
+		}
+		return fmt.Errorf("error creating staging directory %s: %w", dir, err)
+	}
+	if !fileInfo.IsDir() || fileInfo.Mode().Perm() != 0700 {
+		return fmt.Errorf("%w: %s is %s, remove it to stage files for sudo there", errStagingNotPrivate, dir, fileInfo.Mode())
+	}
+	return nil
+}
+
+// checkStaged checks, right before install runs, that staged is a regular
+// file in its private directory and that both belong to the same user
+func checkStaged(b backend.Backend, staged string) error {
+	dir := path.Dir(staged)
+	dirInfo, err := b.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("error reading staging directory info: %w", err)
+	}
+	fileInfo, err := b.Lstat(staged)
+	if err != nil {
+		return fmt.Errorf("error reading staged file info: %w", err)
+	}
+
+	if !dirInfo.IsDir() || dirInfo.Mode().Perm() != 0700 {
+		return fmt.Errorf("%w: %s is %s", errStagingNotPrivate, dir, dirInfo.Mode())
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s is %s, not a regular file", errStagingNotPrivate, staged, fileInfo.Mode())
+	}
+	dirOwned, dirOk := dirInfo.Sys().(backend.Owned)
+	fileOwned, fileOk := fileInfo.Sys().(backend.Owned)
+	if !dirOk || !fileOk {
+		return nil
+	}
+	dirOwner, _ := dirOwned.Ownership()
+	fileOwner, _ := fileOwned.Ownership()
+	if dirOwner != fileOwner {
+		return fmt.Errorf("%w: %s belongs to uid %d, the staged file to uid %d", errStagingNotPrivate, dir, dirOwner, fileOwner)
+	}
+	return nil
+}
+
+// installCommand moves staged over target with mode, owner and group
+func installCommand(staged string, target string, mode string, owner string, group string) string {
+	command := "install -m " + remote.ShellQuote(mode)
+	if owner != "" {
+		command += " -o " + remote.ShellQuote(owner)
+	}
+	if group != "" {
+		command += " -g " + remote.ShellQuote(group)
+	}
+	return command + " -- " + remote.ShellQuote(staged) + " " + remote.ShellQuote(target)
+}
+
+// sudoInstallBackend is an SFTP backend that installs files as root with
+// `sudo install`, after staging them in a private directory under the
+// temporary directory
+type sudoInstallBackend struct {
+	*sftpBackend
+	password string
+}
+
+var _ backend.Installer = &sudoInstallBackend{}
+
+func (b *sudoInstallBackend) Stage(target string) (string, func(), error) {
+	dir := sudoStagingDir(target)
+	err := createStagingDir(b, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return sudoStagingPath(target), func() { _ = b.Remove(dir) }, nil
+}
+
+func (b *sudoInstallBackend) Install(ctx context.Context, staged string, target string, mode os.FileMode, owner string, group string) error {
+	err := checkStaged(b, staged)
+	if err != nil {
+		return err
+	}
+
+	command := installCommand(staged, target, fmt.Sprintf("%04o", mode), owner, group)
+	return runSudo(ctx, b.host, b.password, command)
+}
+
+// Uninstall reports a missing file like sftp.Client.Remove does
+func (b *sudoInstallBackend) Uninstall(ctx context.Context, target string) error {
+	if _, err := b.Lstat(target); err != nil {
+		return err
+	}
+	return runSudo(ctx, b.host, b.password, "rm -f -- "+remote.ShellQuote(target))
+}
@@ -0,0 +1,359 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// mockSudoSSHParams are connection parameters that escalate with sudo
+type mockSudoSSHParams struct {
+	mockSSHParams
+	sudo     string
+	password string
+}
+
+func (m *mockSudoSSHParams) GetSudo() string           { return m.sudo }
+func (m *mockSudoSSHParams) GetSudoPassword() string   { return m.password }
+func (m *mockSudoSSHParams) GetSudoSftpServer() string { return "" }
+
+// quotedArgs returns the single quoted arguments of command
+func quotedArgs(command string) []string {
+	var args []string
+	for parts := strings.Split(command, "'"); len(parts) > 2; parts = parts[2:] {
+		args = append(args, parts[1])
+	}
+	return args
+}
+
+// fakeSudo answers sudo install and rm commands on the test server without
+// changing users
+func fakeSudo(t *testing.T, rootDir string, commands *[]string) execHandler {
+	return func(command string) (string, string, uint32) {
+		*commands = append(*commands, command)
+		args := quotedArgs(command)
+
+		switch {
+		case strings.Contains(command, " install "):
+			staged, target := args[len(args)-2], args[len(args)-1]
+			contents, err := os.ReadFile(staged)
+			if err != nil {
+				return sudoReady + "\n", fmt.Sprintf("install: cannot stat '%s': %v\n", staged, err), 1
+			}
+			if err := os.WriteFile(filepath.Join(rootDir, target), contents, 0644); err != nil {
+				t.Errorf("failed to install %s: %v", target, err)
+			}
+		case strings.Contains(command, " rm "):
+			if err := os.Remove(filepath.Join(rootDir, args[len(args)-1])); err != nil {
+				t.Errorf("failed to remove: %v", err)
+			}
+		default:
+			return "", "sudo: unexpected command\n", 1
+		}
+		return sudoReady + "\n", "", 0
+	}
+}
+
+func TestConnectAndWriteOperation_SudoInstall(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(fakeSudo(t, server.testDir, &commands))
+
+	sshParams := &mockSudoSSHParams{
+		mockSSHParams: mockSSHParams{
+			config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+			address: serverAddr,
+		},
+		sudo:     SudoInstall,
+		password: "secret",
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("protected.conf"),
+		contents:    types.StringValue("written as root"),
+		permissions: types.StringValue("0640"),
+	}
+	output := &mockOutputModel{}
+
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, output, remote.WithOwnership("www-data", "adm"))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	staged := sudoStagingPath("protected.conf")
+	expected := `sudo -S -p '[remotefile sudo password]' sh -c 'echo remotefile-sudo-ready && exec "$0" "$@"' ` +
+		"install -m '0640' -o 'www-data' -g 'adm' -- '" + staged + "' 'protected.conf'"
+	if len(commands) != 1 || commands[0] != expected {
+		t.Errorf("commands = %q, expected %q", commands, expected)
+	}
+
+	content, err := os.ReadFile(filepath.Join(server.testDir, "protected.conf"))
+	if err != nil || string(content) != "written as root" {
+		t.Errorf("File content = %q (%v), expected the new contents", string(content), err)
+	}
+	if _, err := os.Stat(sudoStagingDir("protected.conf")); !os.IsNotExist(err) {
+		t.Errorf("expected the staging directory of %s to be removed, got %v", staged, err)
+	}
+	if output.GetSize().ValueInt64() != int64(len("written as root")) {
+		t.Errorf("output.Size = %d, expected the installed file's size", output.GetSize().ValueInt64())
+	}
+}
+
+func TestConnectAndWriteOperation_SudoInstallFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		stdout   string
+		stderr   string
+		expected error
+	}{
+		{"sudo refused", "", "sudo: a password is required\n", remote.ErrSudo},
+		{"permission denied", sudoReady + "\n", "install: cannot create regular file 'protected.conf': Permission denied\n", remote.ErrPermissionDenied},
+		{"missing directory", sudoReady + "\n", "install: cannot create regular file 'protected.conf': No such file or directory\n", remote.ErrNotFound},
+		{"invalid owner", sudoReady + "\n", "install: invalid user 'www-data'\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverAddr, _, cleanup := setupIntegrationTest(t)
+			defer cleanup()
+
+			var commands []string
+			server.handleExec(recordCommands(&commands, tt.stdout, tt.stderr, 1))
+
+			sshParams := &mockSudoSSHParams{
+				mockSSHParams: mockSSHParams{
+					config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+					address: serverAddr,
+				},
+				sudo: SudoInstall,
+			}
+			input := &mockWriteInputModel{
+				path:        types.StringValue("protected.conf"),
+				contents:    types.StringValue("written as root"),
+				permissions: types.StringValue("0640"),
+			}
+
+			err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithOwnership("www-data", ""))(context.Background())
+			if err == nil || !strings.Contains(err.Error(), strings.TrimSpace(tt.stderr)) {
+				t.Fatalf("expected an error reporting %q, got %v", tt.stderr, err)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+			if tt.expected != remote.ErrSudo && errors.Is(err, remote.ErrSudo) {
+				t.Errorf("expected a failure of install not to be ErrSudo, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConnectAndWriteOperation_SudoInstallRefusesStagingPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(dir string) error
+	}{
+		{"file", func(dir string) error { return os.WriteFile(dir, []byte("planted"), 0666) }},
+		{"shared directory", func(dir string) error {
+			if err := os.Mkdir(dir, 0777); err != nil {
+				return err
+			}
+			return os.Chmod(dir, 0777)
+		}},
+		{"symlink", func(dir string) error { return os.Symlink(t.TempDir(), dir) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverAddr, _, cleanup := setupIntegrationTest(t)
+			defer cleanup()
+
+			var commands []string
+			server.handleExec(fakeSudo(t, server.testDir, &commands))
+
+			// Someone else got to the staging path first
+			target := filepath.Join(server.testDir, "protected.conf")
+			dir := sudoStagingDir(target)
+			if err := tt.create(dir); err != nil {
+				t.Fatalf("failed to create %s: %v", dir, err)
+			}
+			defer os.RemoveAll(dir)
+
+			sshParams := &mockSudoSSHParams{
+				mockSSHParams: mockSSHParams{
+					config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+					address: serverAddr,
+				},
+				sudo: SudoInstall,
+			}
+			input := &mockWriteInputModel{
+				path:        types.StringValue(target),
+				contents:    types.StringValue("written as root"),
+				permissions: types.StringValue("0640"),
+			}
+
+			err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{})(context.Background())
+			if !errors.Is(err, errStagingNotPrivate) || !errors.Is(err, remote.ErrPermissionDenied) {
+				t.Fatalf("expected the staging path to be refused, got %v", err)
+			}
+			if len(commands) != 0 {
+				t.Errorf("commands = %q, expected install not to run", commands)
+			}
+			if _, err := os.Stat(target); !os.IsNotExist(err) {
+				t.Errorf("expected %s not to be written, got %v", target, err)
+			}
+		})
+	}
+}
+
+func TestConnectAndDeleteOperation_SudoInstall(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(fakeSudo(t, server.testDir, &commands))
+
+	sshParams := &mockSudoSSHParams{
+		mockSSHParams: mockSSHParams{
+			config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+			address: serverAddr,
+		},
+		sudo: SudoInstall,
+	}
+	input := &mockWriteInputModel{path: types.StringValue("test.txt")}
+
+	for range 2 {
+		if err := remote.ConnectAndDelete(SftpDialer(sshParams), input)(context.Background()); err != nil {
+			t.Fatalf("ConnectAndDelete() error = %v", err)
+		}
+	}
+
+	// The second delete finds the file gone and runs nothing
+	if len(commands) != 1 || commands[0] != `sudo -n sh -c 'echo remotefile-sudo-ready && exec "$0" "$@"' rm -f -- 'test.txt'` {
+		t.Errorf("commands = %q, expected a single sudo rm", commands)
+	}
+	if _, err := os.Stat(filepath.Join(server.testDir, "test.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected test.txt to be deleted, got %v", err)
+	}
+}
+
+func TestConnectAndCopyOperation_SudoSftpServerRefused(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(func(command string) (string, string, uint32) {
+		commands = append(commands, command)
+		return "", "sudo: a password is required\n", 1
+	})
+
+	sshParams := &mockSudoSSHParams{
+		mockSSHParams: mockSSHParams{
+			config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+			address: serverAddr,
+		},
+		sudo: SudoSftpServer,
+	}
+	input := &mockInputModel{path: types.StringValue("test.txt"), allowMissing: types.BoolValue(false)}
+
+	err := remote.ConnectAndCopy(SftpDialer(sshParams), input, &mockOutputModel{})(context.Background())
+	if !errors.Is(err, remote.ErrSudo) || !strings.Contains(err.Error(), "set sudo_password") {
+		t.Fatalf("expected ErrSudo asking for sudo_password, got %v", err)
+	}
+	if remote.IsRetryable(err) {
+		t.Errorf("expected sudo errors not to be retried")
+	}
+	if len(commands) != 1 || !strings.HasPrefix(commands[0], "sudo -n sh -c ") || !strings.Contains(commands[0], DefaultSftpServer) {
+		t.Errorf("commands = %q, expected the SFTP server started with sudo", commands)
+	}
+}
+
+func TestConnectAndCopyOperation_SudoSftpServer(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	var commands []string
+	server.handleExec(func(command string) (string, string, uint32) {
+		commands = append(commands, command)
+		return sudoReady + "\n" + sftpServerCommand, "", 0
+	})
+
+	sshParams := &mockSudoSSHParams{
+		mockSSHParams: mockSSHParams{
+			config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+			address: serverAddr,
+		},
+		sudo:     SudoSftpServer,
+		password: "secret",
+	}
+	input := &mockInputModel{path: types.StringValue("test.txt"), allowMissing: types.BoolValue(false)}
+	output := &mockOutputModel{}
+
+	err := remote.ConnectAndCopy(SftpDialer(sshParams), input, output)(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if output.GetContents().ValueString() != testContent {
+		t.Errorf("output.Contents = %q, expected %q", output.GetContents().ValueString(), testContent)
+	}
+	expected := `sudo -S -p '[remotefile sudo password]' sh -c 'echo remotefile-sudo-ready && exec "$0" "$@"' '` + DefaultSftpServer + "'"
+	if len(commands) != 1 || commands[0] != expected {
+		t.Errorf("commands = %q, expected %q", commands, expected)
+	}
+}
+
+func TestSudoError(t *testing.T) {
+	tests := []struct {
+		stderr string
+		errMsg string
+	}{
+		{"sudo: sorry, you must have a tty to run sudo\n", "requires a TTY"},
+		{"sudo: a terminal is required to read the password; either use the -S option\n", "requires a TTY"},
+		{"sudo: a password is required\n", "set sudo_password"},
+		{sudoPrompt + "Sorry, try again.\n" + sudoPrompt + "sudo: 1 incorrect password attempt\n", "rejected sudo_password"},
+		{"deploy is not in the sudoers file.\n", "may not run this command"},
+		{"install: invalid user 'nobody2'\n", "invalid user"},
+	}
+
+	for _, tt := range tests {
+		err := sudoError(tt.stderr)
+		if !errors.Is(err, remote.ErrSudo) || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("sudoError(%q) = %v, expected ErrSudo containing %q", tt.stderr, err, tt.errMsg)
+		}
+	}
+}
+
+func TestConnectAndWriteOperation_Ownership(t *testing.T) {
+	server, serverAddr, _, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		contents:    types.StringValue("owned"),
+		permissions: types.StringNull(),
+	}
+
+	// Changing to the current owner is always allowed
+	owner := fmt.Sprint(os.Getuid())
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithOwnership(owner, ""))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithOwnership("no-such-user-remotefile", ""))(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "not found in /etc/passwd") {
+		t.Errorf("expected an unknown user error, got %v", err)
+	}
+}
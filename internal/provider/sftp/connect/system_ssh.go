@@ -0,0 +1,305 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pkg/sftp"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// systemSshExitStatus is what ssh exits with when it failed itself rather
+// than the remote command
+const systemSshExitStatus = 255
+
+// systemSshWaitDelay is how long a killed ssh gets to close its output
+const systemSshWaitDelay = time.Second
+
+// hostKeyAlias is what the host is called in the known_hosts file written
+// for host_key, so it matches whatever host and port ssh connects to
+const hostKeyAlias = "remotefile"
+
+// systemSsher is implemented by connection parameters that connect with the
+// system ssh binary, which honours ~/.ssh/config, ControlMaster, ProxyCommand
+// and the agent
+type systemSsher interface {
+	// GetSystemSsh is the path of the ssh binary, empty to connect with the
+	// built in client
+	GetSystemSsh() string
+	GetHost() string
+	// GetPort is 0 unless set, ssh then takes it from its configuration
+	GetPort() int64
+	GetPrivateKey() string
+	GetHostKey() string
+}
+
+// systemSsh returns the parameters of sshConnParams for the system ssh
+// binary, if it is to be used
+func systemSsh(sshConnParams SshConnectionParameters) (systemSsher, bool) {
+	s, ok := sshConnParams.(systemSsher)
+	if !ok || s.GetSystemSsh() == "" {
+		return nil, false
+	}
+	return s, true
+}
+
+// connectHost connects to the host described by sshConnParams, with the
+// system ssh binary if they say so and the built in client otherwise
+func connectHost(ctx context.Context, sshConnParams SshConnectionParameters) (remoteHost, error) {
+	if s, ok := systemSsh(sshConnParams); ok {
+		return dialSystemSsh(ctx, sshConnParams, s)
+	}
+
+	sshClient, err := dial(ctx, sshConnParams)
+	if err != nil {
+		return nil, err
+	}
+	return &sshHost{sshClient}, nil
+}
+
+// systemSshHost is a host reached by running the system ssh binary, once
+// per command and once for the SFTP subsystem
+type systemSshHost struct {
+	// ctx is the one the host was dialed with, the processes are killed once
+	// it is done
+	ctx    context.Context
+	path   string
+	args   []string
+	target string
+	// tempDir holds the private key and known_hosts files, if any
+	tempDir string
+
+	sftp *exec.Cmd
+}
+
+// dialSystemSsh prepares the arguments of ssh and runs `true` to check that
+// the host can be reached with them
+func dialSystemSsh(ctx context.Context, sshConnParams SshConnectionParameters, s systemSsher) (remoteHost, error) {
+	config := sshConnParams.GetSshConfig()
+	h := &systemSshHost{
+		ctx:    ctx,
+		path:   s.GetSystemSsh(),
+		target: s.GetHost(),
+		args: []string{
+			"-T",
+			"-o", "BatchMode=yes",
+			"-o", "ConnectTimeout=" + strconv.Itoa(int(math.Ceil(config.Timeout.Seconds()))),
+		},
+	}
+	if port := s.GetPort(); port != 0 {
+		h.args = append(h.args, "-p", strconv.FormatInt(port, 10))
+	}
+	if config.User != "" {
+		h.args = append(h.args, "-l", config.User)
+	}
+
+	if s.GetPrivateKey() != "" || s.GetHostKey() != "" {
+		var err error
+		h.tempDir, err = os.MkdirTemp("", "remotefile-ssh-")
+		if err != nil {
+			return nil, fmt.Errorf("error creating directory for ssh files: %w", err)
+		}
+	}
+	if key := s.GetPrivateKey(); key != "" {
+		identity := filepath.Join(h.tempDir, "identity")
+		if err := os.WriteFile(identity, []byte(strings.TrimSpace(key)+"\n"), 0600); err != nil {
+			h.Close()
+			return nil, fmt.Errorf("error writing private key for ssh: %w", err)
+		}
+		h.args = append(h.args, "-i", identity, "-o", "IdentitiesOnly=yes")
+	}
+	if key := s.GetHostKey(); key != "" {
+		knownHosts := filepath.Join(h.tempDir, "known_hosts")
+		if err := os.WriteFile(knownHosts, []byte(hostKeyAlias+" "+strings.TrimSpace(key)+"\n"), 0600); err != nil {
+			h.Close()
+			return nil, fmt.Errorf("error writing host key for ssh: %w", err)
+		}
+		h.args = append(h.args,
+			"-o", "UserKnownHostsFile="+knownHosts,
+			"-o", "GlobalKnownHostsFile=/dev/null",
+			"-o", "HostKeyAlias="+hostKeyAlias,
+			"-o", "StrictHostKeyChecking=yes",
+		)
+	}
+
+	tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "connecting with the system ssh", map[string]interface{}{
+		"ssh":  h.path,
+		"args": strings.Join(h.args, " "),
+	})
+
+	start := time.Now()
+	if _, err := runCommand(ctx, h, "true"); err != nil {
+		h.Close()
+		return nil, err
+	}
+	tflog.SubsystemDebug(ctx, remote.SubsystemSSH, "system ssh connected", map[string]interface{}{
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	return h, nil
+}
+
+// command returns ssh running args on the target
+func (h *systemSshHost) command(args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(h.ctx, h.path, h.args...)
+	cmd.Args = append(cmd.Args, args...)
+	cmd.WaitDelay = systemSshWaitDelay
+	return cmd
+}
+
+func (h *systemSshHost) newSession() (session, error) {
+	s := &systemSshSession{host: h, cmd: h.command()}
+	s.cmd.Stderr = &s.stderr
+	return s, nil
+}
+
+// newSftpClient starts the SFTP subsystem and speaks the protocol over the
+// stdin and stdout of ssh
+func (h *systemSshHost) newSftpClient() (*sftp.Client, error) {
+	var stderr bytes.Buffer
+	cmd := h.command("-s", "--", h.target, "sftp")
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting %s: %w", h.path, err)
+	}
+
+	sftpClient, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		waitErr := cmd.Wait()
+		if status, _ := exitStatus(waitErr); status == systemSshExitStatus {
+			return nil, systemSshError(stderr.String())
+		}
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	h.sftp = cmd
+	return sftpClient, nil
+}
+
+// Close stops the SFTP subsystem and removes the files written for ssh
+func (h *systemSshHost) Close() error {
+	if h.sftp != nil {
+		_ = h.sftp.Process.Kill()
+		_ = h.sftp.Wait()
+		h.sftp = nil
+	}
+	if h.tempDir != "" {
+		return os.RemoveAll(h.tempDir)
+	}
+	return nil
+}
+
+// systemSshSession is ssh running a single command
+type systemSshSession struct {
+	host *systemSshHost
+	cmd  *exec.Cmd
+	// stderr is what ssh printed, which explains why it failed itself
+	stderr bytes.Buffer
+
+	waited bool
+}
+
+func (s *systemSshSession) StdinPipe() (io.WriteCloser, error) {
+	return s.cmd.StdinPipe()
+}
+
+func (s *systemSshSession) StdoutPipe() (io.Reader, error) {
+	return s.cmd.StdoutPipe()
+}
+
+func (s *systemSshSession) setStdout(w io.Writer) {
+	s.cmd.Stdout = w
+}
+
+func (s *systemSshSession) setStderr(w io.Writer) {
+	s.cmd.Stderr = io.MultiWriter(w, &s.stderr)
+}
+
+func (s *systemSshSession) Start(command string) error {
+	s.cmd.Args = append(s.cmd.Args, "--", s.host.target, command)
+	if err := s.cmd.Start(); err != nil {
+		return fmt.Errorf("error starting %s: %w", s.host.path, err)
+	}
+	return nil
+}
+
+func (s *systemSshSession) Run(command string) error {
+	if err := s.Start(command); err != nil {
+		return err
+	}
+	return s.Wait()
+}
+
+// Wait waits for the command to exit. Exit status 255 is taken to be ssh
+// failing to connect rather than the command, and explained from stderr.
+func (s *systemSshSession) Wait() error {
+	s.waited = true
+	err := s.cmd.Wait()
+	if status, _ := exitStatus(err); status == systemSshExitStatus {
+		return systemSshError(s.stderr.String())
+	}
+	return err
+}
+
+// Close kills ssh unless it was waited for
+func (s *systemSshSession) Close() error {
+	if s.cmd.Process == nil || s.waited {
+		return nil
+	}
+	_ = s.cmd.Process.Kill()
+	_ = s.Wait()
+	return nil
+}
+
+// systemSshError turns what ssh printed when it failed into an error wrapping
+// the matching typed error, so it is classified like the errors of the built
+// in client
+func systemSshError(stderr string) error {
+	message := strings.TrimSpace(stderr)
+	if message == "" {
+		message = fmt.Sprintf("ssh exited with status %d", systemSshExitStatus)
+	}
+
+	var kind error
+	switch {
+	case strings.Contains(message, "Permission denied"), strings.Contains(message, "Too many authentication failures"):
+		kind = remote.ErrAuthFailed
+	case strings.Contains(message, "Host key verification failed"),
+		strings.Contains(message, "REMOTE HOST IDENTIFICATION HAS CHANGED"):
+		kind = remote.ErrHostKeyMismatch
+	case strings.Contains(message, "Connection refused"),
+		strings.Contains(message, "Connection timed out"),
+		strings.Contains(message, "Operation timed out"),
+		strings.Contains(message, "Could not resolve hostname"),
+		strings.Contains(message, "No route to host"),
+		strings.Contains(message, "Network is unreachable"),
+		strings.Contains(message, "Connection reset"),
+		strings.Contains(message, "Connection closed"),
+		strings.Contains(message, "kex_exchange_identification"):
+		kind = remote.ErrConnect
+	default:
+		return errors.New(message)
+	}
+	return fmt.Errorf("%w: %s", kind, message)
+}
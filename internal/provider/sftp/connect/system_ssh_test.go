@@ -0,0 +1,189 @@
+package connect
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// mockSystemSshParams connect with the system ssh binary at path
+type mockSystemSshParams struct {
+	mockSSHParams
+	path       string
+	host       string
+	port       int64
+	privateKey string
+	hostKey    string
+}
+
+func (m *mockSystemSshParams) GetSystemSsh() string  { return m.path }
+func (m *mockSystemSshParams) GetHost() string       { return m.host }
+func (m *mockSystemSshParams) GetPort() int64        { return m.port }
+func (m *mockSystemSshParams) GetPrivateKey() string { return m.privateKey }
+func (m *mockSystemSshParams) GetHostKey() string    { return m.hostKey }
+
+// setupSystemSshTest starts the test server with a key authorized and
+// returns parameters connecting to it with the system ssh binary
+func setupSystemSshTest(t *testing.T) (*testServer, *mockSystemSshParams) {
+	t.Helper()
+
+	path, err := exec.LookPath("ssh")
+	if err != nil {
+		t.Skip("no ssh binary in PATH")
+	}
+
+	server, err := setupTestServer(t)
+	if err != nil {
+		t.Fatalf("Failed to setup test server: %v", err)
+	}
+	t.Cleanup(server.cleanup)
+	server.handleExec(func(command string) (string, string, uint32) {
+		if command == "true" {
+			return "", "", 0
+		}
+		return "", "unexpected command " + command, 127
+	})
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.authorize(signer.PublicKey())
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.ParseInt(port, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, &mockSystemSshParams{
+		mockSSHParams: mockSSHParams{
+			config:  &ssh.ClientConfig{User: "testuser", Timeout: 10 * time.Second},
+			address: server.listener.Addr().String(),
+		},
+		path:       path,
+		host:       host,
+		port:       portNumber,
+		privateKey: string(pem.EncodeToMemory(block)),
+		hostKey:    string(ssh.MarshalAuthorizedKey(server.hostPrivateKey.PublicKey())),
+	}
+}
+
+func TestSystemSsh_CopyAndWrite(t *testing.T) {
+	server, params := setupSystemSshTest(t)
+	if err := os.WriteFile(filepath.Join(server.testDir, "test.txt"), []byte("over ssh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := &mockInputModel{path: types.StringValue("test.txt"), allowMissing: types.BoolValue(false)}
+	output := &mockOutputModel{}
+	if err := remote.ConnectAndCopy(SftpDialer(params), input, output)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if output.contents.ValueString() != "over ssh\n" {
+		t.Errorf("contents = %q, expected %q", output.contents.ValueString(), "over ssh\n")
+	}
+
+	write := &mockWriteInputModel{
+		path:        types.StringValue("written.txt"),
+		contents:    types.StringValue("written over ssh\n"),
+		permissions: types.StringValue("0600"),
+	}
+	if err := remote.ConnectAndWrite(SftpDialer(params), write, &mockOutputModel{})(context.Background()); err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(server.testDir, "written.txt"))
+	if err != nil || string(written) != "written over ssh\n" {
+		t.Errorf("written = %q, %v, expected the contents", written, err)
+	}
+}
+
+func TestSystemSsh_HostKeyMismatch(t *testing.T) {
+	_, params := setupSystemSshTest(t)
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ssh.NewPublicKey(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.hostKey = string(ssh.MarshalAuthorizedKey(otherKey))
+
+	_, err = SftpDialer(params)(context.Background())
+	if !errors.Is(err, remote.ErrHostKeyMismatch) {
+		t.Errorf("SftpDialer() error = %v, expected %v", err, remote.ErrHostKeyMismatch)
+	}
+}
+
+func TestSystemSsh_AuthFailed(t *testing.T) {
+	server, params := setupSystemSshTest(t)
+	server.authorize(nil)
+
+	_, err := SftpDialer(params)(context.Background())
+	if !errors.Is(err, remote.ErrAuthFailed) {
+		t.Errorf("SftpDialer() error = %v, expected %v", err, remote.ErrAuthFailed)
+	}
+}
+
+func TestSystemSsh_MissingBinary(t *testing.T) {
+	params := &mockSystemSshParams{
+		mockSSHParams: mockSSHParams{config: &ssh.ClientConfig{Timeout: 10 * time.Second}, address: "127.0.0.1:22"},
+		path:          filepath.Join(t.TempDir(), "ssh"),
+		host:          "127.0.0.1",
+	}
+
+	_, err := SftpDialer(params)(context.Background())
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SftpDialer() error = %v, expected a missing ssh binary", err)
+	}
+}
+
+func TestSystemSshError(t *testing.T) {
+	for stderr, expected := range map[string]error{
+		"testuser@127.0.0.1: Permission denied (publickey).":                         remote.ErrAuthFailed,
+		"Host key verification failed.":                                              remote.ErrHostKeyMismatch,
+		"ssh: connect to host 127.0.0.1 port 1: Connection refused":                  remote.ErrConnect,
+		"ssh: Could not resolve hostname nowhere.invalid: Name or service not known": remote.ErrConnect,
+	} {
+		if err := systemSshError(stderr); !errors.Is(err, expected) {
+			t.Errorf("systemSshError(%q) = %v, expected %v", stderr, err, expected)
+		}
+	}
+
+	err := systemSshError("")
+	if err == nil {
+		t.Fatal("systemSshError(\"\") = nil, expected an error")
+	}
+	for _, kind := range []error{remote.ErrAuthFailed, remote.ErrHostKeyMismatch, remote.ErrConnect} {
+		if errors.Is(err, kind) {
+			t.Errorf("systemSshError(\"\") = %v, expected an unclassified error", err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// stagedContents returns an exec handler that records the commands it is
+// given, reading the file a command names as its last, quoted, argument
+func stagedContents(t *testing.T, rootDir string, commands *[]string, contents *string, status uint32) execHandler {
+	return func(command string) (string, string, uint32) {
+		*commands = append(*commands, command)
+
+		quoted := command[strings.LastIndex(command[:len(command)-1], "'")+1 : len(command)-1]
+		read, err := os.ReadFile(filepath.Join(rootDir, quoted))
+		if err != nil {
+			t.Errorf("validate command given a missing file: %v", err)
+		}
+		*contents = string(read)
+
+		if status != 0 {
+			return "", "syntax error on line 1\n", status
+		}
+		return "syntax is ok\n", "", 0
+	}
+}
+
+func TestConnectAndWriteOperation_Validate(t *testing.T) {
+	for _, resumable := range []bool{false, true} {
+		t.Run(map[bool]string{false: "staged", true: "resumable"}[resumable], func(t *testing.T) {
+			server, serverAddr, _, cleanup := setupIntegrationTest(t)
+			defer cleanup()
+
+			var commands []string
+			var validated string
+			server.handleExec(stagedContents(t, server.testDir, &commands, &validated, 0))
+
+			sshParams := &mockSSHParams{
+				config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+				address: serverAddr,
+			}
+			input := &mockWriteInputModel{
+				path:        types.StringValue("test.txt"),
+				contents:    types.StringValue("valid contents"),
+				permissions: types.StringNull(),
+			}
+
+			operation := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithResumable(resumable), remote.WithValidate("nginx -t -c %s"))
+			if err := operation(context.Background()); err != nil {
+				t.Fatalf("ConnectAndWrite() error = %v", err)
+			}
+
+			if len(commands) != 1 || !strings.HasPrefix(commands[0], "nginx -t -c '.test.txt.") {
+				t.Errorf("commands = %q, expected nginx run against the staged file", commands)
+			}
+			if validated != "valid contents" {
+				t.Errorf("validated contents = %q, expected the new contents", validated)
+			}
+
+			content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+			if err != nil || string(content) != "valid contents" {
+				t.Errorf("File content = %q (%v), expected the new contents", string(content), err)
+			}
+			fileInfo, err := os.Stat(filepath.Join(server.testDir, "test.txt"))
+			if err != nil || fileInfo.Mode().Perm() != 0644 {
+				t.Errorf("File mode = %v (%v), expected the existing mode to be kept", fileInfo.Mode().Perm(), err)
+			}
+		})
+	}
+}
+
+func TestConnectAndWriteOperation_ValidateRejected(t *testing.T) {
+	for _, resumable := range []bool{false, true} {
+		t.Run(map[bool]string{false: "staged", true: "resumable"}[resumable], func(t *testing.T) {
+			server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+			defer cleanup()
+
+			var commands []string
+			var validated string
+			server.handleExec(stagedContents(t, server.testDir, &commands, &validated, 1))
+
+			sshParams := &mockSSHParams{
+				config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+				address: serverAddr,
+			}
+			input := &mockWriteInputModel{
+				path:        types.StringValue("test.txt"),
+				contents:    types.StringValue("broken contents"),
+				permissions: types.StringNull(),
+			}
+
+			operation := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithResumable(resumable), remote.WithValidate("visudo -cf %s"))
+			err := operation(context.Background())
+			if !errors.Is(err, remote.ErrValidation) {
+				t.Fatalf("expected ErrValidation, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "syntax error on line 1") {
+				t.Errorf("expected the command's stderr in %q", err.Error())
+			}
+			if remote.IsRetryable(err) {
+				t.Errorf("expected validation errors not to be retried")
+			}
+
+			// The target is untouched and nothing is left staged
+			content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+			if err != nil || string(content) != testContent {
+				t.Errorf("File content = %q (%v), expected %q", string(content), err, testContent)
+			}
+			entries, err := os.ReadDir(server.testDir)
+			if err != nil || len(entries) != 1 {
+				t.Errorf("expected only test.txt to be left, got %v (%v)", entries, err)
+			}
+		})
+	}
+}
+
+func TestConnectAndWriteOperation_ValidateWithoutExec(t *testing.T) {
+	server, serverAddr, testContent, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	sshParams := &mockSSHParams{
+		config:  getTestClientConfig(server.hostPrivateKey.PublicKey()),
+		address: serverAddr,
+	}
+	input := &mockWriteInputModel{
+		path:        types.StringValue("test.txt"),
+		contents:    types.StringValue("new contents"),
+		permissions: types.StringNull(),
+	}
+
+	// The test server rejects exec requests, as SFTP-only servers do. The
+	// command never ran, so that isn't a rejection of the contents.
+	err := remote.ConnectAndWrite(SftpDialer(sshParams), input, &mockOutputModel{}, remote.WithValidate("true %s"))(context.Background())
+	if err == nil {
+		t.Fatal("expected an error running the validate command")
+	}
+	if errors.Is(err, remote.ErrValidation) {
+		t.Errorf("expected a failure to run the command not to be ErrValidation, got %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(server.testDir, "test.txt"))
+	if err != nil || string(content) != testContent {
+		t.Errorf("File content = %q (%v), expected %q", string(content), err, testContent)
+	}
+}
@@ -3,8 +3,19 @@ package parameters
 import "golang.org/x/crypto/ssh"
 
 type SshConnectionParameters struct {
-	sshConfig *ssh.ClientConfig
-	address   string
+	sshConfig   *ssh.ClientConfig
+	address     string
+	authMethods []string
+
+	sudo           string
+	sudoPassword   string
+	sudoSftpServer string
+
+	systemSsh  string
+	host       string
+	port       int64
+	privateKey string
+	hostKey    string
 }
 
 func (s *SshConnectionParameters) GetSshConfig() *ssh.ClientConfig {
@@ -14,3 +25,51 @@ func (s *SshConnectionParameters) GetSshConfig() *ssh.ClientConfig {
 func (s *SshConnectionParameters) GetAddress() string {
 	return s.address
 }
+
+// GetAuthMethodNames returns the SSH authentication methods offered to the
+// server, in order
+func (s *SshConnectionParameters) GetAuthMethodNames() []string {
+	return s.authMethods
+}
+
+// GetSudo returns how to escalate to root with sudo, empty to not escalate
+func (s *SshConnectionParameters) GetSudo() string {
+	return s.sudo
+}
+
+func (s *SshConnectionParameters) GetSudoPassword() string {
+	return s.sudoPassword
+}
+
+func (s *SshConnectionParameters) GetSudoSftpServer() string {
+	return s.sudoSftpServer
+}
+
+// GetSystemSsh returns the path of the ssh binary to connect with, empty to
+// connect with the built in client
+func (s *SshConnectionParameters) GetSystemSsh() string {
+	return s.systemSsh
+}
+
+// GetHost returns the host the system ssh connects to
+func (s *SshConnectionParameters) GetHost() string {
+	return s.host
+}
+
+// GetPort returns the port the system ssh connects to, 0 to leave it to the
+// ssh configuration
+func (s *SshConnectionParameters) GetPort() int64 {
+	return s.port
+}
+
+// GetPrivateKey returns the private key the system ssh offers, empty to
+// leave it to the agent and the ssh configuration
+func (s *SshConnectionParameters) GetPrivateKey() string {
+	return s.privateKey
+}
+
+// GetHostKey returns the only host key the system ssh accepts, empty to
+// check known_hosts
+func (s *SshConnectionParameters) GetHostKey() string {
+	return s.hostKey
+}
@@ -20,22 +20,53 @@ type SshModelSubset interface {
 	GetUser() types.String
 }
 
+// the terraform schema fields for escalating to root with sudo, implemented
+// by the models that support it
+type SudoModelSubset interface {
+	GetSudo() types.String
+	GetSudoPassword() types.String
+	GetSudoSftpServer() types.String
+}
+
+// the terraform schema fields for connecting with the system ssh binary,
+// implemented by the models that support it
+type SystemSshModelSubset interface {
+	GetUseSystemSsh() types.Bool
+	GetSystemSshPath() types.String
+}
+
+// DefaultSystemSsh is the ssh binary used with use_system_ssh unless
+// system_ssh_path is set, looked up in PATH
+const DefaultSystemSsh = "ssh"
+
 func CreateSSHConnectionParameters(data SshModelSubset) (*SshConnectionParameters, error) {
+	if systemSsh, ok := data.(SystemSshModelSubset); ok {
+		if systemSsh.GetUseSystemSsh().ValueBool() {
+			return createSystemSshParameters(data, systemSsh)
+		}
+		if !systemSsh.GetSystemSshPath().IsNull() {
+			return nil, errors.New("system_ssh_path requires use_system_ssh = true")
+		}
+	}
+
 	// Create a new SSH config based on the connection parameters from the data source model.
 	if data.GetPassword().IsNull() && data.GetPrivateKey().IsNull() {
 		return nil, errors.New("must provide either a password or private key")
 	}
 
 	var authMethod []ssh.AuthMethod
+	var authMethodNames []string
 
 	if !data.GetPassword().IsNull() {
 		authMethod = []ssh.AuthMethod{ssh.Password(data.GetPassword().ValueString())}
+		authMethodNames = []string{"password"}
 	} else {
 		privateKeySigner, err := ssh.ParsePrivateKey([]byte(data.GetPrivateKey().ValueString()))
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse private key: %w", err)
 		}
 		authMethod = []ssh.AuthMethod{ssh.PublicKeys(privateKeySigner)}
+		authMethodNames = []string{"publickey"}
 	}
 
 	var hostKeyCallback ssh.HostKeyCallback
@@ -49,13 +80,9 @@ func CreateSSHConnectionParameters(data SshModelSubset) (*SshConnectionParameter
 		hostKeyCallback = ssh.InsecureIgnoreHostKey()
 	}
 
-	timeout := "5m"
-	if !data.GetTimeout().IsNull() {
-		timeout = data.GetTimeout().ValueString()
-	}
-	timeoutDuration, err := time.ParseDuration(timeout)
+	timeoutDuration, err := parseTimeout(data)
 	if err != nil {
-		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+		return nil, err
 	}
 
 	port := int64(22)
@@ -71,8 +98,115 @@ func CreateSSHConnectionParameters(data SshModelSubset) (*SshConnectionParameter
 	}
 	address := fmt.Sprintf("%s:%d", data.GetHost().ValueString(), port)
 
-	return &SshConnectionParameters{
-		sshConfig: sshConfig,
-		address:   address,
-	}, nil
+	params := &SshConnectionParameters{
+		sshConfig:   sshConfig,
+		address:     address,
+		authMethods: authMethodNames,
+	}
+
+	setSudo(params, data)
+
+	return params, nil
+}
+
+// createSystemSshParameters connects with the system ssh binary, which
+// authenticates with private_key if set and otherwise with the agent and
+// whatever ~/.ssh/config says. It runs in batch mode, so it can't be given a
+// password.
+func createSystemSshParameters(data SshModelSubset, systemSsh SystemSshModelSubset) (*SshConnectionParameters, error) {
+	if !data.GetPassword().IsNull() {
+		return nil, errors.New("password can't be passed to the system ssh, authenticate with private_key, " +
+			"the agent or ~/.ssh/config instead")
+	}
+	if !data.GetPrivateKey().IsNull() {
+		if _, err := ssh.ParsePrivateKey([]byte(data.GetPrivateKey().ValueString())); err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+	}
+	hostKey, err := systemSshHostKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutDuration, err := parseTimeout(data)
+	if err != nil {
+		return nil, err
+	}
+
+	path := DefaultSystemSsh
+	if !systemSsh.GetSystemSshPath().IsNull() {
+		path = systemSsh.GetSystemSshPath().ValueString()
+	}
+
+	host := data.GetHost().ValueString()
+	port := int64(22)
+	params := &SshConnectionParameters{
+		sshConfig: &ssh.ClientConfig{
+			User:    data.GetUser().ValueString(),
+			Timeout: timeoutDuration,
+		},
+		authMethods: []string{"system ssh"},
+		systemSsh:   path,
+		host:        host,
+		privateKey:  data.GetPrivateKey().ValueString(),
+		hostKey:     hostKey,
+	}
+	if !data.GetPort().IsNull() {
+		params.port = data.GetPort().ValueInt64()
+		port = params.port
+	}
+	params.address = fmt.Sprintf("%s:%d", host, port)
+
+	setSudo(params, data)
+
+	return params, nil
+}
+
+// systemSshHostKey returns host_key in the authorized_keys format ssh reads
+// known_hosts in, it may also be given in the wire format the built in client
+// takes
+func systemSshHostKey(data SshModelSubset) (string, error) {
+	if data.GetHostKey().IsNull() {
+		return "", nil
+	}
+
+	hostKey := []byte(data.GetHostKey().ValueString())
+	if _, _, _, _, err := ssh.ParseAuthorizedKey(hostKey); err == nil {
+		return string(hostKey), nil
+	}
+	parsedHostKey, err := ssh.ParsePublicKey(hostKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse host key: %w", err)
+	}
+	return string(ssh.MarshalAuthorizedKey(parsedHostKey)), nil
+}
+
+// parseTimeout returns the timeout of data, 5 minutes unless set
+func parseTimeout(data SshModelSubset) (time.Duration, error) {
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+	return timeoutDuration, nil
+}
+
+// setSudo copies how data escalates to root with sudo to params
+func setSudo(params *SshConnectionParameters, data SshModelSubset) {
+	sudo, ok := data.(SudoModelSubset)
+	if !ok || sudo.GetSudo().ValueString() == "" {
+		return
+	}
+
+	params.sudo = sudo.GetSudo().ValueString()
+	params.sudoSftpServer = sudo.GetSudoSftpServer().ValueString()
+
+	// sudo usually asks for the login password
+	params.sudoPassword = data.GetPassword().ValueString()
+	if !sudo.GetSudoPassword().IsNull() {
+		params.sudoPassword = sudo.GetSudoPassword().ValueString()
+	}
 }
@@ -182,6 +182,9 @@ Vsb3BtZW50AQIDBA==
 	if len(params.sshConfig.Auth) != 1 {
 		t.Errorf("unexpected number of auth methods: %d", len(params.sshConfig.Auth))
 	}
+	if names := params.GetAuthMethodNames(); len(names) != 1 || names[0] != "publickey" {
+		t.Errorf("unexpected auth method names: %v", names)
+	}
 }
 
 func TestTimeoutSupplied(t *testing.T) {
@@ -305,3 +308,181 @@ Vsb3BtZW50AQIDBA==
 		t.Error("expected host key callback to be set")
 	}
 }
+
+type sudoParametersSubset struct {
+	parametersSubset
+	Sudo           types.String
+	SudoPassword   types.String
+	SudoSftpServer types.String
+}
+
+func (p *sudoParametersSubset) GetSudo() types.String {
+	return p.Sudo
+}
+func (p *sudoParametersSubset) GetSudoPassword() types.String {
+	return p.SudoPassword
+}
+func (p *sudoParametersSubset) GetSudoSftpServer() types.String {
+	return p.SudoSftpServer
+}
+
+func TestSudoSupplied(t *testing.T) {
+	tests := []struct {
+		name         string
+		sudo         types.String
+		sudoPassword types.String
+		expected     string
+		password     string
+	}{
+		{"no sudo", types.StringNull(), types.StringValue("secret"), "", ""},
+		{"login password", types.StringValue("install"), types.StringNull(), "install", "password"},
+		{"sudo password", types.StringValue("sftp_server"), types.StringValue("secret"), "sftp_server", "secret"},
+		{"empty sudo password", types.StringValue("install"), types.StringValue(""), "install", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &sudoParametersSubset{
+				parametersSubset: parametersSubset{
+					Host:       types.StringValue("host"),
+					HostKey:    types.StringNull(),
+					Password:   types.StringValue("password"),
+					PrivateKey: types.StringNull(),
+					Timeout:    types.StringNull(),
+					Port:       types.Int64Null(),
+					User:       types.StringValue("user"),
+				},
+				Sudo:           tt.sudo,
+				SudoPassword:   tt.sudoPassword,
+				SudoSftpServer: types.StringNull(),
+			}
+			params, err := CreateSSHConnectionParameters(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if params.GetSudo() != tt.expected {
+				t.Errorf("unexpected sudo: %q", params.GetSudo())
+			}
+			if params.GetSudoPassword() != tt.password {
+				t.Errorf("unexpected sudo password: %q", params.GetSudoPassword())
+			}
+		})
+	}
+}
+
+type systemSshParametersSubset struct {
+	parametersSubset
+	UseSystemSsh  types.Bool
+	SystemSshPath types.String
+}
+
+func (p *systemSshParametersSubset) GetUseSystemSsh() types.Bool {
+	return p.UseSystemSsh
+}
+func (p *systemSshParametersSubset) GetSystemSshPath() types.String {
+	return p.SystemSshPath
+}
+
+func newSystemSshParametersSubset() *systemSshParametersSubset {
+	return &systemSshParametersSubset{
+		parametersSubset: parametersSubset{
+			Host:       types.StringValue("bastioned"),
+			HostKey:    types.StringNull(),
+			Password:   types.StringNull(),
+			PrivateKey: types.StringNull(),
+			Timeout:    types.StringNull(),
+			Port:       types.Int64Null(),
+			User:       types.StringNull(),
+		},
+		UseSystemSsh:  types.BoolValue(true),
+		SystemSshPath: types.StringNull(),
+	}
+}
+
+func TestSystemSshWithoutCredentials(t *testing.T) {
+	params, err := CreateSSHConnectionParameters(newSystemSshParametersSubset())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.GetSystemSsh() != DefaultSystemSsh {
+		t.Errorf("unexpected system ssh: %q", params.GetSystemSsh())
+	}
+	if params.GetHost() != "bastioned" {
+		t.Errorf("unexpected host: %q", params.GetHost())
+	}
+	if params.GetPort() != 0 {
+		t.Errorf("expected the port to be left to the ssh configuration, got %d", params.GetPort())
+	}
+	if params.sshConfig.User != "" {
+		t.Errorf("expected the user to be left to the ssh configuration, got %q", params.sshConfig.User)
+	}
+	if params.GetAddress() != "bastioned:22" {
+		t.Errorf("unexpected address: %s", params.GetAddress())
+	}
+}
+
+func TestSystemSshSupplied(t *testing.T) {
+	key := `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICbM5h6z001fq+dToAcn+jwfXrk+xCHgyiaUc7LJCe4a devel@ubuntu-server`
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		t.Fatalf("unexpected error in test code, check supplied test host key: %v", err)
+	}
+
+	data := newSystemSshParametersSubset()
+	data.SystemSshPath = types.StringValue("/opt/openssh/bin/ssh")
+	data.Port = types.Int64Value(2222)
+	data.User = types.StringValue("deploy")
+	data.HostKey = types.StringValue(string(hostKey.Marshal()))
+
+	params, err := CreateSSHConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.GetSystemSsh() != "/opt/openssh/bin/ssh" {
+		t.Errorf("unexpected system ssh: %q", params.GetSystemSsh())
+	}
+	if params.GetPort() != 2222 || params.GetAddress() != "bastioned:2222" {
+		t.Errorf("unexpected port %d and address %s", params.GetPort(), params.GetAddress())
+	}
+	if params.sshConfig.User != "deploy" {
+		t.Errorf("unexpected user: %q", params.sshConfig.User)
+	}
+	// the wire format the built in client takes is converted for known_hosts
+	if params.GetHostKey() != string(ssh.MarshalAuthorizedKey(hostKey)) {
+		t.Errorf("unexpected host key: %q", params.GetHostKey())
+	}
+}
+
+func TestSystemSshInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*systemSshParametersSubset)
+		expected string
+	}{
+		{"password", func(p *systemSshParametersSubset) {
+			p.Password = types.StringValue("password")
+		}, "password can't be passed to the system ssh"},
+		{"private key", func(p *systemSshParametersSubset) {
+			p.PrivateKey = types.StringValue("invalid")
+		}, "failed to parse private key"},
+		{"host key", func(p *systemSshParametersSubset) {
+			p.HostKey = types.StringValue("invalid")
+		}, "failed to parse host key"},
+		{"path without system ssh", func(p *systemSshParametersSubset) {
+			p.UseSystemSsh = types.BoolValue(false)
+			p.Password = types.StringValue("password")
+			p.SystemSshPath = types.StringValue("/usr/bin/ssh")
+		}, "system_ssh_path requires use_system_ssh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newSystemSshParametersSubset()
+			tt.modify(data)
+			_, err := CreateSSHConnectionParameters(data)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decode parses contents as format into maps, slices, strings, int64,
+// float64, bools and nils. INI, dotenv and properties files only hold
+// strings.
+func Decode(format string, contents string) (any, error) {
+	var document any
+
+	switch format {
+	case FormatJSON:
+		if strings.TrimSpace(contents) == "" {
+			return map[string]any{}, nil
+		}
+		decoder := json.NewDecoder(strings.NewReader(contents))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return nil, fmt.Errorf("error parsing JSON: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal([]byte(contents), &document); err != nil {
+			return nil, fmt.Errorf("error parsing YAML: %w", err)
+		}
+		if document == nil {
+			document = map[string]any{}
+		}
+	case FormatTOML:
+		decoded, err := decodeTOML(contents)
+		if err != nil {
+			return nil, err
+		}
+		document = decoded
+	case FormatINI:
+		decoded, err := decodeINI(contents)
+		if err != nil {
+			return nil, err
+		}
+		document = decoded
+	case FormatDotenv:
+		decoded, err := decodeDotenv(contents)
+		if err != nil {
+			return nil, err
+		}
+		document = decoded
+	case FormatProperties:
+		decoded, err := decodeProperties(contents)
+		if err != nil {
+			return nil, err
+		}
+		document = decoded
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected one of %s", format, strings.Join(DecodeFormats, ", "))
+	}
+
+	return normalize(document), nil
+}
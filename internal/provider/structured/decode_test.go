@@ -0,0 +1,72 @@
+package structured
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		contents string
+		expected string
+	}{
+		{"json", FormatJSON, `{"port": 8080, "tags": ["a"], "ratio": 0.5}`, `{"port":8080,"ratio":0.5,"tags":["a"]}`},
+		{"empty json", FormatJSON, "", `{}`},
+		{"yaml", FormatYAML, "server:\n  port: 8080\n  debug: true\n", `{"server":{"debug":true,"port":8080}}`},
+		{"toml", FormatTOML, "title = \"x\"\n[db]\nport = 5432\n", `{"db":{"port":5432},"title":"x"}`},
+		{"ini", FormatINI, "top = 1\n[mysqld]\nport = 3306\nskip-name-resolve\n", `{"mysqld":{"port":"3306","skip-name-resolve":""},"top":"1"}`},
+		{
+			"dotenv", FormatDotenv,
+			"# comment\nexport A=1\nB = 'x #y'\nC=\"line\\nnext \\\"q\\\"\"\nD=plain # comment\nE=\n",
+			`{"A":"1","B":"x #y","C":"line\nnext \"q\"","D":"plain","E":""}`,
+		},
+		{"dotenv multiline", FormatDotenv, "KEY=\"-----BEGIN\nabc\n-----END\"\nNEXT=1\n", `{"KEY":"-----BEGIN\nabc\n-----END","NEXT":"1"}`},
+		{
+			"properties", FormatProperties,
+			"! comment\n# comment\nserver.port=8080\nname : api\nspaced value\nlist = a, \\\n    b\nescaped\\ key=\\u00e9\\t\n",
+			`{"escaped key":"é\t","list":"a, b","name":"api","server.port":"8080","spaced":"value"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Decode(tt.format, tt.contents)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded := Canonical(document); decoded != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		contents string
+		errMsg   string
+	}{
+		{"unknown format", "xml", "<a/>", "unsupported format"},
+		{"invalid json", FormatJSON, "{", "error parsing JSON"},
+		{"dotenv without separator", FormatDotenv, "A=1\nJUSTAKEY\n", "line 2: expected KEY=value"},
+		{"dotenv unterminated single quote", FormatDotenv, "A='x\n", "unterminated single quoted value"},
+		{"dotenv unterminated double quote", FormatDotenv, "A=\"x\nB=1\n", "unterminated double quoted value"},
+		{"properties bad unicode", FormatProperties, "a=\\uzzzz\n", "invalid unicode escape"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.format, tt.contents)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
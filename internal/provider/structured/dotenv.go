@@ -0,0 +1,107 @@
+package structured
+
+import (
+	"fmt"
+	"strings"
+)
+
+// decodeDotenv parses a .env file of KEY=value lines. Lines may start with
+// export, values may be single quoted, taken literally, or double quoted,
+// where \n, \t, \", \\ are unescaped and the value may span lines. Unquoted
+// values end at a # preceded by whitespace.
+func decodeDotenv(contents string) (map[string]any, error) {
+	document := map[string]any{}
+	lines := splitFile(contents).lines
+
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		trimmed := strings.TrimSpace(strings.TrimSuffix(lines[i], "\r"))
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(trimmed, "export"); ok && strings.TrimLeft(rest, " \t") != rest {
+			trimmed = strings.TrimSpace(rest)
+		}
+
+		key, value, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNumber)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quoted value", lineNumber)
+			}
+			document[key] = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			// A double quoted value continues on the next lines until its
+			// closing quote
+			quoted := value[1:]
+			for {
+				unquoted, end, err := unquoteDotenv(quoted)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				if end >= 0 {
+					document[key] = unquoted
+					break
+				}
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated double quoted value", lineNumber)
+				}
+				quoted += "\n" + strings.TrimSuffix(lines[i], "\r")
+			}
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = value[:comment]
+			}
+			if comment := strings.Index(value, "\t#"); comment >= 0 {
+				value = value[:comment]
+			}
+			document[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return document, nil
+}
+
+// unquoteDotenv unescapes a double quoted value up to its closing quote,
+// returning the index of that quote, or -1 when the value isn't closed yet
+func unquoteDotenv(quoted string) (string, int, error) {
+	var unquoted strings.Builder
+
+	for i := 0; i < len(quoted); i++ {
+		switch c := quoted[i]; c {
+		case '"':
+			return unquoted.String(), i, nil
+		case '\\':
+			if i+1 == len(quoted) {
+				unquoted.WriteByte(c)
+				continue
+			}
+			i++
+			switch escaped := quoted[i]; escaped {
+			case 'n':
+				unquoted.WriteByte('\n')
+			case 'r':
+				unquoted.WriteByte('\r')
+			case 't':
+				unquoted.WriteByte('\t')
+			case '"', '\\', '$':
+				unquoted.WriteByte(escaped)
+			default:
+				unquoted.WriteByte(c)
+				unquoted.WriteByte(escaped)
+			}
+		default:
+			unquoted.WriteByte(c)
+		}
+	}
+
+	return "", -1, nil
+}
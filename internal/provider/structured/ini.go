@@ -0,0 +1,187 @@
+package structured
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iniLine is a line of an INI file that holds a key
+type iniLine struct {
+	section string
+	key     string
+	value   string
+	hasSep  bool
+}
+
+// parseINILine returns the key a line holds, or a section header it starts
+func parseINILine(line string) (key *iniLine, section string, isSection bool) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "", strings.HasPrefix(trimmed, ";"), strings.HasPrefix(trimmed, "#"):
+		return nil, "", false
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		return nil, strings.TrimSpace(trimmed[1 : len(trimmed)-1]), true
+	}
+
+	sep := strings.IndexAny(trimmed, "=:")
+	if sep < 0 {
+		// A bare key, such as skip-name-resolve in my.cnf
+		return &iniLine{key: trimmed}, "", false
+	}
+	return &iniLine{
+		key:    strings.TrimSpace(trimmed[:sep]),
+		value:  strings.TrimSpace(withoutINIComment(trimmed[sep+1:])),
+		hasSep: true,
+	}, "", false
+}
+
+// withoutINIComment returns value without the comment at its end, a ; or #
+// after whitespace, and the whitespace before it
+func withoutINIComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if (value[i] == ';' || value[i] == '#') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimRight(value[:i], " \t")
+		}
+	}
+	return value
+}
+
+// iniPath splits keys into a section, empty for keys before the first
+// section, and a key
+func iniPath(keys []string) (string, string) {
+	if len(keys) == 1 {
+		return "", keys[0]
+	}
+	return keys[0], strings.Join(keys[1:], ".")
+}
+
+// iniValue renders a value for an INI file, which only knows strings
+func iniValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return Canonical(value)
+}
+
+// patchINI sets and removes keys in an INI file line by line, so comments,
+// blank lines and the order of everything else stay as they are
+func patchINI(contents string, changes []change) (string, error) {
+	file := splitFile(contents)
+
+	// Follow the file's style for new keys
+	separator := " = "
+	for _, line := range file.lines {
+		if key, _, _ := parseINILine(line); key != nil && key.hasSep {
+			if !strings.Contains(line, " =") {
+				separator = "="
+			}
+			break
+		}
+	}
+
+	for _, c := range changes {
+		section, key := iniPath(c.keys)
+
+		// New keys go after the last line of their section, or at the top of
+		// the file for keys outside any section
+		current := ""
+		sectionFound := section == ""
+		lastInSection := -1
+		matched := false
+
+		for i := 0; i < len(file.lines); i++ {
+			parsed, name, isSection := parseINILine(file.lines[i])
+			if isSection {
+				current = name
+				if current == section {
+					sectionFound = true
+					lastInSection = i
+				}
+				continue
+			}
+			if parsed == nil || current != section {
+				continue
+			}
+			lastInSection = i
+			if parsed.key != key {
+				continue
+			}
+
+			matched = true
+			if c.remove {
+				file.lines = append(file.lines[:i], file.lines[i+1:]...)
+				i--
+				lastInSection--
+				continue
+			}
+			file.lines[i] = replaceINIValue(file.lines[i], key, iniValue(c.value), separator)
+		}
+
+		if matched || c.remove {
+			continue
+		}
+
+		line := key + separator + iniValue(c.value)
+		switch {
+		case sectionFound:
+			file.insert(lastInSection+1, line)
+		default:
+			if len(file.lines) > 0 && strings.TrimSpace(file.lines[len(file.lines)-1]) != "" {
+				file.lines = append(file.lines, "")
+			}
+			file.lines = append(file.lines, "["+section+"]", line)
+			file.terminated = true
+		}
+	}
+
+	return file.String(), nil
+}
+
+// replaceINIValue replaces the value of the key on line, keeping the
+// indentation, separator and a comment after the value
+func replaceINIValue(line string, key string, value string, separator string) string {
+	sep := strings.IndexAny(line, "=:")
+	if sep < 0 {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		return indent + key + separator + value
+	}
+
+	prefix := line[:sep+1]
+	rest := line[sep+1:]
+	spacing := rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	comment := rest[len(withoutINIComment(rest)):]
+	return prefix + spacing + value + comment
+}
+
+// decodeINI decodes an INI file into sections of keys, with keys before the
+// first section at the top level
+func decodeINI(contents string) (map[string]any, error) {
+	document := map[string]any{}
+	var section map[string]any
+
+	for i, line := range splitFile(contents).lines {
+		parsed, name, isSection := parseINILine(line)
+		if isSection {
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", i+1)
+			}
+			existing, ok := document[name].(map[string]any)
+			if !ok {
+				existing = map[string]any{}
+				document[name] = existing
+			}
+			section = existing
+			continue
+		}
+		if parsed == nil {
+			continue
+		}
+		if section != nil {
+			section[parsed.key] = parsed.value
+		} else {
+			document[parsed.key] = parsed.value
+		}
+	}
+
+	return document, nil
+}
@@ -0,0 +1,274 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// jsonObject is a JSON object that remembers the order of its keys, so that
+// patching a file doesn't reorder it
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: map[string]any{}}
+}
+
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) remove(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// decodeOrderedJSON decodes a document keeping the key order of objects
+func decodeOrderedJSON(contents string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(contents))
+	decoder.UseNumber()
+
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := newJSONObject()
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(keyToken.(string), value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+// detectIndent returns the indentation of the first indented line, or ""
+// for a document on a single line
+func detectIndent(contents string) string {
+	if !strings.Contains(strings.TrimSpace(contents), "\n") {
+		return ""
+	}
+	for _, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// encodeOrderedJSON writes value with indent per level, or compact if indent
+// is empty
+func encodeOrderedJSON(buffer *bytes.Buffer, value any, indent string, depth int) error {
+	newline := func(depth int) {
+		if indent != "" {
+			buffer.WriteByte('\n')
+			buffer.WriteString(strings.Repeat(indent, depth))
+		}
+	}
+	separator := ":"
+	if indent != "" {
+		separator = ": "
+	}
+
+	switch v := value.(type) {
+	case *jsonObject:
+		if len(v.keys) == 0 {
+			buffer.WriteString("{}")
+			return nil
+		}
+		buffer.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := encodeScalarJSON(buffer, key); err != nil {
+				return err
+			}
+			buffer.WriteString(separator)
+			if err := encodeOrderedJSON(buffer, v.values[key], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buffer.WriteByte('}')
+	case map[string]any:
+		object := newJSONObject()
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			object.set(key, v[key])
+		}
+		return encodeOrderedJSON(buffer, object, indent, depth)
+	case []any:
+		if len(v) == 0 {
+			buffer.WriteString("[]")
+			return nil
+		}
+		buffer.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := encodeOrderedJSON(buffer, item, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buffer.WriteByte(']')
+	default:
+		return encodeScalarJSON(buffer, v)
+	}
+	return nil
+}
+
+func encodeScalarJSON(buffer *bytes.Buffer, value any) error {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	buffer.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+	return nil
+}
+
+// patchJSON sets and removes paths in a JSON document, keeping its key order
+// and indentation
+func patchJSON(contents string, changes []change) (string, error) {
+	var root any = newJSONObject()
+	if strings.TrimSpace(contents) != "" {
+		var err error
+		root, err = decodeOrderedJSON(contents)
+		if err != nil {
+			return "", fmt.Errorf("error parsing JSON: %w", err)
+		}
+	}
+
+	for _, c := range changes {
+		if err := c.apply(root, jsonContainer{}); err != nil {
+			return "", err
+		}
+	}
+
+	indent := detectIndent(contents)
+	if strings.TrimSpace(contents) == "" {
+		indent = "  "
+	}
+
+	var buffer bytes.Buffer
+	if err := encodeOrderedJSON(&buffer, root, indent, 0); err != nil {
+		return "", fmt.Errorf("error encoding JSON: %w", err)
+	}
+	if indent != "" || strings.HasSuffix(contents, "\n") {
+		buffer.WriteByte('\n')
+	}
+	return buffer.String(), nil
+}
+
+// jsonContainer navigates the ordered JSON tree for change.apply
+type jsonContainer struct{}
+
+func (jsonContainer) child(node any, key string, create bool) (any, error) {
+	switch n := node.(type) {
+	case *jsonObject:
+		if value, ok := n.values[key]; ok {
+			return value, nil
+		}
+		if !create {
+			return nil, nil
+		}
+		object := newJSONObject()
+		n.set(key, object)
+		return object, nil
+	case []any:
+		i, ok := index(key, len(n))
+		if !ok {
+			return nil, fmt.Errorf("index %s is out of range", key)
+		}
+		return n[i], nil
+	default:
+		return nil, fmt.Errorf("parent of %s is not an object", key)
+	}
+}
+
+func (jsonContainer) set(node any, key string, value any) error {
+	switch n := node.(type) {
+	case *jsonObject:
+		n.set(key, value)
+	case []any:
+		i, ok := index(key, len(n))
+		if !ok {
+			return fmt.Errorf("index %s is out of range", key)
+		}
+		n[i] = value
+	default:
+		return fmt.Errorf("parent of %s is not an object", key)
+	}
+	return nil
+}
+
+func (jsonContainer) remove(node any, key string) error {
+	if object, ok := node.(*jsonObject); ok {
+		object.remove(key)
+		return nil
+	}
+	return fmt.Errorf("parent of %s is not an object", key)
+}
@@ -0,0 +1,40 @@
+package structured
+
+import "strings"
+
+// fileLines is a file split into lines, remembering whether the last line was
+// terminated so that joining it again doesn't touch the end of the file
+type fileLines struct {
+	lines      []string
+	terminated bool
+}
+
+// splitFile splits contents on line feeds
+func splitFile(contents string) fileLines {
+	if contents == "" {
+		return fileLines{terminated: true}
+	}
+
+	return fileLines{
+		lines:      strings.Split(strings.TrimSuffix(contents, "\n"), "\n"),
+		terminated: strings.HasSuffix(contents, "\n"),
+	}
+}
+
+// String joins the lines back into file contents
+func (f fileLines) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+
+	contents := strings.Join(f.lines, "\n")
+	if f.terminated {
+		contents += "\n"
+	}
+	return contents
+}
+
+// insert places line at index i
+func (f *fileLines) insert(i int, line string) {
+	f.lines = append(f.lines[:i], append([]string{line}, f.lines[i:]...)...)
+}
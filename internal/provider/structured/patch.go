@@ -0,0 +1,112 @@
+package structured
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Supported file formats
+const (
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatTOML       = "toml"
+	FormatINI        = "ini"
+	FormatDotenv     = "dotenv"
+	FormatProperties = "properties"
+)
+
+// PatchFormats lists the formats Patch can edit
+var PatchFormats = []string{FormatJSON, FormatYAML, FormatTOML, FormatINI}
+
+// DecodeFormats lists the formats Decode can read
+var DecodeFormats = []string{FormatJSON, FormatYAML, FormatTOML, FormatINI, FormatDotenv, FormatProperties}
+
+// change sets or removes the value at keys
+type change struct {
+	keys   []string
+	value  any
+	remove bool
+}
+
+func (c change) String() string {
+	return strings.Join(c.keys, ".")
+}
+
+// container navigates and edits the tree of a parsed document
+type container interface {
+	// child returns the node at key below node, creating an empty object
+	// when create is set, or nil when it is missing
+	child(node any, key string, create bool) (any, error)
+	set(node any, key string, value any) error
+	remove(node any, key string) error
+}
+
+// apply makes the change to the document rooted at root
+func (c change) apply(root any, tree container) error {
+	action := "set"
+	if c.remove {
+		action = "remove"
+	}
+
+	node := root
+	for _, key := range c.keys[:len(c.keys)-1] {
+		next, err := tree.child(node, key, !c.remove)
+		if err != nil {
+			return fmt.Errorf("cannot %s %s: %w", action, c, err)
+		}
+		if next == nil {
+			// Nothing to remove below a missing key
+			return nil
+		}
+		node = next
+	}
+
+	last := c.keys[len(c.keys)-1]
+	var err error
+	if c.remove {
+		err = tree.remove(node, last)
+	} else {
+		err = tree.set(node, last, c.value)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot %s %s: %w", action, c, err)
+	}
+	return nil
+}
+
+// Patch sets the values in set and removes the paths in remove from contents,
+// which are parsed as format. Paths are dotted as understood by SplitPath,
+// values are typed as returned by ParseValue. Comments and the order of
+// existing keys are kept where the format allows.
+func Patch(format string, contents string, set map[string]any, remove []string) (string, error) {
+	var changes []change
+
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		changes = append(changes, change{keys: SplitPath(path), value: set[path]})
+	}
+
+	removed := append([]string(nil), remove...)
+	sort.Strings(removed)
+	for _, path := range removed {
+		changes = append(changes, change{keys: SplitPath(path), remove: true})
+	}
+
+	switch format {
+	case FormatJSON:
+		return patchJSON(contents, changes)
+	case FormatYAML:
+		return patchYAML(contents, changes)
+	case FormatTOML:
+		return patchTOML(contents, changes)
+	case FormatINI:
+		return patchINI(contents, changes)
+	default:
+		return "", fmt.Errorf("unsupported format %q, expected one of %s", format, strings.Join(PatchFormats, ", "))
+	}
+}
@@ -0,0 +1,247 @@
+package structured
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		contents string
+		set      map[string]any
+		remove   []string
+		expected string
+	}{
+		{
+			name:     "json keeps key order and indentation",
+			format:   FormatJSON,
+			contents: "{\n    \"name\": \"api\",\n    \"server\": {\n        \"port\": 80,\n        \"host\": \"0.0.0.0\"\n    },\n    \"debug\": true\n}\n",
+			set:      map[string]any{"server.port": int64(8080), "server.tls": map[string]any{"enabled": true}},
+			remove:   []string{"debug"},
+			expected: "{\n    \"name\": \"api\",\n    \"server\": {\n        \"port\": 8080,\n        \"host\": \"0.0.0.0\",\n        \"tls\": {\n            \"enabled\": true\n        }\n    }\n}\n",
+		},
+		{
+			name:     "json compact stays compact",
+			format:   FormatJSON,
+			contents: `{"a":1,"b":[1,2]}`,
+			set:      map[string]any{"b.1": "two"},
+			expected: `{"a":1,"b":[1,"two"]}`,
+		},
+		{
+			name:     "json new file",
+			format:   FormatJSON,
+			set:      map[string]any{"a.b": "<c>"},
+			expected: "{\n  \"a\": {\n    \"b\": \"<c>\"\n  }\n}\n",
+		},
+		{
+			name:     "yaml keeps comments",
+			format:   FormatYAML,
+			contents: "# app config\nserver:\n  # listen port\n  port: 80 # default\n  host: 0.0.0.0\nname: 'api'\ndebug: true\n",
+			set:      map[string]any{"server.port": int64(8080), "name": "web", "server.tls.enabled": true},
+			remove:   []string{"debug"},
+			expected: "# app config\nserver:\n  # listen port\n  port: 8080 # default\n  host: 0.0.0.0\n  tls:\n    enabled: true\nname: 'web'\n",
+		},
+		{
+			name:     "yaml quotes strings that look like numbers",
+			format:   FormatYAML,
+			contents: "version: 1\n",
+			set:      map[string]any{"version": "2"},
+			expected: "version: \"2\"\n",
+		},
+		{
+			name:     "yaml keeps further documents",
+			format:   FormatYAML,
+			contents: "a: 1\n---\nb: 2\n",
+			set:      map[string]any{"a": int64(3)},
+			expected: "a: 3\n---\nb: 2\n",
+		},
+		{
+			name:     "toml replaces in place",
+			format:   FormatTOML,
+			contents: "# top\ntitle = \"app\" # the name\n\n[server]\nport = 80 # default\nhosts = [\n  \"a\",\n  \"b\",\n]\n\n[db]\nurl = \"x\"\n",
+			set:      map[string]any{"server.port": int64(8080), "server.hosts": []any{"c"}, "title": "web"},
+			remove:   []string{"db.url"},
+			expected: "# top\ntitle = \"web\" # the name\n\n[server]\nport = 8080 # default\nhosts = [\"c\"]\n\n[db]\n",
+		},
+		{
+			name:     "toml adds keys to existing tables",
+			format:   FormatTOML,
+			contents: "title = \"app\"\n\n[server]\nport = 80\n\n[db]\nurl = \"x\"\n",
+			set:      map[string]any{"server.tls.enabled": true, "debug": false, "cache.size": 1.5},
+			expected: "title = \"app\"\ndebug = false\n\n[server]\nport = 80\ntls.enabled = true\n\n[db]\nurl = \"x\"\n\n[cache]\nsize = 1.5\n",
+		},
+		{
+			name:     "toml removes tables",
+			format:   FormatTOML,
+			contents: "[a]\nx = 1\n\n[b]\ny = 2\n",
+			remove:   []string{"a"},
+			expected: "[b]\ny = 2\n",
+		},
+		{
+			name:     "toml skips multiline strings",
+			format:   FormatTOML,
+			contents: "motd = \"\"\"\nwelcome\nport = 1\n\"\"\"\nbanner = '''\n[server]\n'''\nport = 80\n",
+			set:      map[string]any{"port": int64(8080)},
+			expected: "motd = \"\"\"\nwelcome\nport = 1\n\"\"\"\nbanner = '''\n[server]\n'''\nport = 8080\n",
+		},
+		{
+			name:     "toml replaces multiline strings",
+			format:   FormatTOML,
+			contents: "motd = \"\"\"\nwelcome\n\"\"\" # shown on login\nport = 80\n",
+			set:      map[string]any{"motd": "bye"},
+			expected: "motd = \"bye\" # shown on login\nport = 80\n",
+		},
+		{
+			name:     "toml replaces inline tables",
+			format:   FormatTOML,
+			contents: "owner = { name = \"a\", uid = 1 } # who\nport = 80\n",
+			set:      map[string]any{"owner": map[string]any{"name": "b", "uid": int64(2)}},
+			expected: "owner = { name = \"b\", uid = 2 } # who\nport = 80\n",
+		},
+		{
+			name:     "toml leaves dotted keys of arrays of tables",
+			format:   FormatTOML,
+			contents: "title = \"app\"\n\n[[servers]]\nname = \"a\"\ntls.enabled = false\n\n[[servers]]\nname = \"b\"\n",
+			set:      map[string]any{"tls.enabled": true, "name": "web"},
+			expected: "title = \"app\"\nname = \"web\"\n\n[[servers]]\nname = \"a\"\ntls.enabled = false\n\n[[servers]]\nname = \"b\"\n\n[tls]\nenabled = true\n",
+		},
+		{
+			name:     "toml keeps comments after values",
+			format:   FormatTOML,
+			contents: "port = 80 # default port\nhost = \"a#b\" # not # the value\nlimits = [1, 2] # per second\n",
+			set:      map[string]any{"host": "c", "limits": []any{int64(3)}},
+			remove:   []string{"port"},
+			expected: "host = \"c\" # not # the value\nlimits = [3] # per second\n",
+		},
+		{
+			name:     "yaml replaces aliases without changing their anchor",
+			format:   FormatYAML,
+			contents: "base: &port 80\nserver:\n  port: *port # shared\n",
+			set:      map[string]any{"server.port": int64(8080)},
+			expected: "base: &port 80\nserver:\n  port: 8080 # shared\n",
+		},
+		{
+			name:     "yaml keeps the anchor of replaced values",
+			format:   FormatYAML,
+			contents: "base: &port 80\nserver:\n  port: *port\n",
+			set:      map[string]any{"base": int64(8080)},
+			expected: "base: &port 8080\nserver:\n  port: *port\n",
+		},
+		{
+			name:     "yaml removes anchors only their own value refers to",
+			format:   FormatYAML,
+			contents: "base: &port 80\nserver:\n  port: *port\nlocal:\n  a: &a 1\n  b: *a\n",
+			remove:   []string{"server.port", "local"},
+			expected: "base: &port 80\nserver: {}\n",
+		},
+		{
+			name:     "ini keeps comments and style",
+			format:   FormatINI,
+			contents: "; global\nuser=mysql\n\n[mysqld]\n# tuning\nmax_connections=100\nskip-name-resolve\n\n[client]\nport=3306\n",
+			set:      map[string]any{"mysqld.max_connections": int64(500), "mysqld.bind-address": "0.0.0.0", "user": "db"},
+			remove:   []string{"mysqld.skip-name-resolve"},
+			expected: "; global\nuser=db\n\n[mysqld]\n# tuning\nmax_connections=500\nbind-address=0.0.0.0\n\n[client]\nport=3306\n",
+		},
+		{
+			name:     "ini keeps comments after values",
+			format:   FormatINI,
+			contents: "[server]\nport = 80 ; default\nhost = a#b # not # the value\nname = ; unset\n",
+			set:      map[string]any{"server.port": int64(8080), "server.host": "c", "server.name": "web"},
+			expected: "[server]\nport = 8080 ; default\nhost = c # not # the value\nname = web ; unset\n",
+		},
+		{
+			name:     "ini adds sections",
+			format:   FormatINI,
+			contents: "[a]\nx = 1\n",
+			set:      map[string]any{"b.y": "two words", "top": "1"},
+			expected: "top = 1\n[a]\nx = 1\n\n[b]\ny = two words\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := Patch(tt.format, tt.contents, tt.set, tt.remove)
+			if err != nil {
+				t.Fatalf("Patch() error = %v", err)
+			}
+			if patched != tt.expected {
+				t.Errorf("Patch() =\n%s\nexpected\n%s", patched, tt.expected)
+			}
+
+			// Patched values read back as set
+			document, err := Decode(tt.format, patched)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			for path, value := range tt.set {
+				got, ok := Lookup(document, SplitPath(path))
+				if tt.format == FormatINI {
+					value = iniValue(value)
+				}
+				if !ok || Canonical(got) != Canonical(value) {
+					t.Errorf("Lookup(%s) = %v, %v, expected %v", path, got, ok, value)
+				}
+			}
+			for _, path := range tt.remove {
+				if _, ok := Lookup(document, SplitPath(path)); ok {
+					t.Errorf("Lookup(%s) found a removed path", path)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		contents string
+		set      map[string]any
+		errMsg   string
+	}{
+		{"unknown format", "xml", "", map[string]any{"a": "b"}, "unsupported format"},
+		{"invalid json", FormatJSON, "{", map[string]any{"a": "b"}, "error parsing JSON"},
+		{"json through scalar", FormatJSON, `{"a": 1}`, map[string]any{"a.b": "c"}, "cannot set a.b"},
+		{"json index out of range", FormatJSON, `{"a": [1]}`, map[string]any{"a.5": "c"}, "out of range"},
+		{"invalid yaml", FormatYAML, "a: [", map[string]any{"a": "b"}, "error parsing YAML"},
+		{"yaml through alias", FormatYAML, "d: &d\n  port: 80\nprod: *d\n", map[string]any{"prod.port": int64(81)}, "prod is an alias of &d"},
+		{"invalid toml", FormatTOML, "a = ", map[string]any{"a": "b"}, "error parsing TOML"},
+		{"toml null", FormatTOML, "", map[string]any{"a": nil}, "no null"},
+		{"toml array of tables", FormatTOML, "[[a]]\nx = 1\n", map[string]any{"a.y": "b"}, "array of tables"},
+		{"toml dotted key of array of tables", FormatTOML, "[[a]]\nx.y = 1\n", map[string]any{"a.x.y": int64(2)}, "array of tables"},
+		{"toml inline table key", FormatTOML, "a = { x = 1 }\n", map[string]any{"a.x": int64(2)}, "a is not a table"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Patch(tt.format, tt.contents, tt.set, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Patch() error = %v, expected one containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestPatchRemoveErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		contents string
+		remove   []string
+		errMsg   string
+	}{
+		{"yaml anchor of alias", FormatYAML, "base: &b\n  x: 1\nother: *b\n", []string{"base"}, "base has the anchor &b"},
+		{"yaml nested anchor of alias", FormatYAML, "base:\n  x: &x 1\nother: *x\n", []string{"base"}, "base has the anchor &x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Patch(tt.format, tt.contents, nil, tt.remove)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Patch() error = %v, expected one containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SplitPath splits a dotted path such as server.port into its keys. A dot
+// that is part of a key is escaped as \.
+func SplitPath(path string) []string {
+	var keys []string
+	var key strings.Builder
+
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+
+	return append(keys, key.String())
+}
+
+// ParseValue interprets a configured value. Anything that parses as JSON
+// keeps its type, so 8080 is a number, true a boolean and "8080" a string,
+// everything else is taken as a plain string.
+func ParseValue(value string) any {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	var parsed any
+	if err := decoder.Decode(&parsed); err != nil || decoder.More() {
+		return value
+	}
+	return normalize(parsed)
+}
+
+// normalize converts decoded values into the types every format works with:
+// int64 or float64 for numbers, map[string]any for objects and []any for
+// arrays
+func normalize(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case int:
+		return int64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalize(item)
+		}
+		return converted
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case []map[string]any:
+		converted := make([]any, len(v))
+		for i, item := range v {
+			converted[i] = normalize(item)
+		}
+		return converted
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		// TOML local dates and times
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// Canonical renders a value as a configured value that ParseValue reads back
+// as an equal value. Strings that ParseValue would read as another type are
+// JSON-quoted.
+func Canonical(value any) string {
+	if s, ok := value.(string); ok {
+		if _, isString := ParseValue(s).(string); isString {
+			return s
+		}
+	}
+
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(encoded.String(), "\n")
+}
+
+// Lookup returns the value at keys in a decoded document
+func Lookup(document any, keys []string) (any, bool) {
+	current := document
+	for _, key := range keys {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			i, ok := index(key, len(node))
+			if !ok {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// index parses key as an index into an array of length n
+func index(key string, n int) (int, bool) {
+	var i int
+	if _, err := fmt.Sscanf(key, "%d", &i); err != nil || fmt.Sprint(i) != key {
+		return 0, false
+	}
+	return i, i >= 0 && i < n
+}
+
+// ValueString renders a value the way it reads in a file of the given format
+// as a configured value, so that values can be compared across formats. INI
+// files only hold strings, which are returned as they are.
+func ValueString(format string, value any) string {
+	if format == FormatINI {
+		return iniValue(value)
+	}
+	return Canonical(value)
+}
@@ -0,0 +1,77 @@
+package structured
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := map[string][]string{
+		"port":                {"port"},
+		"server.port":         {"server", "port"},
+		`hosts.example\.com`:  {"hosts", "example.com"},
+		`servers.0.name`:      {"servers", "0", "name"},
+		`mysqld.max_allowed`:  {"mysqld", "max_allowed"},
+		`trailing\`:           {`trailing\`},
+		`a\.b\.c.d`:           {"a.b.c", "d"},
+		`remote "origin".url`: {`remote "origin"`, "url"},
+	}
+
+	for path, expected := range tests {
+		if keys := SplitPath(path); !slices.Equal(keys, expected) {
+			t.Errorf("SplitPath(%q) = %q, expected %q", path, keys, expected)
+		}
+	}
+}
+
+func TestParseValueAndCanonical(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  any
+		canonical string
+	}{
+		{"8080", int64(8080), "8080"},
+		{"1.5", 1.5, "1.5"},
+		{"true", true, "true"},
+		{"null", nil, "null"},
+		{`"8080"`, "8080", `"8080"`},
+		{"hello world", "hello world", "hello world"},
+		{"1 2", "1 2", "1 2"},
+		{`["a",1]`, []any{"a", int64(1)}, `["a",1]`},
+		{`{"b":1,"a":true}`, map[string]any{"a": true, "b": int64(1)}, `{"a":true,"b":1}`},
+	}
+
+	for _, tt := range tests {
+		parsed := ParseValue(tt.value)
+		if Canonical(parsed) != Canonical(tt.expected) {
+			t.Errorf("ParseValue(%q) = %#v, expected %#v", tt.value, parsed, tt.expected)
+		}
+		if canonical := Canonical(parsed); canonical != tt.canonical {
+			t.Errorf("Canonical(ParseValue(%q)) = %q, expected %q", tt.value, canonical, tt.canonical)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	document := ParseValue(`{"server":{"ports":[80,443]},"name":"api"}`)
+
+	tests := []struct {
+		path     string
+		expected any
+		found    bool
+	}{
+		{"name", "api", true},
+		{"server.ports.1", int64(443), true},
+		{"server.ports.2", nil, false},
+		{"server.ports.x", nil, false},
+		{"server.missing", nil, false},
+		{"name.inner", nil, false},
+	}
+
+	for _, tt := range tests {
+		value, found := Lookup(document, SplitPath(tt.path))
+		if found != tt.found || Canonical(value) != Canonical(tt.expected) {
+			t.Errorf("Lookup(%s) = %v, %v, expected %v, %v", tt.path, value, found, tt.expected, tt.found)
+		}
+	}
+}
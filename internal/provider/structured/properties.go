@@ -0,0 +1,108 @@
+package structured
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// decodeProperties parses a Java properties file. Keys are taken as they
+// are, so a.b=c is the single key "a.b". Keys end at the first unescaped =,
+// : or whitespace, a line ending in an odd number of backslashes continues
+// on the next line, and ! as well as # start comments.
+func decodeProperties(contents string) (map[string]any, error) {
+	document := map[string]any{}
+	lines := splitFile(contents).lines
+
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimLeft(strings.TrimSuffix(lines[i], "\r"), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Join continuation lines, dropping the leading whitespace of each
+		for continues(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(strings.TrimSuffix(lines[i], "\r"), " \t\f")
+		}
+
+		key, value := splitProperty(line)
+		unescapedKey, err := unescapeProperty(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		unescapedValue, err := unescapeProperty(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		document[unescapedKey] = unescapedValue
+	}
+
+	return document, nil
+}
+
+// continues reports whether line ends in an odd number of backslashes
+func continues(line string) bool {
+	trailing := len(line) - len(strings.TrimRight(line, `\`))
+	return trailing%2 == 1
+}
+
+// splitProperty splits a logical line into its still escaped key and value
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty resolves the escapes of a key or value, including \uXXXX
+func unescapeProperty(escaped string) (string, error) {
+	if !strings.Contains(escaped, `\`) {
+		return escaped, nil
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c != '\\' || i+1 == len(escaped) {
+			unescaped.WriteByte(c)
+			continue
+		}
+		i++
+		switch escaped[i] {
+		case 't':
+			unescaped.WriteByte('\t')
+		case 'n':
+			unescaped.WriteByte('\n')
+		case 'r':
+			unescaped.WriteByte('\r')
+		case 'f':
+			unescaped.WriteByte('\f')
+		case 'u':
+			if i+5 > len(escaped) {
+				return "", fmt.Errorf("incomplete unicode escape %q", escaped[i-1:])
+			}
+			code, err := strconv.ParseUint(escaped[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", escaped[i-1:i+5])
+			}
+			unescaped.WriteRune(rune(code))
+			i += 4
+		default:
+			unescaped.WriteByte(escaped[i])
+		}
+	}
+	return unescaped.String(), nil
+}
@@ -0,0 +1,453 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlHeader is a [table] or [[array]] header line
+type tomlHeader struct {
+	path  []string
+	array bool
+	start int
+	end   int
+}
+
+// tomlEntry is a key/value pair, positioned by byte offsets into the file
+type tomlEntry struct {
+	path       []string
+	inArray    bool
+	start      int
+	valueStart int
+	valueEnd   int
+	end        int
+}
+
+// tomlFile is the layout of a TOML file, enough to edit it in place
+type tomlFile struct {
+	headers []tomlHeader
+	entries []tomlEntry
+}
+
+// scanTOML finds the headers and keys of a TOML file. It relies on the file
+// being valid TOML, which is checked separately.
+func scanTOML(s string) (tomlFile, error) {
+	var file tomlFile
+	var table []string
+	inArray := false
+
+	pos := 0
+	for pos < len(s) {
+		lineStart := pos
+		pos = skipSpaces(s, pos)
+		if pos >= len(s) {
+			break
+		}
+
+		switch s[pos] {
+		case '\n':
+			pos++
+			continue
+		case '\r', '#':
+			pos = lineEnd(s, pos)
+			continue
+		case '[':
+			array := strings.HasPrefix(s[pos:], "[[")
+			keyStart := pos + 1
+			if array {
+				keyStart++
+			}
+			path, next, err := scanTOMLKey(s, keyStart)
+			if err != nil {
+				return file, err
+			}
+			pos = lineEnd(s, next)
+			file.headers = append(file.headers, tomlHeader{path: path, array: array, start: lineStart, end: pos})
+			table, inArray = path, array
+			continue
+		}
+
+		key, next, err := scanTOMLKey(s, pos)
+		if err != nil {
+			return file, err
+		}
+		next = skipSpaces(s, next)
+		if next >= len(s) || s[next] != '=' {
+			return file, fmt.Errorf("expected = after key %s", strings.Join(key, "."))
+		}
+
+		valueStart := skipSpaces(s, next+1)
+		valueEnd := scanTOMLValue(s, valueStart)
+		pos = lineEnd(s, valueEnd)
+
+		file.entries = append(file.entries, tomlEntry{
+			path:       append(slices.Clone(table), key...),
+			inArray:    inArray,
+			start:      lineStart,
+			valueStart: valueStart,
+			valueEnd:   valueEnd,
+			end:        pos,
+		})
+	}
+
+	return file, nil
+}
+
+func skipSpaces(s string, pos int) int {
+	for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+// lineEnd returns the offset just past the line feed ending the line pos is on
+func lineEnd(s string, pos int) int {
+	if i := strings.IndexByte(s[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(s)
+}
+
+// scanTOMLKey reads a possibly dotted and quoted key, stopping at = or ]
+func scanTOMLKey(s string, pos int) ([]string, int, error) {
+	var key []string
+	for {
+		pos = skipSpaces(s, pos)
+		if pos >= len(s) {
+			return nil, pos, fmt.Errorf("unexpected end of file in key")
+		}
+
+		switch s[pos] {
+		case '"':
+			end := scanBasicString(s, pos)
+			var segment string
+			if err := json.Unmarshal([]byte(s[pos:end]), &segment); err != nil {
+				segment = s[pos+1 : end-1]
+			}
+			key = append(key, segment)
+			pos = end
+		case '\'':
+			end := strings.IndexByte(s[pos+1:], '\'')
+			if end < 0 {
+				return nil, pos, fmt.Errorf("unterminated key")
+			}
+			key = append(key, s[pos+1:pos+1+end])
+			pos += end + 2
+		default:
+			start := pos
+			for pos < len(s) && isBareKeyChar(s[pos]) {
+				pos++
+			}
+			if start == pos {
+				return nil, pos, fmt.Errorf("invalid character %q in key", s[pos])
+			}
+			key = append(key, s[start:pos])
+		}
+
+		pos = skipSpaces(s, pos)
+		if pos < len(s) && s[pos] == '.' {
+			pos++
+			continue
+		}
+		for pos < len(s) && s[pos] == ']' {
+			pos++
+		}
+		return key, pos, nil
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// scanBasicString returns the offset just past the "string" starting at pos
+func scanBasicString(s string, pos int) int {
+	for i := pos + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"', '\n':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// scanTOMLValue returns the offset just past the value starting at pos,
+// which may span lines, without any trailing comment or whitespace
+func scanTOMLValue(s string, pos int) int {
+	depth := 0
+	end := pos
+
+	for pos < len(s) {
+		switch {
+		case strings.HasPrefix(s[pos:], `"""`):
+			closing := strings.Index(s[pos+3:], `"""`)
+			for closing >= 0 && s[pos+3+closing-1] == '\\' {
+				next := strings.Index(s[pos+3+closing+1:], `"""`)
+				if next < 0 {
+					closing = -1
+					break
+				}
+				closing += next + 1
+			}
+			if closing < 0 {
+				return len(s)
+			}
+			pos += 3 + closing + 3
+			// Up to two more quotes belong to the string
+			for pos < len(s) && s[pos] == '"' {
+				pos++
+			}
+		case strings.HasPrefix(s[pos:], "'''"):
+			closing := strings.Index(s[pos+3:], "'''")
+			if closing < 0 {
+				return len(s)
+			}
+			pos += 3 + closing + 3
+			for pos < len(s) && s[pos] == '\'' {
+				pos++
+			}
+		case s[pos] == '"':
+			pos = scanBasicString(s, pos)
+		case s[pos] == '\'':
+			closing := strings.IndexAny(s[pos+1:], "'\n")
+			if closing < 0 {
+				return len(s)
+			}
+			pos += closing + 2
+		case s[pos] == '[' || s[pos] == '{':
+			depth++
+			pos++
+		case s[pos] == ']' || s[pos] == '}':
+			depth--
+			pos++
+		case s[pos] == '#':
+			if depth <= 0 {
+				return end
+			}
+			pos = lineEnd(s, pos)
+			continue
+		case s[pos] == '\n' || s[pos] == '\r':
+			if depth <= 0 {
+				return end
+			}
+			pos++
+			continue
+		case s[pos] == ' ' || s[pos] == '\t' || s[pos] == ',':
+			pos++
+			continue
+		default:
+			pos++
+		}
+		end = pos
+	}
+
+	return end
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey renders key segments, quoting those that aren't bare keys
+func tomlKey(keys []string) string {
+	rendered := make([]string, len(keys))
+	for i, key := range keys {
+		if bareKey.MatchString(key) {
+			rendered[i] = key
+		} else {
+			rendered[i] = strconv.Quote(key)
+		}
+	}
+	return strings.Join(rendered, ".")
+}
+
+// tomlValue renders a value as a TOML literal
+func tomlValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", fmt.Errorf("TOML has no null value")
+	case string:
+		return encodeJSONString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		rendered := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(rendered, ".eEn") {
+			rendered += ".0"
+		}
+		return rendered, nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			rendered, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = rendered
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}", nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			rendered, err := tomlValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			items[i] = tomlKey([]string{key}) + " = " + rendered
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// encodeJSONString quotes s, which is also a valid TOML basic string
+func encodeJSONString(s string) string {
+	var encoded strings.Builder
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(encoded.String(), "\n")
+}
+
+// patchTOML sets and removes keys in a TOML file in place, so comments,
+// formatting and the order of everything else stay as they are
+func patchTOML(contents string, changes []change) (string, error) {
+	if _, err := decodeTOML(contents); err != nil {
+		return "", err
+	}
+
+	for _, c := range changes {
+		file, err := scanTOML(contents)
+		if err != nil {
+			return "", fmt.Errorf("error parsing TOML: %w", err)
+		}
+
+		if c.remove {
+			contents = file.remove(contents, c.keys)
+			continue
+		}
+
+		rendered, err := tomlValue(c.value)
+		if err != nil {
+			return "", fmt.Errorf("cannot set %s: %w", c, err)
+		}
+		contents, err = file.set(contents, c.keys, rendered)
+		if err != nil {
+			return "", fmt.Errorf("cannot set %s: %w", c, err)
+		}
+	}
+
+	if _, err := decodeTOML(contents); err != nil {
+		return "", fmt.Errorf("patching would produce invalid TOML: %w", err)
+	}
+	return contents, nil
+}
+
+// set replaces the value at keys, or adds it to the closest existing table
+func (f tomlFile) set(contents string, keys []string, value string) (string, error) {
+	for _, entry := range f.entries {
+		if !entry.inArray && slices.Equal(entry.path, keys) {
+			return contents[:entry.valueStart] + value + contents[entry.valueEnd:], nil
+		}
+		// Inline tables are only ever replaced as a whole
+		if !entry.inArray && len(entry.path) < len(keys) && slices.Equal(entry.path, keys[:len(entry.path)]) {
+			return "", fmt.Errorf("%s is not a table, set it as a whole", tomlKey(entry.path))
+		}
+	}
+
+	// Find the deepest table the key belongs in, counting the top level
+	best := -1
+	depth := 0
+	for i, header := range f.headers {
+		n := len(header.path)
+		if n < len(keys) && n > depth && slices.Equal(header.path, keys[:n]) {
+			if header.array {
+				return "", fmt.Errorf("%s is an array of tables", tomlKey(header.path))
+			}
+			best, depth = i, n
+		}
+	}
+
+	if best < 0 && len(keys) > 1 {
+		// Start a new table at the end of the file
+		prefix := ""
+		if contents != "" {
+			if !strings.HasSuffix(contents, "\n") {
+				prefix = "\n"
+			}
+			prefix += "\n"
+		}
+		return contents + prefix + "[" + tomlKey(keys[:len(keys)-1]) + "]\n" +
+			tomlKey(keys[len(keys)-1:]) + " = " + value + "\n", nil
+	}
+
+	// Add the key after the last key of the table, or after its header
+	regionStart, regionEnd := 0, len(contents)
+	if best >= 0 {
+		regionStart = f.headers[best].end
+	}
+	if best+1 < len(f.headers) {
+		regionEnd = f.headers[best+1].start
+	}
+
+	insertAt := regionStart
+	for _, entry := range f.entries {
+		if entry.start >= regionStart && entry.start < regionEnd {
+			insertAt = entry.end
+		}
+	}
+
+	line := tomlKey(keys[depth:]) + " = " + value + "\n"
+	if insertAt > 0 && !strings.HasSuffix(contents[:insertAt], "\n") {
+		line = "\n" + line
+	}
+	return contents[:insertAt] + line + contents[insertAt:], nil
+}
+
+// remove deletes the key, or the whole table, at keys
+func (f tomlFile) remove(contents string, keys []string) string {
+	for _, entry := range f.entries {
+		if !entry.inArray && slices.Equal(entry.path, keys) {
+			return contents[:entry.start] + contents[entry.end:]
+		}
+	}
+
+	for i, header := range f.headers {
+		if !header.array && slices.Equal(header.path, keys) {
+			end := len(contents)
+			if i+1 < len(f.headers) {
+				end = f.headers[i+1].start
+			}
+			return contents[:header.start] + contents[end:]
+		}
+	}
+
+	return contents
+}
+
+// decodeTOML decodes a TOML document
+func decodeTOML(contents string) (map[string]any, error) {
+	document := map[string]any{}
+	if err := toml.Unmarshal([]byte(contents), &document); err != nil {
+		return nil, fmt.Errorf("error parsing TOML: %w", err)
+	}
+	return document, nil
+}
@@ -0,0 +1,225 @@
+package structured
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// patchYAML sets and removes paths in the first document of a YAML file,
+// keeping comments, key order and any further documents
+func patchYAML(contents string, changes []change) (string, error) {
+	var documents []*yaml.Node
+
+	decoder := yaml.NewDecoder(strings.NewReader(contents))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error parsing YAML: %w", err)
+		}
+		documents = append(documents, &document)
+	}
+
+	if len(documents) == 0 {
+		documents = append(documents, &yaml.Node{Kind: yaml.DocumentNode})
+	}
+	first := documents[0]
+	if len(first.Content) == 0 {
+		first.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	for _, c := range changes {
+		if err := c.apply(first.Content[0], yamlContainer{document: first}); err != nil {
+			return "", err
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(detectYAMLIndent(contents))
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return "", fmt.Errorf("error encoding YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("error encoding YAML: %w", err)
+	}
+
+	return buffer.String(), nil
+}
+
+// detectYAMLIndent returns the number of spaces nested mappings are indented
+// by, 2 if there are none
+func detectYAMLIndent(contents string) int {
+	for _, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent > 0 {
+			return indent
+		}
+	}
+	return 2
+}
+
+// yamlContainer navigates the YAML node tree of document for change.apply
+type yamlContainer struct {
+	document *yaml.Node
+}
+
+// resolve follows aliases to the node they refer to
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func (yamlContainer) child(parent any, key string, create bool) (any, error) {
+	node := resolve(parent.(*yaml.Node))
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				// Editing through an alias would also edit its anchor and
+				// every other alias of it
+				if value := node.Content[i+1]; value.Kind == yaml.AliasNode {
+					return nil, fmt.Errorf("%s is an alias of &%s, edit the anchor instead", key, value.Value)
+				}
+				return node.Content[i+1], nil
+			}
+		}
+		if !create {
+			return nil, nil
+		}
+		child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		return child, nil
+	case yaml.SequenceNode:
+		i, ok := index(key, len(node.Content))
+		if !ok {
+			return nil, fmt.Errorf("index %s is out of range", key)
+		}
+		if value := node.Content[i]; value.Kind == yaml.AliasNode {
+			return nil, fmt.Errorf("%s is an alias of &%s, edit the anchor instead", key, value.Value)
+		}
+		return node.Content[i], nil
+	default:
+		return nil, fmt.Errorf("parent of %s is not an object", key)
+	}
+}
+
+func (yamlContainer) set(parent any, key string, value any) error {
+	node := resolve(parent.(*yaml.Node))
+
+	var replacement yaml.Node
+	if err := replacement.Encode(value); err != nil {
+		return err
+	}
+
+	// The slot holding the value is replaced rather than the node itself,
+	// aliases of it keep referring to the node
+	var slot **yaml.Node
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				slot = &node.Content[i+1]
+				break
+			}
+		}
+		if slot == nil {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &replacement)
+			return nil
+		}
+	case yaml.SequenceNode:
+		i, ok := index(key, len(node.Content))
+		if !ok {
+			return fmt.Errorf("index %s is out of range", key)
+		}
+		slot = &node.Content[i]
+	default:
+		return fmt.Errorf("parent of %s is not an object", key)
+	}
+	existing := *slot
+
+	// Keep the comments around the value, and the quoting of strings
+	replacement.HeadComment = existing.HeadComment
+	replacement.LineComment = existing.LineComment
+	replacement.FootComment = existing.FootComment
+	// Aliases are written by name, so they follow the anchor to its new value
+	replacement.Anchor = existing.Anchor
+	if replacement.Kind == yaml.ScalarNode && existing.Kind == yaml.ScalarNode &&
+		replacement.Tag == "!!str" && existing.Tag == "!!str" && replacement.Style == 0 {
+		replacement.Style = existing.Style
+	}
+	*slot = &replacement
+
+	return nil
+}
+
+func (c yamlContainer) remove(parent any, key string) error {
+	node := resolve(parent.(*yaml.Node))
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("parent of %s is not an object", key)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			// Aliases left behind would refer to an anchor that is gone
+			if anchor := referencedAnchor(c.document, node.Content[i+1]); anchor != "" {
+				return fmt.Errorf("%s has the anchor &%s, remove its aliases first", key, anchor)
+			}
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// referencedAnchor returns the anchor of removed, or of a node below it, that
+// an alias outside of removed refers to, or "" if there is none
+func referencedAnchor(document *yaml.Node, removed *yaml.Node) string {
+	anchored := map[*yaml.Node]bool{}
+	var collect func(node *yaml.Node)
+	collect = func(node *yaml.Node) {
+		if node.Anchor != "" {
+			anchored[node] = true
+		}
+		for _, child := range node.Content {
+			collect(child)
+		}
+	}
+	collect(removed)
+	if len(anchored) == 0 {
+		return ""
+	}
+
+	var find func(node *yaml.Node) string
+	find = func(node *yaml.Node) string {
+		if node == removed {
+			return ""
+		}
+		if node.Kind == yaml.AliasNode && anchored[node.Alias] {
+			return node.Alias.Anchor
+		}
+		for _, child := range node.Content {
+			if anchor := find(child); anchor != "" {
+				return anchor
+			}
+		}
+		return ""
+	}
+	return find(document)
+}
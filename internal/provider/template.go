@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/model"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/render"
+)
+
+// templateRenderer returns the function rendering the template of data with
+// its vars and the remote facts, or nil when the contents aren't templated
+func templateRenderer(ctx context.Context, data *model.RemoteFileResourceModel) (remote.RenderFunc, diag.Diagnostics) {
+	if data.Template.IsNull() {
+		return nil, nil
+	}
+
+	vars := map[string]string{}
+	if !data.Vars.IsNull() {
+		diags := data.Vars.ElementsAs(ctx, &vars, false)
+		if diags.HasError() {
+			return nil, diags
+		}
+	}
+
+	format := data.TemplateFormat.ValueString()
+	text := data.Template.ValueString()
+
+	return func(facts remote.RemoteFacts) (string, error) {
+		return render.Render(format, text, vars, render.Facts{
+			Hostname: facts.Hostname,
+			Exists:   facts.Exists,
+			Existing: facts.Existing,
+			ReadFile: facts.ReadFile,
+		})
+	}, nil
+}
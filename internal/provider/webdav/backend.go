@@ -0,0 +1,235 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// webdavBackend is a server reached over WebDAV. WebDAV can't run commands,
+// set permissions or change owners, so none of the attributes relying on
+// them are available.
+//
+// last_modified and size come from PROPFIND, the contents from GET. The
+// entity tag PROPFIND reported is checked against the one GET returns, so a
+// file changing between both requests fails with remote.ErrConflict and is
+// read again rather than stored with properties of another version. Entity
+// tags are only compared within a read and never kept in state, drift is
+// found by comparing the contents downloaded like for the other protocols.
+type webdavBackend struct {
+	// ctx is the one the backend was dialed with, the Backend methods don't
+	// take one
+	ctx     context.Context
+	client  *client
+	address string
+
+	// etags are the entity tags PROPFIND last reported, by path
+	etags map[string]string
+}
+
+var _ backend.Backend = &webdavBackend{}
+
+// Dialer returns a Dialer for the WebDAV server described by params. HTTP has
+// no session, so dialing checks the server answers and accepts the
+// credentials with an OPTIONS request.
+func Dialer(params *ConnectionParameters) backend.Dialer {
+	return func(ctx context.Context) (backend.Backend, error) {
+		tflog.SubsystemDebug(ctx, Subsystem, "connecting to WebDAV server", map[string]interface{}{
+			"url":  params.GetBaseURL().String(),
+			"user": params.GetUser(),
+		})
+
+		client := newClient(params)
+		response, err := client.do(ctx, http.MethodOptions, "/", nil, nil, http.StatusOK, http.StatusNoContent)
+		if err != nil {
+			client.http.CloseIdleConnections()
+			tflog.SubsystemDebug(ctx, Subsystem, "WebDAV server unavailable", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, err
+		}
+		response.Body.Close()
+
+		if response.Header.Get("DAV") == "" {
+			client.http.CloseIdleConnections()
+			return nil, fmt.Errorf("%s does not support WebDAV, OPTIONS returned no DAV header", params.GetBaseURL())
+		}
+
+		return &webdavBackend{
+			ctx:     ctx,
+			client:  client,
+			address: params.GetAddress(),
+			etags:   map[string]string{},
+		}, nil
+	}
+}
+
+func (b *webdavBackend) Address() string {
+	return b.address
+}
+
+// Open starts downloading path. It fails with remote.ErrConflict if the
+// contents are not those of the last Stat of path.
+func (b *webdavBackend) Open(path string) (backend.File, error) {
+	response, err := b.client.do(b.ctx, http.MethodGet, path, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, stated := b.etags[path]
+	if etag := response.Header.Get("ETag"); stated && expected != "" && etag != "" && etag != expected {
+		response.Body.Close()
+		return nil, fmt.Errorf("%w: %s changed between reading its properties and its contents", remote.ErrConflict, path)
+	}
+
+	return &webdavFile{body: response.Body}, nil
+}
+
+// OpenFile opens path for writing unless flag is os.O_RDONLY. The contents
+// are uploaded with a single PUT on close, which replaces the whole file, so
+// appending isn't supported.
+func (b *webdavBackend) OpenFile(path string, flag int) (backend.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return b.Open(path)
+	}
+	if flag&os.O_APPEND != 0 {
+		return nil, errors.New("WebDAV can't append to files")
+	}
+	return &webdavFile{backend: b, path: path, upload: &bytes.Buffer{}}, nil
+}
+
+func (b *webdavBackend) Stat(path string) (os.FileInfo, error) {
+	info, err := b.client.stat(b.ctx, path)
+	if err != nil {
+		delete(b.etags, path)
+		return nil, err
+	}
+	b.etags[path] = info.ETag()
+	return info, nil
+}
+
+// Lstat is Stat, WebDAV has no symbolic links
+func (b *webdavBackend) Lstat(path string) (os.FileInfo, error) {
+	return b.Stat(path)
+}
+
+func (b *webdavBackend) Chmod(path string, mode os.FileMode) error {
+	return errors.New("WebDAV can't change the permissions of files")
+}
+
+func (b *webdavBackend) Chown(path string, uid int, gid int) error {
+	return errors.New("WebDAV can't change the owner of files")
+}
+
+// Rename moves oldpath over newpath with MOVE
+func (b *webdavBackend) Rename(oldpath string, newpath string) error {
+	header := http.Header{
+		"Destination": {b.client.url(newpath).String()},
+		"Overwrite":   {"T"},
+	}
+	response, err := b.client.do(b.ctx, "MOVE", oldpath, header, nil, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	delete(b.etags, oldpath)
+	delete(b.etags, newpath)
+	return response.Body.Close()
+}
+
+// Remove deletes path, a collection is deleted with everything in it
+func (b *webdavBackend) Remove(path string) error {
+	response, err := b.client.do(b.ctx, http.MethodDelete, path, nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	delete(b.etags, path)
+	return response.Body.Close()
+}
+
+func (b *webdavBackend) Mkdir(path string) error {
+	response, err := b.client.do(b.ctx, "MKCOL", path, nil, nil, http.StatusCreated)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusMethodNotAllowed {
+		// MKCOL is not allowed where something exists
+		return fmt.Errorf("%w: %w", os.ErrExist, err)
+	}
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func (b *webdavBackend) ReadDir(path string) ([]os.FileInfo, error) {
+	infos, err := b.client.list(b.ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]os.FileInfo, len(infos))
+	for i, info := range infos {
+		entries[i] = info
+	}
+	return entries, nil
+}
+
+func (b *webdavBackend) Close() error {
+	b.client.http.CloseIdleConnections()
+	return nil
+}
+
+// webdavFile is a download streaming the body of a GET, or an upload
+// buffered until it is sent with PUT on close
+type webdavFile struct {
+	body io.ReadCloser
+
+	backend *webdavBackend
+	path    string
+	upload  *bytes.Buffer
+
+	closed bool
+}
+
+func (f *webdavFile) Read(p []byte) (int, error) {
+	if f.upload != nil {
+		return 0, errors.New("file was opened for writing")
+	}
+	return f.body.Read(p)
+}
+
+func (f *webdavFile) Write(p []byte) (int, error) {
+	if f.upload == nil {
+		return 0, errors.New("file was opened for reading")
+	}
+	return f.upload.Write(p)
+}
+
+// Close ends a download or sends the contents of an upload
+func (f *webdavFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if f.upload == nil {
+		return f.body.Close()
+	}
+
+	b := f.backend
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	response, err := b.client.do(b.ctx, http.MethodPut, f.path, header, bytes.NewReader(f.upload.Bytes()),
+		http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	delete(b.etags, f.path)
+	return response.Body.Close()
+}
@@ -0,0 +1,381 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/backend/backendtest"
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// newTestModel returns a model authenticating to server, with nothing else set
+func newTestModel(server *testServer) *backendtest.Model {
+	host, port := server.host()
+	data := backendtest.NullModel(host)
+	data.Port = types.Int64Value(port)
+	data.Timeout = types.StringValue("5s")
+	data.Webdav.Scheme = types.StringValue(SchemeHTTP)
+	if server.user != "" {
+		data.User = types.StringValue(server.user)
+		data.Password = types.StringValue(server.password)
+	}
+	if server.token != "" {
+		data.Webdav.BearerToken = types.StringValue(server.token)
+	}
+	if server.basePath != "" {
+		data.Webdav.BasePath = types.StringValue(server.basePath)
+	}
+	if server.caCert != "" {
+		data.Webdav.Scheme = types.StringValue(SchemeHTTPS)
+		data.Webdav.CaCert = types.StringValue(server.caCert)
+	}
+	return data
+}
+
+// testDialer returns the dialer for data
+func testDialer(t *testing.T, data *backendtest.Model) func(context.Context) (*webdavBackend, error) {
+	t.Helper()
+	return backendtest.Dialer[*webdavBackend, ModelSubset](t, data, CreateConnectionParameters, Dialer)
+}
+
+func TestDialerAuthentication(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []testServerOption
+	}{
+		{name: "basic"},
+		{name: "bearer", options: []testServerOption{withBearerToken("s3cr3t-token")}},
+		{name: "TLS", options: []testServerOption{withTLS()}},
+		{name: "base path", options: []testServerOption{withBasePath("/remote.php/dav/files/deploy")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.options...)
+			if err := os.Mkdir(filepath.Join(server.root, "etc"), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+
+			data := newTestModel(server)
+			data.Path = types.StringValue("/etc/motd")
+			data.Contents = types.StringValue("welcome\n")
+
+			params, err := CreateConnectionParameters(data)
+			if err != nil {
+				t.Fatalf("CreateConnectionParameters() error = %v", err)
+			}
+			dial := Dialer(params)
+			ctx := context.Background()
+
+			if err := remote.ConnectAndWrite(dial, data, data)(ctx); err != nil {
+				t.Fatalf("ConnectAndWrite() error = %v", err)
+			}
+			written, err := os.ReadFile(filepath.Join(server.root, "etc", "motd"))
+			if err != nil {
+				t.Fatalf("Failed to read written file: %v", err)
+			}
+			if string(written) != "welcome\n" {
+				t.Errorf("written = %q, expected %q", written, "welcome\n")
+			}
+			if data.Size.ValueInt64() != 8 {
+				t.Errorf("size after write = %d, expected 8", data.Size.ValueInt64())
+			}
+
+			read := newTestModel(server)
+			read.Path = data.Path
+			if err := remote.ConnectAndCopy(dial, read, read)(ctx); err != nil {
+				t.Fatalf("ConnectAndCopy() error = %v", err)
+			}
+			if read.Contents.ValueString() != "welcome\n" {
+				t.Errorf("contents = %q, expected %q", read.Contents.ValueString(), "welcome\n")
+			}
+			if read.ID.ValueString() != "motd" {
+				t.Errorf("id = %q, expected %q", read.ID.ValueString(), "motd")
+			}
+			if read.LastModified.ValueString() != data.LastModified.ValueString() {
+				t.Errorf("last_modified = %q, expected %q", read.LastModified.ValueString(), data.LastModified.ValueString())
+			}
+
+			if err := remote.ConnectAndDelete(dial, data)(ctx); err != nil {
+				t.Fatalf("ConnectAndDelete() error = %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(server.root, "etc", "motd")); !os.IsNotExist(err) {
+				t.Errorf("file still exists after delete, stat error = %v", err)
+			}
+
+			for _, request := range []string{"OPTIONS", "PROPFIND", "PUT", "GET", "DELETE"} {
+				if !server.received(request + " ") {
+					t.Errorf("expected a %s request", request)
+				}
+			}
+		})
+	}
+}
+
+func TestDialerLastModified(t *testing.T) {
+	server := newTestServer(t)
+	modTime := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	target := filepath.Join(server.root, "version")
+	if err := os.WriteFile(target, []byte("1.2.3"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(target, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set file time: %v", err)
+	}
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/version")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	if err := remote.ConnectAndCopy(Dialer(params), data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() error = %v", err)
+	}
+	if data.Contents.ValueString() != "1.2.3" || data.Size.ValueInt64() != 5 {
+		t.Errorf("contents = %q (%d bytes), expected %q", data.Contents.ValueString(), data.Size.ValueInt64(), "1.2.3")
+	}
+	if data.LastModified.ValueString() != "2024-05-01T12:30:00Z" {
+		t.Errorf("last_modified = %q, expected the modification time of the file", data.LastModified.ValueString())
+	}
+}
+
+func TestDialerChangedWhileReading(t *testing.T) {
+	var server *testServer
+	changes := 0
+	server = newTestServer(t, withBefore(func(r *http.Request) {
+		// the file changes between PROPFIND and GET, once
+		if r.Method == http.MethodGet && changes == 0 {
+			changes++
+			target := filepath.Join(server.root, "counter")
+			modTime := time.Now().Add(time.Hour)
+			if err := os.WriteFile(target, []byte("2"), 0644); err != nil {
+				t.Errorf("Failed to write file: %v", err)
+			}
+			if err := os.Chtimes(target, modTime, modTime); err != nil {
+				t.Errorf("Failed to set file time: %v", err)
+			}
+		}
+	}))
+	if err := os.WriteFile(filepath.Join(server.root, "counter"), []byte("1"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/counter")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	operation := remote.ConnectAndCopy(Dialer(params), data, data)
+
+	err = operation(context.Background())
+	if !errors.Is(err, remote.ErrConflict) {
+		t.Fatalf("ConnectAndCopy() error = %v, expected ErrConflict", err)
+	}
+	if !remote.IsRetryable(err) {
+		t.Errorf("expected %v to be retried", err)
+	}
+
+	if err := operation(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() retry error = %v", err)
+	}
+	if data.Contents.ValueString() != "2" {
+		t.Errorf("contents = %q, expected the changed contents", data.Contents.ValueString())
+	}
+}
+
+func TestDialerMissingFile(t *testing.T) {
+	server := newTestServer(t)
+	data := newTestModel(server)
+	data.Path = types.StringValue("/missing")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+	dial := Dialer(params)
+
+	err = remote.ConnectAndCopy(dial, data, data)(context.Background())
+	if !remote.IsFileNotFound(err) {
+		t.Errorf("ConnectAndCopy() error = %v, expected a missing file", err)
+	}
+
+	data.AllowMissing = types.BoolValue(true)
+	if err := remote.ConnectAndCopy(dial, data, data)(context.Background()); err != nil {
+		t.Fatalf("ConnectAndCopy() with allow_missing error = %v", err)
+	}
+	if data.Size.ValueInt64() != -1 {
+		t.Errorf("size = %d, expected -1 for a missing file", data.Size.ValueInt64())
+	}
+
+	// deleting a file that is already gone succeeds
+	if err := remote.ConnectAndDelete(dial, data)(context.Background()); err != nil {
+		t.Errorf("ConnectAndDelete() error = %v", err)
+	}
+}
+
+func TestDialerAuthFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*backendtest.Model)
+	}{
+		{name: "wrong password", modify: func(m *backendtest.Model) { m.Password = types.StringValue("wrong") }},
+		{name: "no credentials", modify: func(m *backendtest.Model) { m.User, m.Password = types.StringNull(), types.StringNull() }},
+		{name: "wrong token", modify: func(m *backendtest.Model) {
+			m.User, m.Password = types.StringNull(), types.StringNull()
+			m.Webdav.BearerToken = types.StringValue("wrong")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			data := newTestModel(server)
+			tt.modify(data)
+
+			_, err := testDialer(t, data)(context.Background())
+			if !errors.Is(err, remote.ErrAuthFailed) {
+				t.Errorf("Dialer() error = %v, expected ErrAuthFailed", err)
+			}
+			if remote.IsRetryable(err) {
+				t.Errorf("expected %v not to be retried", err)
+			}
+		})
+	}
+}
+
+func TestDialerUntrustedCertificate(t *testing.T) {
+	server := newTestServer(t, withTLS())
+	data := newTestModel(server)
+	data.Webdav.CaCert = types.StringNull()
+
+	_, err := testDialer(t, data)(context.Background())
+	if err == nil {
+		t.Fatal("Dialer() succeeded, expected the self-signed certificate to be rejected")
+	}
+}
+
+func TestDialerNotWebdav(t *testing.T) {
+	server := newTestServer(t)
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	server.Server = plain
+
+	_, err := testDialer(t, newTestModel(server))(context.Background())
+	if err == nil {
+		t.Fatal("Dialer() succeeded, expected a server without DAV header to be rejected")
+	}
+}
+
+func TestDialerResumable(t *testing.T) {
+	server := newTestServer(t)
+	if err := os.WriteFile(filepath.Join(server.root, ".app.conf.partial"), []byte("stale"), 0644); err != nil {
+		t.Fatalf("Failed to write partial file: %v", err)
+	}
+
+	data := newTestModel(server)
+	data.Path = types.StringValue("/app.conf")
+	data.Contents = types.StringValue("listen 8080\n")
+	params, err := CreateConnectionParameters(data)
+	if err != nil {
+		t.Fatalf("CreateConnectionParameters() error = %v", err)
+	}
+
+	err = remote.ConnectAndWrite(Dialer(params), data, data, remote.WithResumable(true))(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectAndWrite() error = %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(server.root, "app.conf"))
+	if err != nil || string(written) != "listen 8080\n" {
+		t.Errorf("written = %q (%v), expected the new contents", written, err)
+	}
+	if _, err := os.Stat(filepath.Join(server.root, ".app.conf.partial")); !os.IsNotExist(err) {
+		t.Errorf("partial file still exists, stat error = %v", err)
+	}
+	if !server.received("MOVE ") {
+		t.Error("expected the partial file to be moved into place with MOVE")
+	}
+}
+
+func TestBackendReadDir(t *testing.T) {
+	server := newTestServer(t)
+	for _, name := range []string{"a.conf", "b.conf"} {
+		if err := os.WriteFile(filepath.Join(server.root, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	b, err := testDialer(t, newTestModel(server))(context.Background())
+	if err != nil {
+		t.Fatalf("Dialer() error = %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Mkdir("/conf.d"); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := b.Mkdir("/conf.d"); !errors.Is(err, os.ErrExist) {
+		t.Errorf("Mkdir() of an existing collection error = %v, expected os.ErrExist", err)
+	}
+
+	entries, err := b.ReadDir("/")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		if entry.Name() == "conf.d" && !entry.IsDir() {
+			t.Error("expected conf.d to be a directory")
+		}
+		if entry.Name() == "a.conf" && entry.Size() != 6 {
+			t.Errorf("a.conf size = %d, expected 6", entry.Size())
+		}
+	}
+	sort.Strings(names)
+	if len(names) != 3 || names[0] != "a.conf" || names[1] != "b.conf" || names[2] != "conf.d" {
+		t.Errorf("entries = %v, expected a.conf, b.conf and conf.d", names)
+	}
+
+	if err := b.Chmod("/a.conf", 0600); err == nil {
+		t.Error("Chmod() succeeded, expected WebDAV to refuse changing permissions")
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected error
+	}{
+		{code: http.StatusUnauthorized, expected: remote.ErrAuthFailed},
+		{code: http.StatusForbidden, expected: os.ErrPermission},
+		{code: http.StatusNotFound, expected: os.ErrNotExist},
+		{code: http.StatusConflict, expected: os.ErrNotExist},
+		{code: http.StatusPreconditionFailed, expected: remote.ErrConflict},
+		{code: http.StatusLocked, expected: remote.ErrConflict},
+		{code: http.StatusInsufficientStorage, expected: syscall.EDQUOT},
+		{code: http.StatusServiceUnavailable, expected: remote.ErrConnect},
+		{code: http.StatusTooManyRequests, expected: remote.ErrConnect},
+		{code: http.StatusInternalServerError, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			err := &StatusError{Method: http.MethodPut, Path: "/file", Code: tt.code}
+			if unwrapped := err.Unwrap(); unwrapped != tt.expected {
+				t.Errorf("Unwrap() = %v, expected %v", unwrapped, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/zerobull-consulting/terraform-provider-remotefile/internal/provider/remote"
+)
+
+// Subsystem is the tflog subsystem the WebDAV client logs to
+const Subsystem = "webdav"
+
+// StatusError is a response refusing a request. WebDAV servers only explain
+// themselves in the body, so Unwrap maps the status code alone to the os,
+// syscall or typed error the remote operations look for.
+type StatusError struct {
+	Method string
+	Path   string
+	Code   int
+	// Body is the start of the response body, servers explain themselves there
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	message := fmt.Sprintf("webdav: %s %s: %d %s", e.Method, e.Path, e.Code, http.StatusText(e.Code))
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+	return message
+}
+
+func (e *StatusError) Unwrap() error {
+	switch e.Code {
+	case http.StatusUnauthorized:
+		return remote.ErrAuthFailed
+	case http.StatusForbidden:
+		return os.ErrPermission
+	case http.StatusNotFound, http.StatusGone:
+		return os.ErrNotExist
+	case http.StatusConflict:
+		// a collection on the way to the resource is missing
+		return os.ErrNotExist
+	case http.StatusPreconditionFailed, http.StatusLocked:
+		return remote.ErrConflict
+	case http.StatusInsufficientStorage, http.StatusRequestEntityTooLarge:
+		return syscall.EDQUOT
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return remote.ErrConnect
+	}
+	return nil
+}
+
+// maxErrorBody is how much of the body of a failed response is kept
+const maxErrorBody = 512
+
+// propfindBody asks for the properties a fileInfo is made of
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop>` +
+	`<D:resourcetype/><D:getcontentlength/><D:getlastmodified/><D:getetag/>` +
+	`</D:prop></D:propfind>`
+
+// client sends requests for the files below the base URL of a server
+type client struct {
+	params *ConnectionParameters
+	http   *http.Client
+	base   *url.URL
+}
+
+func newClient(params *ConnectionParameters) *client {
+	dialer := &net.Dialer{Timeout: params.GetTimeout()}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     params.GetTLSConfig(),
+		TLSHandshakeTimeout: params.GetTimeout(),
+		ForceAttemptHTTP2:   true,
+	}
+
+	return &client{
+		params: params,
+		// redirects would drop the body of PUT and the Destination of MOVE
+		http: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		base: params.GetBaseURL(),
+	}
+}
+
+// url returns the URL of the file at remotePath
+func (c *client) url(remotePath string) *url.URL {
+	u := *c.base
+	u.Path = path.Join(c.base.Path, remotePath)
+	return &u
+}
+
+// do sends a request for remotePath and returns the response if its status
+// is one of expected, the caller closes its body
+func (c *client) do(ctx context.Context, method string, remotePath string, header http.Header, body io.Reader, expected ...int) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.url(remotePath).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	if token := c.params.GetBearerToken(); token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	} else if c.params.GetUser() != "" {
+		request.SetBasicAuth(c.params.GetUser(), c.params.GetPassword())
+	}
+
+	start := time.Now()
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, Subsystem, "WebDAV request", map[string]interface{}{
+		"method":      method,
+		"path":        remotePath,
+		"status":      response.StatusCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	for _, code := range expected {
+		if response.StatusCode == code {
+			return response, nil
+		}
+	}
+
+	defer response.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	return nil, &StatusError{
+		Method: method,
+		Path:   remotePath,
+		Code:   response.StatusCode,
+		Body:   strings.TrimSpace(string(message)),
+	}
+}
+
+// propfind returns the files at remotePath, with depth "1" those in the
+// collection as well
+func (c *client) propfind(ctx context.Context, remotePath string, depth string) ([]*fileInfo, error) {
+	header := http.Header{
+		"Depth":        {depth},
+		"Content-Type": {`application/xml; charset="utf-8"`},
+	}
+	response, err := c.do(ctx, "PROPFIND", remotePath, header, strings.NewReader(propfindBody), http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var status multistatus
+	if err := xml.NewDecoder(response.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("error parsing PROPFIND response for %s: %w", remotePath, err)
+	}
+
+	infos := make([]*fileInfo, 0, len(status.Responses))
+	for _, r := range status.Responses {
+		info, err := r.fileInfo()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing PROPFIND response for %s: %w", remotePath, err)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// stat returns the properties of the file at remotePath
+func (c *client) stat(ctx context.Context, remotePath string) (*fileInfo, error) {
+	infos, err := c.propfind(ctx, remotePath, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("PROPFIND response for %s is empty", remotePath)
+	}
+	info := infos[0]
+	// the name is the one asked for, not whatever the server normalised it to
+	info.name = path.Base(remotePath)
+	return info, nil
+}
+
+// list returns the files in the collection at remotePath
+func (c *client) list(ctx context.Context, remotePath string) ([]*fileInfo, error) {
+	infos, err := c.propfind(ctx, remotePath, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	// the collection itself is listed as well
+	self := strings.TrimSuffix(c.url(remotePath).Path, "/")
+	entries := make([]*fileInfo, 0, len(infos))
+	for _, info := range infos {
+		if strings.TrimSuffix(info.href, "/") == self {
+			continue
+		}
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+// multistatus is the body of a PROPFIND response
+type multistatus struct {
+	Responses []propResponse `xml:"DAV: response"`
+}
+
+type propResponse struct {
+	Href      string     `xml:"DAV: href"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   struct {
+		ResourceType struct {
+			Collection *struct{} `xml:"DAV: collection"`
+		} `xml:"DAV: resourcetype"`
+		ContentLength string `xml:"DAV: getcontentlength"`
+		LastModified  string `xml:"DAV: getlastmodified"`
+		ETag          string `xml:"DAV: getetag"`
+	} `xml:"DAV: prop"`
+}
+
+// fileInfo returns the properties of the response the server found, it
+// reports the others with a status other than 200
+func (r *propResponse) fileInfo() (*fileInfo, error) {
+	href, err := url.Parse(r.Href)
+	if err != nil {
+		return nil, fmt.Errorf("invalid href %q: %w", r.Href, err)
+	}
+
+	info := &fileInfo{
+		href: href.Path,
+		name: path.Base(strings.TrimSuffix(href.Path, "/")),
+	}
+	for _, ps := range r.Propstats {
+		if !strings.Contains(ps.Status, " 200 ") {
+			continue
+		}
+		if ps.Prop.ResourceType.Collection != nil {
+			info.dir = true
+		}
+		if ps.Prop.ContentLength != "" {
+			size, err := strconv.ParseInt(strings.TrimSpace(ps.Prop.ContentLength), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid getcontentlength %q: %w", ps.Prop.ContentLength, err)
+			}
+			info.size = size
+		}
+		if ps.Prop.LastModified != "" {
+			modTime, err := http.ParseTime(strings.TrimSpace(ps.Prop.LastModified))
+			if err != nil {
+				return nil, fmt.Errorf("invalid getlastmodified %q: %w", ps.Prop.LastModified, err)
+			}
+			info.modTime = modTime
+		}
+		if ps.Prop.ETag != "" {
+			info.etag = strings.TrimSpace(ps.Prop.ETag)
+		}
+	}
+	return info, nil
+}
+
+// fileInfo is a file as described by its WebDAV properties. WebDAV has no
+// permissions, files report 0644 and collections 0755.
+type fileInfo struct {
+	href    string
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	etag    string
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ETag returns the entity tag of the contents, empty if the server has none
+func (i *fileInfo) ETag() string {
+	return i.etag
+}
@@ -0,0 +1,172 @@
+package webdav
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// URL schemes
+const (
+	SchemeHTTPS = "https"
+	// SchemeHTTP sends the credentials in clear text
+	SchemeHTTP = "http"
+)
+
+// Schemes are the supported values of scheme
+var Schemes = []string{SchemeHTTPS, SchemeHTTP}
+
+// the subset of terraform schema fields that are needed to connect to a
+// WebDAV server
+type ModelSubset interface {
+	GetHost() types.String
+	GetHostKey() types.String
+	GetPassword() types.String
+	GetPort() types.Int64
+	GetPrivateKey() types.String
+	GetTimeout() types.String
+	GetUser() types.String
+	GetWebdavBasePath() types.String
+	GetWebdavBearerToken() types.String
+	GetWebdavCaCert() types.String
+	GetWebdavScheme() types.String
+}
+
+// ConnectionParameters describes how to reach and authenticate to a WebDAV
+// server
+type ConnectionParameters struct {
+	baseURL     *url.URL
+	user        string
+	password    string
+	bearerToken string
+	timeout     time.Duration
+
+	// tlsConfig is nil over plain HTTP
+	tlsConfig *tls.Config
+}
+
+// GetAddress returns host:port of the server
+func (p *ConnectionParameters) GetAddress() string {
+	if p.baseURL.Port() != "" {
+		return p.baseURL.Host
+	}
+	port := "443"
+	if p.baseURL.Scheme == SchemeHTTP {
+		port = "80"
+	}
+	return net.JoinHostPort(p.baseURL.Hostname(), port)
+}
+
+// GetBaseURL returns the URL the file paths are resolved against
+func (p *ConnectionParameters) GetBaseURL() *url.URL {
+	baseURL := *p.baseURL
+	return &baseURL
+}
+
+func (p *ConnectionParameters) GetUser() string {
+	return p.user
+}
+
+func (p *ConnectionParameters) GetPassword() string {
+	return p.password
+}
+
+// GetBearerToken returns the token sent instead of basic auth, empty if none
+func (p *ConnectionParameters) GetBearerToken() string {
+	return p.bearerToken
+}
+
+func (p *ConnectionParameters) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// GetTLSConfig returns the configuration HTTPS connections are made with, nil
+// over plain HTTP
+func (p *ConnectionParameters) GetTLSConfig() *tls.Config {
+	return p.tlsConfig
+}
+
+func CreateConnectionParameters(data ModelSubset) (*ConnectionParameters, error) {
+	if !data.GetHostKey().IsNull() {
+		return nil, errors.New("host_key only applies to SSH, set webdav.ca_cert to verify an HTTPS server")
+	}
+	if !data.GetPrivateKey().IsNull() {
+		return nil, errors.New("private_key only applies to SSH, WebDAV authenticates with user and password or webdav.bearer_token")
+	}
+
+	scheme := SchemeHTTPS
+	if value := data.GetWebdavScheme(); !value.IsNull() {
+		scheme = value.ValueString()
+	}
+	if !slices.Contains(Schemes, scheme) {
+		return nil, fmt.Errorf("webdav.scheme must be one of %s, got %q", strings.Join(Schemes, ", "), scheme)
+	}
+
+	timeout := "5m"
+	if !data.GetTimeout().IsNull() {
+		timeout = data.GetTimeout().ValueString()
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout duration: %w", err)
+	}
+
+	host := data.GetHost().ValueString()
+	urlHost := host
+	if strings.Contains(host, ":") {
+		// IPv6 literals are bracketed in URLs
+		urlHost = "[" + host + "]"
+	}
+	if !data.GetPort().IsNull() {
+		urlHost = net.JoinHostPort(host, strconv.FormatInt(data.GetPort().ValueInt64(), 10))
+	}
+
+	basePath := path.Clean("/" + data.GetWebdavBasePath().ValueString())
+
+	params := &ConnectionParameters{
+		baseURL:     &url.URL{Scheme: scheme, Host: urlHost, Path: basePath},
+		user:        data.GetUser().ValueString(),
+		password:    data.GetPassword().ValueString(),
+		bearerToken: data.GetWebdavBearerToken().ValueString(),
+		timeout:     timeoutDuration,
+	}
+
+	if params.bearerToken != "" && (params.user != "" || params.password != "") {
+		return nil, errors.New("webdav.bearer_token conflicts with user and password, set either")
+	}
+	if params.user == "" && params.password != "" {
+		return nil, errors.New("password requires user, basic auth sends both")
+	}
+
+	caCert := data.GetWebdavCaCert()
+	if scheme == SchemeHTTP {
+		if !caCert.IsNull() {
+			return nil, errors.New("webdav.ca_cert only applies when webdav.scheme is https")
+		}
+		return params, nil
+	}
+
+	params.tlsConfig = &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if !caCert.IsNull() {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert.ValueString())) {
+			return nil, errors.New("failed to parse webdav.ca_cert: no PEM certificates found")
+		}
+		params.tlsConfig.RootCAs = pool
+	}
+
+	return params, nil
+}
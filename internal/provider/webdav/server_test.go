@@ -0,0 +1,143 @@
+package webdav
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	xwebdav "golang.org/x/net/webdav"
+)
+
+// testServer is a golang.org/x/net/webdav handler serving a temporary
+// directory below basePath
+type testServer struct {
+	*httptest.Server
+
+	root     string
+	basePath string
+
+	// user and password are accepted with basic auth, token as a bearer
+	// token, an empty one isn't accepted
+	user     string
+	password string
+	token    string
+
+	locks xwebdav.LockSystem
+
+	// caCert is the PEM certificate of the server if it speaks TLS
+	caCert string
+
+	mu sync.Mutex
+	// requests are "METHOD path" of every request received
+	requests []string
+	// before runs ahead of every request
+	before func(r *http.Request)
+}
+
+type testServerOption func(*testServer)
+
+func withTLS() testServerOption {
+	return func(s *testServer) {
+		s.Server = httptest.NewUnstartedServer(s)
+		s.StartTLS()
+		s.caCert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+	}
+}
+
+func withBearerToken(token string) testServerOption {
+	return func(s *testServer) {
+		s.user, s.password, s.token = "", "", token
+	}
+}
+
+func withBasePath(basePath string) testServerOption {
+	return func(s *testServer) {
+		s.basePath = basePath
+	}
+}
+
+// withBefore runs before ahead of every request
+func withBefore(before func(r *http.Request)) testServerOption {
+	return func(s *testServer) {
+		s.before = before
+	}
+}
+
+func newTestServer(t *testing.T, opts ...testServerOption) *testServer {
+	t.Helper()
+
+	s := &testServer{
+		root:     t.TempDir(),
+		user:     "deploy",
+		password: "secret",
+		locks:    xwebdav.NewMemLS(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.Server == nil {
+		s.Server = httptest.NewServer(s)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	before := s.before
+	s.mu.Unlock()
+
+	user, password, basic := r.BasicAuth()
+	switch {
+	case s.token != "" && r.Header.Get("Authorization") == "Bearer "+s.token:
+	case s.user != "" && basic && user == s.user && password == s.password:
+	default:
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if before != nil {
+		before(r)
+	}
+
+	handler := &xwebdav.Handler{
+		Prefix:     s.basePath,
+		FileSystem: xwebdav.Dir(s.root),
+		LockSystem: s.locks,
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// host returns the host and port the server listens on
+func (s *testServer) host() (string, int64) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.ParseInt(u.Port(), 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return u.Hostname(), port
+}
+
+// received reports whether a request starting with prefix was received
+func (s *testServer) received(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, request := range s.requests {
+		if strings.HasPrefix(request, prefix) {
+			return true
+		}
+	}
+	return false
+}